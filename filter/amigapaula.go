@@ -0,0 +1,151 @@
+package filter
+
+import (
+	"math"
+
+	"github.com/gotracker/playback/frequency"
+	"github.com/gotracker/playback/mixing/volume"
+)
+
+// AmigaModel selects the Amiga whose analog output stage is modelled
+type AmigaModel uint8
+
+const (
+	// AmigaModelA500 has a fixed RC low-pass filter at around 4.4kHz
+	AmigaModelA500 = AmigaModel(iota)
+	// AmigaModelA1200 has a fixed RC low-pass filter at around 34kHz
+	AmigaModelA1200
+)
+
+func (a AmigaModel) String() string {
+	switch a {
+	case AmigaModelA500:
+		return "A500"
+	case AmigaModelA1200:
+		return "A1200"
+	default:
+		return "unknown"
+	}
+}
+
+// component values of the Amiga output stage
+const (
+	amigaA500LowPassHz  = 1 / (2 * math.Pi * 360 * 0.1e-6)   // R=360 ohm, C=0.1uF
+	amigaA1200LowPassHz = 1 / (2 * math.Pi * 680 * 6.8e-9)   // R=680 ohm, C=6.8nF
+	amigaHighPassHz     = 1 / (2 * math.Pi * 1390 * 22e-6)   // R=1390 ohm, C=22uF
+	amigaLEDCutoffHz    = 1 / (2 * math.Pi * 10e3 * 5.15e-9) // R1=R2=10k ohm, sqrt(C1*C2) for C1=6.8nF, C2=3.9nF
+	amigaLEDQ           = 0.660                              // sqrt(R1*R2*C1*C2) / (C2*(R1+R2))
+)
+
+// AmigaFilterSettings are the parameters of the Amiga output filter
+type AmigaFilterSettings struct {
+	Model AmigaModel
+	LED   bool // the "LED" filter is switched on
+}
+
+// LEDFilter is a filter that has a switchable "LED" stage
+type LEDFilter interface {
+	SetLED(enabled bool)
+}
+
+type amigaFilterChannelData struct {
+	lp  float64
+	led biquadState
+	hp  float64
+}
+
+// AmigaFilter models the analog output stage of an Amiga: the fixed RC low-pass filter of the
+// selected model, the 2-pole Sallen-Key "LED" low-pass filter that can be switched on and off,
+// and the high-pass filter formed by the output's DC blocking capacitor
+type AmigaFilter struct {
+	AmigaFilterSettings
+
+	channels []amigaFilterChannelData
+	lp       float64
+	led      biquad
+	hp       float64
+
+	playbackRate frequency.Frequency
+}
+
+var _ LEDFilter = (*AmigaFilter)(nil)
+
+// NewAmigaFilter creates a new AmigaFilter
+func NewAmigaFilter(playback frequency.Frequency, settings AmigaFilterSettings) *AmigaFilter {
+	f := AmigaFilter{
+		AmigaFilterSettings: settings,
+	}
+	f.SetPlaybackRate(playback)
+	return &f
+}
+
+// onePoleCoeff returns the coefficient of a one-pole RC filter with a cutoff of `freq`
+func onePoleCoeff(rate, freq float64) float64 {
+	return 1 - math.Exp(-2*math.Pi*freq/rate)
+}
+
+func (f *AmigaFilter) SetPlaybackRate(playback frequency.Frequency) {
+	if f.playbackRate == playback {
+		return
+	}
+	f.playbackRate = playback
+	if playback <= 0 {
+		return
+	}
+
+	rate := float64(playback)
+	lowPass := amigaA500LowPassHz
+	if f.Model == AmigaModelA1200 {
+		lowPass = amigaA1200LowPassHz
+	}
+	f.lp = onePoleCoeff(rate, lowPass)
+	f.led = lowpassBiquad(rate, amigaLEDCutoffHz, amigaLEDQ)
+	f.hp = onePoleCoeff(rate, amigaHighPassHz)
+}
+
+// SetLED switches the "LED" filter on or off
+func (f *AmigaFilter) SetLED(enabled bool) {
+	f.LED = enabled
+}
+
+func (f *AmigaFilter) Clone() Filter {
+	c := *f
+	c.channels = make([]amigaFilterChannelData, len(f.channels))
+	copy(c.channels, f.channels)
+	return &c
+}
+
+// Filter processes incoming (dry) samples and produces an outgoing filtered (wet) result
+func (f *AmigaFilter) Filter(dry volume.Matrix) volume.Matrix {
+	if dry.Channels == 0 {
+		return volume.Matrix{}
+	}
+	if f.playbackRate <= 0 {
+		return dry
+	}
+
+	wet := dry
+	for i := 0; i < dry.Channels; i++ {
+		for len(f.channels) <= i {
+			f.channels = append(f.channels, amigaFilterChannelData{})
+		}
+		c := &f.channels[i]
+
+		c.lp += f.lp * (float64(dry.StaticMatrix[i]) - c.lp)
+		s := c.lp
+
+		// the LED filter is always in the circuit; it's just bypassed when switched off
+		led := c.led.process(&f.led, s)
+		if f.LED {
+			s = led
+		}
+
+		c.hp += f.hp * (s - c.hp)
+		wet.StaticMatrix[i] = volume.Volume(s - c.hp)
+	}
+	return wet
+}
+
+// UpdateEnv updates the filter with the value from the filter envelope
+func (f *AmigaFilter) UpdateEnv(v uint8) {
+}
@@ -0,0 +1,37 @@
+package filter
+
+import (
+	"github.com/gotracker/playback/frequency"
+	"github.com/gotracker/playback/mixing/volume"
+)
+
+// Chain is a series of filters, where the output of each filter feeds the next
+type Chain []Filter
+
+func (c Chain) Filter(dry volume.Matrix) volume.Matrix {
+	wet := dry
+	for _, f := range c {
+		wet = f.Filter(wet)
+	}
+	return wet
+}
+
+func (c Chain) SetPlaybackRate(playback frequency.Frequency) {
+	for _, f := range c {
+		f.SetPlaybackRate(playback)
+	}
+}
+
+func (c Chain) UpdateEnv(val uint8) {
+	for _, f := range c {
+		f.UpdateEnv(val)
+	}
+}
+
+func (c Chain) Clone() Filter {
+	clone := make(Chain, len(c))
+	for i, f := range c {
+		clone[i] = f.Clone()
+	}
+	return clone
+}
@@ -0,0 +1,82 @@
+package filter
+
+import (
+	"math"
+
+	"github.com/gotracker/playback/frequency"
+	"github.com/gotracker/playback/mixing/volume"
+)
+
+const dcBlockerDefaultCutoffHz = 10.0
+
+type dcBlockerChannelData struct {
+	x1, y1 float64
+}
+
+// DCBlockerFilter removes any DC offset from the signal with a gentle high-pass filter
+type DCBlockerFilter struct {
+	Cutoff float64 // in Hz; 0 uses 10Hz
+
+	channels []dcBlockerChannelData
+	r        float64
+
+	playbackRate frequency.Frequency
+}
+
+// NewDCBlockerFilter creates a new DCBlockerFilter
+func NewDCBlockerFilter(cutoff float64) *DCBlockerFilter {
+	return &DCBlockerFilter{
+		Cutoff: cutoff,
+	}
+}
+
+func (e *DCBlockerFilter) SetPlaybackRate(playback frequency.Frequency) {
+	if e.playbackRate == playback {
+		return
+	}
+	e.playbackRate = playback
+	if playback <= 0 {
+		return
+	}
+
+	cutoff := e.Cutoff
+	if cutoff <= 0 {
+		cutoff = dcBlockerDefaultCutoffHz
+	}
+	e.r = math.Exp(-2 * math.Pi * cutoff / float64(playback))
+}
+
+func (e *DCBlockerFilter) Clone() Filter {
+	clone := *e
+	clone.channels = make([]dcBlockerChannelData, len(e.channels))
+	copy(clone.channels, e.channels)
+	return &clone
+}
+
+// Filter processes incoming (dry) samples and produces an outgoing filtered (wet) result
+func (e *DCBlockerFilter) Filter(dry volume.Matrix) volume.Matrix {
+	if dry.Channels == 0 {
+		return volume.Matrix{}
+	}
+	if e.playbackRate <= 0 {
+		return dry
+	}
+
+	wet := dry
+	for c := 0; c < dry.Channels; c++ {
+		for len(e.channels) <= c {
+			e.channels = append(e.channels, dcBlockerChannelData{})
+		}
+		ch := &e.channels[c]
+
+		x := float64(dry.StaticMatrix[c])
+		y := x - ch.x1 + e.r*ch.y1
+		ch.x1, ch.y1 = x, y
+		wet.StaticMatrix[c] = volume.Volume(y)
+	}
+	return wet
+}
+
+// UpdateEnv updates the filter with the value from the filter envelope
+func (e *DCBlockerFilter) UpdateEnv(v uint8) {
+}
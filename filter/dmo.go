@@ -0,0 +1,171 @@
+package filter
+
+import (
+	"math"
+)
+
+// The DirectX Media Object (DMO) effects store each of their parameters as a
+// normalized value between 0 and 1. The helpers in this file are shared by the
+// native implementations of those effects.
+
+// dmoParam denormalizes a DMO parameter value into the range [lo, hi]
+func dmoParam(v float32, lo, hi float64) float64 {
+	return lo + float64(min(max(v, 0), 1))*(hi-lo)
+}
+
+// dbToAmp converts a value in decibels into a linear amplitude
+func dbToAmp(db float64) float64 {
+	return math.Pow(10, db/20)
+}
+
+// ampToDB converts a linear amplitude into a value in decibels
+func ampToDB(amp float64) float64 {
+	if amp <= 1e-10 {
+		return -200
+	}
+	return 20 * math.Log10(amp)
+}
+
+// dmoDelayLine is a circular delay buffer supporting fractional reads
+type dmoDelayLine struct {
+	buf []float64
+	pos int
+}
+
+func (d *dmoDelayLine) resize(size int) {
+	d.buf = make([]float64, max(size, 1))
+	d.pos = 0
+}
+
+func (d dmoDelayLine) clone() dmoDelayLine {
+	c := dmoDelayLine{
+		buf: make([]float64, len(d.buf)),
+		pos: d.pos,
+	}
+	copy(c.buf, d.buf)
+	return c
+}
+
+// write pushes a new sample into the delay line
+func (d *dmoDelayLine) write(v float64) {
+	if len(d.buf) == 0 {
+		return
+	}
+	d.buf[d.pos] = v
+	d.pos++
+	if d.pos >= len(d.buf) {
+		d.pos = 0
+	}
+}
+
+// read returns the sample written `delay` samples before the most recent one,
+// linearly interpolating between samples for fractional delays
+func (d dmoDelayLine) read(delay float64) float64 {
+	n := len(d.buf)
+	if n == 0 {
+		return 0
+	}
+	delay = min(max(delay, 0), float64(n-2))
+	i := int(delay)
+	frac := delay - float64(i)
+
+	at := func(ofs int) float64 {
+		p := d.pos - 1 - ofs
+		for p < 0 {
+			p += n
+		}
+		return d.buf[p]
+	}
+	a := at(i)
+	b := at(i + 1)
+	return a + (b-a)*frac
+}
+
+// biquad holds the normalized coefficients of a 2nd order IIR filter section
+type biquad struct {
+	b0, b1, b2 float64
+	a1, a2     float64
+}
+
+// biquadState holds the history of a single channel running through a biquad
+type biquadState struct {
+	x1, x2 float64
+	y1, y2 float64
+}
+
+func (s *biquadState) process(c *biquad, x float64) float64 {
+	y := c.b0*x + c.b1*s.x1 + c.b2*s.x2 - c.a1*s.y1 - c.a2*s.y2
+	s.x2, s.x1 = s.x1, x
+	s.y2, s.y1 = s.y1, y
+	return y
+}
+
+func newBiquad(b0, b1, b2, a0, a1, a2 float64) biquad {
+	return biquad{
+		b0: b0 / a0,
+		b1: b1 / a0,
+		b2: b2 / a0,
+		a1: a1 / a0,
+		a2: a2 / a0,
+	}
+}
+
+// clampBiquadFreq keeps a filter frequency safely below the Nyquist limit
+func clampBiquadFreq(rate, freq float64) float64 {
+	return min(max(freq, 1), rate*0.499)
+}
+
+// lowpassBiquad builds a low-pass filter section
+func lowpassBiquad(rate, freq, q float64) biquad {
+	w0 := 2 * math.Pi * clampBiquadFreq(rate, freq) / rate
+	cosW0 := math.Cos(w0)
+	alpha := math.Sin(w0) / (2 * q)
+	return newBiquad((1-cosW0)/2, 1-cosW0, (1-cosW0)/2, 1+alpha, -2*cosW0, 1-alpha)
+}
+
+// bandpassBiquad builds a band-pass filter section with a bandwidth in Hz and a peak gain of 0 dB
+func bandpassBiquad(rate, freq, bandwidth float64) biquad {
+	freq = clampBiquadFreq(rate, freq)
+	q := freq / max(bandwidth, 1)
+	w0 := 2 * math.Pi * freq / rate
+	cosW0 := math.Cos(w0)
+	alpha := math.Sin(w0) / (2 * q)
+	return newBiquad(alpha, 0, -alpha, 1+alpha, -2*cosW0, 1-alpha)
+}
+
+// peakingBiquad builds a peaking equalizer section with a bandwidth in octaves
+func peakingBiquad(rate, freq, octaves, gainDB float64) biquad {
+	w0 := 2 * math.Pi * clampBiquadFreq(rate, freq) / rate
+	cosW0 := math.Cos(w0)
+	sinW0 := math.Sin(w0)
+	alpha := sinW0 * math.Sinh(math.Ln2/2*octaves*w0/sinW0)
+	a := math.Pow(10, gainDB/40)
+	return newBiquad(1+alpha*a, -2*cosW0, 1-alpha*a, 1+alpha/a, -2*cosW0, 1-alpha/a)
+}
+
+// dmoLFO is a low-frequency oscillator producing values between -1 and 1
+type dmoLFO struct {
+	phase    float64 // 0..1
+	inc      float64
+	triangle bool
+}
+
+func (l *dmoLFO) setup(rate, freq float64, triangle bool) {
+	l.inc = freq / rate
+	l.triangle = triangle
+}
+
+// value returns the oscillator output at the current phase plus an offset in cycles
+func (l dmoLFO) value(offset float64) float64 {
+	p := l.phase + offset
+	p -= math.Floor(p)
+	if l.triangle {
+		return 1 - 4*math.Abs(p-0.5)
+	}
+	return math.Sin(2 * math.Pi * p)
+}
+
+func (l *dmoLFO) advance() {
+	l.phase += l.inc
+	l.phase -= math.Floor(l.phase)
+}
@@ -0,0 +1,157 @@
+package filter
+
+import (
+	"math"
+
+	"github.com/gotracker/playback/frequency"
+	"github.com/gotracker/playback/mixing/volume"
+)
+
+// ChorusFilterSettings are the parameters of the DMO Chorus effect
+type ChorusFilterSettings struct {
+	WetDryMix float32
+	Depth     float32
+	Frequency float32
+	WaveShape float32
+	Phase     float32
+	Feedback  float32
+	Delay     float32
+}
+
+type ChorusFilterFactory struct {
+	Reserved00 [4]byte
+	ChorusFilterSettings
+}
+
+func (e *ChorusFilterFactory) Factory() Factory {
+	return func(instrument frequency.Frequency) Filter {
+		return &ChorusFilter{
+			ChorusFilterSettings: e.ChorusFilterSettings,
+		}
+	}
+}
+
+//===========
+
+// ChorusFilter is a native implementation of the DMO Chorus effect
+type ChorusFilter struct {
+	ChorusFilterSettings
+	core modulatedDelay
+}
+
+func (e *ChorusFilter) SetPlaybackRate(playback frequency.Frequency) {
+	e.core.setup(playback, modulatedDelaySettings{
+		WetDryMix: float64(e.WetDryMix),
+		Depth:     float64(e.Depth),
+		Frequency: dmoParam(e.Frequency, 0, 10),
+		Triangle:  e.WaveShape < 1,
+		Phase:     e.Phase,
+		Feedback:  dmoParam(e.Feedback, -99, 99) / 100,
+		DelayMs:   dmoParam(e.Delay, 0, 20),
+		MaxDelay:  20,
+	})
+}
+
+func (e *ChorusFilter) Clone() Filter {
+	clone := ChorusFilter{
+		ChorusFilterSettings: e.ChorusFilterSettings,
+		core:                 e.core.clone(),
+	}
+	return &clone
+}
+
+func (e *ChorusFilter) Filter(dry volume.Matrix) volume.Matrix {
+	return e.core.filter(dry)
+}
+
+func (e *ChorusFilter) UpdateEnv(val uint8) {
+
+}
+
+//===========
+
+// modulatedDelaySettings are the denormalized parameters shared by the chorus and flanger effects
+type modulatedDelaySettings struct {
+	WetDryMix float64
+	Depth     float64
+	Frequency float64 // Hz
+	Triangle  bool
+	Phase     float32 // normalized; selects a stereo phase difference of -180, -90, 0, 90 or 180 degrees
+	Feedback  float64
+	DelayMs   float64
+	MaxDelay  float64 // ms
+}
+
+// modulatedDelay is a delay line whose length is swept by a low-frequency oscillator
+type modulatedDelay struct {
+	settings    modulatedDelaySettings
+	lfo         dmoLFO
+	phaseOffset float64
+	delay       float64 // samples
+	lines       []dmoDelayLine
+	bufferSize  int
+
+	playbackRate frequency.Frequency
+}
+
+func (m *modulatedDelay) setup(playback frequency.Frequency, s modulatedDelaySettings) {
+	if m.playbackRate == playback {
+		return
+	}
+	m.playbackRate = playback
+	m.settings = s
+
+	rate := float64(playback)
+	m.lfo.setup(rate, s.Frequency, s.Triangle)
+	m.phaseOffset = (math.Round(float64(min(max(s.Phase, 0), 1))*4) - 2) / 4
+	m.delay = s.DelayMs * rate / 1000
+	// the sweep spans 0..2x the delay, plus room for interpolation
+	m.bufferSize = int(2*s.MaxDelay*rate/1000) + 4
+	for i := range m.lines {
+		m.lines[i].resize(m.bufferSize)
+	}
+}
+
+func (m modulatedDelay) clone() modulatedDelay {
+	c := m
+	c.lines = make([]dmoDelayLine, len(m.lines))
+	for i := range m.lines {
+		c.lines[i] = m.lines[i].clone()
+	}
+	return c
+}
+
+func (m *modulatedDelay) filter(dry volume.Matrix) volume.Matrix {
+	if dry.Channels == 0 {
+		return volume.Matrix{}
+	}
+	if m.playbackRate == 0 {
+		return dry
+	}
+
+	wet := dry
+	for c := 0; c < dry.Channels; c++ {
+		for len(m.lines) <= c {
+			var l dmoDelayLine
+			l.resize(m.bufferSize)
+			m.lines = append(m.lines, l)
+		}
+		line := &m.lines[c]
+
+		offset := 0.0
+		if c%2 == 1 {
+			offset = m.phaseOffset
+		}
+		lfo := m.lfo.value(offset)
+		delay := max(m.delay*(1+m.settings.Depth*lfo), 0)
+
+		in := float64(dry.StaticMatrix[c])
+		delayed := line.read(delay)
+		line.write(in + delayed*m.settings.Feedback)
+
+		out := in*(1-m.settings.WetDryMix) + delayed*m.settings.WetDryMix
+		wet.StaticMatrix[c] = volume.Volume(out)
+	}
+	m.lfo.advance()
+	return wet
+}
@@ -0,0 +1,123 @@
+package filter
+
+import (
+	"math"
+
+	"github.com/gotracker/playback/frequency"
+	"github.com/gotracker/playback/mixing/volume"
+)
+
+// CompressorFilterSettings are the parameters of the DMO Compressor effect
+type CompressorFilterSettings struct {
+	Gain      float32
+	Attack    float32
+	Release   float32
+	Threshold float32
+	Ratio     float32
+	Predelay  float32
+}
+
+type CompressorFilterFactory struct {
+	Reserved00 [4]byte
+	CompressorFilterSettings
+}
+
+func (e *CompressorFilterFactory) Factory() Factory {
+	return func(instrument frequency.Frequency) Filter {
+		return &CompressorFilter{
+			CompressorFilterSettings: e.CompressorFilterSettings,
+		}
+	}
+}
+
+//===========
+
+// CompressorFilter is a native implementation of the DMO Compressor effect.
+// The channels share a single envelope so that the stereo image is preserved.
+type CompressorFilter struct {
+	CompressorFilterSettings
+
+	gain        float64
+	threshold   float64 // dB
+	ratio       float64
+	attackCoef  float64
+	releaseCoef float64
+	predelay    float64 // samples
+	env         float64
+	lines       []dmoDelayLine
+	bufferSize  int
+
+	playbackRate frequency.Frequency
+}
+
+func (e *CompressorFilter) SetPlaybackRate(playback frequency.Frequency) {
+	if e.playbackRate == playback {
+		return
+	}
+	e.playbackRate = playback
+
+	rate := float64(playback)
+	e.gain = dmoParam(e.Gain, -60, 60)
+	e.threshold = dmoParam(e.Threshold, -60, 0)
+	e.ratio = dmoParam(e.Ratio, 1, 100)
+	e.attackCoef = math.Exp(-1000 / (dmoParam(e.Attack, 0.01, 500) * rate))
+	e.releaseCoef = math.Exp(-1000 / (dmoParam(e.Release, 50, 3000) * rate))
+	e.predelay = dmoParam(e.Predelay, 0, 4) * rate / 1000
+	e.bufferSize = int(4*rate/1000) + 4
+	for i := range e.lines {
+		e.lines[i].resize(e.bufferSize)
+	}
+}
+
+func (e *CompressorFilter) Clone() Filter {
+	clone := *e
+	clone.lines = make([]dmoDelayLine, len(e.lines))
+	for i := range e.lines {
+		clone.lines[i] = e.lines[i].clone()
+	}
+	return &clone
+}
+
+func (e *CompressorFilter) Filter(dry volume.Matrix) volume.Matrix {
+	if dry.Channels == 0 {
+		return volume.Matrix{}
+	}
+	if e.playbackRate == 0 {
+		return dry
+	}
+
+	// the detector looks at the incoming signal, while the output is delayed by
+	// the predelay, which lets the compressor react ahead of transients
+	var peak float64
+	for c := 0; c < dry.Channels; c++ {
+		peak = max(peak, math.Abs(float64(dry.StaticMatrix[c])))
+	}
+	coef := e.releaseCoef
+	if peak > e.env {
+		coef = e.attackCoef
+	}
+	e.env = coef*e.env + (1-coef)*peak
+
+	reduction := 0.0
+	if over := ampToDB(e.env) - e.threshold; over > 0 {
+		reduction = over * (1 - 1/e.ratio)
+	}
+	amp := dbToAmp(e.gain - reduction)
+
+	wet := dry
+	for c := 0; c < dry.Channels; c++ {
+		for len(e.lines) <= c {
+			var l dmoDelayLine
+			l.resize(e.bufferSize)
+			e.lines = append(e.lines, l)
+		}
+		line := &e.lines[c]
+		line.write(float64(dry.StaticMatrix[c]))
+		wet.StaticMatrix[c] = volume.Volume(line.read(e.predelay) * amp)
+	}
+	return wet
+}
+
+func (e *CompressorFilter) UpdateEnv(val uint8) {
+
+}
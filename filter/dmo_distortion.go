@@ -0,0 +1,98 @@
+package filter
+
+import (
+	"math"
+
+	"github.com/gotracker/playback/frequency"
+	"github.com/gotracker/playback/mixing/volume"
+)
+
+// DistortionFilterSettings are the parameters of the DMO Distortion effect
+type DistortionFilterSettings struct {
+	Gain                  float32
+	Edge                  float32
+	PreLowpassCutoff      float32
+	PostEQCenterFrequency float32
+	PostEQBandwidth       float32
+}
+
+type DistortionFilterFactory struct {
+	Reserved00 [4]byte
+	DistortionFilterSettings
+}
+
+func (e *DistortionFilterFactory) Factory() Factory {
+	return func(instrument frequency.Frequency) Filter {
+		return &DistortionFilter{
+			DistortionFilterSettings: e.DistortionFilterSettings,
+		}
+	}
+}
+
+type distortionChannelData struct {
+	pre  biquadState
+	post biquadState
+}
+
+//===========
+
+// DistortionFilter is a native implementation of the DMO Distortion effect:
+// the signal is low-passed, waveshaped, then run through a band-pass equalizer
+type DistortionFilter struct {
+	DistortionFilterSettings
+
+	channels []distortionChannelData
+	pre      biquad
+	post     biquad
+	drive    float64
+	gain     float64
+
+	playbackRate frequency.Frequency
+}
+
+func (e *DistortionFilter) SetPlaybackRate(playback frequency.Frequency) {
+	if e.playbackRate == playback {
+		return
+	}
+	e.playbackRate = playback
+
+	rate := float64(playback)
+	e.pre = lowpassBiquad(rate, dmoParam(e.PreLowpassCutoff, 100, 8000), math.Sqrt2/2)
+	e.post = bandpassBiquad(rate, dmoParam(e.PostEQCenterFrequency, 100, 8000), dmoParam(e.PostEQBandwidth, 100, 8000))
+	e.drive = 1 + dmoParam(e.Edge, 0, 100)/100*31
+	e.gain = dbToAmp(dmoParam(e.Gain, -60, 0))
+}
+
+func (e *DistortionFilter) Clone() Filter {
+	clone := *e
+	clone.channels = make([]distortionChannelData, len(e.channels))
+	copy(clone.channels, e.channels)
+	return &clone
+}
+
+func (e *DistortionFilter) Filter(dry volume.Matrix) volume.Matrix {
+	if dry.Channels == 0 {
+		return volume.Matrix{}
+	}
+	if e.playbackRate == 0 {
+		return dry
+	}
+
+	wet := dry
+	for c := 0; c < dry.Channels; c++ {
+		for len(e.channels) <= c {
+			e.channels = append(e.channels, distortionChannelData{})
+		}
+		cd := &e.channels[c]
+
+		x := cd.pre.process(&e.pre, float64(dry.StaticMatrix[c]))
+		x = math.Tanh(x*e.drive) / math.Tanh(e.drive)
+		x = cd.post.process(&e.post, x)
+		wet.StaticMatrix[c] = volume.Volume(x * e.gain)
+	}
+	return wet
+}
+
+func (e *DistortionFilter) UpdateEnv(val uint8) {
+
+}
@@ -0,0 +1,68 @@
+package filter
+
+import (
+	"github.com/gotracker/playback/frequency"
+	"github.com/gotracker/playback/mixing/volume"
+)
+
+// FlangerFilterSettings are the parameters of the DMO Flanger effect
+type FlangerFilterSettings struct {
+	WetDryMix float32
+	Depth     float32
+	Frequency float32
+	WaveShape float32
+	Phase     float32
+	Feedback  float32
+	Delay     float32
+}
+
+type FlangerFilterFactory struct {
+	Reserved00 [4]byte
+	FlangerFilterSettings
+}
+
+func (e *FlangerFilterFactory) Factory() Factory {
+	return func(instrument frequency.Frequency) Filter {
+		return &FlangerFilter{
+			FlangerFilterSettings: e.FlangerFilterSettings,
+		}
+	}
+}
+
+//===========
+
+// FlangerFilter is a native implementation of the DMO Flanger effect.
+// It is a chorus with a much shorter delay.
+type FlangerFilter struct {
+	FlangerFilterSettings
+	core modulatedDelay
+}
+
+func (e *FlangerFilter) SetPlaybackRate(playback frequency.Frequency) {
+	e.core.setup(playback, modulatedDelaySettings{
+		WetDryMix: float64(e.WetDryMix),
+		Depth:     float64(e.Depth),
+		Frequency: dmoParam(e.Frequency, 0, 10),
+		Triangle:  e.WaveShape < 1,
+		Phase:     e.Phase,
+		Feedback:  dmoParam(e.Feedback, -99, 99) / 100,
+		DelayMs:   dmoParam(e.Delay, 0, 4),
+		MaxDelay:  4,
+	})
+}
+
+func (e *FlangerFilter) Clone() Filter {
+	clone := FlangerFilter{
+		FlangerFilterSettings: e.FlangerFilterSettings,
+		core:                  e.core.clone(),
+	}
+	return &clone
+}
+
+func (e *FlangerFilter) Filter(dry volume.Matrix) volume.Matrix {
+	return e.core.filter(dry)
+}
+
+func (e *FlangerFilter) UpdateEnv(val uint8) {
+
+}
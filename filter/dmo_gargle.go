@@ -0,0 +1,89 @@
+package filter
+
+import (
+	"github.com/gotracker/playback/frequency"
+	"github.com/gotracker/playback/mixing/volume"
+)
+
+// GargleFilterSettings are the parameters of the DMO Gargle effect
+type GargleFilterSettings struct {
+	Rate      float32
+	WaveShape float32
+}
+
+type GargleFilterFactory struct {
+	Reserved00 [4]byte
+	GargleFilterSettings
+}
+
+func (e *GargleFilterFactory) Factory() Factory {
+	return func(instrument frequency.Frequency) Filter {
+		return &GargleFilter{
+			GargleFilterSettings: e.GargleFilterSettings,
+		}
+	}
+}
+
+//===========
+
+// GargleFilter is a native implementation of the DMO Gargle effect,
+// which modulates the amplitude of the signal with a triangle or square wave
+type GargleFilter struct {
+	GargleFilterSettings
+
+	period  int // samples
+	counter int
+	square  bool
+
+	playbackRate frequency.Frequency
+}
+
+func (e *GargleFilter) SetPlaybackRate(playback frequency.Frequency) {
+	if e.playbackRate == playback {
+		return
+	}
+	e.playbackRate = playback
+
+	rate := dmoParam(e.Rate, 1, 1000)
+	e.period = max(int(float64(playback)/rate), 2)
+	e.square = e.WaveShape >= 0.5
+	e.counter %= e.period
+}
+
+func (e *GargleFilter) Clone() Filter {
+	clone := *e
+	return &clone
+}
+
+func (e *GargleFilter) Filter(dry volume.Matrix) volume.Matrix {
+	if dry.Channels == 0 {
+		return volume.Matrix{}
+	}
+	if e.playbackRate == 0 {
+		return dry
+	}
+
+	half := e.period / 2
+	var amp volume.Volume
+	switch {
+	case e.square:
+		if e.counter < half {
+			amp = 1
+		}
+	case e.counter < half:
+		amp = volume.Volume(e.counter) / volume.Volume(half)
+	default:
+		amp = volume.Volume(e.period-e.counter) / volume.Volume(e.period-half)
+	}
+
+	e.counter++
+	if e.counter >= e.period {
+		e.counter = 0
+	}
+
+	return dry.Apply(amp)
+}
+
+func (e *GargleFilter) UpdateEnv(val uint8) {
+
+}
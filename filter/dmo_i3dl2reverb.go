@@ -0,0 +1,144 @@
+package filter
+
+import (
+	"math"
+
+	"github.com/gotracker/playback/frequency"
+	"github.com/gotracker/playback/mixing/volume"
+)
+
+// I3DL2ReverbFilterSettings are the parameters of the DMO I3DL2Reverb effect
+type I3DL2ReverbFilterSettings struct {
+	Room              float32
+	RoomHF            float32
+	RoomRolloffFactor float32
+	DecayTime         float32
+	DecayHFRatio      float32
+	Reflections       float32
+	ReflectionsDelay  float32
+	Reverb            float32
+	ReverbDelay       float32
+	Diffusion         float32
+	Density           float32
+	HFReference       float32
+	Quality           float32
+}
+
+type I3DL2ReverbFilterFactory struct {
+	Reserved00 [4]byte
+	I3DL2ReverbFilterSettings
+}
+
+func (e *I3DL2ReverbFilterFactory) Factory() Factory {
+	return func(instrument frequency.Frequency) Filter {
+		return &I3DL2ReverbFilter{
+			I3DL2ReverbFilterSettings: e.I3DL2ReverbFilterSettings,
+		}
+	}
+}
+
+type i3dl2ReverbChannelData struct {
+	hf   biquadState
+	line dmoDelayLine
+}
+
+//===========
+
+// I3DL2ReverbFilter is a native implementation of the DMO I3DL2Reverb effect.
+// The room rolloff factor only applies to 3D sources and the quality setting
+// only trades accuracy for speed, so neither affects the output here.
+type I3DL2ReverbFilter struct {
+	I3DL2ReverbFilterSettings
+
+	channels         []i3dl2ReverbChannelData
+	tank             reverbTank
+	hf               biquad
+	room             float64
+	roomHF           float64
+	reflections      float64
+	reverb           float64
+	reflectionsDelay float64 // samples
+	reverbDelay      float64 // samples, after the reflections
+	bufferSize       int
+
+	playbackRate frequency.Frequency
+}
+
+// millibelsToAmp converts a level in hundredths of a decibel to a linear amplitude
+func millibelsToAmp(mb float64) float64 {
+	return dbToAmp(mb / 100)
+}
+
+func (e *I3DL2ReverbFilter) SetPlaybackRate(playback frequency.Frequency) {
+	if e.playbackRate == playback {
+		return
+	}
+	e.playbackRate = playback
+
+	rate := float64(playback)
+	e.room = millibelsToAmp(dmoParam(e.Room, -10000, 0))
+	e.roomHF = millibelsToAmp(dmoParam(e.RoomHF, -10000, 0))
+	e.reflections = millibelsToAmp(dmoParam(e.Reflections, -10000, 1000))
+	e.reverb = millibelsToAmp(dmoParam(e.Reverb, -10000, 2000))
+	e.reflectionsDelay = dmoParam(e.ReflectionsDelay, 0, 0.3) * rate
+	e.reverbDelay = dmoParam(e.ReverbDelay, 0, 0.1) * rate
+	e.hf = lowpassBiquad(rate, dmoParam(e.HFReference, 20, 20000), math.Sqrt2/2)
+	e.tank.setup(rate,
+		dmoParam(e.DecayTime, 0.1, 20),
+		dmoParam(e.DecayHFRatio, 0.1, 2),
+		dmoParam(e.Diffusion, 0, 100)/100,
+		dmoParam(e.Density, 0, 100)/100,
+	)
+
+	e.bufferSize = int(0.4*rate) + 4
+	for i := range e.channels {
+		e.channels[i].line.resize(e.bufferSize)
+	}
+}
+
+func (e *I3DL2ReverbFilter) Clone() Filter {
+	clone := *e
+	clone.tank = e.tank.clone()
+	clone.channels = make([]i3dl2ReverbChannelData, len(e.channels))
+	for i, cd := range e.channels {
+		clone.channels[i] = i3dl2ReverbChannelData{
+			hf:   cd.hf,
+			line: cd.line.clone(),
+		}
+	}
+	return &clone
+}
+
+func (e *I3DL2ReverbFilter) Filter(dry volume.Matrix) volume.Matrix {
+	if dry.Channels == 0 {
+		return volume.Matrix{}
+	}
+	if e.playbackRate == 0 {
+		return dry
+	}
+
+	wet := dry
+	for c := 0; c < dry.Channels; c++ {
+		for len(e.channels) <= c {
+			var cd i3dl2ReverbChannelData
+			cd.line.resize(e.bufferSize)
+			e.channels = append(e.channels, cd)
+		}
+		cd := &e.channels[c]
+
+		in := float64(dry.StaticMatrix[c])
+		// RoomHF attenuates the content above the HF reference frequency
+		low := cd.hf.process(&e.hf, in)
+		cd.line.write(low + (in-low)*e.roomHF)
+
+		early := cd.line.read(e.reflectionsDelay) * e.reflections
+		late := e.tank.process(c, cd.line.read(e.reflectionsDelay+e.reverbDelay)) * e.reverb
+
+		wet.StaticMatrix[c] = volume.Volume(in + (early+late)*e.room)
+	}
+	return wet
+}
+
+func (e *I3DL2ReverbFilter) UpdateEnv(val uint8) {
+
+}
@@ -0,0 +1,79 @@
+package filter
+
+import (
+	"github.com/gotracker/playback/frequency"
+	"github.com/gotracker/playback/mixing/volume"
+)
+
+// ParamEqFilterSettings are the parameters of the DMO ParamEq effect
+type ParamEqFilterSettings struct {
+	Center    float32
+	Bandwidth float32
+	Gain      float32
+}
+
+type ParamEqFilterFactory struct {
+	Reserved00 [4]byte
+	ParamEqFilterSettings
+}
+
+func (e *ParamEqFilterFactory) Factory() Factory {
+	return func(instrument frequency.Frequency) Filter {
+		return &ParamEqFilter{
+			ParamEqFilterSettings: e.ParamEqFilterSettings,
+		}
+	}
+}
+
+//===========
+
+// ParamEqFilter is a native implementation of the DMO ParamEq effect,
+// a single band peaking equalizer
+type ParamEqFilter struct {
+	ParamEqFilterSettings
+
+	channels []biquadState
+	eq       biquad
+
+	playbackRate frequency.Frequency
+}
+
+func (e *ParamEqFilter) SetPlaybackRate(playback frequency.Frequency) {
+	if e.playbackRate == playback {
+		return
+	}
+	e.playbackRate = playback
+
+	// the bandwidth is expressed in semitones
+	octaves := dmoParam(e.Bandwidth, 1, 36) / 12
+	e.eq = peakingBiquad(float64(playback), dmoParam(e.Center, 80, 16000), octaves, dmoParam(e.Gain, -15, 15))
+}
+
+func (e *ParamEqFilter) Clone() Filter {
+	clone := *e
+	clone.channels = make([]biquadState, len(e.channels))
+	copy(clone.channels, e.channels)
+	return &clone
+}
+
+func (e *ParamEqFilter) Filter(dry volume.Matrix) volume.Matrix {
+	if dry.Channels == 0 {
+		return volume.Matrix{}
+	}
+	if e.playbackRate == 0 {
+		return dry
+	}
+
+	wet := dry
+	for c := 0; c < dry.Channels; c++ {
+		for len(e.channels) <= c {
+			e.channels = append(e.channels, biquadState{})
+		}
+		wet.StaticMatrix[c] = volume.Volume(e.channels[c].process(&e.eq, float64(dry.StaticMatrix[c])))
+	}
+	return wet
+}
+
+func (e *ParamEqFilter) UpdateEnv(val uint8) {
+
+}
@@ -0,0 +1,119 @@
+package filter
+
+import (
+	"math"
+)
+
+// reverbTank is a Schroeder-style reverberator (parallel damped comb filters
+// followed by series all-pass filters), shared by the DMO reverb effects
+
+var (
+	reverbCombTunings    = [...]int{1116, 1188, 1277, 1356, 1422, 1491}
+	reverbAllpassTunings = [...]int{556, 441, 341}
+)
+
+const (
+	reverbTuningRate   = 44100
+	reverbStereoSpread = 23
+	reverbInputGain    = 0.1
+)
+
+type reverbComb struct {
+	line     []float64
+	pos      int
+	store    float64
+	feedback float64
+}
+
+func (c *reverbComb) process(in, damp float64) float64 {
+	out := c.line[c.pos]
+	c.store = out*(1-damp) + c.store*damp
+	c.line[c.pos] = in + c.store*c.feedback
+	c.pos++
+	if c.pos >= len(c.line) {
+		c.pos = 0
+	}
+	return out
+}
+
+type reverbAllpass struct {
+	line []float64
+	pos  int
+}
+
+func (a *reverbAllpass) process(in, g float64) float64 {
+	buf := a.line[a.pos]
+	a.line[a.pos] = in + buf*g
+	a.pos++
+	if a.pos >= len(a.line) {
+		a.pos = 0
+	}
+	return buf - in
+}
+
+type reverbTank struct {
+	combs     [2][len(reverbCombTunings)]reverbComb
+	allpasses [2][len(reverbAllpassTunings)]reverbAllpass
+	damp      float64
+	diffusion float64
+}
+
+// setup sizes the tank for the playback rate. decay is the RT60 time in seconds,
+// hfRatio is the ratio of the high-frequency decay time to the decay time, and
+// diffusion and density are values between 0 and 1
+func (t *reverbTank) setup(rate, decay, hfRatio, diffusion, density float64) {
+	scale := rate / reverbTuningRate * (0.5 + 0.5*density)
+	decay = max(decay, 0.001)
+
+	for ch := range t.combs {
+		spread := ch * reverbStereoSpread
+		for i := range t.combs[ch] {
+			c := &t.combs[ch][i]
+			size := max(int(float64(reverbCombTunings[i]+spread)*scale), 1)
+			c.line = make([]float64, size)
+			c.pos = 0
+			c.store = 0
+			c.feedback = math.Pow(10, -3*float64(size)/(decay*rate))
+		}
+		for i := range t.allpasses[ch] {
+			a := &t.allpasses[ch][i]
+			size := max(int(float64(reverbAllpassTunings[i]+spread)*scale), 1)
+			a.line = make([]float64, size)
+			a.pos = 0
+		}
+	}
+
+	t.damp = min(max(1-hfRatio, 0), 0.9)
+	t.diffusion = 0.2 + 0.5*diffusion
+}
+
+func (t reverbTank) clone() reverbTank {
+	c := t
+	for ch := range t.combs {
+		for i := range t.combs[ch] {
+			c.combs[ch][i].line = append([]float64(nil), t.combs[ch][i].line...)
+		}
+		for i := range t.allpasses[ch] {
+			c.allpasses[ch][i].line = append([]float64(nil), t.allpasses[ch][i].line...)
+		}
+	}
+	return c
+}
+
+// process runs a single sample of a channel through the tank and returns the reverberated result
+func (t *reverbTank) process(ch int, in float64) float64 {
+	side := ch % 2
+	if len(t.combs[side][0].line) == 0 {
+		return 0
+	}
+
+	in *= reverbInputGain
+	var out float64
+	for i := range t.combs[side] {
+		out += t.combs[side][i].process(in, t.damp)
+	}
+	for i := range t.allpasses[side] {
+		out = t.allpasses[side][i].process(out, t.diffusion)
+	}
+	return out
+}
@@ -0,0 +1,80 @@
+package filter
+
+import (
+	"github.com/gotracker/playback/frequency"
+	"github.com/gotracker/playback/mixing/volume"
+)
+
+// WavesReverbFilterSettings are the parameters of the DMO WavesReverb effect
+type WavesReverbFilterSettings struct {
+	InGain          float32
+	ReverbMix       float32
+	ReverbTime      float32
+	HighFreqRTRatio float32
+}
+
+type WavesReverbFilterFactory struct {
+	Reserved00 [4]byte
+	WavesReverbFilterSettings
+}
+
+func (e *WavesReverbFilterFactory) Factory() Factory {
+	return func(instrument frequency.Frequency) Filter {
+		return &WavesReverbFilter{
+			WavesReverbFilterSettings: e.WavesReverbFilterSettings,
+		}
+	}
+}
+
+//===========
+
+// WavesReverbFilter is a native implementation of the DMO WavesReverb effect
+type WavesReverbFilter struct {
+	WavesReverbFilterSettings
+
+	tank   reverbTank
+	inGain float64
+	mix    float64
+
+	playbackRate frequency.Frequency
+}
+
+func (e *WavesReverbFilter) SetPlaybackRate(playback frequency.Frequency) {
+	if e.playbackRate == playback {
+		return
+	}
+	e.playbackRate = playback
+
+	e.inGain = dbToAmp(dmoParam(e.InGain, -96, 0))
+	// -96dB is the dry signal only, 0dB is the reverberated signal only
+	e.mix = dbToAmp(dmoParam(e.ReverbMix, -96, 0))
+	reverbTime := dmoParam(e.ReverbTime, 0.001, 3000) / 1000
+	e.tank.setup(float64(playback), reverbTime, dmoParam(e.HighFreqRTRatio, 0.001, 0.999), 1, 1)
+}
+
+func (e *WavesReverbFilter) Clone() Filter {
+	clone := *e
+	clone.tank = e.tank.clone()
+	return &clone
+}
+
+func (e *WavesReverbFilter) Filter(dry volume.Matrix) volume.Matrix {
+	if dry.Channels == 0 {
+		return volume.Matrix{}
+	}
+	if e.playbackRate == 0 {
+		return dry
+	}
+
+	wet := dry
+	for c := 0; c < dry.Channels; c++ {
+		in := float64(dry.StaticMatrix[c]) * e.inGain
+		rev := e.tank.process(c, in)
+		wet.StaticMatrix[c] = volume.Volume(in*(1-e.mix) + rev*e.mix)
+	}
+	return wet
+}
+
+func (e *WavesReverbFilter) UpdateEnv(val uint8) {
+
+}
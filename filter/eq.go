@@ -0,0 +1,151 @@
+package filter
+
+import (
+	"math"
+
+	"github.com/gotracker/playback/frequency"
+	"github.com/gotracker/playback/mixing/volume"
+)
+
+// EQBandType is the shape of an equalizer band
+type EQBandType uint8
+
+const (
+	// EQBandPeaking boosts or cuts the frequencies around the band's frequency
+	EQBandPeaking = EQBandType(iota)
+	// EQBandLowShelf boosts or cuts the frequencies below the band's frequency
+	EQBandLowShelf
+	// EQBandHighShelf boosts or cuts the frequencies above the band's frequency
+	EQBandHighShelf
+)
+
+func (t EQBandType) String() string {
+	switch t {
+	case EQBandPeaking:
+		return "peaking"
+	case EQBandLowShelf:
+		return "lowshelf"
+	case EQBandHighShelf:
+		return "highshelf"
+	default:
+		return "unknown"
+	}
+}
+
+// EQBand is a single band of an equalizer
+type EQBand struct {
+	Type      EQBandType
+	Frequency float64 // center (peaking) or corner (shelves) frequency in Hz
+	Gain      float64 // in dB
+	Q         float64 // 0 uses a Q of 1/sqrt(2)
+}
+
+// EQFilter is a multi-band parametric equalizer
+type EQFilter struct {
+	Bands []EQBand
+
+	sections []biquad
+	channels [][]biquadState
+
+	playbackRate frequency.Frequency
+}
+
+// NewEQFilter creates a new EQFilter with the provided bands
+func NewEQFilter(bands ...EQBand) *EQFilter {
+	return &EQFilter{
+		Bands: bands,
+	}
+}
+
+// eqBiquad builds the filter section for an equalizer band
+func eqBiquad(rate float64, band EQBand) biquad {
+	q := band.Q
+	if q <= 0 {
+		q = math.Sqrt2 / 2
+	}
+	w0 := 2 * math.Pi * clampBiquadFreq(rate, band.Frequency) / rate
+	cosW0 := math.Cos(w0)
+	alpha := math.Sin(w0) / (2 * q)
+	a := math.Pow(10, band.Gain/40)
+
+	switch band.Type {
+	case EQBandLowShelf:
+		sq := 2 * math.Sqrt(a) * alpha
+		return newBiquad(
+			a*((a+1)-(a-1)*cosW0+sq),
+			2*a*((a-1)-(a+1)*cosW0),
+			a*((a+1)-(a-1)*cosW0-sq),
+			(a+1)+(a-1)*cosW0+sq,
+			-2*((a-1)+(a+1)*cosW0),
+			(a+1)+(a-1)*cosW0-sq,
+		)
+	case EQBandHighShelf:
+		sq := 2 * math.Sqrt(a) * alpha
+		return newBiquad(
+			a*((a+1)+(a-1)*cosW0+sq),
+			-2*a*((a-1)+(a+1)*cosW0),
+			a*((a+1)+(a-1)*cosW0-sq),
+			(a+1)-(a-1)*cosW0+sq,
+			2*((a-1)-(a+1)*cosW0),
+			(a+1)-(a-1)*cosW0-sq,
+		)
+	default:
+		return newBiquad(1+alpha*a, -2*cosW0, 1-alpha*a, 1+alpha/a, -2*cosW0, 1-alpha/a)
+	}
+}
+
+func (e *EQFilter) SetPlaybackRate(playback frequency.Frequency) {
+	if e.playbackRate == playback && len(e.sections) == len(e.Bands) {
+		return
+	}
+	e.playbackRate = playback
+	if playback <= 0 {
+		return
+	}
+
+	e.sections = make([]biquad, len(e.Bands))
+	for i, band := range e.Bands {
+		e.sections[i] = eqBiquad(float64(playback), band)
+	}
+	for c := range e.channels {
+		e.channels[c] = make([]biquadState, len(e.sections))
+	}
+}
+
+func (e *EQFilter) Clone() Filter {
+	c := *e
+	c.Bands = append([]EQBand(nil), e.Bands...)
+	c.sections = append([]biquad(nil), e.sections...)
+	c.channels = make([][]biquadState, len(e.channels))
+	for i, ch := range e.channels {
+		c.channels[i] = append([]biquadState(nil), ch...)
+	}
+	return &c
+}
+
+// Filter processes incoming (dry) samples and produces an outgoing filtered (wet) result
+func (e *EQFilter) Filter(dry volume.Matrix) volume.Matrix {
+	if dry.Channels == 0 {
+		return volume.Matrix{}
+	}
+	if e.playbackRate <= 0 {
+		return dry
+	}
+
+	wet := dry
+	for c := 0; c < dry.Channels; c++ {
+		for len(e.channels) <= c {
+			e.channels = append(e.channels, make([]biquadState, len(e.sections)))
+		}
+		s := float64(dry.StaticMatrix[c])
+		for i := range e.sections {
+			s = e.channels[c][i].process(&e.sections[i], s)
+		}
+		wet.StaticMatrix[c] = volume.Volume(s)
+	}
+	return wet
+}
+
+// UpdateEnv updates the filter with the value from the filter envelope
+func (e *EQFilter) UpdateEnv(v uint8) {
+}
@@ -92,3 +92,436 @@ func TestResonantFilterAppliesCoefficients(t *testing.T) {
 		t.Fatalf("expected filtered output near %v, got %v", expected, wet.StaticMatrix[0])
 	}
 }
+
+func TestResonantFilterSetCutoffAndResonance(t *testing.T) {
+	f := NewITResonantFilter(0, 0, false, false).(*ResonantFilter)
+	f.SetPlaybackRate(frequency.Frequency(44100))
+	if f.enabled {
+		t.Fatalf("expected filter to be bypassed without cutoff or resonance")
+	}
+
+	f.SetCutoff(0x40)
+	if !f.enabled {
+		t.Fatalf("expected filter to be enabled after setting cutoff")
+	}
+	if c, set := f.cutoff.Get(); !set || c != 0x40 {
+		t.Fatalf("expected cutoff 0x40, got %v (set=%v)", c, set)
+	}
+	a0 := f.a0
+
+	f.SetResonance(0xFF)
+	if r, set := f.resonance.Get(); !set || r != 0x7F {
+		t.Fatalf("expected resonance clamped to 0x7F, got %v (set=%v)", r, set)
+	}
+	if f.a0 == a0 {
+		t.Fatalf("expected coefficients to change with resonance")
+	}
+}
+
+func TestDMOFiltersHandleEmptyInput(t *testing.T) {
+	filters := []Filter{
+		&ChorusFilter{},
+		&FlangerFilter{},
+		&CompressorFilter{},
+		&DistortionFilter{},
+		&GargleFilter{},
+		&ParamEqFilter{},
+		&WavesReverbFilter{},
+		&I3DL2ReverbFilter{},
+	}
+	for _, f := range filters {
+		f.SetPlaybackRate(frequency.Frequency(44100))
+		if got := f.Filter(volume.Matrix{}); got.Channels != 0 {
+			t.Fatalf("%T: expected empty output for empty input, got %v", f, got)
+		}
+		dry := volume.Matrix{StaticMatrix: volume.StaticMatrix{0.5, -0.5}, Channels: 2}
+		if got := f.Clone().Filter(dry); got.Channels != 2 {
+			t.Fatalf("%T: expected stereo output from clone, got %d channels", f, got.Channels)
+		}
+	}
+}
+
+func TestChorusFilterDryWhenWetMixIsZero(t *testing.T) {
+	f := &ChorusFilter{ChorusFilterSettings: ChorusFilterSettings{WetDryMix: 0, Depth: 0.5, Frequency: 0.5, Delay: 0.5, Feedback: 0.5}}
+	f.SetPlaybackRate(frequency.Frequency(44100))
+
+	dry := volume.Matrix{StaticMatrix: volume.StaticMatrix{0.25, -0.75}, Channels: 2}
+	for i := 0; i < 100; i++ {
+		assertMatrixAlmostEqual(t, f.Filter(dry), dry, 1e-6)
+	}
+}
+
+func TestCompressorFilterUnityBelowThreshold(t *testing.T) {
+	// gain 0dB (0.5), threshold 0dB (1.0), no predelay
+	f := &CompressorFilter{CompressorFilterSettings: CompressorFilterSettings{Gain: 0.5, Threshold: 1, Ratio: 1}}
+	f.SetPlaybackRate(frequency.Frequency(44100))
+
+	dry := volume.Matrix{StaticMatrix: volume.StaticMatrix{0.5, -0.5}, Channels: 2}
+	assertMatrixAlmostEqual(t, f.Filter(dry), dry, 1e-6)
+}
+
+func TestCompressorFilterReducesLoudSignal(t *testing.T) {
+	// gain 0dB, threshold -60dB, ratio 100:1, fastest attack
+	f := &CompressorFilter{CompressorFilterSettings: CompressorFilterSettings{Gain: 0.5, Threshold: 0, Ratio: 1}}
+	f.SetPlaybackRate(frequency.Frequency(44100))
+
+	dry := volume.Matrix{StaticMatrix: volume.StaticMatrix{1, 1}, Channels: 2}
+	var out volume.Matrix
+	for i := 0; i < 1000; i++ {
+		out = f.Filter(dry)
+	}
+	if out.StaticMatrix[0] >= 0.1 {
+		t.Fatalf("expected heavy gain reduction, got %v", out.StaticMatrix[0])
+	}
+}
+
+func TestGargleFilterSquareGatesSignal(t *testing.T) {
+	// rate 1Hz at 4Hz playback: 2 samples on, 2 samples off
+	f := &GargleFilter{GargleFilterSettings: GargleFilterSettings{Rate: 0, WaveShape: 1}}
+	f.SetPlaybackRate(frequency.Frequency(4))
+
+	dry := volume.Matrix{StaticMatrix: volume.StaticMatrix{1}, Channels: 1}
+	want := []volume.Volume{1, 1, 0, 0, 1}
+	for i, w := range want {
+		if got := f.Filter(dry).StaticMatrix[0]; got != w {
+			t.Fatalf("sample %d: got %v want %v", i, got, w)
+		}
+	}
+}
+
+func TestParamEqFilterFlatAtZeroGain(t *testing.T) {
+	f := &ParamEqFilter{ParamEqFilterSettings: ParamEqFilterSettings{Center: 0.5, Bandwidth: 0.5, Gain: 0.5}}
+	f.SetPlaybackRate(frequency.Frequency(44100))
+
+	for i := 0; i < 64; i++ {
+		v := volume.Volume(math.Sin(float64(i) / 3))
+		dry := volume.Matrix{StaticMatrix: volume.StaticMatrix{v}, Channels: 1}
+		assertMatrixAlmostEqual(t, f.Filter(dry), dry, 1e-5)
+	}
+}
+
+func TestWavesReverbFilterDryAtMinimumMix(t *testing.T) {
+	// input gain 0dB, reverb mix -96dB
+	f := &WavesReverbFilter{WavesReverbFilterSettings: WavesReverbFilterSettings{InGain: 1, ReverbMix: 0, ReverbTime: 0.5, HighFreqRTRatio: 0.5}}
+	f.SetPlaybackRate(frequency.Frequency(44100))
+
+	dry := volume.Matrix{StaticMatrix: volume.StaticMatrix{0.5, -0.5}, Channels: 2}
+	for i := 0; i < 100; i++ {
+		assertMatrixAlmostEqual(t, f.Filter(dry), dry, 1e-3)
+	}
+}
+
+func TestI3DL2ReverbFilterProducesTail(t *testing.T) {
+	f := &I3DL2ReverbFilter{I3DL2ReverbFilterSettings: I3DL2ReverbFilterSettings{
+		Room:        1,
+		RoomHF:      1,
+		DecayTime:   0.1,
+		Reflections: 0.9,
+		Reverb:      0.9,
+		Diffusion:   1,
+		Density:     1,
+		HFReference: 0.25,
+	}}
+	f.SetPlaybackRate(frequency.Frequency(8000))
+
+	f.Filter(volume.Matrix{StaticMatrix: volume.StaticMatrix{1, 1}, Channels: 2})
+	silence := volume.Matrix{Channels: 2}
+	var energy float64
+	for i := 0; i < 8000; i++ {
+		out := f.Filter(silence)
+		energy += math.Abs(float64(out.StaticMatrix[0]))
+	}
+	if energy == 0 {
+		t.Fatalf("expected a reverb tail after an impulse")
+	}
+}
+
+func TestChainAppliesFiltersInOrder(t *testing.T) {
+	gate := &GargleFilter{GargleFilterSettings: GargleFilterSettings{Rate: 0, WaveShape: 1}}
+	unity := &CompressorFilter{CompressorFilterSettings: CompressorFilterSettings{Gain: 0.5, Threshold: 1}}
+	chain := Chain{gate, unity}
+	chain.SetPlaybackRate(frequency.Frequency(4))
+
+	clone := chain.Clone().(Chain)
+	if clone[0] == chain[0] || clone[1] == chain[1] {
+		t.Fatalf("expected clone to copy the filters")
+	}
+
+	dry := volume.Matrix{StaticMatrix: volume.StaticMatrix{0.5}, Channels: 1}
+	want := []volume.Volume{0.5, 0.5, 0, 0}
+	for i, w := range want {
+		if got := chain.Filter(dry).StaticMatrix[0]; !almostEqualVol(got, w, 1e-6) {
+			t.Fatalf("sample %d: got %v want %v", i, got, w)
+		}
+	}
+}
+
+// amigaFilterPeak runs a full-scale Nyquist-rate square wave through the filter and returns the
+// peak output level once it has settled
+func amigaFilterPeak(f *AmigaFilter) volume.Volume {
+	var peak volume.Volume
+	for i := 0; i < 4096; i++ {
+		v := volume.Volume(1)
+		if i&1 != 0 {
+			v = -1
+		}
+		out := f.Filter(volume.Matrix{StaticMatrix: volume.StaticMatrix{v}, Channels: 1})
+		if i >= 2048 {
+			peak = max(peak, volume.Volume(math.Abs(float64(out.StaticMatrix[0]))))
+		}
+	}
+	return peak
+}
+
+func TestAmigaFilterModelsAndLED(t *testing.T) {
+	rate := frequency.Frequency(44100)
+
+	a500 := amigaFilterPeak(NewAmigaFilter(rate, AmigaFilterSettings{Model: AmigaModelA500}))
+	a1200 := amigaFilterPeak(NewAmigaFilter(rate, AmigaFilterSettings{Model: AmigaModelA1200}))
+	led := amigaFilterPeak(NewAmigaFilter(rate, AmigaFilterSettings{Model: AmigaModelA1200, LED: true}))
+
+	if a1200 <= a500 {
+		t.Fatalf("expected the A1200 to pass more high end than the A500: a500=%v a1200=%v", a500, a1200)
+	}
+	if led >= a1200 {
+		t.Fatalf("expected the LED filter to cut the high end: off=%v on=%v", a1200, led)
+	}
+}
+
+func TestAmigaFilterBlocksDC(t *testing.T) {
+	f := NewAmigaFilter(frequency.Frequency(44100), AmigaFilterSettings{Model: AmigaModelA500})
+
+	var out volume.Matrix
+	for i := 0; i < 44100; i++ {
+		out = f.Filter(volume.Matrix{StaticMatrix: volume.StaticMatrix{1}, Channels: 1})
+	}
+	if !almostEqualVol(out.StaticMatrix[0], 0, 1e-3) {
+		t.Fatalf("expected DC to be blocked, got %v", out.StaticMatrix[0])
+	}
+}
+
+func TestAmigaFilterSetLED(t *testing.T) {
+	f := NewAmigaFilter(frequency.Frequency(44100), AmigaFilterSettings{})
+
+	var led LEDFilter = f
+	led.SetLED(true)
+	if !f.LED {
+		t.Fatalf("expected LED filter to be switched on")
+	}
+
+	c := f.Clone().(*AmigaFilter)
+	c.SetLED(false)
+	if !f.LED {
+		t.Fatalf("expected clone to switch the LED filter independently")
+	}
+}
+
+func TestSoundBlasterFilterQuantizesToDAC(t *testing.T) {
+	rate := frequency.Frequency(44100)
+	in := volume.Matrix{StaticMatrix: volume.StaticMatrix{0.3, 0.3}, Channels: 2}
+
+	pro := NewSoundBlasterFilter(rate, SoundBlasterFilterSettings{Model: SoundBlasterModelPro, Stereo: true})
+	sb16 := NewSoundBlasterFilter(rate, SoundBlasterFilterSettings{Model: SoundBlasterModel16, Stereo: true})
+
+	// the first sample is barely touched by the coupling capacitors, so it shows the DAC step
+	want := volume.Volume(math.Round(0.3*128) / 128)
+	if out := pro.Filter(in); !almostEqualVol(out.StaticMatrix[0], want, 1e-3) {
+		t.Fatalf("expected 8-bit quantization to %v, got %v", want, out.StaticMatrix[0])
+	}
+	if out := sb16.Filter(in); !almostEqualVol(out.StaticMatrix[0], 0.3, 1e-3) {
+		t.Fatalf("expected 16-bit output to stay near the input, got %v", out.StaticMatrix[0])
+	}
+
+	clip := NewSoundBlasterFilter(rate, SoundBlasterFilterSettings{Model: SoundBlasterModel16, Stereo: true})
+	if out := clip.Filter(volume.Matrix{StaticMatrix: volume.StaticMatrix{2, -2}, Channels: 2}); out.StaticMatrix[0] > 1 || out.StaticMatrix[1] < -1 {
+		t.Fatalf("expected the DAC to clip, got %v", out.StaticMatrix)
+	}
+}
+
+func TestSoundBlasterFilterMonoFold(t *testing.T) {
+	f := NewSoundBlasterFilter(frequency.Frequency(44100), SoundBlasterFilterSettings{Model: SoundBlasterModel16})
+
+	out := f.Filter(volume.Matrix{StaticMatrix: volume.StaticMatrix{0.5, -0.1}, Channels: 2})
+	if out.Channels != 2 {
+		t.Fatalf("expected the channel count to be kept, got %d", out.Channels)
+	}
+	if !almostEqualVol(out.StaticMatrix[0], out.StaticMatrix[1], 1e-9) || !almostEqualVol(out.StaticMatrix[0], 0.2, 1e-3) {
+		t.Fatalf("expected both channels to carry the mono mix, got %v", out.StaticMatrix)
+	}
+}
+
+func TestSoundBlasterFilterLowPass(t *testing.T) {
+	peak := func(f Filter) volume.Volume {
+		var peak volume.Volume
+		for i := 0; i < 4410; i++ {
+			v := volume.Volume(0.5)
+			if i&1 != 0 {
+				v = -v
+			}
+			out := f.Filter(volume.Matrix{StaticMatrix: volume.StaticMatrix{v}, Channels: 1})
+			if i >= 441 {
+				peak = max(peak, volume.Volume(math.Abs(float64(out.StaticMatrix[0]))))
+			}
+		}
+		return peak
+	}
+
+	rate := frequency.Frequency(44100)
+	off := peak(NewSoundBlasterFilter(rate, SoundBlasterFilterSettings{Model: SoundBlasterModelPro}))
+	on := peak(NewSoundBlasterFilter(rate, SoundBlasterFilterSettings{Model: SoundBlasterModelPro, LowPass: true}))
+	sb16 := peak(NewSoundBlasterFilter(rate, SoundBlasterFilterSettings{Model: SoundBlasterModel16, LowPass: true}))
+
+	if on >= off*0.1 {
+		t.Fatalf("expected the SB Pro output filter to cut the high end: off=%v on=%v", off, on)
+	}
+	if !almostEqualVol(sb16, off, 0.01) {
+		t.Fatalf("expected the SB16 to have no switchable output filter: %v vs %v", sb16, off)
+	}
+}
+
+func TestSoundBlasterFilterClone(t *testing.T) {
+	f := NewSoundBlasterFilter(frequency.Frequency(44100), SoundBlasterFilterSettings{Model: SoundBlasterModelPro, LowPass: true})
+	f.Filter(volume.Matrix{StaticMatrix: volume.StaticMatrix{1}, Channels: 1})
+
+	c := f.Clone().(*SoundBlasterFilter)
+	want := f.Filter(volume.Matrix{StaticMatrix: volume.StaticMatrix{0}, Channels: 1})
+	got := c.Filter(volume.Matrix{StaticMatrix: volume.StaticMatrix{0}, Channels: 1})
+	assertMatrixAlmostEqual(t, got, want, 1e-12)
+}
+
+func eqGain(f Filter, freq float64) float64 {
+	rate := 44100.0
+	f.SetPlaybackRate(frequency.Frequency(rate))
+	var peak float64
+	for i := 0; i < 8820; i++ {
+		v := volume.Volume(math.Sin(2 * math.Pi * freq * float64(i) / rate))
+		out := f.Filter(volume.Matrix{StaticMatrix: volume.StaticMatrix{v}, Channels: 1})
+		if i >= 4410 {
+			peak = max(peak, math.Abs(float64(out.StaticMatrix[0])))
+		}
+	}
+	return peak
+}
+
+func TestEQFilterBands(t *testing.T) {
+	if g := eqGain(NewEQFilter(EQBand{Type: EQBandPeaking, Frequency: 1000}), 1000); math.Abs(g-1) > 0.01 {
+		t.Fatalf("expected a flat response at 0dB gain, got %v", g)
+	}
+
+	boost := NewEQFilter(EQBand{Type: EQBandPeaking, Frequency: 1000, Gain: 6, Q: 1})
+	if g := eqGain(boost, 1000); math.Abs(ampToDB(g)-6) > 0.1 {
+		t.Fatalf("expected a 6dB boost at the center frequency, got %vdB", ampToDB(g))
+	}
+
+	shelves := NewEQFilter(
+		EQBand{Type: EQBandLowShelf, Frequency: 200, Gain: -12},
+		EQBand{Type: EQBandHighShelf, Frequency: 5000, Gain: 6},
+	)
+	if g := eqGain(shelves.Clone(), 50); ampToDB(g) > -11 {
+		t.Fatalf("expected the low shelf to cut the lows, got %vdB", ampToDB(g))
+	}
+	if g := eqGain(shelves.Clone(), 15000); ampToDB(g) < 5 {
+		t.Fatalf("expected the high shelf to boost the highs, got %vdB", ampToDB(g))
+	}
+}
+
+func TestReverbFilterMixAndTail(t *testing.T) {
+	dry := NewReverbFilter(ReverbFilterSettings{})
+	dry.SetPlaybackRate(frequency.Frequency(44100))
+	in := volume.Matrix{StaticMatrix: volume.StaticMatrix{0.5, -0.25, 0.1}, Channels: 3}
+	assertMatrixAlmostEqual(t, dry.Filter(in), in, 1e-12)
+
+	f := NewReverbFilter(ReverbFilterSettings{DecayTime: 1, Mix: 1})
+	f.SetPlaybackRate(frequency.Frequency(44100))
+	f.Filter(volume.Matrix{StaticMatrix: volume.StaticMatrix{1, 1}, Channels: 2})
+	var tail float64
+	for i := 0; i < 4410; i++ {
+		out := f.Filter(volume.Matrix{Channels: 2})
+		tail = max(tail, math.Abs(float64(out.StaticMatrix[0])))
+	}
+	if tail == 0 {
+		t.Fatalf("expected a reverb tail after an impulse")
+	}
+}
+
+func TestLimiterFilterHoldsCeiling(t *testing.T) {
+	f := NewLimiterFilter(LimiterFilterSettings{Threshold: -6, Release: 10})
+	f.SetPlaybackRate(frequency.Frequency(44100))
+	ceiling := dbToAmp(-6)
+
+	for i := 0; i < 100; i++ {
+		out := f.Filter(volume.Matrix{StaticMatrix: volume.StaticMatrix{2, -1}, Channels: 2})
+		if math.Abs(float64(out.StaticMatrix[0])) > ceiling+1e-9 {
+			t.Fatalf("expected the output to stay under the ceiling, got %v", out.StaticMatrix[0])
+		}
+		if !almostEqualVol(out.StaticMatrix[1], -out.StaticMatrix[0]/2, 1e-9) {
+			t.Fatalf("expected the gain reduction to be linked across channels, got %v", out.StaticMatrix)
+		}
+	}
+	if f.GainReduction() < 11.9 {
+		t.Fatalf("expected about 12dB of gain reduction, got %v", f.GainReduction())
+	}
+
+	var out volume.Matrix
+	for i := 0; i < 44100; i++ {
+		out = f.Filter(volume.Matrix{StaticMatrix: volume.StaticMatrix{0.1}, Channels: 1})
+	}
+	if !almostEqualVol(out.StaticMatrix[0], 0.1, 1e-6) {
+		t.Fatalf("expected the gain to recover, got %v", out.StaticMatrix[0])
+	}
+}
+
+func TestDCBlockerFilterRemovesOffset(t *testing.T) {
+	f := NewDCBlockerFilter(0)
+	f.SetPlaybackRate(frequency.Frequency(44100))
+
+	var out volume.Matrix
+	for i := 0; i < 44100; i++ {
+		v := volume.Volume(0.5 + 0.25*math.Sin(2*math.Pi*1000*float64(i)/44100))
+		out = f.Filter(volume.Matrix{StaticMatrix: volume.StaticMatrix{v, 0.5}, Channels: 2})
+	}
+	if !almostEqualVol(out.StaticMatrix[1], 0, 1e-3) {
+		t.Fatalf("expected the offset to be removed, got %v", out.StaticMatrix[1])
+	}
+	if g := eqGain(NewDCBlockerFilter(0), 1000); math.Abs(g-1) > 0.01 {
+		t.Fatalf("expected audible frequencies to pass, got %v", g)
+	}
+}
+
+func lfeGain(t *testing.T, freq float64) float64 {
+	t.Helper()
+	rate := 44100.0
+	f := NewLFECrossoverFilter(volume.SurroundLFE, 0)
+	f.SetPlaybackRate(frequency.Frequency(rate))
+	var peak float64
+	for i := 0; i < 44100; i++ {
+		v := volume.Volume(math.Sin(2 * math.Pi * freq * float64(i) / rate))
+		out := f.Filter(volume.Matrix{StaticMatrix: volume.StaticMatrix{v, v, v, v, v, v}, Channels: 6})
+		for c := 0; c < out.Channels; c++ {
+			if c != volume.SurroundLFE && out.StaticMatrix[c] != v {
+				t.Fatalf("expected the crossover to leave channel %d untouched", c)
+			}
+		}
+		if i >= 22050 {
+			peak = max(peak, math.Abs(float64(out.StaticMatrix[volume.SurroundLFE])))
+		}
+	}
+	return peak
+}
+
+func TestLFECrossoverFilterLowPassesLFEChannel(t *testing.T) {
+	if g := lfeGain(t, 30); math.Abs(g-1) > 0.01 {
+		t.Fatalf("expected the lows to pass, got %v", g)
+	}
+	if g := ampToDB(lfeGain(t, 120)); math.Abs(g+6) > 0.1 {
+		t.Fatalf("expected -6dB at the crossover frequency, got %vdB", g)
+	}
+	if g := ampToDB(lfeGain(t, 1000)); g > -60 {
+		t.Fatalf("expected the highs to be removed, got %vdB", g)
+	}
+
+	f := NewLFECrossoverFilter(volume.SurroundLFE, 0)
+	f.SetPlaybackRate(frequency.Frequency(44100))
+	in := volume.Matrix{StaticMatrix: volume.StaticMatrix{0.5, -0.5}, Channels: 2}
+	assertMatrixAlmostEqual(t, f.Filter(in), in, 0)
+}
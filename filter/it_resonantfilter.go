@@ -234,3 +234,20 @@ func (f *ResonantFilter) recalculate(v uint8) {
 func (f *ResonantFilter) UpdateEnv(cutoff uint8) {
 	f.recalculate(cutoff)
 }
+
+// SetCutoff sets the filter cutoff (0-127) and recalculates the coefficients
+func (f *ResonantFilter) SetCutoff(cutoff uint8) {
+	c := min(cutoff, 0x7F)
+	f.cutoff.Set(c)
+	f.recalculate(c)
+}
+
+// SetResonance sets the filter resonance (0-127) and recalculates the coefficients
+func (f *ResonantFilter) SetResonance(resonance uint8) {
+	f.resonance.Set(min(resonance, 0x7F))
+	c := uint8(0x7F)
+	if v, set := f.cutoff.Get(); set {
+		c = v
+	}
+	f.recalculate(c)
+}
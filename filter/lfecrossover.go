@@ -0,0 +1,73 @@
+package filter
+
+import (
+	"math"
+
+	"github.com/gotracker/playback/frequency"
+	"github.com/gotracker/playback/mixing/volume"
+)
+
+const lfeCrossoverDefaultHz = 120.0
+
+// LFECrossoverFilter band-limits the LFE channel of a surround mix with a 4th order
+// Linkwitz-Riley low-pass filter (two cascaded Butterworth sections). The other channels are
+// passed through untouched.
+type LFECrossoverFilter struct {
+	Channel   int     // the LFE channel of the layout
+	Frequency float64 // in Hz; 0 uses 120Hz
+
+	lp    biquad
+	state [2]biquadState
+
+	playbackRate frequency.Frequency
+}
+
+// NewLFECrossoverFilter creates a new LFECrossoverFilter
+func NewLFECrossoverFilter(channel int, freq float64) *LFECrossoverFilter {
+	return &LFECrossoverFilter{
+		Channel:   channel,
+		Frequency: freq,
+	}
+}
+
+func (e *LFECrossoverFilter) SetPlaybackRate(playback frequency.Frequency) {
+	if e.playbackRate == playback {
+		return
+	}
+	e.playbackRate = playback
+	if playback <= 0 {
+		return
+	}
+
+	freq := e.Frequency
+	if freq <= 0 {
+		freq = lfeCrossoverDefaultHz
+	}
+	e.lp = lowpassBiquad(float64(playback), freq, math.Sqrt2/2)
+}
+
+func (e *LFECrossoverFilter) Clone() Filter {
+	clone := *e
+	return &clone
+}
+
+// Filter processes incoming (dry) samples and produces an outgoing filtered (wet) result
+func (e *LFECrossoverFilter) Filter(dry volume.Matrix) volume.Matrix {
+	if dry.Channels == 0 {
+		return volume.Matrix{}
+	}
+	if e.playbackRate <= 0 || e.Channel < 0 || e.Channel >= dry.Channels {
+		return dry
+	}
+
+	wet := dry
+	s := float64(dry.StaticMatrix[e.Channel])
+	s = e.state[0].process(&e.lp, s)
+	s = e.state[1].process(&e.lp, s)
+	wet.StaticMatrix[e.Channel] = volume.Volume(s)
+	return wet
+}
+
+// UpdateEnv updates the filter with the value from the filter envelope
+func (e *LFECrossoverFilter) UpdateEnv(v uint8) {
+}
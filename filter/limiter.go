@@ -0,0 +1,93 @@
+package filter
+
+import (
+	"math"
+
+	"github.com/gotracker/playback/frequency"
+	"github.com/gotracker/playback/mixing/volume"
+)
+
+// LimiterFilterSettings are the parameters of the limiter
+type LimiterFilterSettings struct {
+	Threshold float64 // ceiling in dBFS; values above 0 are treated as 0
+	Release   float64 // time in milliseconds for the gain to recover; 0 uses 50ms
+}
+
+// LimiterFilter is a peak limiter that keeps the output from going past its threshold.
+// The gain reduction is applied instantly and linked across all channels, so the stereo
+// image doesn't shift while it's limiting.
+type LimiterFilter struct {
+	LimiterFilterSettings
+
+	ceiling float64
+	release float64
+	gain    float64
+
+	playbackRate frequency.Frequency
+}
+
+// NewLimiterFilter creates a new LimiterFilter
+func NewLimiterFilter(settings LimiterFilterSettings) *LimiterFilter {
+	return &LimiterFilter{
+		LimiterFilterSettings: settings,
+		gain:                  1,
+	}
+}
+
+func (e *LimiterFilter) SetPlaybackRate(playback frequency.Frequency) {
+	if e.playbackRate == playback {
+		return
+	}
+	e.playbackRate = playback
+	if playback <= 0 {
+		return
+	}
+
+	e.ceiling = dbToAmp(min(e.Threshold, 0))
+	release := e.Release
+	if release <= 0 {
+		release = 50
+	}
+	e.release = 1 - math.Exp(-1/(release/1000*float64(playback)))
+}
+
+func (e *LimiterFilter) Clone() Filter {
+	clone := *e
+	return &clone
+}
+
+// GainReduction returns the current gain reduction in dB
+func (e *LimiterFilter) GainReduction() float64 {
+	return -ampToDB(e.gain)
+}
+
+// Filter processes incoming (dry) samples and produces an outgoing filtered (wet) result
+func (e *LimiterFilter) Filter(dry volume.Matrix) volume.Matrix {
+	if dry.Channels == 0 {
+		return volume.Matrix{}
+	}
+	if e.playbackRate <= 0 {
+		return dry
+	}
+
+	var peak float64
+	for c := 0; c < dry.Channels; c++ {
+		peak = max(peak, math.Abs(float64(dry.StaticMatrix[c])))
+	}
+
+	target := 1.0
+	if peak > e.ceiling {
+		target = e.ceiling / peak
+	}
+	if target < e.gain {
+		e.gain = target
+	} else {
+		e.gain += (target - e.gain) * e.release
+	}
+
+	return dry.Apply(volume.Volume(e.gain))
+}
+
+// UpdateEnv updates the filter with the value from the filter envelope
+func (e *LimiterFilter) UpdateEnv(v uint8) {
+}
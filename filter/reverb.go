@@ -0,0 +1,90 @@
+package filter
+
+import (
+	"github.com/gotracker/playback/frequency"
+	"github.com/gotracker/playback/mixing/volume"
+)
+
+// ReverbFilterSettings are the parameters of the reverb effect
+type ReverbFilterSettings struct {
+	DecayTime float64 // RT60 time in seconds; 0 uses 1.5 seconds
+	Damping   float64 // 0 (bright) to 1 (dark)
+	Mix       float64 // 0 (dry) to 1 (wet)
+}
+
+// ReverbFilter is a room reverb. Channels are fed to the left and right sides of the
+// reverberator alternately, so any number of channels can be processed.
+type ReverbFilter struct {
+	ReverbFilterSettings
+
+	tank reverbTank
+
+	playbackRate frequency.Frequency
+}
+
+// NewReverbFilter creates a new ReverbFilter
+func NewReverbFilter(settings ReverbFilterSettings) *ReverbFilter {
+	return &ReverbFilter{
+		ReverbFilterSettings: settings,
+	}
+}
+
+func (e *ReverbFilter) SetPlaybackRate(playback frequency.Frequency) {
+	if e.playbackRate == playback {
+		return
+	}
+	e.playbackRate = playback
+	if playback <= 0 {
+		return
+	}
+
+	decay := e.DecayTime
+	if decay <= 0 {
+		decay = 1.5
+	}
+	hfRatio := 1 - min(max(e.Damping, 0), 0.999)
+	e.tank.setup(float64(playback), decay, hfRatio, 1, 1)
+}
+
+func (e *ReverbFilter) Clone() Filter {
+	clone := *e
+	clone.tank = e.tank.clone()
+	return &clone
+}
+
+// Filter processes incoming (dry) samples and produces an outgoing filtered (wet) result
+func (e *ReverbFilter) Filter(dry volume.Matrix) volume.Matrix {
+	if dry.Channels == 0 {
+		return volume.Matrix{}
+	}
+	if e.playbackRate <= 0 {
+		return dry
+	}
+
+	var (
+		in    [2]float64
+		count [2]int
+	)
+	for c := 0; c < dry.Channels; c++ {
+		in[c%2] += float64(dry.StaticMatrix[c])
+		count[c%2]++
+	}
+
+	var rev [2]float64
+	for side := range rev {
+		if count[side] > 0 {
+			rev[side] = e.tank.process(side, in[side]/float64(count[side]))
+		}
+	}
+
+	mix := min(max(e.Mix, 0), 1)
+	wet := dry
+	for c := 0; c < dry.Channels; c++ {
+		wet.StaticMatrix[c] = volume.Volume(float64(dry.StaticMatrix[c])*(1-mix) + rev[c%2]*mix)
+	}
+	return wet
+}
+
+// UpdateEnv updates the filter with the value from the filter envelope
+func (e *ReverbFilter) UpdateEnv(v uint8) {
+}
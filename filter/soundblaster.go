@@ -0,0 +1,146 @@
+package filter
+
+import (
+	"math"
+
+	"github.com/gotracker/playback/frequency"
+	"github.com/gotracker/playback/mixing/volume"
+)
+
+// SoundBlasterModel selects the SoundBlaster whose output stage is modelled
+type SoundBlasterModel uint8
+
+const (
+	// SoundBlasterModelPro has an 8-bit DAC and a switchable output low-pass filter
+	SoundBlasterModelPro = SoundBlasterModel(iota)
+	// SoundBlasterModel16 has a 16-bit DAC and no switchable output filter
+	SoundBlasterModel16
+)
+
+func (s SoundBlasterModel) String() string {
+	switch s {
+	case SoundBlasterModelPro:
+		return "SB Pro"
+	case SoundBlasterModel16:
+		return "SB16"
+	default:
+		return "unknown"
+	}
+}
+
+const (
+	soundBlasterLowPassHz  = 3200.0 // SB Pro output filter
+	soundBlasterHighPassHz = 10.0   // output coupling capacitors
+)
+
+// SoundBlasterFilterSettings are the parameters of the SoundBlaster output filter
+type SoundBlasterFilterSettings struct {
+	Model   SoundBlasterModel
+	LowPass bool // the SB Pro output filter is switched on
+	Stereo  bool // false has the card play the mix back in mono
+}
+
+type soundBlasterChannelData struct {
+	lp biquadState
+	hp float64
+}
+
+// SoundBlasterFilter models the output stage of a SoundBlaster, so it is intended to be run
+// over the final mix rather than individual channels: the mix is folded down to mono when the
+// card isn't in stereo mode, then it goes through the DAC (which is where clipping occurs),
+// the output low-pass filter of the SB Pro, if it's switched on, and the coupling capacitors
+type SoundBlasterFilter struct {
+	SoundBlasterFilterSettings
+
+	channels []soundBlasterChannelData
+	lp       biquad
+	hp       float64
+
+	playbackRate frequency.Frequency
+}
+
+// NewSoundBlasterFilter creates a new SoundBlasterFilter
+func NewSoundBlasterFilter(playback frequency.Frequency, settings SoundBlasterFilterSettings) *SoundBlasterFilter {
+	f := SoundBlasterFilter{
+		SoundBlasterFilterSettings: settings,
+	}
+	f.SetPlaybackRate(playback)
+	return &f
+}
+
+func (f *SoundBlasterFilter) SetPlaybackRate(playback frequency.Frequency) {
+	if f.playbackRate == playback {
+		return
+	}
+	f.playbackRate = playback
+	if playback <= 0 {
+		return
+	}
+
+	rate := float64(playback)
+	f.lp = lowpassBiquad(rate, soundBlasterLowPassHz, math.Sqrt2/2)
+	f.hp = onePoleCoeff(rate, soundBlasterHighPassHz)
+}
+
+func (f *SoundBlasterFilter) Clone() Filter {
+	c := *f
+	c.channels = make([]soundBlasterChannelData, len(f.channels))
+	copy(c.channels, f.channels)
+	return &c
+}
+
+// dacLevels returns the number of quantization steps on either side of zero for the model's DAC
+func (f *SoundBlasterFilter) dacLevels() float64 {
+	if f.Model == SoundBlasterModel16 {
+		return 32768
+	}
+	return 128
+}
+
+// Filter processes incoming (dry) samples and produces an outgoing filtered (wet) result
+func (f *SoundBlasterFilter) Filter(dry volume.Matrix) volume.Matrix {
+	if dry.Channels == 0 {
+		return volume.Matrix{}
+	}
+	if f.playbackRate <= 0 {
+		return dry
+	}
+
+	wet := dry
+	if !f.Stereo && dry.Channels > 1 {
+		var mono volume.Volume
+		for i := 0; i < dry.Channels; i++ {
+			mono += dry.StaticMatrix[i]
+		}
+		mono /= volume.Volume(dry.Channels)
+		for i := 0; i < dry.Channels; i++ {
+			wet.StaticMatrix[i] = mono
+		}
+	}
+
+	levels := f.dacLevels()
+	for i := 0; i < wet.Channels; i++ {
+		for len(f.channels) <= i {
+			f.channels = append(f.channels, soundBlasterChannelData{})
+		}
+		c := &f.channels[i]
+
+		s := math.Round(float64(wet.StaticMatrix[i]) * levels)
+		s = min(max(s, -levels), levels-1) / levels
+
+		if f.Model == SoundBlasterModelPro {
+			lp := c.lp.process(&f.lp, s)
+			if f.LowPass {
+				s = lp
+			}
+		}
+
+		c.hp += f.hp * (s - c.hp)
+		wet.StaticMatrix[i] = volume.Volume(s - c.hp)
+	}
+	return wet
+}
+
+// UpdateEnv updates the filter with the value from the filter envelope
+func (f *SoundBlasterFilter) UpdateEnv(v uint8) {
+}
@@ -0,0 +1,219 @@
+// Package builder authors songs from code, for music that is made up as a program runs rather
+// than loaded from a file.
+package builder
+
+import (
+	"errors"
+	"fmt"
+
+	s3mPanning "github.com/gotracker/playback/format/s3m/panning"
+	xmPanning "github.com/gotracker/playback/format/xm/panning"
+	"github.com/gotracker/playback/index"
+	"github.com/gotracker/playback/mixing/panning"
+	"github.com/gotracker/playback/period"
+	"github.com/gotracker/playback/song"
+
+	// the formats register their machines as they load
+	_ "github.com/gotracker/playback/format/s3m"
+	_ "github.com/gotracker/playback/format/xm"
+)
+
+// Target is the format a built song follows: the effects its patterns use and how it plays them
+type Target int
+
+const (
+	// TargetS3M builds a ScreamTracker 3 song
+	TargetS3M = Target(iota)
+	// TargetXM builds a FastTracker 2 song
+	TargetXM
+)
+
+func (t Target) String() string {
+	switch t {
+	case TargetS3M:
+		return "S3M"
+	case TargetXM:
+		return "XM"
+	default:
+		return fmt.Sprintf("Target(%d)", int(t))
+	}
+}
+
+// the limits of the targets
+type targetLimits struct {
+	minBPM         int
+	maxChannels    int
+	maxInstruments int
+	maxRows        int
+}
+
+var limits = map[Target]targetLimits{
+	TargetS3M: {minBPM: 33, maxChannels: 16, maxInstruments: 99, maxRows: 64},
+	TargetXM:  {minBPM: 32, maxChannels: 32, maxInstruments: 128, maxRows: 256},
+}
+
+const (
+	// DefaultBPM is the BPM of a new song
+	DefaultBPM = 125
+	// DefaultTempo is the ticks per row of a new song
+	DefaultTempo = 6
+)
+
+// Builder builds a song from code. Fill it in, then call Build for a song that a machine plays
+// like one loaded from a file. A Builder isn't safe to use from more than one goroutine at a
+// time.
+type Builder struct {
+	target       Target
+	limits       targetLimits
+	name         string
+	bpm          int
+	tempo        int
+	linearSlides bool
+	channelPans  []panning.Position
+	instruments  []Instrument
+	patterns     []*Pattern
+	orders       []index.Pattern
+}
+
+// New returns a Builder for a song with `channels` channels in the target format. XM songs start
+// out with linear slides, S3M songs with their channels panned left and right in turn.
+func New(target Target, channels int) (*Builder, error) {
+	l, found := limits[target]
+	if !found {
+		return nil, fmt.Errorf("unknown target: %v", target)
+	}
+	if channels < 1 || channels > l.maxChannels {
+		return nil, fmt.Errorf("%v songs have 1 to %d channels, not %d", target, l.maxChannels, channels)
+	}
+
+	b := &Builder{
+		target:       target,
+		limits:       l,
+		bpm:          DefaultBPM,
+		tempo:        DefaultTempo,
+		linearSlides: target == TargetXM,
+		channelPans:  make([]panning.Position, channels),
+	}
+	for i := range b.channelPans {
+		b.channelPans[i] = b.getDefaultChannelPan(index.Channel(i))
+	}
+	return b, nil
+}
+
+func (b *Builder) getDefaultChannelPan(ch index.Channel) panning.Position {
+	switch b.target {
+	case TargetS3M:
+		if ch%2 == 0 {
+			return s3mPanning.DefaultPanningLeft.ToPosition()
+		}
+		return s3mPanning.DefaultPanningRight.ToPosition()
+	default:
+		return xmPanning.DefaultPanningPosition
+	}
+}
+
+// SetName sets the name of the song
+func (b *Builder) SetName(name string) {
+	b.name = name
+}
+
+// SetBPM sets the BPM the song starts at
+func (b *Builder) SetBPM(bpm int) error {
+	if bpm < b.limits.minBPM || bpm > 255 {
+		return fmt.Errorf("bpm out of range: %d", bpm)
+	}
+	b.bpm = bpm
+	return nil
+}
+
+// SetTempo sets the ticks per row the song starts at
+func (b *Builder) SetTempo(tempo int) error {
+	if tempo < 1 || tempo > 255 {
+		return fmt.Errorf("tempo out of range: %d", tempo)
+	}
+	b.tempo = tempo
+	return nil
+}
+
+// SetLinearSlides picks between linear and Amiga frequency slides. Only XM songs have linear
+// slides.
+func (b *Builder) SetLinearSlides(enabled bool) error {
+	if enabled && b.target != TargetXM {
+		return fmt.Errorf("%v songs can't have linear slides", b.target)
+	}
+	b.linearSlides = enabled
+	return nil
+}
+
+// SetChannelPan sets the panning a channel starts with
+func (b *Builder) SetChannelPan(ch index.Channel, pos panning.Position) error {
+	if int(ch) >= len(b.channelPans) {
+		return fmt.Errorf("channel out of range: %d", ch)
+	}
+	b.channelPans[ch] = pos
+	return nil
+}
+
+// AddInstrument adds an instrument to the song and returns its number, as the patterns refer to
+// it
+func (b *Builder) AddInstrument(inst Instrument) (int, error) {
+	if len(b.instruments) >= b.limits.maxInstruments {
+		return 0, fmt.Errorf("%v songs have at most %d instruments", b.target, b.limits.maxInstruments)
+	}
+	if err := inst.validate(b.target); err != nil {
+		return 0, fmt.Errorf("instrument %d: %w", len(b.instruments)+1, err)
+	}
+
+	b.instruments = append(b.instruments, inst)
+	return len(b.instruments), nil
+}
+
+// AddPattern adds an empty pattern of `rows` rows to the song and returns it, with its index for
+// the order list
+func (b *Builder) AddPattern(rows int) (*Pattern, index.Pattern, error) {
+	if rows < 1 || rows > b.limits.maxRows {
+		return nil, 0, fmt.Errorf("%v patterns have 1 to %d rows, not %d", b.target, b.limits.maxRows, rows)
+	}
+	// the last pattern numbers are markers in the order list
+	if len(b.patterns) >= int(index.NextPattern) {
+		return nil, 0, fmt.Errorf("songs have at most %d patterns", index.NextPattern)
+	}
+
+	p := newPattern(b.target, rows, len(b.channelPans))
+	b.patterns = append(b.patterns, p)
+	return p, index.Pattern(len(b.patterns) - 1), nil
+}
+
+// AddOrders adds patterns to the end of the order list
+func (b *Builder) AddOrders(patterns ...index.Pattern) {
+	b.orders = append(b.orders, patterns...)
+}
+
+// Build returns the song. The Builder can go on being filled in afterwards without changing it.
+func (b *Builder) Build() (song.Data, error) {
+	if len(b.orders) == 0 {
+		return nil, errors.New("the order list is empty")
+	}
+	for i, p := range b.orders {
+		if int(p) >= len(b.patterns) {
+			return nil, fmt.Errorf("order %d: no such pattern: %d", i, p)
+		}
+	}
+	for i, p := range b.patterns {
+		if err := p.validate(len(b.instruments)); err != nil {
+			return nil, fmt.Errorf("pattern %d: %w", i, err)
+		}
+	}
+
+	switch b.target {
+	case TargetS3M:
+		return b.buildS3M()
+	case TargetXM:
+		if b.linearSlides {
+			return buildXM[period.Linear](b)
+		}
+		return buildXM[period.Amiga](b)
+	default:
+		return nil, fmt.Errorf("unknown target: %v", b.target)
+	}
+}
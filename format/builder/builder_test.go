@@ -0,0 +1,304 @@
+package builder
+
+import (
+	"errors"
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/heucuva/optional"
+
+	s3mLayout "github.com/gotracker/playback/format/s3m/layout"
+	xmLayout "github.com/gotracker/playback/format/xm/layout"
+	xmPanning "github.com/gotracker/playback/format/xm/panning"
+	xmVolume "github.com/gotracker/playback/format/xm/volume"
+	"github.com/gotracker/playback/index"
+	"github.com/gotracker/playback/instrument"
+	"github.com/gotracker/playback/mixing/panning"
+	"github.com/gotracker/playback/note"
+	"github.com/gotracker/playback/period"
+	"github.com/gotracker/playback/player/machine"
+	"github.com/gotracker/playback/player/machine/settings"
+	"github.com/gotracker/playback/song"
+	"github.com/gotracker/playback/voice/loop"
+)
+
+type noteOn struct {
+	order    index.Order
+	row      index.Row
+	channel  index.Channel
+	semitone note.Semitone
+}
+
+func sineInstrument(t *testing.T) Instrument {
+	t.Helper()
+	data := make([]float32, 32)
+	for i := range data {
+		data[i] = float32(math.Sin(2 * math.Pi * float64(i) / float64(len(data))))
+	}
+	sample, err := NewSample(data, 1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return Instrument{
+		Name:   "sine",
+		Sample: sample,
+		Loop:   Loop{Mode: loop.ModeNormal, Begin: 0, End: len(data)},
+	}
+}
+
+func volumeColumn(v uint8) optional.Value[uint8] {
+	var o optional.Value[uint8]
+	o.Set(v)
+	return o
+}
+
+// play plays the song through once, returning the notes it started and the tempos it set
+func play(t *testing.T, songData song.Data) ([]noteOn, []int, machine.Snapshot) {
+	t.Helper()
+	m, err := machine.NewMachine(songData, settings.UserSettings{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var (
+		notes  []noteOn
+		tempos []int
+		first  machine.Snapshot
+	)
+	m.Subscribe(func(ev machine.Event) {
+		switch e := ev.(type) {
+		case machine.NoteOnEvent:
+			p := e.Info().Position
+			notes = append(notes, noteOn{order: p.Order, row: p.Row, channel: e.Channel, semitone: e.Semitone})
+		case machine.TempoChangeEvent:
+			tempos = append(tempos, e.Tempo)
+		}
+	})
+
+	for i := 0; ; i++ {
+		if i > 1000 {
+			t.Fatalf("expected the song to end")
+		}
+		err := m.Advance()
+		if errors.Is(err, song.ErrStopSong) {
+			break
+		} else if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(notes) != 0 && len(first.Channels) == 0 {
+			first = m.Snapshot()
+		}
+	}
+	return notes, tempos, first
+}
+
+func TestBuildXM(t *testing.T) {
+	b, err := New(TargetXM, 2)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	b.SetName("procedural")
+	if err := b.SetBPM(150); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	inst := sineInstrument(t)
+	inst.VolumeEnvelope = Envelope{
+		Points:  []EnvelopePoint{{Tick: 0, Value: 1}, {Tick: 10, Value: 0.5}, {Tick: 20, Value: 0}},
+		Sustain: optional.NewValue(1),
+	}
+	inst.FadeOut = 1.0 / 64
+	id, err := b.AddInstrument(inst)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	p, pi, err := b.AddPattern(4)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, c := range []struct {
+		row  int
+		ch   index.Channel
+		cell Cell
+	}{
+		{0, 0, Cell{Note: note.Normal(48), Instrument: id, Volume: volumeColumn(0x30)}},
+		{1, 1, Cell{Note: note.Normal(52), Instrument: id, Effect: "F03"}},
+		{3, 0, Cell{Note: note.ReleaseNote{}}},
+	} {
+		if err := p.Set(c.row, c.ch, c.cell); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	b.AddOrders(pi, pi)
+
+	songData, err := b.Build()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	s, ok := songData.(*xmLayout.Song[period.Linear])
+	if !ok {
+		t.Fatalf("expected an XM song with linear slides, got %T", songData)
+	}
+	if s.GetName() != "procedural" || s.InitialBPM != 150 || s.ChannelSettings[1].InitialPanning != xmPanning.DefaultPanning {
+		t.Fatalf("unexpected song settings: %+v", s.BaseSong)
+	}
+	pcm := s.Instruments[0].Inst.(*instrument.PCM[xmVolume.XmVolume, xmVolume.XmVolume, xmPanning.Panning])
+	var envY []xmVolume.XmVolume
+	for _, v := range pcm.VolEnv.Values {
+		envY = append(envY, v.Y)
+	}
+	if !pcm.VolEnv.Enabled || !pcm.VolEnv.Sustain.Enabled() || !reflect.DeepEqual(envY, []xmVolume.XmVolume{64, 32, 0}) {
+		t.Fatalf("unexpected volume envelope: %+v", pcm.VolEnv)
+	}
+
+	notes, tempos, first := play(t, songData)
+	want := []noteOn{{0, 0, 0, 48}, {0, 1, 1, 52}, {1, 0, 0, 48}, {1, 1, 1, 52}}
+	if !reflect.DeepEqual(notes, want) {
+		t.Fatalf("expected notes %v, got %v", want, notes)
+	}
+	if len(tempos) == 0 || tempos[0] != 3 {
+		t.Fatalf("expected the tempo to change to 3, got %v", tempos)
+	}
+	if c := first.Channels[0]; !c.Active || math.Abs(float64(c.Frequency)-float64(DefaultSampleRate)) > 1 {
+		t.Fatalf("expected the C-4 to play at the sample rate, got %+v", c)
+	}
+}
+
+func TestBuildS3M(t *testing.T) {
+	b, err := New(TargetS3M, 3)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := b.SetChannelPan(2, panning.MakeStereoPosition(1, 0, 1)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	id, err := b.AddInstrument(sineInstrument(t))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	p0, pi0, _ := b.AddPattern(2)
+	p1, pi1, _ := b.AddPattern(2)
+	if err := p0.Set(0, 1, Cell{Note: note.Normal(60), Instrument: id, Volume: volumeColumn(32), Effect: "A03"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := p1.Set(1, 2, Cell{Note: note.Normal(36), Instrument: id}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := p1.Set(0, 1, Cell{Note: note.StopNote{}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	b.AddOrders(pi1, pi0)
+
+	songData, err := b.Build()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	s, ok := songData.(*s3mLayout.Song)
+	if !ok {
+		t.Fatalf("expected an S3M song, got %T", songData)
+	}
+	var pans []uint8
+	for _, cs := range s.ChannelSettings {
+		pans = append(pans, uint8(cs.InitialPanning))
+	}
+	if !reflect.DeepEqual(pans, []uint8{0x3, 0xC, 0xF}) || !reflect.DeepEqual(s.ChannelOrders, []index.Channel{0, 2, 1}) {
+		t.Fatalf("unexpected channels: pans %v, orders %v", pans, s.ChannelOrders)
+	}
+
+	notes, tempos, first := play(t, songData)
+	want := []noteOn{{0, 1, 2, 36}, {1, 0, 1, 60}}
+	if !reflect.DeepEqual(notes, want) {
+		t.Fatalf("expected notes %v, got %v", want, notes)
+	}
+	if !reflect.DeepEqual(tempos, []int{3}) {
+		t.Fatalf("expected the tempo to change to 3, got %v", tempos)
+	}
+	if c := first.Channels[2]; !c.Active || math.Abs(float64(c.Frequency)-float64(DefaultSampleRate)/2) > 1 {
+		t.Fatalf("expected the C-3 to play at half the sample rate, got %+v", c)
+	}
+}
+
+func TestBuilderRejects(t *testing.T) {
+	if _, err := New(TargetS3M, 17); err == nil {
+		t.Fatalf("expected an error for too many channels")
+	}
+
+	b, _ := New(TargetS3M, 2)
+	if err := b.SetBPM(32); err == nil {
+		t.Fatalf("expected an error for a BPM of 32")
+	}
+	if err := b.SetLinearSlides(true); err == nil {
+		t.Fatalf("expected an error for linear slides")
+	}
+	if _, _, err := b.AddPattern(65); err == nil {
+		t.Fatalf("expected an error for too many rows")
+	}
+
+	inst := sineInstrument(t)
+	inst.VolumeEnvelope.Points = []EnvelopePoint{{Tick: 0, Value: 1}}
+	if _, err := b.AddInstrument(inst); err == nil {
+		t.Fatalf("expected an error for an envelope")
+	}
+	inst = sineInstrument(t)
+	inst.Loop.End = 33
+	if _, err := b.AddInstrument(inst); err == nil {
+		t.Fatalf("expected an error for a loop past the end of the sample")
+	}
+
+	p, pi, _ := b.AddPattern(1)
+	for _, c := range []Cell{
+		{Note: note.Normal(120)},
+		{Note: note.ReleaseNote{}},
+		{Volume: volumeColumn(65)},
+		{Effect: "A6"},
+		{Effect: "1FF"},
+		{Effect: "AXX"},
+	} {
+		if err := p.Set(0, 0, c); err == nil {
+			t.Fatalf("expected an error for %+v", c)
+		}
+	}
+
+	if _, err := b.Build(); err == nil {
+		t.Fatalf("expected an error for an empty order list")
+	}
+	b.AddOrders(pi + 1)
+	if _, err := b.Build(); err == nil {
+		t.Fatalf("expected an error for a missing pattern")
+	}
+
+	b, _ = New(TargetXM, 1)
+	p, pi, _ = b.AddPattern(1)
+	b.AddOrders(pi)
+	if err := p.Set(0, 0, Cell{Note: note.Normal(48), Instrument: 1}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := b.Build(); err == nil {
+		t.Fatalf("expected an error for a missing instrument")
+	}
+}
+
+func TestNewSample(t *testing.T) {
+	s, err := NewSample([]float32{0.25, -0.25, 0.5, -0.5}, 2)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if s.Length() != 2 || s.Channels() != 2 {
+		t.Fatalf("expected 2 frames of 2 channels, got %d of %d", s.Length(), s.Channels())
+	}
+	s.Seek(1)
+	m, err := s.Read()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if m.StaticMatrix[0] != 0.5 || m.StaticMatrix[1] != -0.5 {
+		t.Fatalf("unexpected frame: %v", m)
+	}
+
+	if _, err := NewSample([]float32{0, 0, 0}, 2); err == nil {
+		t.Fatalf("expected an error for a short frame")
+	}
+}
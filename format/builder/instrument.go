@@ -0,0 +1,181 @@
+package builder
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/heucuva/optional"
+
+	"github.com/gotracker/playback/frequency"
+	"github.com/gotracker/playback/mixing/panning"
+	"github.com/gotracker/playback/mixing/volume"
+	"github.com/gotracker/playback/voice/loop"
+	"github.com/gotracker/playback/voice/pcm"
+)
+
+// DefaultSampleRate is the rate a sample plays at for a C-4 when the instrument doesn't say
+const DefaultSampleRate = frequency.Frequency(8363)
+
+// Instrument is a PCM instrument of a song
+type Instrument struct {
+	Name string
+	// Sample is the sound of the instrument; NewSample makes one from floating-point data
+	Sample pcm.Sample
+	// SampleRate is the rate the sample plays at for a C-4, or DefaultSampleRate when it's 0
+	SampleRate frequency.Frequency
+	// Volume is the volume notes start at, or full volume when it isn't set
+	Volume optional.Value[volume.Volume]
+	// Panning is where notes of the instrument are panned to when they start, or the panning of
+	// the channel when it isn't set. Only XM instruments have a panning.
+	Panning optional.Value[panning.Position]
+	Loop    Loop
+	// FadeOut is how much the volume drops every tick after a note is let go, as a part of full
+	// volume. Only XM instruments fade out, and only while their volume envelope plays.
+	FadeOut         volume.Volume
+	VolumeEnvelope  Envelope
+	PanningEnvelope Envelope
+}
+
+// Loop is the loop of a sample, in sample frames. A loop with a Mode of loop.ModeDisabled
+// doesn't loop.
+type Loop struct {
+	Mode  loop.Mode
+	Begin int
+	End   int
+}
+
+// Envelope is an envelope of an instrument, which is enabled when it has points. Only XM
+// instruments have envelopes.
+type Envelope struct {
+	Points []EnvelopePoint
+	// Loop is the range of points the envelope loops over
+	Loop optional.Value[EnvelopeLoop]
+	// Sustain is the point the envelope holds at until the note is let go
+	Sustain optional.Value[int]
+}
+
+// EnvelopePoint is a point of an envelope. Value runs from 0 to 1: from silence to full volume,
+// or from the left to the right.
+type EnvelopePoint struct {
+	Tick  int
+	Value float32
+}
+
+// EnvelopeLoop is the range of points an envelope loops over
+type EnvelopeLoop struct {
+	Begin int
+	End   int
+}
+
+// NewSample returns a sample of floating-point data, with the channels interleaved
+func NewSample(data []float32, channels int) (pcm.Sample, error) {
+	if channels < 1 || channels > len(volume.StaticMatrix{}) {
+		return nil, fmt.Errorf("channels out of range: %d", channels)
+	}
+	if len(data)%channels != 0 {
+		return nil, errors.New("the data doesn't fill the last frame")
+	}
+
+	frames := make([]volume.Matrix, len(data)/channels)
+	for i := range frames {
+		f := &frames[i]
+		f.Channels = channels
+		for c := 0; c < channels; c++ {
+			f.StaticMatrix[c] = volume.Volume(data[i*channels+c])
+		}
+	}
+	return pcm.NewSampleNative(frames, len(frames), channels), nil
+}
+
+func (i Instrument) getSampleRate() frequency.Frequency {
+	if i.SampleRate == 0 {
+		return DefaultSampleRate
+	}
+	return i.SampleRate
+}
+
+func (i Instrument) getVolume() volume.Volume {
+	if v, set := i.Volume.Get(); set {
+		return v
+	}
+	return 1
+}
+
+func (i Instrument) validate(target Target) error {
+	if i.Sample == nil {
+		return errors.New("no sample")
+	}
+	if i.SampleRate < 0 {
+		return fmt.Errorf("sample rate out of range: %v", i.SampleRate)
+	}
+	if v := i.getVolume(); v < 0 || v > 1 {
+		return fmt.Errorf("volume out of range: %v", v)
+	}
+	if i.FadeOut < 0 || i.FadeOut > 1 {
+		return fmt.Errorf("fadeout out of range: %v", i.FadeOut)
+	}
+
+	switch i.Loop.Mode {
+	case loop.ModeDisabled:
+	case loop.ModeNormal, loop.ModePingPong:
+		if i.Loop.Mode == loop.ModePingPong && target != TargetXM {
+			return fmt.Errorf("%v samples can't ping-pong", target)
+		}
+		if i.Loop.Begin < 0 || i.Loop.Begin >= i.Loop.End || i.Loop.End > i.Sample.Length() {
+			return fmt.Errorf("loop out of range: %d-%d", i.Loop.Begin, i.Loop.End)
+		}
+	default:
+		return fmt.Errorf("unsupported loop mode: %v", i.Loop.Mode)
+	}
+
+	if target != TargetXM {
+		switch {
+		case i.Panning.IsSet():
+			return fmt.Errorf("%v instruments have no panning", target)
+		case i.FadeOut != 0:
+			return fmt.Errorf("%v instruments don't fade out", target)
+		case len(i.VolumeEnvelope.Points) != 0 || len(i.PanningEnvelope.Points) != 0:
+			return fmt.Errorf("%v instruments have no envelopes", target)
+		}
+	}
+
+	if err := i.VolumeEnvelope.validate(); err != nil {
+		return fmt.Errorf("volume envelope: %w", err)
+	}
+	if err := i.PanningEnvelope.validate(); err != nil {
+		return fmt.Errorf("panning envelope: %w", err)
+	}
+	return nil
+}
+
+func (e Envelope) validate() error {
+	if len(e.Points) == 0 {
+		if e.Loop.IsSet() || e.Sustain.IsSet() {
+			return errors.New("no points")
+		}
+		return nil
+	}
+
+	for i, p := range e.Points {
+		switch {
+		case p.Value < 0 || p.Value > 1:
+			return fmt.Errorf("point %d: value out of range: %v", i, p.Value)
+		case i == 0 && p.Tick != 0:
+			return errors.New("the first point isn't at tick 0")
+		case i > 0 && p.Tick <= e.Points[i-1].Tick:
+			return fmt.Errorf("point %d: not after the point before it", i)
+		}
+	}
+
+	if l, set := e.Loop.Get(); set {
+		if l.Begin < 0 || l.Begin > l.End || l.End >= len(e.Points) {
+			return fmt.Errorf("loop out of range: %d-%d", l.Begin, l.End)
+		}
+	}
+	if s, set := e.Sustain.Get(); set {
+		if s < 0 || s >= len(e.Points) {
+			return fmt.Errorf("sustain point out of range: %d", s)
+		}
+	}
+	return nil
+}
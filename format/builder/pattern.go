@@ -0,0 +1,113 @@
+package builder
+
+import (
+	"fmt"
+
+	"github.com/heucuva/optional"
+
+	"github.com/gotracker/playback/index"
+	"github.com/gotracker/playback/note"
+	"github.com/gotracker/playback/period"
+)
+
+// Cell is what a channel plays on a row of a pattern. Its volume and effect are written as the
+// target format writes them.
+type Cell struct {
+	// Note is the note to play, or nil for none. C-4 is note.Normal(48).
+	Note note.Note
+	// Instrument is the number AddInstrument returned, or 0 for none
+	Instrument int
+	// Volume is the volume column: 0 to 64 for an S3M song, or the byte of the volume column of
+	// an XM song, where 0x10 to 0x50 sets the volume and the values above are its effects
+	Volume optional.Value[uint8]
+	// Effect is the effect and its parameter in hexadecimal, as the tracker shows them, such as
+	// "A06" in an S3M song or "F06" in an XM song, or empty for none
+	Effect string
+}
+
+// Pattern is a pattern of a song
+type Pattern struct {
+	target Target
+	rows   [][]Cell
+}
+
+func newPattern(target Target, rows, channels int) *Pattern {
+	p := Pattern{
+		target: target,
+		rows:   make([][]Cell, rows),
+	}
+	for r := range p.rows {
+		p.rows[r] = make([]Cell, channels)
+	}
+	return &p
+}
+
+// NumRows returns the number of rows of the pattern
+func (p *Pattern) NumRows() int {
+	return len(p.rows)
+}
+
+// Set sets the cell at a row and channel of the pattern
+func (p *Pattern) Set(row int, ch index.Channel, c Cell) error {
+	if row < 0 || row >= len(p.rows) {
+		return fmt.Errorf("row out of range: %d", row)
+	}
+	if int(ch) >= len(p.rows[row]) {
+		return fmt.Errorf("channel out of range: %d", ch)
+	}
+	if c.Instrument < 0 || c.Instrument > limits[p.target].maxInstruments {
+		return fmt.Errorf("instrument out of range: %d", c.Instrument)
+	}
+
+	var err error
+	switch p.target {
+	case TargetS3M:
+		_, err = convertS3MCell(ch, c)
+	case TargetXM:
+		_, err = convertXMCell[period.Linear](c)
+	}
+	if err != nil {
+		return fmt.Errorf("row %d channel %d: %w", row, ch, err)
+	}
+
+	p.rows[row][ch] = c
+	return nil
+}
+
+// Get returns the cell at a row and channel of the pattern
+func (p *Pattern) Get(row int, ch index.Channel) Cell {
+	return p.rows[row][ch]
+}
+
+func (p *Pattern) validate(numInstruments int) error {
+	for r, row := range p.rows {
+		for ch, c := range row {
+			if c.Instrument > numInstruments {
+				return fmt.Errorf("row %d channel %d: no such instrument: %d", r, ch, c.Instrument)
+			}
+		}
+	}
+	return nil
+}
+
+// parseEffectParameter reads the two hexadecimal digits of an effect's parameter
+func parseEffectParameter(s string) (uint8, error) {
+	var v uint8
+	if len(s) != 2 {
+		return 0, fmt.Errorf("effect parameter isn't 2 hexadecimal digits: %q", s)
+	}
+	for _, r := range s {
+		v <<= 4
+		switch {
+		case r >= '0' && r <= '9':
+			v |= uint8(r - '0')
+		case r >= 'A' && r <= 'F':
+			v |= uint8(r-'A') + 10
+		case r >= 'a' && r <= 'f':
+			v |= uint8(r-'a') + 10
+		default:
+			return 0, fmt.Errorf("effect parameter isn't 2 hexadecimal digits: %q", s)
+		}
+	}
+	return v, nil
+}
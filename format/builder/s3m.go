@@ -0,0 +1,171 @@
+package builder
+
+import (
+	"fmt"
+	"math"
+
+	s3mfile "github.com/gotracker/goaudiofile/music/tracked/s3m"
+
+	"github.com/gotracker/playback/format/common"
+	"github.com/gotracker/playback/format/s3m/channel"
+	"github.com/gotracker/playback/format/s3m/layout"
+	s3mPanning "github.com/gotracker/playback/format/s3m/panning"
+	s3mSettings "github.com/gotracker/playback/format/s3m/settings"
+	s3mSystem "github.com/gotracker/playback/format/s3m/system"
+	s3mVolume "github.com/gotracker/playback/format/s3m/volume"
+	"github.com/gotracker/playback/index"
+	"github.com/gotracker/playback/instrument"
+	"github.com/gotracker/playback/mixing/panning"
+	"github.com/gotracker/playback/note"
+	"github.com/gotracker/playback/period"
+	"github.com/gotracker/playback/song"
+	"github.com/gotracker/playback/voice/fadeout"
+	"github.com/gotracker/playback/voice/loop"
+)
+
+// s3mMixingVolume is the master volume ScreamTracker 3 gives a new song
+const s3mMixingVolume = s3mVolume.FineVolume(0x30)
+
+func (b *Builder) buildS3M() (*layout.Song, error) {
+	s := layout.Song{
+		BaseSong: common.BaseSong[period.Amiga, s3mVolume.Volume, s3mVolume.FineVolume, s3mVolume.Volume, s3mPanning.Panning]{
+			System:       s3mSystem.S3MSystem,
+			MS:           s3mSettings.GetMachineSettings(false),
+			Name:         b.name,
+			InitialBPM:   b.bpm,
+			InitialTempo: b.tempo,
+			GlobalVolume: s3mVolume.MaxVolume,
+			MixingVolume: s3mMixingVolume,
+			InitialOrder: 0,
+			Instruments:  make([]*instrument.Instrument[period.Amiga, s3mVolume.FineVolume, s3mVolume.Volume, s3mPanning.Panning], len(b.instruments)),
+			Patterns:     make([]song.Pattern, len(b.patterns)),
+			OrderList:    append([]index.Pattern(nil), b.orders...),
+		},
+		NumChannels: len(b.channelPans),
+	}
+
+	for i, inst := range b.instruments {
+		s.Instruments[i] = convertS3MInstrument(inst, channel.InstID(i+1))
+	}
+
+	for i, p := range b.patterns {
+		pat := make(song.Pattern, len(p.rows))
+		for r, cells := range p.rows {
+			row := make(layout.Row, len(cells))
+			for ch, c := range cells {
+				cd, err := convertS3MCell(index.Channel(ch), c)
+				if err != nil {
+					return nil, fmt.Errorf("pattern %d row %d channel %d: %w", i, r, ch, err)
+				}
+				row[ch] = cd
+			}
+			pat[r] = row
+		}
+		s.Patterns[i] = pat
+	}
+
+	sharedMem := channel.SharedMemory{
+		ResetMemoryAtStartOfOrder0: true,
+	}
+
+	// the channels are left and right in turn, and the machine plays all the lefts first
+	var lefts, rights []index.Channel
+	s.ChannelSettings = make([]layout.ChannelSetting, len(b.channelPans))
+	for ch, pan := range b.channelPans {
+		chn := s3mfile.ChannelIDL1
+		if ch%2 == 0 {
+			lefts = append(lefts, index.Channel(ch))
+		} else {
+			chn = s3mfile.ChannelIDR1
+			rights = append(rights, index.Channel(ch))
+		}
+		chn += s3mfile.ChannelID(ch / 2)
+
+		s.ChannelSettings[ch] = layout.ChannelSetting{
+			Enabled:          true,
+			Category:         chn.GetChannelCategory(),
+			OutputChannelNum: ch / 2,
+			InitialVolume:    s3mVolume.Volume(s3mfile.DefaultVolume),
+			PanEnabled:       true,
+			InitialPanning:   s3mPanning.Panning(math.Round(float64(panning.FromStereoPosition(pan, 0, float32(s3mPanning.MaxPanning))))),
+			Memory: channel.Memory{
+				Shared: &sharedMem,
+			},
+		}
+	}
+	s.ChannelOrders = append(lefts, rights...)
+
+	return &s, nil
+}
+
+func convertS3MInstrument(inst Instrument, id channel.InstID) *instrument.Instrument[period.Amiga, s3mVolume.FineVolume, s3mVolume.Volume, s3mPanning.Panning] {
+	ii := instrument.PCM[s3mVolume.FineVolume, s3mVolume.Volume, s3mPanning.Panning]{
+		Sample:      inst.Sample,
+		Loop:        &loop.Disabled{},
+		SustainLoop: loop.NewLoop(inst.Loop.Mode, loop.Settings{Begin: inst.Loop.Begin, End: inst.Loop.End}),
+		FadeOut: fadeout.Settings{
+			Mode: fadeout.ModeDisabled,
+		},
+	}
+
+	return &instrument.Instrument[period.Amiga, s3mVolume.FineVolume, s3mVolume.Volume, s3mPanning.Panning]{
+		Static: instrument.StaticValues[period.Amiga, s3mVolume.FineVolume, s3mVolume.Volume, s3mPanning.Panning]{
+			Name:   inst.Name,
+			ID:     id,
+			Volume: s3mVolume.Volume(math.Round(float64(inst.getVolume()) * 64)),
+		},
+		Inst:       &ii,
+		SampleRate: inst.getSampleRate(),
+	}
+}
+
+func convertS3MCell(ch index.Channel, c Cell) (channel.Data, error) {
+	cd := channel.Data{
+		What:   s3mfile.PatternFlags(ch & 0x1F),
+		Note:   s3mfile.EmptyNote,
+		Volume: s3mVolume.Volume(s3mfile.EmptyVolume),
+	}
+
+	switch n := c.Note.(type) {
+	case nil, note.EmptyNote:
+	case note.Normal:
+		st := note.Semitone(n)
+		if st/12 >= 10 {
+			return cd, fmt.Errorf("note out of range: %v", n)
+		}
+		cd.Note = s3mfile.Note(st/12)<<4 | s3mfile.Note(st%12)
+	case note.StopNote, note.StopOrReleaseNote:
+		cd.Note = s3mfile.StopNote
+	default:
+		return cd, fmt.Errorf("S3M songs can't play %v", n)
+	}
+
+	// the note and the instrument go together in an S3M pattern
+	if cd.Note != s3mfile.EmptyNote || c.Instrument != 0 {
+		cd.What |= s3mfile.PatternFlagNote
+		cd.Instrument = uint8(c.Instrument)
+	}
+
+	if v, set := c.Volume.Get(); set {
+		if v > uint8(s3mVolume.MaxVolume) {
+			return cd, fmt.Errorf("volume out of range: %d", v)
+		}
+		cd.What |= s3mfile.PatternFlagVolume
+		cd.Volume = s3mVolume.Volume(v)
+	}
+
+	if c.Effect != "" {
+		if len(c.Effect) != 3 || c.Effect[0] < 'A' || c.Effect[0] > 'Z' {
+			return cd, fmt.Errorf("effect isn't a command and 2 hexadecimal digits: %q", c.Effect)
+		}
+		info, err := parseEffectParameter(c.Effect[1:])
+		if err != nil {
+			return cd, err
+		}
+		cd.What |= s3mfile.PatternFlagCommand
+		cd.Command = c.Effect[0] - '@'
+		cd.Info = channel.DataEffect(info)
+	}
+
+	return cd, nil
+}
@@ -0,0 +1,211 @@
+package builder
+
+import (
+	"fmt"
+	"math"
+
+	xmfile "github.com/gotracker/goaudiofile/music/tracked/xm"
+	"github.com/heucuva/optional"
+
+	"github.com/gotracker/playback/format/common"
+	xmChannel "github.com/gotracker/playback/format/xm/channel"
+	xmLayout "github.com/gotracker/playback/format/xm/layout"
+	xmPanning "github.com/gotracker/playback/format/xm/panning"
+	xmSettings "github.com/gotracker/playback/format/xm/settings"
+	xmSystem "github.com/gotracker/playback/format/xm/system"
+	xmVolume "github.com/gotracker/playback/format/xm/volume"
+	"github.com/gotracker/playback/index"
+	"github.com/gotracker/playback/instrument"
+	"github.com/gotracker/playback/note"
+	"github.com/gotracker/playback/period"
+	"github.com/gotracker/playback/song"
+	"github.com/gotracker/playback/voice/autovibrato"
+	"github.com/gotracker/playback/voice/envelope"
+	"github.com/gotracker/playback/voice/fadeout"
+	"github.com/gotracker/playback/voice/loop"
+)
+
+// the highest note of an XM song, B-7
+const maxXMSemitone = note.Semitone(95)
+
+func buildXM[TPeriod period.Period](b *Builder) (*xmLayout.Song[TPeriod], error) {
+	ms := xmSettings.GetMachineSettings[TPeriod]()
+
+	s := xmLayout.Song[TPeriod]{
+		BaseSong: common.BaseSong[TPeriod, xmVolume.XmVolume, xmVolume.XmVolume, xmVolume.XmVolume, xmPanning.Panning]{
+			System:       xmSystem.XMSystem,
+			MS:           ms,
+			Name:         b.name,
+			InitialBPM:   b.bpm,
+			InitialTempo: b.tempo,
+			GlobalVolume: xmVolume.DefaultXmVolume,
+			MixingVolume: xmVolume.DefaultXmMixingVolume,
+			InitialOrder: 0,
+			Instruments:  make([]*instrument.Instrument[TPeriod, xmVolume.XmVolume, xmVolume.XmVolume, xmPanning.Panning], len(b.instruments)),
+			Patterns:     make([]song.Pattern, len(b.patterns)),
+			OrderList:    append([]index.Pattern(nil), b.orders...),
+		},
+		InstrumentNoteMap: make(map[uint8]xmLayout.SemitoneSamples),
+	}
+
+	for i, inst := range b.instruments {
+		s.Instruments[i] = convertXMInstrument(inst, uint8(i+1), ms.PeriodConverter)
+	}
+
+	for i, p := range b.patterns {
+		pat := make(song.Pattern, len(p.rows))
+		for r, cells := range p.rows {
+			row := make(xmLayout.Row[TPeriod], len(cells))
+			for ch, c := range cells {
+				cd, err := convertXMCell[TPeriod](c)
+				if err != nil {
+					return nil, fmt.Errorf("pattern %d row %d channel %d: %w", i, r, ch, err)
+				}
+				row[ch] = cd
+			}
+			pat[r] = row
+		}
+		s.Patterns[i] = pat
+	}
+
+	sharedMem := xmChannel.SharedMemory{
+		LinearFreqSlides:           b.linearSlides,
+		ResetMemoryAtStartOfOrder0: true,
+	}
+
+	s.ChannelSettings = make([]xmLayout.ChannelSetting, len(b.channelPans))
+	for ch, pan := range b.channelPans {
+		s.ChannelSettings[ch] = xmLayout.ChannelSetting{
+			Enabled:          true,
+			OutputChannelNum: ch,
+			InitialVolume:    xmVolume.DefaultXmVolume,
+			InitialPanning:   xmPanning.Panning(xmPanning.PanningToXm(pan)),
+			Memory: xmChannel.Memory{
+				Shared: &sharedMem,
+			},
+		}
+	}
+
+	return &s, nil
+}
+
+func convertXMInstrument[TPeriod period.Period](inst Instrument, id uint8, pc period.PeriodConverter[TPeriod]) *instrument.Instrument[TPeriod, xmVolume.XmVolume, xmVolume.XmVolume, xmPanning.Panning] {
+	ii := instrument.PCM[xmVolume.XmVolume, xmVolume.XmVolume, xmPanning.Panning]{
+		Sample:      inst.Sample,
+		Loop:        &loop.Disabled{},
+		SustainLoop: loop.NewLoop(inst.Loop.Mode, loop.Settings{Begin: inst.Loop.Begin, End: inst.Loop.End}),
+		FadeOut: fadeout.Settings{
+			Mode:   fadeout.ModeOnlyIfVolEnvActive,
+			Amount: inst.FadeOut,
+		},
+		VolEnv: convertXMEnvelope(inst.VolumeEnvelope, func(v float32) xmVolume.XmVolume {
+			return xmVolume.XmVolume(math.Round(float64(v) * 64))
+		}),
+		PanEnv: convertXMEnvelope(inst.PanningEnvelope, func(v float32) xmPanning.Panning {
+			return xmPanning.Panning(math.Round(float64(v) * 255))
+		}),
+	}
+	if pan, set := inst.Panning.Get(); set {
+		ii.Panning = optional.NewValue(xmPanning.Panning(xmPanning.PanningToXm(pan)))
+	}
+
+	return &instrument.Instrument[TPeriod, xmVolume.XmVolume, xmVolume.XmVolume, xmPanning.Panning]{
+		Static: instrument.StaticValues[TPeriod, xmVolume.XmVolume, xmVolume.XmVolume, xmPanning.Panning]{
+			PC:     pc,
+			Name:   inst.Name,
+			ID:     xmChannel.SampleID{InstID: id},
+			Volume: xmVolume.XmVolume(math.Round(float64(inst.getVolume()) * 64)),
+			AutoVibrato: autovibrato.AutoVibratoConfig[TPeriod]{
+				FactoryName: "vibrato",
+			},
+		},
+		Inst:       &ii,
+		SampleRate: inst.getSampleRate(),
+	}
+}
+
+func convertXMEnvelope[T any](e Envelope, toY func(v float32) T) envelope.Envelope[T] {
+	env := envelope.Envelope[T]{
+		Enabled: len(e.Points) != 0,
+		Loop:    &loop.Disabled{},
+		Sustain: &loop.Disabled{},
+	}
+	if !env.Enabled {
+		return env
+	}
+
+	env.Values = make([]envelope.Point[T], len(e.Points))
+	for i, p := range e.Points {
+		v := &env.Values[i]
+		v.Pos = p.Tick
+		v.Y = toY(p.Value)
+		if i+1 < len(e.Points) {
+			v.Length = e.Points[i+1].Tick - p.Tick
+		} else {
+			v.Length = math.MaxInt64
+			env.Length = p.Tick
+		}
+	}
+
+	if l, set := e.Loop.Get(); set {
+		env.Loop = loop.NewLoop(loop.ModeNormal, loop.Settings{Begin: l.Begin, End: l.End})
+	}
+	if s, set := e.Sustain.Get(); set {
+		env.Sustain = loop.NewLoop(loop.ModeNormal, loop.Settings{Begin: s, End: s})
+	}
+	return env
+}
+
+func convertXMCell[TPeriod period.Period](c Cell) (xmChannel.Data[TPeriod], error) {
+	var cd xmChannel.Data[TPeriod]
+
+	switch n := c.Note.(type) {
+	case nil, note.EmptyNote:
+	case note.Normal:
+		if st := note.Semitone(n); st > maxXMSemitone {
+			return cd, fmt.Errorf("note out of range: %v", n)
+		}
+		cd.What |= xmfile.ChannelFlagHasNote
+		cd.Note = uint8(n) + 1
+	case note.ReleaseNote:
+		cd.What |= xmfile.ChannelFlagHasNote
+		cd.Note = 97
+	default:
+		return cd, fmt.Errorf("XM songs can't play %v", n)
+	}
+
+	if c.Instrument != 0 {
+		cd.What |= xmfile.ChannelFlagHasInstrument
+		cd.Instrument = uint8(c.Instrument)
+	}
+
+	if v, set := c.Volume.Get(); set {
+		if v < 0x10 {
+			return cd, fmt.Errorf("volume column out of range: %02X", v)
+		}
+		cd.What |= xmfile.ChannelFlagHasVolume
+		cd.Volume = xmVolume.VolEffect(v)
+	}
+
+	if c.Effect != "" {
+		if len(c.Effect) != 3 {
+			return cd, fmt.Errorf("effect isn't a command and 2 hexadecimal digits: %q", c.Effect)
+		}
+		switch r := c.Effect[0]; {
+		case r >= '0' && r <= '9':
+			cd.Effect = xmChannel.Command(r - '0')
+		case r >= 'A' && r <= 'Z':
+			cd.Effect = xmChannel.Command(r-'A') + 10
+		default:
+			return cd, fmt.Errorf("unknown effect: %q", c.Effect)
+		}
+		param, err := parseEffectParameter(c.Effect[1:])
+		if err != nil {
+			return cd, err
+		}
+		cd.What |= xmfile.ChannelFlagHasEffect | xmfile.ChannelFlagHasEffectParameter
+		cd.EffectParameter = xmChannel.DataEffect(param)
+	}
+
+	return cd, nil
+}
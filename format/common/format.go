@@ -4,6 +4,7 @@ import (
 	"github.com/gotracker/playback/index"
 	"github.com/gotracker/playback/player/feature"
 	"github.com/gotracker/playback/player/machine/settings"
+	"github.com/gotracker/playback/player/metering"
 )
 
 type Format struct{}
@@ -29,6 +30,17 @@ func (Format) ConvertFeaturesToSettings(us *settings.UserSettings, features []fe
 			us.Start.BPM = f.BPM
 		case feature.IgnoreUnknownEffect:
 			us.IgnoreUnknownEffect = f.Enabled
+		case feature.RecordOPL2:
+			us.OPL2Recorder = f.Recorder
+		case feature.MIDIMacroHandler:
+			us.MIDIMacroHandler = f.Handler
+		case feature.Metering:
+			us.Metering = metering.Settings{
+				Handler:      f.Handler,
+				ScopeSamples: f.ScopeSamples,
+			}
+		case feature.RandomSeed:
+			us.RandomSeed.Set(f.Seed)
 		case feature.QuirksMode:
 			if prof, ok := f.Profile.Get(); ok {
 				us.Quirks.Profile.Set(prof)
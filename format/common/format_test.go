@@ -3,8 +3,11 @@ package common
 import (
 	"testing"
 
+	"github.com/gotracker/playback/index"
 	"github.com/gotracker/playback/player/feature"
 	"github.com/gotracker/playback/player/machine/settings"
+	"github.com/gotracker/playback/player/metering"
+	"github.com/gotracker/playback/player/oplrecord"
 	optional "github.com/heucuva/optional"
 )
 
@@ -89,6 +92,86 @@ func TestConvertFeaturesIgnoresUnknown(t *testing.T) {
 	}
 }
 
+func TestConvertFeaturesSetsOPL2Recorder(t *testing.T) {
+	us := settings.UserSettings{}
+	rec := oplrecord.NewRecorder()
+
+	features := []feature.Feature{
+		feature.RecordOPL2{Recorder: rec},
+	}
+
+	if err := (Format{}).ConvertFeaturesToSettings(&us, features); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if us.OPL2Recorder != rec {
+		t.Fatalf("expected OPL2Recorder to be set")
+	}
+}
+
+func TestConvertFeaturesSetsMIDIMacroHandler(t *testing.T) {
+	us := settings.UserSettings{}
+	var got []byte
+
+	features := []feature.Feature{
+		feature.MIDIMacroHandler{Handler: func(ch index.Channel, msg []byte) {
+			got = msg
+		}},
+	}
+
+	if err := (Format{}).ConvertFeaturesToSettings(&us, features); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if us.MIDIMacroHandler == nil {
+		t.Fatalf("expected MIDIMacroHandler to be set")
+	}
+	us.MIDIMacroHandler(0, []byte{0x90})
+	if len(got) != 1 || got[0] != 0x90 {
+		t.Fatalf("expected handler to be invoked, got %x", got)
+	}
+}
+
+func TestConvertFeaturesSetsMetering(t *testing.T) {
+	us := settings.UserSettings{}
+	var got metering.Tick
+
+	features := []feature.Feature{
+		feature.Metering{
+			Handler:      func(tick metering.Tick) { got = tick },
+			ScopeSamples: 32,
+		},
+	}
+
+	if err := (Format{}).ConvertFeaturesToSettings(&us, features); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if us.Metering.Handler == nil || us.Metering.ScopeSamples != 32 {
+		t.Fatalf("expected metering to be set, got %+v", us.Metering)
+	}
+	us.Metering.Handler(metering.Tick{Row: 3})
+	if got.Row != 3 {
+		t.Fatalf("expected handler to be invoked, got %+v", got)
+	}
+}
+
+func TestConvertFeaturesSetsRandomSeed(t *testing.T) {
+	us := settings.UserSettings{}
+
+	features := []feature.Feature{
+		feature.RandomSeed{Seed: 42},
+	}
+
+	if err := (Format{}).ConvertFeaturesToSettings(&us, features); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if seed, ok := us.RandomSeed.Get(); !ok || seed != 42 {
+		t.Fatalf("expected random seed 42 set, got %v set=%v", seed, ok)
+	}
+}
+
 func TestConvertFeaturesSetsQuirksMode(t *testing.T) {
 	us := settings.UserSettings{}
 
@@ -1,6 +1,12 @@
 package common
 
-import "github.com/gotracker/playback/player/feature"
+import (
+	"math"
+
+	"github.com/gotracker/playback/frequency"
+	"github.com/gotracker/playback/period"
+	"github.com/gotracker/playback/player/feature"
+)
 
 // ResolveLinearSlides returns the desired linear slides setting, allowing
 // user-provided quirks configuration to override the file's default flag.
@@ -14,3 +20,77 @@ func ResolveLinearSlides(defaultLinear bool, features []feature.Feature) bool {
 	}
 	return defaultLinear
 }
+
+const (
+	linearFinetunesPerOctave = 768
+	ft2LinearC4Period        = 6 * linearFinetunesPerOctave
+	ft2LinearC4Frequency     = 8363
+)
+
+// linearFreqTable is Fasttracker II's table of the frequencies of the 768 linear periods of
+// its highest octave (where a C-4 sample plays at 535232Hz), going down in pitch
+var linearFreqTable = func() (t [linearFinetunesPerOctave]uint32) {
+	for i := range t {
+		t[i] = uint32(math.Round(ft2LinearC4Frequency * 64 * math.Exp2(-float64(i)/linearFinetunesPerOctave)))
+	}
+	return
+}()
+
+// LinearSlideUpTable is Impulse Tracker's table of 16.16 fixed-point frequency multipliers
+// for the 256 coarse linear slide steps (1/16th of a semitone each). Unlike the fine table,
+// its entries are truncated rather than rounded.
+var LinearSlideUpTable = func() (t [256]uint32) {
+	for i := range t {
+		t[i] = uint32(math.Floor(65536 * math.Exp2(float64(i)/192)))
+	}
+	return
+}()
+
+// FineLinearSlideUpTable is Impulse Tracker's table of 16.16 fixed-point frequency multipliers
+// for the 16 fine linear slide steps (1/64th of a semitone each)
+var FineLinearSlideUpTable = func() (t [16]uint32) {
+	for i := range t {
+		t[i] = uint32(math.Round(65536 * math.Exp2(float64(i)/linearFinetunesPerOctave)))
+	}
+	return
+}()
+
+// FT2LinearFrequencyTable converts linear periods into frequencies the way Fasttracker II
+// does: the frequency of the period's position within the octave is looked up, then shifted
+// down by the octave, which truncates the frequency to whole Hz
+type FT2LinearFrequencyTable struct{}
+
+var _ period.LinearFrequencyTable = FT2LinearFrequencyTable{}
+
+// GetFrequency returns the frequency multiplier of the period `finetunes` away from C-4
+func (FT2LinearFrequencyTable) GetFrequency(finetunes int) frequency.Frequency {
+	p := max(ft2LinearC4Period-finetunes, 0)
+	octave := p / linearFinetunesPerOctave
+	if octave >= 32 {
+		return 0
+	}
+	hz := linearFreqTable[p%linearFinetunesPerOctave] >> octave
+	return frequency.Frequency(hz) / ft2LinearC4Frequency
+}
+
+// ITLinearFrequencyTable converts linear periods into frequencies the way Impulse Tracker
+// does: the coarse and fine slide multipliers are applied in 16.16 fixed-point, one after the
+// other, then the result is scaled by the octave
+type ITLinearFrequencyTable struct{}
+
+var _ period.LinearFrequencyTable = ITLinearFrequencyTable{}
+
+// GetFrequency returns the frequency multiplier of the period `finetunes` away from C-5
+func (ITLinearFrequencyTable) GetFrequency(finetunes int) frequency.Frequency {
+	octave := finetunes / linearFinetunesPerOctave
+	rem := finetunes % linearFinetunesPerOctave
+	if rem < 0 {
+		rem += linearFinetunesPerOctave
+		octave--
+	}
+
+	m := uint64(65536)
+	m = (m * uint64(LinearSlideUpTable[rem/4])) >> 16
+	m = (m * uint64(FineLinearSlideUpTable[rem%4])) >> 16
+	return frequency.Frequency(math.Ldexp(float64(m)/65536, octave))
+}
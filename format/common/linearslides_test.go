@@ -1,8 +1,11 @@
 package common
 
 import (
+	"math"
 	"testing"
 
+	"github.com/gotracker/playback/frequency"
+	"github.com/gotracker/playback/period"
 	"github.com/gotracker/playback/player/feature"
 	"github.com/heucuva/optional"
 )
@@ -21,3 +24,48 @@ func TestResolveLinearSlidesFallsBackToDefault(t *testing.T) {
 		t.Fatalf("expected default value to be used when no override present")
 	}
 }
+
+func TestLinearSlideTables(t *testing.T) {
+	if linearFreqTable[0] != 535232 || linearFreqTable[1] != 534749 || linearFreqTable[767] != 267858 {
+		t.Fatalf("unexpected linearFreqTable values: %d %d %d", linearFreqTable[0], linearFreqTable[1], linearFreqTable[767])
+	}
+	if LinearSlideUpTable[0] != 65536 || LinearSlideUpTable[1] != 65773 || LinearSlideUpTable[2] != 66010 || LinearSlideUpTable[3] != 66249 {
+		t.Fatalf("unexpected LinearSlideUpTable values: %v", LinearSlideUpTable[:4])
+	}
+	if FineLinearSlideUpTable[0] != 65536 || FineLinearSlideUpTable[3] != 65714 || FineLinearSlideUpTable[8] != 66011 {
+		t.Fatalf("unexpected FineLinearSlideUpTable values: %v", FineLinearSlideUpTable[:9])
+	}
+}
+
+func TestLinearFrequencyTablesTrackPowerOf2(t *testing.T) {
+	tables := map[string]period.LinearFrequencyTable{
+		"ft2": FT2LinearFrequencyTable{},
+		"it":  ITLinearFrequencyTable{},
+	}
+	for name, table := range tables {
+		t.Run(name, func(t *testing.T) {
+			if f := table.GetFrequency(0); f != 1 {
+				t.Fatalf("expected the base period to play at its own rate, got %v", f)
+			}
+			if f := table.GetFrequency(768); f != 2 {
+				t.Fatalf("expected an octave up to double the frequency, got %v", f)
+			}
+			for _, ft := range []int{-1000, -100, -1, 1, 37, 500, 1500} {
+				want := math.Exp2(float64(ft) / 768)
+				got := float64(table.GetFrequency(ft))
+				if math.Abs(got-want)/want > 1e-3 {
+					t.Fatalf("finetunes %d: got %v want %v", ft, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestFT2LinearFrequencyTableTruncatesToWholeHz(t *testing.T) {
+	// 2 octaves below C-4, FT2 shifts the table entry down by 8 instead of 6
+	got := FT2LinearFrequencyTable{}.GetFrequency(-2*768 - 1)
+	want := frequency.Frequency(linearFreqTable[1]>>8) / 8363
+	if got != want {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+}
@@ -161,6 +161,10 @@ func (d Data[TPeriod]) ToInstructions(m machine.Machine[TPeriod, itVolume.FineVo
 		return nil, err
 	}
 
+	if n, ok := d.GetNote().(note.Normal); ok && d.HasNote() {
+		mem.SetLastNote(note.Semitone(n))
+	}
+
 	if e := EffectFactory[TPeriod](mem, d); e != nil {
 		instructions = append(instructions, e)
 	}
@@ -0,0 +1,107 @@
+package channel
+
+import (
+	"fmt"
+
+	itPanning "github.com/gotracker/playback/format/it/panning"
+	itVolume "github.com/gotracker/playback/format/it/volume"
+	"github.com/gotracker/playback/index"
+	"github.com/gotracker/playback/period"
+	"github.com/gotracker/playback/player/machine"
+)
+
+// MIDIMacro defines a MIDI macro effect
+type MIDIMacro[TPeriod period.Period] DataEffect // 'Zxx'
+
+func (e MIDIMacro[TPeriod]) String() string {
+	return fmt.Sprintf("Z%0.2x", DataEffect(e))
+}
+
+func (e MIDIMacro[TPeriod]) RowStart(ch index.Channel, m machine.Machine[TPeriod, itVolume.FineVolume, itVolume.FineVolume, itVolume.Volume, itPanning.Panning]) error {
+	mem, err := machine.GetChannelMemory[*Memory](m, ch)
+	if err != nil {
+		return err
+	}
+
+	xx := uint8(e)
+	var macro string
+	if xx < 0x80 {
+		// Z00-Z7F: parametered macro, selected by SFx
+		macro = mem.Shared.MIDIMacros.Parametered[mem.ActiveMIDIMacro()]
+	} else {
+		// Z80-ZFF: fixed macro
+		macro = mem.Shared.MIDIMacros.Fixed[xx-0x80]
+	}
+	if macro == "" {
+		return nil
+	}
+
+	vol, err := m.GetChannelVolume(ch)
+	if err != nil {
+		return err
+	}
+
+	pan, err := m.GetChannelPan(ch)
+	if err != nil {
+		return err
+	}
+
+	inst, err := m.GetChannelInstrument(ch)
+	if err != nil {
+		return err
+	}
+
+	ctx := midiMacroContext{
+		Channel: uint8(ch),
+		Volume:  uint8(min(int(vol)*2, 0x7F)),
+		Pan:     uint8(pan >> 1),
+		Param:   xx,
+	}
+	if st, set := mem.LastNote().Get(); set {
+		ctx.Note.Set(uint8(st))
+	}
+	if inst != nil {
+		if id, ok := inst.GetID().(SampleID); ok {
+			if prog, ok := mem.Shared.MIDIPrograms[id.InstID]; ok {
+				ctx.Program.Set(prog)
+			}
+		}
+	}
+
+	msg := evaluateMIDIMacro(macro, ctx)
+
+	return sendMIDIMacro(ch, m, mem, msg)
+}
+
+func (e MIDIMacro[TPeriod]) TraceData() string {
+	return e.String()
+}
+
+// sendMIDIMacro interprets the internal filter messages (F0 F0 00 xx = cutoff, F0 F0 01 xx = resonance)
+// and passes anything else on to the machine's MIDI macro handler. The filter settings are kept in
+// the channel memory, as they outlast the note they're set on.
+func sendMIDIMacro[TPeriod period.Period](ch index.Channel, m machine.Machine[TPeriod, itVolume.FineVolume, itVolume.FineVolume, itVolume.Volume, itPanning.Panning], mem *Memory, msg []byte) error {
+	for len(msg) >= 4 && msg[0] == 0xF0 && msg[1] == 0xF0 {
+		var err error
+		switch msg[2] {
+		case 0x00:
+			mem.SetFilterCutoff(msg[3] & 0x7F)
+			err = m.SetChannelFilterCutoff(ch, msg[3]&0x7F)
+		case 0x01:
+			mem.SetFilterResonance(msg[3] & 0x7F)
+			err = m.SetChannelFilterResonance(ch, msg[3]&0x7F)
+		default:
+			// unsupported internal message - let the handler see it
+			return m.SendChannelMIDIMacro(ch, msg)
+		}
+		if err != nil {
+			return err
+		}
+		msg = msg[4:]
+	}
+
+	if len(msg) == 0 {
+		return nil
+	}
+	return m.SendChannelMIDIMacro(ch, msg)
+}
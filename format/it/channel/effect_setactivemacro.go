@@ -0,0 +1,32 @@
+package channel
+
+import (
+	"fmt"
+
+	itPanning "github.com/gotracker/playback/format/it/panning"
+	itVolume "github.com/gotracker/playback/format/it/volume"
+	"github.com/gotracker/playback/index"
+	"github.com/gotracker/playback/period"
+	"github.com/gotracker/playback/player/machine"
+)
+
+// SetActiveMacro defines a set active macro effect
+type SetActiveMacro[TPeriod period.Period] DataEffect // 'SFx'
+
+func (e SetActiveMacro[TPeriod]) String() string {
+	return fmt.Sprintf("S%0.2x", DataEffect(e))
+}
+
+func (e SetActiveMacro[TPeriod]) RowStart(ch index.Channel, m machine.Machine[TPeriod, itVolume.FineVolume, itVolume.FineVolume, itVolume.Volume, itPanning.Panning]) error {
+	mem, err := machine.GetChannelMemory[*Memory](m, ch)
+	if err != nil {
+		return err
+	}
+
+	mem.SetActiveMIDIMacro(uint8(e & 0x0F))
+	return nil
+}
+
+func (e SetActiveMacro[TPeriod]) TraceData() string {
+	return e.String()
+}
@@ -19,6 +19,9 @@ func (e SetCoarsePanPosition[TPeriod]) String() string {
 
 func (e SetCoarsePanPosition[TPeriod]) RowStart(ch index.Channel, m machine.Machine[TPeriod, itVolume.FineVolume, itVolume.FineVolume, itVolume.Volume, itPanning.Panning]) error {
 	pan := itPanning.Panning((e & 0x0f) << 2)
+	if err := m.SetChannelSurround(ch, false); err != nil {
+		return err
+	}
 	return m.SetChannelPan(ch, pan)
 }
 
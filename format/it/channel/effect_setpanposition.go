@@ -19,6 +19,9 @@ func (e SetPanPosition[TPeriod]) String() string {
 
 func (e SetPanPosition[TPeriod]) RowStart(ch index.Channel, m machine.Machine[TPeriod, itVolume.FineVolume, itVolume.FineVolume, itVolume.Volume, itPanning.Panning]) error {
 	pan := itPanning.Panning(e)
+	if err := m.SetChannelSurround(ch, false); err != nil {
+		return err
+	}
 	return m.SetChannelPan(ch, pan)
 }
 
@@ -0,0 +1,26 @@
+package channel
+
+import (
+	"fmt"
+
+	itPanning "github.com/gotracker/playback/format/it/panning"
+	itVolume "github.com/gotracker/playback/format/it/volume"
+	"github.com/gotracker/playback/index"
+	"github.com/gotracker/playback/period"
+	"github.com/gotracker/playback/player/machine"
+)
+
+// SurroundOff defines a set surround off effect
+type SurroundOff[TPeriod period.Period] DataEffect // 'S90'
+
+func (e SurroundOff[TPeriod]) String() string {
+	return fmt.Sprintf("S%0.2x", DataEffect(e))
+}
+
+func (e SurroundOff[TPeriod]) RowStart(ch index.Channel, m machine.Machine[TPeriod, itVolume.FineVolume, itVolume.FineVolume, itVolume.Volume, itPanning.Panning]) error {
+	return m.SetChannelSurround(ch, false)
+}
+
+func (e SurroundOff[TPeriod]) TraceData() string {
+	return e.String()
+}
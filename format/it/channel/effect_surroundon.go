@@ -18,8 +18,7 @@ func (e SurroundOn[TPeriod]) String() string {
 }
 
 func (e SurroundOn[TPeriod]) RowStart(ch index.Channel, m machine.Machine[TPeriod, itVolume.FineVolume, itVolume.FineVolume, itVolume.Volume, itPanning.Panning]) error {
-	// TODO: support for surround function
-	return nil
+	return m.SetChannelSurround(ch, true)
 }
 
 func (e SurroundOn[TPeriod]) TraceData() string {
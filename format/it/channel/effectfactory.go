@@ -133,7 +133,7 @@ func standardEffectFactory[TPeriod period.Period](mem *Memory, data Data[TPeriod
 	case 'Y': // Panbrello
 		return Panbrello[TPeriod](data.EffectParameter)
 	case 'Z': // MIDI Macro
-		return nil // TODO: MIDIMacro
+		return MIDIMacro[TPeriod](data.EffectParameter)
 	default:
 	}
 	return UnhandledCommand[TPeriod]{Command: data.Effect, Info: data.EffectParameter}
@@ -172,7 +172,7 @@ func specialEffect[TPeriod period.Period](data Data[TPeriod]) EffectIT {
 	case 0xE: // Pattern Delay
 		return PatternDelay[TPeriod](data.EffectParameter)
 	case 0xF: // Set Active Macro
-		return nil // TODO: SetActiveMacro
+		return SetActiveMacro[TPeriod](data.EffectParameter)
 	default:
 	}
 	return UnhandledCommand[TPeriod]{Command: data.Effect, Info: data.EffectParameter}
@@ -234,8 +234,8 @@ func volumeSlideFactory[TPeriod period.Period](mem *Memory, cd Command, ce DataE
 func soundControlEffect[TPeriod period.Period](data Data[TPeriod]) EffectIT {
 	switch data.EffectParameter & 0xF {
 	case 0x0: // Surround Off
+		return SurroundOff[TPeriod](data.EffectParameter)
 	case 0x1: // Surround On
-		// only S91 is supported directly by IT
 		return SurroundOn[TPeriod](data.EffectParameter)
 	case 0x8: // Reverb Off
 	case 0x9: // Reverb On
@@ -1,7 +1,10 @@
 package channel
 
 import (
+	"github.com/heucuva/optional"
+
 	"github.com/gotracker/playback/memory"
+	"github.com/gotracker/playback/note"
 	"github.com/gotracker/playback/tremor"
 )
 
@@ -25,8 +28,13 @@ type Memory struct {
 	panbrello          memory.Value[DataEffect] `usage:"Yxy"`
 	volChanVolumeSlide memory.Value[DataEffect] `usage:"vDxy"`
 
-	tremorMem  tremor.Tremor
-	HighOffset int
+	tremorMem   tremor.Tremor
+	HighOffset  int
+	activeMacro uint8
+	lastNote    optional.Value[note.Semitone]
+
+	filterCutoff    optional.Value[uint8]
+	filterResonance optional.Value[uint8]
 
 	Shared *SharedMemory
 }
@@ -127,6 +135,47 @@ func (m *Memory) TremorMem() *tremor.Tremor {
 	return &m.tremorMem
 }
 
+// ActiveMIDIMacro returns the index of the parametered MIDI macro selected by SFx
+func (m *Memory) ActiveMIDIMacro() uint8 {
+	return m.activeMacro
+}
+
+// SetActiveMIDIMacro selects the parametered MIDI macro used by Z00-Z7F
+func (m *Memory) SetActiveMIDIMacro(idx uint8) {
+	m.activeMacro = idx & 0x0F
+}
+
+// LastNote returns the note the channel last played, if any
+func (m *Memory) LastNote() optional.Value[note.Semitone] {
+	return m.lastNote
+}
+
+// SetLastNote keeps the note the channel played, for the n letter of the MIDI macros
+func (m *Memory) SetLastNote(st note.Semitone) {
+	m.lastNote.Set(st)
+}
+
+// FilterCutoff returns the filter cutoff a MIDI macro or an instrument last set, if any
+func (m *Memory) FilterCutoff() optional.Value[uint8] {
+	return m.filterCutoff
+}
+
+// SetFilterCutoff keeps the filter cutoff a MIDI macro or an instrument set, so new notes start with it
+func (m *Memory) SetFilterCutoff(cutoff uint8) {
+	m.filterCutoff.Set(cutoff)
+}
+
+// FilterResonance returns the filter resonance a MIDI macro or an instrument last set, if any
+func (m *Memory) FilterResonance() optional.Value[uint8] {
+	return m.filterResonance
+}
+
+// SetFilterResonance keeps the filter resonance a MIDI macro or an instrument set, so new notes
+// start with it
+func (m *Memory) SetFilterResonance(resonance uint8) {
+	m.filterResonance.Set(resonance)
+}
+
 // Retrigger runs certain operations when a note is retriggered
 func (m *Memory) Retrigger() {
 }
@@ -151,5 +200,7 @@ func (m *Memory) StartOrder0() {
 		m.globalVolumeSlide.Reset()
 		m.panbrello.Reset()
 		m.volChanVolumeSlide.Reset()
+		m.filterCutoff.Reset()
+		m.filterResonance.Reset()
 	}
 }
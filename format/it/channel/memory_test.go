@@ -1,6 +1,10 @@
 package channel
 
-import "testing"
+import (
+	"testing"
+
+	"github.com/gotracker/playback/song"
+)
 
 func TestMemoryEFGLinkModeSharesRegisters(t *testing.T) {
 	mem := Memory{Shared: &SharedMemory{EFGLinkMode: true}}
@@ -37,3 +41,25 @@ func TestMemoryStartOrderReset(t *testing.T) {
 		t.Fatalf("expected vibrato memory reset to 0, got 0x%02x", got)
 	}
 }
+
+func TestMemoryKeepsTheMacroFilter(t *testing.T) {
+	var fm song.FilterMemory = &Memory{Shared: &SharedMemory{ResetMemoryAtStartOfOrder0: true}}
+	mem := fm.(*Memory)
+	if fm.FilterCutoff().IsSet() || fm.FilterResonance().IsSet() {
+		t.Fatalf("expected no filter before a macro sets one")
+	}
+
+	mem.SetFilterCutoff(0x20)
+	mem.SetFilterResonance(0x28)
+	if v, _ := fm.FilterCutoff().Get(); v != 0x20 {
+		t.Fatalf("expected cutoff 0x20, got 0x%02x", v)
+	}
+	if v, _ := fm.FilterResonance().Get(); v != 0x28 {
+		t.Fatalf("expected resonance 0x28, got 0x%02x", v)
+	}
+
+	mem.StartOrder0()
+	if fm.FilterCutoff().IsSet() || fm.FilterResonance().IsSet() {
+		t.Fatalf("expected the filter memory to reset at the start of order 0")
+	}
+}
@@ -0,0 +1,121 @@
+package channel
+
+import (
+	"fmt"
+
+	"github.com/heucuva/optional"
+)
+
+const (
+	// NumGlobalMIDIMacros is the number of global (system) MIDI macros in a MIDI configuration
+	NumGlobalMIDIMacros = 9
+	// NumParameteredMIDIMacros is the number of parametered (SFx) MIDI macros in a MIDI configuration
+	NumParameteredMIDIMacros = 16
+	// NumFixedMIDIMacros is the number of fixed (Z80-ZFF) MIDI macros in a MIDI configuration
+	NumFixedMIDIMacros = 128
+	// MIDIMacroLength is the length of a single macro string, including the NUL terminator
+	MIDIMacroLength = 32
+)
+
+// MIDIMacroConfig is the MIDI macro configuration of a song
+type MIDIMacroConfig struct {
+	Global      [NumGlobalMIDIMacros]string
+	Parametered [NumParameteredMIDIMacros]string // SF0-SFF
+	Fixed       [NumFixedMIDIMacros]string       // Z80-ZFF
+}
+
+// DefaultMIDIMacroConfig returns the MIDI macro configuration used by Impulse Tracker
+// when a song does not embed its own: SF0 controls the resonant filter cutoff and
+// Z80-Z8F set the resonant filter resonance in steps of 8
+func DefaultMIDIMacroConfig() MIDIMacroConfig {
+	var c MIDIMacroConfig
+	c.Global = [NumGlobalMIDIMacros]string{
+		"FF",     // MIDI Start
+		"FC",     // MIDI Stop
+		"",       // MIDI Tick
+		"9c n v", // Note On
+		"9c n 0", // Note Off
+		"",       // Volume
+		"",       // Pan
+		"",       // Bank Change
+		"Cc p",   // Program Change
+	}
+	c.Parametered[0] = "F0F000z"
+	for i := 0; i < 16; i++ {
+		c.Fixed[i] = fmt.Sprintf("F0F001%02X", i*8)
+	}
+	return c
+}
+
+// midiMacroContext holds the values substituted for the letters of a macro string
+type midiMacroContext struct {
+	Channel uint8                 // c
+	Note    optional.Value[uint8] // n
+	Volume  uint8                 // v, u
+	Pan     uint8                 // x, y
+	Program optional.Value[uint8] // p
+	Param   uint8                 // z
+}
+
+// evaluateMIDIMacro converts a macro string into the MIDI bytes it represents. A macro with a
+// letter that has no value - an unsupported one, or a note or program the channel doesn't have -
+// evaluates to nothing, as sending it without the value would send the wrong message.
+func evaluateMIDIMacro(macro string, ctx midiMacroContext) []byte {
+	var (
+		out     []byte
+		acc     uint8
+		nibbles int
+	)
+	flush := func() {
+		if nibbles > 0 {
+			out = append(out, acc)
+		}
+		acc = 0
+		nibbles = 0
+	}
+	emit := func(b uint8) {
+		flush()
+		out = append(out, b)
+	}
+
+	for _, r := range macro {
+		switch {
+		case r >= '0' && r <= '9':
+			acc = acc<<4 | uint8(r-'0')
+			nibbles++
+		case r >= 'A' && r <= 'F':
+			acc = acc<<4 | uint8(r-'A'+10)
+			nibbles++
+		case r == 'c':
+			// channel is a nibble, combined with the preceding status nibble
+			acc = acc<<4 | (ctx.Channel & 0x0F)
+			nibbles++
+		case r == 'v', r == 'u':
+			emit(ctx.Volume & 0x7F)
+		case r == 'x', r == 'y':
+			emit(ctx.Pan & 0x7F)
+		case r == 'z':
+			emit(ctx.Param & 0x7F)
+		case r == 'n', r == 'p':
+			v, set := ctx.Note.Get()
+			if r == 'p' {
+				v, set = ctx.Program.Get()
+			}
+			if !set {
+				return nil
+			}
+			emit(v & 0x7F)
+		case r >= 'a' && r <= 'z':
+			// bank, offset, checksum and the like aren't tracked by the player
+			return nil
+		default:
+			// whitespace is ignored
+			continue
+		}
+		if nibbles == 2 {
+			flush()
+		}
+	}
+	flush()
+	return out
+}
@@ -0,0 +1,99 @@
+package channel
+
+import (
+	"bytes"
+	"testing"
+
+	itfile "github.com/gotracker/goaudiofile/music/tracked/it"
+	"github.com/heucuva/optional"
+
+	"github.com/gotracker/playback/period"
+)
+
+func TestEvaluateMIDIMacroDefaultCutoff(t *testing.T) {
+	cfg := DefaultMIDIMacroConfig()
+	got := evaluateMIDIMacro(cfg.Parametered[0], midiMacroContext{Param: 0x40})
+	if want := []byte{0xF0, 0xF0, 0x00, 0x40}; !bytes.Equal(got, want) {
+		t.Fatalf("unexpected macro bytes: got %x want %x", got, want)
+	}
+}
+
+func TestEvaluateMIDIMacroDefaultResonance(t *testing.T) {
+	cfg := DefaultMIDIMacroConfig()
+	got := evaluateMIDIMacro(cfg.Fixed[0x05], midiMacroContext{Param: 0x85})
+	if want := []byte{0xF0, 0xF0, 0x01, 0x28}; !bytes.Equal(got, want) {
+		t.Fatalf("unexpected macro bytes: got %x want %x", got, want)
+	}
+	if cfg.Fixed[0x10] != "" {
+		t.Fatalf("expected Z90 to be empty by default, got %q", cfg.Fixed[0x10])
+	}
+}
+
+func TestEvaluateMIDIMacroSubstitutions(t *testing.T) {
+	ctx := midiMacroContext{Channel: 0x13, Volume: 0x64, Pan: 0x20, Param: 0x7F}
+	got := evaluateMIDIMacro("Bc 07 v Bc 0A x B z", ctx)
+	want := []byte{0xB3, 0x07, 0x64, 0xB3, 0x0A, 0x20, 0x0B, 0x7F}
+	if !bytes.Equal(got, want) {
+		t.Fatalf("unexpected macro bytes: got %x want %x", got, want)
+	}
+}
+
+func TestEvaluateMIDIMacroNoteAndProgram(t *testing.T) {
+	cfg := DefaultMIDIMacroConfig()
+	ctx := midiMacroContext{
+		Channel: 0x02,
+		Note:    optional.NewValue[uint8](60),
+		Volume:  0x64,
+		Program: optional.NewValue[uint8](0x05),
+	}
+
+	if got, want := evaluateMIDIMacro(cfg.Global[3], ctx), []byte{0x92, 0x3C, 0x64}; !bytes.Equal(got, want) {
+		t.Fatalf("unexpected note on bytes: got %x want %x", got, want)
+	}
+	if got, want := evaluateMIDIMacro(cfg.Global[4], ctx), []byte{0x92, 0x3C, 0x00}; !bytes.Equal(got, want) {
+		t.Fatalf("unexpected note off bytes: got %x want %x", got, want)
+	}
+	if got, want := evaluateMIDIMacro(cfg.Global[8], ctx), []byte{0xC2, 0x05}; !bytes.Equal(got, want) {
+		t.Fatalf("unexpected program change bytes: got %x want %x", got, want)
+	}
+}
+
+func TestEvaluateMIDIMacroUnresolvedLetters(t *testing.T) {
+	cfg := DefaultMIDIMacroConfig()
+	ctx := midiMacroContext{Channel: 0x02, Volume: 0x64}
+
+	for _, macro := range []string{cfg.Global[3], cfg.Global[8], "F0 F0 02 o"} {
+		if got := evaluateMIDIMacro(macro, ctx); got != nil {
+			t.Fatalf("expected %q to evaluate to nothing without its values, got %x", macro, got)
+		}
+	}
+}
+
+func TestEffectFactoryMIDIMacros(t *testing.T) {
+	mem := Memory{Shared: &SharedMemory{}}
+	z := Data[period.Linear]{
+		What:            itfile.ChannelDataFlagCommand,
+		Effect:          Command('Z' - '@'),
+		EffectParameter: 0x40,
+	}
+	if _, ok := EffectFactory[period.Linear](&mem, z).(MIDIMacro[period.Linear]); !ok {
+		t.Fatalf("expected Zxx to produce a MIDIMacro effect")
+	}
+
+	sf := Data[period.Linear]{
+		What:            itfile.ChannelDataFlagCommand,
+		Effect:          Command('S' - '@'),
+		EffectParameter: 0xF3,
+	}
+	if _, ok := EffectFactory[period.Linear](&mem, sf).(SetActiveMacro[period.Linear]); !ok {
+		t.Fatalf("expected SFx to produce a SetActiveMacro effect")
+	}
+}
+
+func TestMemoryActiveMIDIMacro(t *testing.T) {
+	var mem Memory
+	mem.SetActiveMIDIMacro(0x1A)
+	if got := mem.ActiveMIDIMacro(); got != 0x0A {
+		t.Fatalf("expected active macro 0x0A, got 0x%02x", got)
+	}
+}
@@ -13,4 +13,9 @@ type SharedMemory struct {
 	EFGLinkMode bool
 	// ResetMemoryAtStartOfOrder0 if true will reset the memory registers when the first tick of the first row of the first order pattern plays
 	ResetMemoryAtStartOfOrder0 bool
+	// MIDIMacros is the MIDI macro configuration used by the Zxx and SFx effects
+	MIDIMacros MIDIMacroConfig
+	// MIDIPrograms is the MIDI program (0-127) of each instrument number that has one, for the p
+	// letter of the MIDI macros
+	MIDIPrograms map[uint8]uint8
 }
@@ -31,6 +31,82 @@ func Factory(name string, instrumentRate frequency.Frequency, params any) (filte
 		}
 		f = &filter.EchoFilter{EchoFilterSettings: p}
 
+	case "chorus":
+		p, ok := params.(filter.ChorusFilterSettings)
+		if !ok {
+			return nil, errors.New("could not convert chorus filter parameters")
+		}
+		f = &filter.ChorusFilter{ChorusFilterSettings: p}
+
+	case "flanger":
+		p, ok := params.(filter.FlangerFilterSettings)
+		if !ok {
+			return nil, errors.New("could not convert flanger filter parameters")
+		}
+		f = &filter.FlangerFilter{FlangerFilterSettings: p}
+
+	case "compressor":
+		p, ok := params.(filter.CompressorFilterSettings)
+		if !ok {
+			return nil, errors.New("could not convert compressor filter parameters")
+		}
+		f = &filter.CompressorFilter{CompressorFilterSettings: p}
+
+	case "distortion":
+		p, ok := params.(filter.DistortionFilterSettings)
+		if !ok {
+			return nil, errors.New("could not convert distortion filter parameters")
+		}
+		f = &filter.DistortionFilter{DistortionFilterSettings: p}
+
+	case "gargle":
+		p, ok := params.(filter.GargleFilterSettings)
+		if !ok {
+			return nil, errors.New("could not convert gargle filter parameters")
+		}
+		f = &filter.GargleFilter{GargleFilterSettings: p}
+
+	case "parameq":
+		p, ok := params.(filter.ParamEqFilterSettings)
+		if !ok {
+			return nil, errors.New("could not convert parameq filter parameters")
+		}
+		f = &filter.ParamEqFilter{ParamEqFilterSettings: p}
+
+	case "wavesreverb":
+		p, ok := params.(filter.WavesReverbFilterSettings)
+		if !ok {
+			return nil, errors.New("could not convert wavesreverb filter parameters")
+		}
+		f = &filter.WavesReverbFilter{WavesReverbFilterSettings: p}
+
+	case "i3dl2reverb":
+		p, ok := params.(filter.I3DL2ReverbFilterSettings)
+		if !ok {
+			return nil, errors.New("could not convert i3dl2reverb filter parameters")
+		}
+		f = &filter.I3DL2ReverbFilter{I3DL2ReverbFilterSettings: p}
+
+	case "chain":
+		// a plugin routed into further plugins
+		p, ok := params.([]filter.Info)
+		if !ok {
+			return nil, errors.New("could not convert filter chain parameters")
+		}
+		var chain filter.Chain
+		for _, info := range p {
+			cf, err := Factory(info.Name, instrumentRate, info.Params)
+			if err != nil {
+				return nil, err
+			}
+			if cf != nil {
+				chain = append(chain, cf)
+			}
+		}
+		if len(chain) > 0 {
+			f = chain
+		}
+
 	default:
 		return nil, fmt.Errorf("unsupported filter name: %q", name)
 	}
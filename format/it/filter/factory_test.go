@@ -58,3 +58,51 @@ func TestFactoryEmptyAndUnknown(t *testing.T) {
 		t.Fatalf("expected error for unsupported filter")
 	}
 }
+
+func TestFactoryDMOFilters(t *testing.T) {
+	tests := []struct {
+		name   string
+		params any
+	}{
+		{"chorus", pf.ChorusFilterSettings{}},
+		{"flanger", pf.FlangerFilterSettings{}},
+		{"compressor", pf.CompressorFilterSettings{}},
+		{"distortion", pf.DistortionFilterSettings{}},
+		{"gargle", pf.GargleFilterSettings{}},
+		{"parameq", pf.ParamEqFilterSettings{}},
+		{"wavesreverb", pf.WavesReverbFilterSettings{}},
+		{"i3dl2reverb", pf.I3DL2ReverbFilterSettings{}},
+	}
+
+	for _, tt := range tests {
+		f, err := Factory(tt.name, frequency.Frequency(44100), tt.params)
+		if err != nil {
+			t.Fatalf("expected nil error for %s: %v", tt.name, err)
+		}
+		if f == nil {
+			t.Fatalf("expected filter instance for %s", tt.name)
+		}
+		if _, err := Factory(tt.name, frequency.Frequency(44100), "bad"); err == nil {
+			t.Fatalf("expected type assertion error for wrong %s params", tt.name)
+		}
+	}
+}
+
+func TestFactoryChain(t *testing.T) {
+	params := []pf.Info{
+		{Name: "gargle", Params: pf.GargleFilterSettings{}},
+		{Name: "parameq", Params: pf.ParamEqFilterSettings{}},
+	}
+	f, err := Factory("chain", frequency.Frequency(44100), params)
+	if err != nil {
+		t.Fatalf("expected nil error for chain: %v", err)
+	}
+	chain, ok := f.(pf.Chain)
+	if !ok || len(chain) != 2 {
+		t.Fatalf("expected a chain of 2 filters, got %#v", f)
+	}
+
+	if _, err := Factory("chain", frequency.Frequency(44100), []pf.Info{{Name: "nope"}}); err == nil {
+		t.Fatalf("expected error for unsupported filter in chain")
+	}
+}
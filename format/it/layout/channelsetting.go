@@ -21,6 +21,7 @@ type ChannelSetting struct {
 	InitialPanning   itPanning.Panning
 	Memory           channel.Memory
 	Vol0OptEnabled   bool
+	PluginFilter     filter.Info
 }
 
 var _ song.ChannelSettings = (*ChannelSetting)(nil)
@@ -61,11 +62,11 @@ func (c ChannelSetting) IsPanEnabled() bool {
 }
 
 func (c ChannelSetting) GetDefaultFilterInfo() filter.Info {
-	return filter.Info{}
+	return c.PluginFilter
 }
 
 func (c ChannelSetting) IsDefaultFilterEnabled() bool {
-	return false
+	return c.PluginFilter.Name != ""
 }
 
 func (c ChannelSetting) GetVol0OptimizationSettings() vol0optimization.Vol0OptimizationSettings {
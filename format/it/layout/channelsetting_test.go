@@ -60,3 +60,13 @@ func TestChannelSettingFilterDefaults(t *testing.T) {
 		t.Fatalf("expected default filter disabled")
 	}
 }
+
+func TestChannelSettingPluginFilter(t *testing.T) {
+	cs := ChannelSetting{PluginFilter: filter.Info{Name: "echo"}}
+	if got := cs.GetDefaultFilterInfo(); got.Name != "echo" {
+		t.Fatalf("expected channel plugin filter, got %+v", got)
+	}
+	if !cs.IsDefaultFilterEnabled() {
+		t.Fatalf("expected default filter enabled when a plugin is routed to the channel")
+	}
+}
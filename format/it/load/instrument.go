@@ -76,6 +76,13 @@ func convertITInstrumentOldToInstrument[TPeriod period.Period](inst *itfile.IMPI
 			Inst: &id,
 			Static: instrument.StaticValues[TPeriod, itVolume.FineVolume, itVolume.Volume, itPanning.Panning]{
 				PC: pc,
+				// no filter of its own, but one a MIDI macro brings in gets the song's filter range
+				VoiceFilter: filter.Info{
+					Params: filter.ITResonantFilterParams{
+						ExtendedFilterRange: convSettings.extendedFilterRange,
+						Highpass:            convSettings.useHighPassFilter,
+					},
+				},
 			},
 		}
 
@@ -92,6 +99,11 @@ func convertITInstrumentOldToInstrument[TPeriod period.Period](inst *itfile.IMPI
 			ii.Static.NewNoteAction = note.ActionCut
 		}
 
+		if inst.DuplicateNoteCheck == itfile.DuplicateNoteCheckOn {
+			ii.Static.DuplicateCheckType = note.DuplicateCheckTypeNote
+			ii.Static.DuplicateCheckAction = note.ActionCut
+		}
+
 		ci.Inst = &ii
 		if err := addSampleInfoToConvertedInstrument(ci.Inst, &id, &sampData[i], volume.Volume(1), convSettings, features); err != nil {
 			return nil, err
@@ -147,20 +159,22 @@ func convertITInstrumentToInstrument[TPeriod period.Period](inst *itfile.IMPIIns
 		return nil, err
 	}
 
-	var (
-		voiceFilter  filter.Info
-		pluginFilter filter.Info
-	)
-	if inst.InitialFilterResonance != 0 {
-		voiceFilter.Name = "itresonant"
-		voiceFilter.Params = filter.ITResonantFilterParams{
+	// the parameters are kept even when the instrument has no filter of its own, so a filter
+	// that a MIDI macro brings in later gets the song's filter range
+	voiceFilter := filter.Info{
+		Params: filter.ITResonantFilterParams{
 			Cutoff:              inst.InitialFilterCutoff,
 			Resonance:           inst.InitialFilterResonance,
 			ExtendedFilterRange: convSettings.extendedFilterRange,
 			Highpass:            convSettings.useHighPassFilter,
-		}
+		},
+	}
+	if inst.InitialFilterCutoff&0x80 != 0 || inst.InitialFilterResonance != 0 {
+		voiceFilter.Name = "itresonant"
 	}
 
+	var pluginFilter filter.Info
+
 	if inst.MidiChannel >= 0x81 {
 		if pf, ok := pluginFilters[int(inst.MidiChannel)-0x81]; ok {
 			pluginFilter = pf
@@ -185,6 +199,8 @@ func convertITInstrumentToInstrument[TPeriod period.Period](inst *itfile.IMPIIns
 				PC:           pc,
 				VoiceFilter:  voiceFilter,
 				PluginFilter: pluginFilter,
+				VolumeSwing:  float32(min(inst.RandomVolumeVariation, 100)) / 100,
+				PanningSwing: float32(min(inst.RandomPanVariation, 64)) / 64,
 			},
 			Inst: &id,
 		}
@@ -202,6 +218,9 @@ func convertITInstrumentToInstrument[TPeriod period.Period](inst *itfile.IMPIIns
 			ii.Static.NewNoteAction = note.ActionCut
 		}
 
+		ii.Static.DuplicateCheckType = convertDuplicateCheckType(inst.DuplicateCheckType)
+		ii.Static.DuplicateCheckAction = convertDuplicateCheckAction(inst.DuplicateCheckAction)
+
 		mixVol := volume.Volume(inst.GlobalVolume.Value())
 		if !inst.DefaultPan.IsDisabled() {
 			ii.Static.Panning = optional.NewValue(util.Lerp(float64(inst.DefaultPan.Value()), 0, itPanning.MaxPanning))
@@ -230,6 +249,30 @@ func convertITInstrumentToInstrument[TPeriod period.Period](inst *itfile.IMPIIns
 	return outInsts, nil
 }
 
+func convertDuplicateCheckType(dct itfile.DuplicateCheckType) note.DuplicateCheckType {
+	switch dct {
+	case itfile.DuplicateCheckTypeNote:
+		return note.DuplicateCheckTypeNote
+	case itfile.DuplicateCheckTypeSample:
+		return note.DuplicateCheckTypeSample
+	case itfile.DuplicateCheckTypeInstrument:
+		return note.DuplicateCheckTypeInstrument
+	default:
+		return note.DuplicateCheckTypeOff
+	}
+}
+
+func convertDuplicateCheckAction(dca itfile.DuplicateCheckAction) note.Action {
+	switch dca {
+	case itfile.DuplicateCheckActionOff:
+		return note.ActionRelease
+	case itfile.DuplicateCheckActionFade:
+		return note.ActionFadeout
+	default:
+		return note.ActionCut
+	}
+}
+
 func convertVolEnvValue(v int8) itVolume.Volume {
 	vol := itVolume.Volume(uint8(v))
 	if vol > itVolume.Volume(itVolume.MaxItVolume) {
@@ -258,8 +301,12 @@ func convertPitchEnvValue(v int8) types.PitchFiltValue {
 	return types.PitchFiltValue(v)
 }
 
+// envelopeFlagCarry is the OpenMPT extension flag for envelope carry
+const envelopeFlagCarry = itfile.EnvelopeFlags(1 << 3)
+
 func convertEnvelope[T any](outEnv *envelope.Envelope[T], inEnv *itfile.Envelope, convert func(int8) T) error {
 	outEnv.Enabled = (inEnv.Flags & itfile.EnvelopeFlagEnvelopeOn) != 0
+	outEnv.Carry = (inEnv.Flags & envelopeFlagCarry) != 0
 	if !outEnv.Enabled {
 		var disabled loop.Disabled
 		outEnv.Loop = &disabled
@@ -3,7 +3,11 @@ package load
 import (
 	"testing"
 
+	itfile "github.com/gotracker/goaudiofile/music/tracked/it"
+
 	itPanning "github.com/gotracker/playback/format/it/panning"
+	"github.com/gotracker/playback/note"
+	"github.com/gotracker/playback/voice/envelope"
 )
 
 func TestConvertPanEnvValueScalesToPanRange(t *testing.T) {
@@ -23,3 +27,51 @@ func TestConvertPanEnvValueScalesToPanRange(t *testing.T) {
 		}
 	}
 }
+
+func TestConvertDuplicateCheck(t *testing.T) {
+	types := map[itfile.DuplicateCheckType]note.DuplicateCheckType{
+		itfile.DuplicateCheckTypeOff:        note.DuplicateCheckTypeOff,
+		itfile.DuplicateCheckTypeNote:       note.DuplicateCheckTypeNote,
+		itfile.DuplicateCheckTypeSample:     note.DuplicateCheckTypeSample,
+		itfile.DuplicateCheckTypeInstrument: note.DuplicateCheckTypeInstrument,
+	}
+	for in, want := range types {
+		if got := convertDuplicateCheckType(in); got != want {
+			t.Fatalf("convertDuplicateCheckType(%d) = %v, want %v", in, got, want)
+		}
+	}
+
+	actions := map[itfile.DuplicateCheckAction]note.Action{
+		itfile.DuplicateCheckActionCut:  note.ActionCut,
+		itfile.DuplicateCheckActionOff:  note.ActionRelease,
+		itfile.DuplicateCheckActionFade: note.ActionFadeout,
+	}
+	for in, want := range actions {
+		if got := convertDuplicateCheckAction(in); got != want {
+			t.Fatalf("convertDuplicateCheckAction(%d) = %v, want %v", in, got, want)
+		}
+	}
+}
+
+func TestConvertEnvelopeCarry(t *testing.T) {
+	in := itfile.Envelope{
+		Flags: itfile.EnvelopeFlagEnvelopeOn | envelopeFlagCarry,
+		Count: 1,
+	}
+
+	var out envelope.Envelope[int8]
+	if err := convertEnvelope(&out, &in, func(v int8) int8 { return v }); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !out.Carry {
+		t.Fatal("expected carry to be set")
+	}
+
+	in.Flags &^= envelopeFlagCarry
+	if err := convertEnvelope(&out, &in, func(v int8) int8 { return v }); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if out.Carry {
+		t.Fatal("expected carry to be cleared")
+	}
+}
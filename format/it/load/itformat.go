@@ -2,9 +2,7 @@ package load
 
 import (
 	"bytes"
-	"encoding/binary"
 	"errors"
-	"fmt"
 	"io"
 	"strconv"
 
@@ -90,15 +88,15 @@ func convertItPattern[TPeriod period.Period](pkt itfile.PackedPattern, channels
 	return pat, int(maxCh), nil
 }
 
-func convertItFileToSong(f *itfile.File, features []feature.Feature) (song.Data, error) {
+func convertItFileToSong(f *itfile.File, midiMacros channel.MIDIMacroConfig, plugins pluginConfig, features []feature.Feature) (song.Data, error) {
 	linearSlides := common.ResolveLinearSlides(f.Head.Flags.IsLinearSlides(), features)
 	if linearSlides {
-		return convertItFileToTypedSong[period.Linear](f, features, linearSlides)
+		return convertItFileToTypedSong[period.Linear](f, midiMacros, plugins, features, linearSlides)
 	}
-	return convertItFileToTypedSong[period.Amiga](f, features, linearSlides)
+	return convertItFileToTypedSong[period.Amiga](f, midiMacros, plugins, features, linearSlides)
 }
 
-func convertItFileToTypedSong[TPeriod period.Period](f *itfile.File, features []feature.Feature, linearFrequencySlides bool) (*layout.Song[TPeriod], error) {
+func convertItFileToTypedSong[TPeriod period.Period](f *itfile.File, midiMacros channel.MIDIMacroConfig, plugins pluginConfig, features []feature.Feature, linearFrequencySlides bool) (*layout.Song[TPeriod], error) {
 	h, err := moduleHeaderToHeader(&f.Head, linearFrequencySlides)
 	if err != nil {
 		return nil, err
@@ -129,21 +127,30 @@ func convertItFileToTypedSong[TPeriod period.Period](f *itfile.File, features []
 		FilterPlugins:     make(map[int]filter.Info),
 	}
 
+	if plugins.Plugins == nil {
+		plugins.Plugins = make(map[int]*itblock.FX)
+	}
 	for _, block := range f.Blocks {
 		switch t := block.(type) {
 		case *itblock.FX:
-			if filter, err := decodeFilter(t); err == nil {
-				if i, err := strconv.Atoi(string(t.Identifier[2:])); err == nil {
-					songData.FilterPlugins[i] = filter
+			if i, err := strconv.Atoi(string(t.Identifier[2:])); err == nil {
+				if _, found := plugins.Plugins[i]; !found {
+					plugins.Plugins[i] = t
 				}
 			}
 		}
 	}
+	for i := range plugins.Plugins {
+		if info := plugins.FilterInfo(i); info.Name != "" {
+			songData.FilterPlugins[i] = info
+		}
+	}
 
 	for i := 0; i < int(f.Head.OrderCount); i++ {
 		songData.OrderList[i] = index.Pattern(f.OrderList[i])
 	}
 
+	midiPrograms := make(map[uint8]uint8)
 	if f.Head.Flags.IsUseInstruments() {
 		for instNum, inst := range f.Instruments {
 			convSettings := convertITInstrumentSettings{
@@ -171,6 +178,11 @@ func convertItFileToTypedSong[TPeriod period.Period](f *itfile.File, features []
 				for _, ci := range instMap {
 					addSampleWithNoteMapToSong(songData, instNum, ci.Inst, ci.NR)
 				}
+
+				// 0xFF (or anything past the last program) means the instrument doesn't set one
+				if ii.MidiProgram < 0x80 {
+					midiPrograms[uint8(instNum+1)] = ii.MidiProgram
+				}
 			}
 		}
 	}
@@ -194,6 +206,8 @@ func convertItFileToTypedSong[TPeriod period.Period](f *itfile.File, features []
 		OldEffectMode:              oldEffectMode,
 		EFGLinkMode:                efgLinkMode,
 		ResetMemoryAtStartOfOrder0: true,
+		MIDIMacros:                 midiMacros,
+		MIDIPrograms:               midiPrograms,
 	}
 
 	channels := make([]layout.ChannelSetting, lastEnabledChannel+1)
@@ -214,6 +228,7 @@ func convertItFileToTypedSong[TPeriod period.Period](f *itfile.File, features []
 				Shared: &sharedMem,
 			},
 			Vol0OptEnabled: vol0Enabled,
+			PluginFilter:   plugins.ChannelFilterInfo(chNum),
 		}
 
 		channels[chNum] = cs
@@ -223,27 +238,6 @@ func convertItFileToTypedSong[TPeriod period.Period](f *itfile.File, features []
 	return songData, nil
 }
 
-func decodeFilter(f *itblock.FX) (filter.Info, error) {
-	lib := f.LibraryName.String()
-	name := f.UserPluginName.String()
-	switch {
-	case lib == "Echo" && name == "Echo":
-		r := bytes.NewReader(f.Data)
-		e := filter.EchoFilterFactory{}
-		if err := binary.Read(r, binary.LittleEndian, &e); err != nil {
-			return filter.Info{}, err
-		}
-		echo := filter.Info{
-			Name:   "echo",
-			Params: e.EchoFilterSettings,
-		}
-		return echo, nil
-
-	default:
-		return filter.Info{}, fmt.Errorf("unhandled fx lib[%s] name[%s]", lib, name)
-	}
-}
-
 type noteRemap struct {
 	Orig  note.Semitone
 	Remap note.Semitone
@@ -278,10 +272,22 @@ func addSampleWithNoteMapToSong[TPeriod period.Period](song *layout.Song[TPeriod
 }
 
 func readIT(r io.Reader, features []feature.Feature) (song.Data, error) {
-	f, err := itfile.Read(r)
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := itfile.Read(bytes.NewReader(data))
 	if err != nil {
 		return nil, err
 	}
 
-	return convertItFileToSong(f, features)
+	midiMacros, err := readMIDIMacroConfig(data, &f.Head)
+	if err != nil {
+		return nil, err
+	}
+
+	plugins := readPluginConfig(data, &f.Head)
+
+	return convertItFileToSong(f, midiMacros, plugins, features)
 }
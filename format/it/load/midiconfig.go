@@ -0,0 +1,67 @@
+package load
+
+import (
+	"encoding/binary"
+	"errors"
+
+	itfile "github.com/gotracker/goaudiofile/music/tracked/it"
+
+	"github.com/gotracker/playback/format/it/channel"
+)
+
+const midiMacroConfigLength = (channel.NumGlobalMIDIMacros + channel.NumParameteredMIDIMacros + channel.NumFixedMIDIMacros) * channel.MIDIMacroLength
+
+var errMIDIMacroConfigTruncated = errors.New("embedded midi macro configuration is truncated")
+
+// readMIDIMacroConfig reads the MIDI macro configuration embedded in the raw IT file data.
+// If the file does not embed one, the default configuration is returned.
+func readMIDIMacroConfig(data []byte, fh *itfile.ModuleHeader) (channel.MIDIMacroConfig, error) {
+	if !fh.SpecialFlags.IsEmbedMidi() {
+		return channel.DefaultMIDIMacroConfig(), nil
+	}
+
+	pos, ok := extensionDataOffset(data, fh)
+	if !ok || pos+midiMacroConfigLength > len(data) {
+		return channel.MIDIMacroConfig{}, errMIDIMacroConfigTruncated
+	}
+
+	var cfg channel.MIDIMacroConfig
+	next := func() string {
+		s := getMacroString(data[pos : pos+channel.MIDIMacroLength])
+		pos += channel.MIDIMacroLength
+		return s
+	}
+	for i := range cfg.Global {
+		cfg.Global[i] = next()
+	}
+	for i := range cfg.Parametered {
+		cfg.Parametered[i] = next()
+	}
+	for i := range cfg.Fixed {
+		cfg.Fixed[i] = next()
+	}
+	return cfg, nil
+}
+
+// extensionDataOffset returns the position of the data that immediately follows the
+// parapointer tables and the (optional) edit history
+func extensionDataOffset(data []byte, fh *itfile.ModuleHeader) (int, bool) {
+	pos := 0x00C0 + int(fh.OrderCount) + int(fh.InstrumentCount)*4 + int(fh.SampleCount)*4 + int(fh.PatternCount)*4
+	if fh.SpecialFlags.IsHistoryIncluded() {
+		if pos+2 > len(data) {
+			return 0, false
+		}
+		histLen := int(binary.LittleEndian.Uint16(data[pos:]))
+		pos += 2 + histLen*8
+	}
+	return pos, pos <= len(data)
+}
+
+func getMacroString(b []byte) string {
+	for i, c := range b {
+		if c == 0 {
+			return string(b[:i])
+		}
+	}
+	return string(b)
+}
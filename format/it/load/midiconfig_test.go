@@ -0,0 +1,55 @@
+package load
+
+import (
+	"encoding/binary"
+	"testing"
+
+	itfile "github.com/gotracker/goaudiofile/music/tracked/it"
+
+	"github.com/gotracker/playback/format/it/channel"
+)
+
+func TestReadMIDIMacroConfigDefault(t *testing.T) {
+	fh := itfile.ModuleHeader{}
+	cfg, err := readMIDIMacroConfig(nil, &fh)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg != channel.DefaultMIDIMacroConfig() {
+		t.Fatalf("expected default midi macro configuration")
+	}
+}
+
+func TestReadMIDIMacroConfigEmbedded(t *testing.T) {
+	fh := itfile.ModuleHeader{
+		OrderCount:   2,
+		SampleCount:  1,
+		SpecialFlags: itfile.IMPMSpecialFlagEmbedMidi | itfile.IMPMSpecialFlagHistoryIncluded,
+	}
+	base := 0xC0 + 2 + 4
+	histLen := 3
+	cfgPos := base + 2 + histLen*8
+
+	data := make([]byte, cfgPos+midiMacroConfigLength)
+	binary.LittleEndian.PutUint16(data[base:], uint16(histLen))
+	copy(data[cfgPos+channel.NumGlobalMIDIMacros*channel.MIDIMacroLength:], "F0F001z")
+	copy(data[cfgPos+(channel.NumGlobalMIDIMacros+channel.NumParameteredMIDIMacros+1)*channel.MIDIMacroLength:], "F0F00010")
+
+	cfg, err := readMIDIMacroConfig(data, &fh)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Parametered[0] != "F0F001z" {
+		t.Fatalf("unexpected SF0 macro: %q", cfg.Parametered[0])
+	}
+	if cfg.Fixed[1] != "F0F00010" {
+		t.Fatalf("unexpected Z81 macro: %q", cfg.Fixed[1])
+	}
+	if cfg.Fixed[0] != "" {
+		t.Fatalf("expected Z80 macro to be empty, got %q", cfg.Fixed[0])
+	}
+
+	if _, err := readMIDIMacroConfig(data[:cfgPos+10], &fh); err == nil {
+		t.Fatalf("expected error for truncated configuration")
+	}
+}
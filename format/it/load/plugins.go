@@ -0,0 +1,234 @@
+package load
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"strconv"
+
+	itfile "github.com/gotracker/goaudiofile/music/tracked/it"
+	itblock "github.com/gotracker/goaudiofile/music/tracked/it/block"
+
+	"github.com/gotracker/playback/filter"
+)
+
+const (
+	pluginRoutingBypass  = 0x02 // routing flag: the plugin is bypassed
+	pluginOutputToPlugin = 0x80 // output routing: 0x80 + x sends the output to plugin x
+)
+
+// pluginConfig holds the mix plugins that OpenMPT embeds in an IT file and how they are routed
+type pluginConfig struct {
+	Plugins        map[int]*itblock.FX
+	ChannelPlugins []int // 1-based plugin number for each channel; 0 means no plugin
+}
+
+// readPluginConfig scans the extension blocks that follow the header tables (and the
+// embedded MIDI configuration, if any) for mix plugin definitions and channel routing.
+// The blocks are read from the raw file data, as they are not located by the file reader
+// when the file embeds a MIDI configuration.
+func readPluginConfig(data []byte, fh *itfile.ModuleHeader) pluginConfig {
+	pc := pluginConfig{
+		Plugins: make(map[int]*itblock.FX),
+	}
+
+	pos, ok := extensionDataOffset(data, fh)
+	if !ok {
+		return pc
+	}
+	if fh.SpecialFlags.IsEmbedMidi() {
+		pos += midiMacroConfigLength
+	}
+
+	end := firstParaPointer(data, fh)
+	for pos+8 <= end {
+		id := data[pos : pos+4]
+		if !isBlockIdent(id) {
+			break
+		}
+		blockLen := int(binary.LittleEndian.Uint32(data[pos+4:]))
+		next := pos + 8 + blockLen
+		if next > end || next < pos {
+			break
+		}
+		body := data[pos+8 : next]
+
+		switch {
+		case string(id) == "CHFX":
+			for i := 0; i+4 <= len(body); i += 4 {
+				pc.ChannelPlugins = append(pc.ChannelPlugins, int(binary.LittleEndian.Uint32(body[i:])))
+			}
+		case string(id[:2]) == "FX":
+			if i, err := strconv.Atoi(string(id[2:])); err == nil {
+				if fx, err := readPluginBlock(id, body); err == nil {
+					pc.Plugins[i] = fx
+				}
+			}
+		}
+
+		pos = next
+	}
+	return pc
+}
+
+// firstParaPointer returns the position of the first instrument, sample or pattern in the data,
+// which bounds the extension blocks
+func firstParaPointer(data []byte, fh *itfile.ModuleHeader) int {
+	end := len(data)
+	pos := 0x00C0 + int(fh.OrderCount)
+	count := int(fh.InstrumentCount) + int(fh.SampleCount) + int(fh.PatternCount)
+	for i := 0; i < count && pos+4 <= len(data); i, pos = i+1, pos+4 {
+		if ptr := int(binary.LittleEndian.Uint32(data[pos:])); ptr != 0 && ptr < end {
+			end = ptr
+		}
+	}
+	return end
+}
+
+func isBlockIdent(id []byte) bool {
+	for _, c := range id {
+		if c < 0x20 || c > 0x7E {
+			return false
+		}
+	}
+	return string(id) != "IMPI" && string(id) != "IMPS"
+}
+
+type pluginBlockHeader struct {
+	PluginType     itblock.FXPluginType
+	UniqueID       [4]byte
+	RoutingFlags   uint8
+	MixMode        uint8
+	GainFactor     itblock.FXGainFactor
+	Reserved0B     uint8
+	OutputRouting  uint32
+	Reserved10     [16]byte
+	UserPluginName itblock.FXUserPluginName
+	LibraryName    itblock.FXLibraryName
+	DataLength     uint32
+}
+
+func readPluginBlock(id []byte, body []byte) (*itblock.FX, error) {
+	r := bytes.NewReader(body)
+	var h pluginBlockHeader
+	if err := binary.Read(r, binary.LittleEndian, &h); err != nil {
+		return nil, err
+	}
+	if int(h.DataLength) > r.Len() {
+		return nil, fmt.Errorf("plugin data length %d exceeds block", h.DataLength)
+	}
+
+	fx := itblock.FX{
+		PluginType:     h.PluginType,
+		UniqueID:       h.UniqueID,
+		RoutingFlags:   h.RoutingFlags,
+		MixMode:        h.MixMode,
+		GainFactor:     h.GainFactor,
+		OutputRouting:  h.OutputRouting,
+		UserPluginName: h.UserPluginName,
+		LibraryName:    h.LibraryName,
+		DataLength:     h.DataLength,
+		Data:           make([]byte, h.DataLength),
+	}
+	copy(fx.Identifier[:], id)
+	fx.BlockLen = uint32(len(body))
+	if _, err := r.Read(fx.Data); err != nil && h.DataLength > 0 {
+		return nil, err
+	}
+	return &fx, nil
+}
+
+// FilterInfo returns the filter for the plugin at index `idx`, followed by every plugin
+// its output is routed into. Bypassed and unsupported plugins pass their input through.
+func (pc pluginConfig) FilterInfo(idx int) filter.Info {
+	var chain []filter.Info
+	visited := make(map[int]struct{})
+	for {
+		fx, ok := pc.Plugins[idx]
+		if !ok {
+			break
+		}
+		if _, seen := visited[idx]; seen {
+			break
+		}
+		visited[idx] = struct{}{}
+
+		if fx.RoutingFlags&pluginRoutingBypass == 0 {
+			if info, err := decodeFilter(fx); err == nil {
+				chain = append(chain, info)
+			}
+		}
+
+		if fx.OutputRouting < pluginOutputToPlugin {
+			break
+		}
+		idx = int(fx.OutputRouting - pluginOutputToPlugin)
+	}
+
+	switch len(chain) {
+	case 0:
+		return filter.Info{}
+	case 1:
+		return chain[0]
+	default:
+		return filter.Info{
+			Name:   "chain",
+			Params: chain,
+		}
+	}
+}
+
+// ChannelFilterInfo returns the filter for the plugin assigned to channel `ch`
+func (pc pluginConfig) ChannelFilterInfo(ch int) filter.Info {
+	if ch >= len(pc.ChannelPlugins) || pc.ChannelPlugins[ch] == 0 {
+		return filter.Info{}
+	}
+	return pc.FilterInfo(pc.ChannelPlugins[ch] - 1)
+}
+
+func decodeFilter(f *itblock.FX) (filter.Info, error) {
+	lib := f.LibraryName.String()
+	switch lib {
+	case "Echo":
+		var e filter.EchoFilterFactory
+		return decodeDMOFilter(f.Data, &e, "echo", func() any { return e.EchoFilterSettings })
+	case "Chorus":
+		var e filter.ChorusFilterFactory
+		return decodeDMOFilter(f.Data, &e, "chorus", func() any { return e.ChorusFilterSettings })
+	case "Flanger":
+		var e filter.FlangerFilterFactory
+		return decodeDMOFilter(f.Data, &e, "flanger", func() any { return e.FlangerFilterSettings })
+	case "Compressor":
+		var e filter.CompressorFilterFactory
+		return decodeDMOFilter(f.Data, &e, "compressor", func() any { return e.CompressorFilterSettings })
+	case "Distortion":
+		var e filter.DistortionFilterFactory
+		return decodeDMOFilter(f.Data, &e, "distortion", func() any { return e.DistortionFilterSettings })
+	case "Gargle":
+		var e filter.GargleFilterFactory
+		return decodeDMOFilter(f.Data, &e, "gargle", func() any { return e.GargleFilterSettings })
+	case "ParamEq":
+		var e filter.ParamEqFilterFactory
+		return decodeDMOFilter(f.Data, &e, "parameq", func() any { return e.ParamEqFilterSettings })
+	case "WavesReverb":
+		var e filter.WavesReverbFilterFactory
+		return decodeDMOFilter(f.Data, &e, "wavesreverb", func() any { return e.WavesReverbFilterSettings })
+	case "I3DL2Reverb":
+		var e filter.I3DL2ReverbFilterFactory
+		return decodeDMOFilter(f.Data, &e, "i3dl2reverb", func() any { return e.I3DL2ReverbFilterSettings })
+
+	default:
+		return filter.Info{}, fmt.Errorf("unhandled fx lib[%s] name[%s]", lib, f.UserPluginName.String())
+	}
+}
+
+// decodeDMOFilter reads the saved parameter chunk of a DMO plugin into `factory`
+func decodeDMOFilter(data []byte, factory any, name string, params func() any) (filter.Info, error) {
+	if err := binary.Read(bytes.NewReader(data), binary.LittleEndian, factory); err != nil {
+		return filter.Info{}, err
+	}
+	return filter.Info{
+		Name:   name,
+		Params: params(),
+	}, nil
+}
@@ -0,0 +1,103 @@
+package load
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"testing"
+
+	itfile "github.com/gotracker/goaudiofile/music/tracked/it"
+
+	"github.com/gotracker/playback/filter"
+)
+
+func makePluginBlock(t *testing.T, id string, lib string, routing uint8, output uint32, params ...float32) []byte {
+	t.Helper()
+	var chunk bytes.Buffer
+	chunk.Write(make([]byte, 4)) // parameter chunk header
+	for _, p := range params {
+		_ = binary.Write(&chunk, binary.LittleEndian, math.Float32bits(p))
+	}
+
+	h := pluginBlockHeader{
+		RoutingFlags:  routing,
+		OutputRouting: output,
+		DataLength:    uint32(chunk.Len()),
+	}
+	copy(h.LibraryName[:], lib)
+
+	var body bytes.Buffer
+	if err := binary.Write(&body, binary.LittleEndian, &h); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	body.Write(chunk.Bytes())
+
+	var b bytes.Buffer
+	b.WriteString(id)
+	_ = binary.Write(&b, binary.LittleEndian, uint32(body.Len()))
+	b.Write(body.Bytes())
+	return b.Bytes()
+}
+
+func makeChannelPluginBlock(plugins ...uint32) []byte {
+	var b bytes.Buffer
+	b.WriteString("CHFX")
+	_ = binary.Write(&b, binary.LittleEndian, uint32(len(plugins)*4))
+	for _, p := range plugins {
+		_ = binary.Write(&b, binary.LittleEndian, p)
+	}
+	return b.Bytes()
+}
+
+func TestReadPluginConfigRouting(t *testing.T) {
+	fh := itfile.ModuleHeader{
+		OrderCount:   2,
+		SpecialFlags: itfile.IMPMSpecialFlagEmbedMidi,
+	}
+	data := make([]byte, 0xC0+2+midiMacroConfigLength)
+	// FX00 (Gargle) routes into FX01 (bypassed ParamEq), which routes into FX02 (Echo)
+	data = append(data, makePluginBlock(t, "FX00", "Gargle", 0, 0x81, 0.25, 1)...)
+	data = append(data, makePluginBlock(t, "FX01", "ParamEq", pluginRoutingBypass, 0x82, 0.5, 0.5, 0.5)...)
+	data = append(data, makePluginBlock(t, "FX02", "Echo", 0, 0, 0.5, 0.5, 0.25, 0.25, 0)...)
+	data = append(data, makeChannelPluginBlock(0, 3)...)
+
+	pc := readPluginConfig(data, &fh)
+	if len(pc.Plugins) != 3 {
+		t.Fatalf("expected 3 plugins, got %d", len(pc.Plugins))
+	}
+
+	info := pc.FilterInfo(0)
+	chain, ok := info.Params.([]filter.Info)
+	if info.Name != "chain" || !ok || len(chain) != 2 {
+		t.Fatalf("expected gargle->echo chain, got %+v", info)
+	}
+	if chain[0].Name != "gargle" || chain[1].Name != "echo" {
+		t.Fatalf("unexpected chain order: %s, %s", chain[0].Name, chain[1].Name)
+	}
+	if g := chain[0].Params.(filter.GargleFilterSettings); g.Rate != 0.25 || g.WaveShape != 1 {
+		t.Fatalf("unexpected gargle settings: %+v", g)
+	}
+
+	if got := pc.ChannelFilterInfo(0); got.Name != "" {
+		t.Fatalf("expected no plugin on channel 0, got %q", got.Name)
+	}
+	if got := pc.ChannelFilterInfo(1); got.Name != "echo" {
+		t.Fatalf("expected echo plugin on channel 1, got %q", got.Name)
+	}
+	if got := pc.ChannelFilterInfo(2); got.Name != "" {
+		t.Fatalf("expected no plugin past the routed channels, got %q", got.Name)
+	}
+}
+
+func TestPluginConfigRoutingLoopTerminates(t *testing.T) {
+	fh := itfile.ModuleHeader{}
+	data := make([]byte, 0xC0)
+	data = append(data, makePluginBlock(t, "FX00", "Gargle", 0, 0x81, 0.25, 1)...)
+	data = append(data, makePluginBlock(t, "FX01", "Gargle", 0, 0x80, 0.5, 0)...)
+
+	pc := readPluginConfig(data, &fh)
+	chain, ok := pc.FilterInfo(0).Params.([]filter.Info)
+	if !ok || len(chain) != 2 {
+		t.Fatalf("expected a chain of 2 plugins, got %+v", pc.FilterInfo(0))
+	}
+}
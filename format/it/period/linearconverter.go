@@ -1,10 +1,13 @@
 package period
 
 import (
+	"github.com/gotracker/playback/format/common"
 	"github.com/gotracker/playback/format/it/system"
 	"github.com/gotracker/playback/period"
 )
 
 var LinearConverter period.PeriodConverter[period.Linear] = period.LinearConverter{
-	System: system.ITSystem,
+	System:   system.ITSystem,
+	Table:    common.ITLinearFrequencyTable{},
+	UseTable: true,
 }
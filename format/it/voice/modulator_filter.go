@@ -0,0 +1,35 @@
+package voice
+
+import (
+	"github.com/gotracker/playback/filter"
+)
+
+// == FilterModulator ==
+
+func (v *itVoice[TPeriod]) SetFilterCutoff(cutoff uint8) error {
+	v.getResonantFilter().SetCutoff(cutoff)
+	return nil
+}
+
+func (v *itVoice[TPeriod]) SetFilterResonance(resonance uint8) error {
+	v.getResonantFilter().SetResonance(resonance)
+	return nil
+}
+
+// getResonantFilter returns the voice's resonant filter, creating one if the
+// instrument did not specify a filter of its own. A filter created here uses the
+// song's filter range, which the instrument carries in its filter parameters.
+func (v *itVoice[TPeriod]) getResonantFilter() *filter.ResonantFilter {
+	if rf, ok := v.voiceFilter.(*filter.ResonantFilter); ok {
+		return rf
+	}
+
+	var p filter.ITResonantFilterParams
+	if v.inst != nil {
+		p, _ = v.inst.GetVoiceFilterInfo().Params.(filter.ITResonantFilterParams)
+	}
+
+	rf := filter.NewITResonantFilter(0, 0, p.ExtendedFilterRange, p.Highpass).(*filter.ResonantFilter)
+	v.voiceFilter = rf
+	return rf
+}
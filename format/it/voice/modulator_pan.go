@@ -25,6 +25,17 @@ func (v itVoice[TPeriod]) GetPanDelta() types.PanDelta {
 	return v.pan.GetPanDelta()
 }
 
+func (v *itVoice[TPeriod]) SetSurround(enabled bool) error {
+	if err := v.pan.SetSurround(enabled); err != nil {
+		return err
+	}
+	return v.updateFinal()
+}
+
+func (v itVoice[TPeriod]) IsSurround() bool {
+	return v.pan.IsSurround()
+}
+
 func (v itVoice[TPeriod]) GetPanSeparation() float32 {
 	return v.pitchPan.GetPanSeparation()
 }
@@ -3,6 +3,7 @@ package voice
 import (
 	"errors"
 	"fmt"
+	"math/rand"
 
 	"github.com/gotracker/playback/filter"
 	itFilter "github.com/gotracker/playback/format/it/filter"
@@ -18,6 +19,7 @@ import (
 	"github.com/gotracker/playback/voice/autovibrato"
 	"github.com/gotracker/playback/voice/component"
 	"github.com/gotracker/playback/voice/fadeout"
+	"github.com/gotracker/playback/voice/types"
 )
 
 type Period interface {
@@ -48,6 +50,11 @@ type itVoice[TPeriod Period] struct {
 	filterEnv   component.FilterEnvelope
 	vol0Opt     component.Vol0Optimization
 	voiceFilter filter.Filter
+	rng         *rand.Rand
+
+	// random variations, chosen on attack
+	volSwing volume.Volume
+	panSwing types.PanDelta
 
 	// finals
 	finalVol    volume.Volume
@@ -61,11 +68,13 @@ var (
 	_ voice.FadeoutModulator                                                          = (*itVoice[period.Linear])(nil)
 	_ voice.FreqModulator[period.Linear]                                              = (*itVoice[period.Linear])(nil)
 	_ voice.PanModulator[itPanning.Panning]                                           = (*itVoice[period.Linear])(nil)
+	_ voice.SurroundModulator                                                         = (*itVoice[period.Linear])(nil)
 	_ voice.PitchPanModulator[itPanning.Panning]                                      = (*itVoice[period.Linear])(nil)
 	_ voice.VolumeEnvelope[itVolume.FineVolume, itVolume.FineVolume, itVolume.Volume] = (*itVoice[period.Linear])(nil)
 	_ voice.PitchEnvelope[period.Linear]                                              = (*itVoice[period.Linear])(nil)
 	_ voice.PanEnvelope[itPanning.Panning]                                            = (*itVoice[period.Linear])(nil)
 	_ voice.FilterEnvelope                                                            = (*itVoice[period.Linear])(nil)
+	_ voice.FilterModulator                                                           = (*itVoice[period.Linear])(nil)
 )
 
 func New[TPeriod Period](config voice.VoiceConfig[TPeriod, itVolume.FineVolume, itVolume.FineVolume, itVolume.Volume, itPanning.Panning]) voice.RenderVoice[TPeriod, itVolume.FineVolume, itVolume.FineVolume, itVolume.Volume, itPanning.Panning] {
@@ -97,6 +106,7 @@ func New[TPeriod Period](config voice.VoiceConfig[TPeriod, itVolume.FineVolume,
 	})
 
 	v.vol0Opt.Setup(config.Vol0Optimization)
+	v.rng = config.Random
 
 	return v
 }
@@ -104,6 +114,7 @@ func New[TPeriod Period](config voice.VoiceConfig[TPeriod, itVolume.FineVolume,
 func (v *itVoice[TPeriod]) doAttack() {
 	v.vol0Opt.Reset()
 	v.autoVibrato.Reset()
+	v.updateSwing()
 
 	v.SetVolumeEnvelopePosition(0)
 	v.SetPitchEnvelopePosition(0)
@@ -220,6 +231,7 @@ func (v *itVoice[TPeriod]) Setup(inst *instrument.Instrument[TPeriod, itVolume.F
 			MixVolume:     itVolume.MaxItFineVolume,
 			WholeLoop:     d.Loop,
 			SustainLoop:   d.SustainLoop,
+			Interpolation: d.Interpolation,
 		})
 		v.voicer = &s
 
@@ -351,6 +363,9 @@ func (v *itVoice[TPeriod]) Clone(background bool) voice.Voice {
 		panEnv:                  v.panEnv.Clone(nil),
 		filterEnv:               v.filterEnv.Clone(nil),
 		vol0Opt:                 v.vol0Opt.Clone(),
+		rng:                     v.rng,
+		volSwing:                v.volSwing,
+		panSwing:                v.panSwing,
 	}
 
 	vv.volEnv = v.volEnv.Clone(func(v voice.Voice) {
@@ -390,7 +405,7 @@ func (v *itVoice[TPeriod]) updateFinal() error {
 	}
 	fadeVol := v.fadeout.GetFinalVolume()
 
-	v.finalVol = vol * volEnv * fadeVol
+	v.finalVol = min(max(vol*(1+v.volSwing), 0), 1) * volEnv * fadeVol
 
 	// period
 	p, err := v.freq.GetFinalPeriod()
@@ -407,11 +422,33 @@ func (v *itVoice[TPeriod]) updateFinal() error {
 	v.finalPeriod = p
 
 	// panning
-	if !v.IsPanEnvelopeEnabled() {
+	switch {
+	case v.pan.IsSurround():
+		// surround is honored over the pan envelope
 		v.finalPan = v.pan.GetFinalPan()
-	} else {
-		envPan := v.panEnv.GetCurrentValue()
+	case v.IsPanEnvelopeEnabled():
+		envPan := types.AddPanningDelta(v.panEnv.GetCurrentValue(), v.panSwing)
 		v.finalPan = v.pitchPan.GetSeparatedPan(envPan).ToPosition()
+	case v.panSwing != 0:
+		v.finalPan = types.AddPanningDelta(v.pan.GetPan(), v.pan.GetPanDelta()+v.panSwing).ToPosition()
+	default:
+		v.finalPan = v.pan.GetFinalPan()
 	}
 	return err
 }
+
+// updateSwing chooses new random volume and panning variations from the instrument settings
+func (v *itVoice[TPeriod]) updateSwing() {
+	v.volSwing = 0
+	v.panSwing = 0
+	if v.inst == nil || v.rng == nil {
+		return
+	}
+
+	if vs := v.inst.Static.VolumeSwing; vs > 0 {
+		v.volSwing = volume.Volume(vs * (2*v.rng.Float32() - 1))
+	}
+	if ps := v.inst.Static.PanningSwing; ps > 0 {
+		v.panSwing = types.PanDelta(ps * float32(itPanning.MaxPanning) * (2*v.rng.Float32() - 1))
+	}
+}
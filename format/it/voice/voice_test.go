@@ -1,8 +1,11 @@
 package voice
 
 import (
+	"math/rand"
+	"reflect"
 	"testing"
 
+	"github.com/gotracker/playback/filter"
 	itPanning "github.com/gotracker/playback/format/it/panning"
 	itPeriod "github.com/gotracker/playback/format/it/period"
 	itVolume "github.com/gotracker/playback/format/it/volume"
@@ -87,3 +90,113 @@ func TestVoiceStopMarksDone(t *testing.T) {
 		t.Fatalf("expected final volume to be 0 after stop, got %v", fv)
 	}
 }
+
+func swungFinalVolume(t *testing.T, seed int64) volume.Volume {
+	t.Helper()
+	cfg := voiceCore.VoiceConfig[period.Linear, itVolume.FineVolume, itVolume.FineVolume, itVolume.Volume, itPanning.Panning]{
+		PC:            itPeriod.LinearConverter,
+		InitialVolume: itVolume.Volume(32),
+		InitialMixing: itVolume.FineVolume(64),
+		PanEnabled:    true,
+		InitialPan:    itPanning.DefaultPanning,
+		Random:        rand.New(rand.NewSource(seed)),
+	}
+	v := New[period.Linear](cfg).(testVoice)
+	inst := makeTestInstrument()
+	inst.Static.VolumeSwing = 0.5
+	inst.Static.PanningSwing = 0.5
+
+	if err := v.Setup(&inst); err != nil {
+		t.Fatalf("voice setup error: %v", err)
+	}
+	v.Attack()
+	if err := v.Tick(); err != nil {
+		t.Fatalf("tick error: %v", err)
+	}
+	return v.GetFinalVolume()
+}
+
+func TestVoiceSwingIsDeterministicForSeed(t *testing.T) {
+	a := swungFinalVolume(t, 1234)
+	b := swungFinalVolume(t, 1234)
+	if a != b {
+		t.Fatalf("expected identical swing for identical seeds, got %v and %v", a, b)
+	}
+
+	v := makeVoice()
+	inst := makeTestInstrument()
+	if err := v.Setup(&inst); err != nil {
+		t.Fatalf("voice setup error: %v", err)
+	}
+	v.Attack()
+	if err := v.Tick(); err != nil {
+		t.Fatalf("tick error: %v", err)
+	}
+	base := v.GetFinalVolume()
+	if a == base {
+		t.Fatalf("expected swing to vary the final volume from %v", base)
+	}
+	if a < base*0.5 || a > base*1.5 {
+		t.Fatalf("expected swung volume %v within 50%% of %v", a, base)
+	}
+}
+
+func TestVoiceSwingStaysWithinFullVolume(t *testing.T) {
+	// find a seed that swings the volume well up
+	for seed := int64(1); ; seed++ {
+		cfg := voiceCore.VoiceConfig[period.Linear, itVolume.FineVolume, itVolume.FineVolume, itVolume.Volume, itPanning.Panning]{
+			PC:            itPeriod.LinearConverter,
+			InitialVolume: itVolume.Volume(itVolume.MaxItVolume),
+			InitialMixing: itVolume.MaxItFineVolume,
+			PanEnabled:    true,
+			InitialPan:    itPanning.DefaultPanning,
+			Random:        rand.New(rand.NewSource(seed)),
+		}
+		v := New[period.Linear](cfg).(testVoice)
+		inst := makeTestInstrument()
+		inst.Static.Volume = itVolume.Volume(itVolume.MaxItVolume)
+		inst.Static.VolumeSwing = 1
+
+		if err := v.Setup(&inst); err != nil {
+			t.Fatalf("voice setup error: %v", err)
+		}
+		v.Attack()
+		if v.(*itVoice[period.Linear]).volSwing < 0.5 {
+			continue
+		}
+
+		if err := v.Tick(); err != nil {
+			t.Fatalf("tick error: %v", err)
+		}
+		if fv := v.GetFinalVolume(); fv > 1 {
+			t.Fatalf("expected the swung volume to stay within full volume, got %v", fv)
+		}
+		return
+	}
+}
+
+func TestVoiceMacroFilterUsesTheSongFilterRange(t *testing.T) {
+	v := makeVoice()
+	inst := makeTestInstrument()
+	// no filter of its own, in a song with the extended filter range
+	inst.Static.VoiceFilter.Params = filter.ITResonantFilterParams{ExtendedFilterRange: true}
+
+	if err := v.Setup(&inst); err != nil {
+		t.Fatalf("voice setup error: %v", err)
+	}
+	fm := v.(voiceCore.FilterModulator)
+	if err := fm.SetFilterCutoff(0x40); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := fm.SetFilterResonance(0x20); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := filter.NewITResonantFilter(0x80|0x40, 0x80|0x20, true, false)
+	got := v.(*itVoice[period.Linear]).voiceFilter
+	got.SetPlaybackRate(44100)
+	want.SetPlaybackRate(44100)
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected the macro filter to use the extended filter range, got %+v want %+v", got, want)
+	}
+}
@@ -23,6 +23,17 @@ func (e EnableFilter) Tick(ch index.Channel, m machine.Machine[period.Amiga, s3m
 	}
 
 	x := DataEffect(e) & 0xf
+
+	mem, err := machine.GetChannelMemory[*Memory](m, ch)
+	if err != nil {
+		return err
+	}
+
+	if mem.Shared.AmigaOutput {
+		// like ProTracker, an even value switches the LED filter on and an odd one switches it off
+		return m.SetLEDFilter(x&1 == 0)
+	}
+
 	return m.SetFilterOnAllChannelsByFilterName("amigalpf", x != 0, nil)
 }
 
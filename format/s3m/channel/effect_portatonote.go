@@ -37,7 +37,14 @@ func (e PortaToNote) Tick(ch index.Channel, m machine.Machine[period.Amiga, s3mV
 	if mem.Shared.ST300Portas {
 		mul = 2
 	}
-	return m.DoChannelPortaToNote(ch, period.Delta(xx)*mul)
+	if err := m.DoChannelPortaToNote(ch, period.Delta(xx)*mul); err != nil {
+		return err
+	}
+
+	if mem.Glissando() {
+		return m.DoChannelGlissando(ch)
+	}
+	return nil
 }
 
 func (e PortaToNote) TraceData() string {
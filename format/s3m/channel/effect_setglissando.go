@@ -0,0 +1,32 @@
+package channel
+
+import (
+	"fmt"
+
+	s3mPanning "github.com/gotracker/playback/format/s3m/panning"
+	s3mVolume "github.com/gotracker/playback/format/s3m/volume"
+	"github.com/gotracker/playback/index"
+	"github.com/gotracker/playback/period"
+	"github.com/gotracker/playback/player/machine"
+)
+
+// SetGlissando defines a set glissando control effect
+type SetGlissando ChannelCommand // 'S1x'
+
+func (e SetGlissando) String() string {
+	return fmt.Sprintf("S%0.2x", DataEffect(e))
+}
+
+func (e SetGlissando) RowStart(ch index.Channel, m machine.Machine[period.Amiga, s3mVolume.Volume, s3mVolume.FineVolume, s3mVolume.Volume, s3mPanning.Panning]) error {
+	mem, err := machine.GetChannelMemory[*Memory](m, ch)
+	if err != nil {
+		return err
+	}
+
+	mem.SetGlissando((DataEffect(e) & 0x0F) != 0)
+	return nil
+}
+
+func (e SetGlissando) TraceData() string {
+	return e.String()
+}
@@ -18,6 +18,9 @@ func (e SetPanPosition) String() string {
 }
 
 func (e SetPanPosition) Tick(ch index.Channel, m machine.Machine[period.Amiga, s3mVolume.Volume, s3mVolume.FineVolume, s3mVolume.Volume, s3mPanning.Panning], tick int) error {
+	if err := m.SetChannelSurround(ch, false); err != nil {
+		return err
+	}
 	return m.SetChannelPan(ch, s3mPanning.Panning(uint8(e)&0xf))
 }
 
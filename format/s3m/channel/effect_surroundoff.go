@@ -0,0 +1,26 @@
+package channel
+
+import (
+	"fmt"
+
+	s3mPanning "github.com/gotracker/playback/format/s3m/panning"
+	s3mVolume "github.com/gotracker/playback/format/s3m/volume"
+	"github.com/gotracker/playback/index"
+	"github.com/gotracker/playback/period"
+	"github.com/gotracker/playback/player/machine"
+)
+
+// SurroundOff defines a set surround off effect
+type SurroundOff ChannelCommand // 'S90'
+
+func (e SurroundOff) String() string {
+	return fmt.Sprintf("S%0.2x", DataEffect(e))
+}
+
+func (e SurroundOff) RowStart(ch index.Channel, m machine.Machine[period.Amiga, s3mVolume.Volume, s3mVolume.FineVolume, s3mVolume.Volume, s3mPanning.Panning]) error {
+	return m.SetChannelSurround(ch, false)
+}
+
+func (e SurroundOff) TraceData() string {
+	return e.String()
+}
@@ -93,8 +93,8 @@ func specialEffect(mem *Memory, data Data) playback.Effect {
 	switch cmd >> 4 {
 	case 0x0: // Set Filter on/off
 		return EnableFilter(data.Info)
-	//case 0x1: // Set Glissando on/off
-
+	case 0x1: // Set Glissando on/off
+		return SetGlissando(data.Info)
 	case 0x2: // Set FineTune
 		return SetFinetune(data.Info)
 	case 0x3: // Set Vibrato Waveform
@@ -153,8 +153,8 @@ func volumeSlideFactory(mem *Memory, cd uint8, ce DataEffect) playback.Effect {
 func soundControlEffect(data Data) playback.Effect {
 	switch data.Info & 0xF {
 	case 0x0: // Surround Off
+		return SurroundOff(data.Info)
 	case 0x1: // Surround On
-		// only S91 is supported directly by S3M
 		return SurroundOn(data.Info)
 	case 0x8: // Reverb Off
 	case 0x9: // Reverb On
@@ -18,6 +18,7 @@ type Memory struct {
 	lastNonZero   memory.Value[DataEffect]
 
 	tremorMem tremor.Tremor
+	glissando bool
 
 	Shared *SharedMemory
 }
@@ -74,6 +75,16 @@ func (m *Memory) TremorMem() *tremor.Tremor {
 	return &m.tremorMem
 }
 
+// Glissando returns true if glissando control is enabled for tone portamento
+func (m *Memory) Glissando() bool {
+	return m.glissando
+}
+
+// SetGlissando sets the glissando control for tone portamento
+func (m *Memory) SetGlissando(enabled bool) {
+	m.glissando = enabled
+}
+
 // Retrigger is called when a voice is triggered
 func (m *Memory) Retrigger() {
 }
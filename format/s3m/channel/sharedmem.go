@@ -14,4 +14,6 @@ type SharedMemory struct {
 	AmigaLimits         bool
 	// Mod quirks mode
 	ModCompatibility bool
+	// AmigaOutput if true will have S0x (E0x) switch the Amiga "LED" filter
+	AmigaOutput bool
 }
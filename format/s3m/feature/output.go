@@ -0,0 +1,45 @@
+package feature
+
+// OutputMode selects the sound hardware whose output behavior is used when playing S3M songs
+// (and MOD songs, which are played as S3M songs)
+type OutputMode uint8
+
+const (
+	// OutputModeDefault keeps the player's own output behavior
+	OutputModeDefault = OutputMode(iota)
+	// OutputModeGUS plays back as ScreamTracker 3 does on a Gravis UltraSound: the master volume
+	// and the stereo flag of the song are ignored
+	OutputModeGUS
+	// OutputModeSBPro plays back as ScreamTracker 3 does on a SoundBlaster Pro: the master volume
+	// and the stereo flag of the song apply, and the final mix goes through the card's 8-bit DAC
+	// and output filter
+	OutputModeSBPro
+	// OutputModeSB16 plays back as ScreamTracker 3 does on a SoundBlaster 16: the master volume
+	// and the stereo flag of the song apply, and the final mix goes through the card's 16-bit DAC
+	OutputModeSB16
+	// OutputModeClean applies the master volume and stereo flag of the song, without modelling
+	// any of the sound hardware
+	OutputModeClean
+)
+
+func (o OutputMode) String() string {
+	switch o {
+	case OutputModeDefault:
+		return "default"
+	case OutputModeGUS:
+		return "GUS"
+	case OutputModeSBPro:
+		return "SB Pro"
+	case OutputModeSB16:
+		return "SB16"
+	case OutputModeClean:
+		return "clean"
+	default:
+		return "unknown"
+	}
+}
+
+// Output selects the output mode
+type Output struct {
+	Mode OutputMode
+}
@@ -1,6 +1,7 @@
 package filter
 
 import (
+	"errors"
 	"fmt"
 
 	"github.com/gotracker/playback/filter"
@@ -16,6 +17,20 @@ func Factory(name string, instrumentRate frequency.Frequency, params any) (filte
 	case "amigalpf":
 		f = filter.NewAmigaLPF(instrumentRate)
 
+	case "amigapaula":
+		p, ok := params.(filter.AmigaFilterSettings)
+		if !ok {
+			return nil, errors.New("could not convert amiga filter parameters")
+		}
+		f = filter.NewAmigaFilter(instrumentRate, p)
+
+	case "soundblaster":
+		p, ok := params.(filter.SoundBlasterFilterSettings)
+		if !ok {
+			return nil, errors.New("could not convert soundblaster filter parameters")
+		}
+		f = filter.NewSoundBlasterFilter(instrumentRate, p)
+
 	default:
 		return nil, fmt.Errorf("unsupported filter name: %q", name)
 	}
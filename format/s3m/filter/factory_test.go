@@ -3,6 +3,7 @@ package filter
 import (
 	"testing"
 
+	"github.com/gotracker/playback/filter"
 	"github.com/gotracker/playback/frequency"
 )
 
@@ -27,3 +28,39 @@ func TestFactoryEmptyAndUnknown(t *testing.T) {
 		t.Fatalf("expected error for unsupported filter")
 	}
 }
+
+func TestFactoryAmigaPaula(t *testing.T) {
+	f, err := Factory("amigapaula", frequency.Frequency(44100), filter.AmigaFilterSettings{Model: filter.AmigaModelA1200})
+	if err != nil {
+		t.Fatalf("expected nil error for amigapaula: %v", err)
+	}
+	af, ok := f.(*filter.AmigaFilter)
+	if !ok {
+		t.Fatalf("expected an amiga filter, got %T", f)
+	}
+	if af.Model != filter.AmigaModelA1200 {
+		t.Fatalf("expected A1200 model, got %v", af.Model)
+	}
+
+	if _, err := Factory("amigapaula", frequency.Frequency(44100), nil); err == nil {
+		t.Fatalf("expected error for missing amigapaula parameters")
+	}
+}
+
+func TestFactorySoundBlaster(t *testing.T) {
+	f, err := Factory("soundblaster", frequency.Frequency(44100), filter.SoundBlasterFilterSettings{Model: filter.SoundBlasterModel16, Stereo: true})
+	if err != nil {
+		t.Fatalf("expected nil error for soundblaster: %v", err)
+	}
+	sf, ok := f.(*filter.SoundBlasterFilter)
+	if !ok {
+		t.Fatalf("expected a soundblaster filter, got %T", f)
+	}
+	if sf.Model != filter.SoundBlasterModel16 || !sf.Stereo {
+		t.Fatalf("unexpected soundblaster settings: %+v", sf.SoundBlasterFilterSettings)
+	}
+
+	if _, err := Factory("soundblaster", frequency.Frequency(44100), nil); err == nil {
+		t.Fatalf("expected error for missing soundblaster parameters")
+	}
+}
@@ -7,9 +7,12 @@ import (
 	"io"
 
 	s3mfile "github.com/gotracker/goaudiofile/music/tracked/s3m"
+	"github.com/heucuva/optional"
 
+	"github.com/gotracker/playback/filter"
 	"github.com/gotracker/playback/format/common"
 	"github.com/gotracker/playback/format/s3m/channel"
+	s3mFeature "github.com/gotracker/playback/format/s3m/feature"
 	"github.com/gotracker/playback/format/s3m/layout"
 	s3mPanning "github.com/gotracker/playback/format/s3m/panning"
 	"github.com/gotracker/playback/format/s3m/settings"
@@ -18,6 +21,7 @@ import (
 	"github.com/gotracker/playback/frequency"
 	"github.com/gotracker/playback/index"
 	"github.com/gotracker/playback/instrument"
+	"github.com/gotracker/playback/mixing/sampling"
 	"github.com/gotracker/playback/mixing/volume"
 	"github.com/gotracker/playback/period"
 	"github.com/gotracker/playback/player/feature"
@@ -255,6 +259,10 @@ func convertS3MPackedPattern(pkt s3mfile.PackedPattern, numRows uint8) (song.Pat
 	return pat, int(maxCh)
 }
 
+// gusMasterVolume is the mixing volume used for GUS output, which ignores the master volume of the
+// song. It matches the default master volume of ScreamTracker 3.
+const gusMasterVolume = s3mVolume.FineVolume(0x30)
+
 func convertS3MFileToSong(f *s3mfile.File, getPatternLen func(patNum int) uint8, features []feature.Feature, wasModFile bool) (*layout.Song, error) {
 	h, err := moduleHeaderToHeader(&f.Head)
 	if err != nil {
@@ -263,10 +271,45 @@ func convertS3MFileToSong(f *s3mfile.File, getPatternLen func(patNum int) uint8,
 
 	amigaLimits := (f.Head.Flags&0x0010) != 0 || wasModFile
 
+	var amigaOutput optional.Value[filter.AmigaModel]
+	outputMode := s3mFeature.OutputModeDefault
+	for _, feat := range features {
+		switch f := feat.(type) {
+		case feature.AmigaOutput:
+			amigaOutput.Set(f.Model)
+		case s3mFeature.Output:
+			outputMode = f.Mode
+		}
+	}
+
+	stereoMode := (f.Head.MixingVolume&0x80) != 0 || wasModFile
+	sbFilterEnable := (f.Head.Flags&0x0020) != 0 || wasModFile
+
+	var outputFilter filter.Info
+	switch outputMode {
+	case s3mFeature.OutputModeGUS:
+		// the GUS always plays in stereo and at the same level
+		stereoMode = true
+		h.MixingVolume = gusMasterVolume
+	case s3mFeature.OutputModeSBPro, s3mFeature.OutputModeSB16:
+		model := filter.SoundBlasterModelPro
+		if outputMode == s3mFeature.OutputModeSB16 {
+			model = filter.SoundBlasterModel16
+		}
+		outputFilter = filter.Info{
+			Name: "soundblaster",
+			Params: filter.SoundBlasterFilterSettings{
+				Model:   model,
+				LowPass: sbFilterEnable,
+				Stereo:  stereoMode,
+			},
+		}
+	}
+
 	s := layout.Song{
 		BaseSong: common.BaseSong[period.Amiga, s3mVolume.Volume, s3mVolume.FineVolume, s3mVolume.Volume, s3mPanning.Panning]{
 			System:       s3mSystem.S3MSystem,
-			MS:           settings.GetMachineSettings(amigaLimits),
+			MS:           settings.GetMachineSettingsWithOutput(amigaLimits, outputFilter),
 			Name:         h.Name,
 			InitialBPM:   h.InitialTempo,
 			InitialTempo: h.InitialSpeed,
@@ -294,12 +337,10 @@ func convertS3MFileToSong(f *s3mfile.File, getPatternLen func(patNum int) uint8,
 
 	signedSamples := f.Head.FileFormatInformation == 1
 
-	stereoMode := (f.Head.MixingVolume&0x80) != 0 || wasModFile
 	st2Vibrato := (f.Head.Flags & 0x0001) != 0
 	st2Tempo := (f.Head.Flags & 0x0002) != 0
 	amigaSlides := (f.Head.Flags&0x0004) != 0 || wasModFile
 	zeroVolOpt := (f.Head.Flags&0x0008) != 0 && !wasModFile
-	sbFilterEnable := (f.Head.Flags&0x0020) != 0 || wasModFile
 	st300volSlides := (f.Head.Flags&0x0040) != 0 || f.Head.TrackerVersion == 0x1300
 	st300portas := f.Head.TrackerVersion == 0x1300
 	//ptrSpecialIsValid := (f.Head.Flags & 0x0080) != 0
@@ -318,6 +359,9 @@ func convertS3MFileToSong(f *s3mfile.File, getPatternLen func(patNum int) uint8,
 			continue
 		}
 		sample.Static.ID = channel.InstID(uint8(instNum + 1))
+		if d, ok := sample.Inst.(*instrument.PCM[s3mVolume.FineVolume, s3mVolume.Volume, s3mPanning.Panning]); ok && amigaOutput.IsSet() {
+			d.Interpolation = sampling.InterpolationBLEP
+		}
 		s.Instruments[instNum] = sample
 	}
 
@@ -345,6 +389,7 @@ func convertS3MFileToSong(f *s3mfile.File, getPatternLen func(patNum int) uint8,
 		ZeroVolOptimization:        zeroVolOpt,
 		AmigaLimits:                amigaLimits,
 		ModCompatibility:           wasModFile,
+		AmigaOutput:                amigaOutput.IsSet(),
 	}
 
 	channels := make([]layout.ChannelSetting, 0, maxPatternChannel+1)
@@ -364,7 +409,12 @@ func convertS3MFileToSong(f *s3mfile.File, getPatternLen func(patNum int) uint8,
 			},
 		}
 
-		if sbFilterEnable {
+		if model, ok := amigaOutput.Get(); ok {
+			cs.DefaultFilter = filter.Info{
+				Name:   "amigapaula",
+				Params: filter.AmigaFilterSettings{Model: model},
+			}
+		} else if sbFilterEnable && outputMode == s3mFeature.OutputModeDefault {
 			cs.DefaultFilter.Name = "amigalpf"
 		}
 
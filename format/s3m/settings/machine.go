@@ -1,6 +1,7 @@
 package settings
 
 import (
+	"github.com/gotracker/playback/filter"
 	s3mPanning "github.com/gotracker/playback/format/s3m/panning"
 	s3mVolume "github.com/gotracker/playback/format/s3m/volume"
 	"github.com/gotracker/playback/period"
@@ -15,6 +16,19 @@ func GetMachineSettings(modLimits bool) *settings.MachineSettings[period.Amiga,
 	return amigaS3MSettings
 }
 
+// GetMachineSettingsWithOutput returns the machine settings for a song whose final mix is run
+// through the output filter described by `output`
+func GetMachineSettingsWithOutput(modLimits bool, output filter.Info) *settings.MachineSettings[period.Amiga, s3mVolume.Volume, s3mVolume.FineVolume, s3mVolume.Volume, s3mPanning.Panning] {
+	ms := GetMachineSettings(modLimits)
+	if output.Name == "" {
+		return ms
+	}
+
+	withOutput := *ms
+	withOutput.OutputFilter = output
+	return &withOutput
+}
+
 var (
 	amigaMOD31Settings = quirks.GetS3MMachineSettings(quirks.ProfileST321_ModLimits, amigaVoiceFactory)
 	amigaS3MSettings   = quirks.GetS3MMachineSettings(quirks.ProfileST321, amigaVoiceFactory)
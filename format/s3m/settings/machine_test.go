@@ -3,6 +3,7 @@ package settings
 import (
 	"testing"
 
+	"github.com/gotracker/playback/filter"
 	"github.com/gotracker/playback/period"
 )
 
@@ -40,3 +41,24 @@ func TestSettingsPeriodType(t *testing.T) {
 		t.Fatalf("expected period converter present")
 	}
 }
+
+func TestGetMachineSettingsWithOutput(t *testing.T) {
+	if ms := GetMachineSettingsWithOutput(false, filter.Info{}); ms != amigaS3MSettings {
+		t.Fatalf("expected amigaS3MSettings pointer without an output filter")
+	}
+
+	output := filter.Info{Name: "soundblaster", Params: filter.SoundBlasterFilterSettings{Model: filter.SoundBlasterModelPro}}
+	ms := GetMachineSettingsWithOutput(true, output)
+	if ms == amigaMOD31Settings {
+		t.Fatalf("expected a copy of the machine settings")
+	}
+	if ms.OutputFilter != output {
+		t.Fatalf("unexpected output filter: %+v", ms.OutputFilter)
+	}
+	if ms.PeriodConverter != amigaMOD31Settings.PeriodConverter {
+		t.Fatalf("unexpected period converter")
+	}
+	if amigaMOD31Settings.OutputFilter.Name != "" {
+		t.Fatalf("expected shared settings to be left alone")
+	}
+}
@@ -4,8 +4,6 @@ import (
 	"errors"
 	"fmt"
 
-	"github.com/gotracker/opl2"
-
 	"github.com/gotracker/playback/filter"
 	s3mFilter "github.com/gotracker/playback/format/s3m/filter"
 	s3mPanning "github.com/gotracker/playback/format/s3m/panning"
@@ -22,7 +20,7 @@ import (
 
 type s3mVoice struct {
 	inst        *instrument.Instrument[period.Amiga, s3mVolume.FineVolume, s3mVolume.Volume, s3mPanning.Panning]
-	opl2Chip    *opl2.Chip
+	opl2Chip    voice.OPL2Chip
 	opl2Channel index.OPLChannel
 
 	component.KeyModulator
@@ -42,6 +40,7 @@ var (
 	_ voice.AmpModulator[s3mVolume.Volume, s3mVolume.FineVolume, s3mVolume.Volume] = (*s3mVoice)(nil)
 	_ voice.FreqModulator[period.Amiga]                                            = (*s3mVoice)(nil)
 	_ voice.PanModulator[s3mPanning.Panning]                                       = (*s3mVoice)(nil)
+	_ voice.SurroundModulator                                                      = (*s3mVoice)(nil)
 )
 
 func New(config voice.VoiceConfig[period.Amiga, s3mVolume.Volume, s3mVolume.FineVolume, s3mVolume.Volume, s3mPanning.Panning]) voice.RenderVoice[period.Amiga, s3mVolume.Volume, s3mVolume.FineVolume, s3mVolume.Volume, s3mPanning.Panning] {
@@ -78,7 +77,7 @@ func New(config voice.VoiceConfig[period.Amiga, s3mVolume.Volume, s3mVolume.Fine
 	return v
 }
 
-func (v *s3mVoice) SetOPL2Chip(chip *opl2.Chip) {
+func (v *s3mVoice) SetOPL2Chip(chip voice.OPL2Chip) {
 	v.opl2Chip = chip
 }
 
@@ -142,6 +141,7 @@ func (v *s3mVoice) Setup(inst *instrument.Instrument[period.Amiga, s3mVolume.Fin
 			MixVolume:     s3mVolume.MaxFineVolume,
 			WholeLoop:     d.Loop,
 			SustainLoop:   d.SustainLoop,
+			Interpolation: d.Interpolation,
 		})
 		v.voicer = &s
 
@@ -32,7 +32,14 @@ func (e PortaToNote[TPeriod]) Tick(ch index.Channel, m machine.Machine[TPeriod,
 	}
 
 	xx := mem.PortaToNote(DataEffect(e))
-	return m.DoChannelPortaToNote(ch, period.Delta(xx)*4)
+	if err := m.DoChannelPortaToNote(ch, period.Delta(xx)*4); err != nil {
+		return err
+	}
+
+	if mem.Glissando() {
+		return m.DoChannelGlissando(ch)
+	}
+	return nil
 }
 
 func (e PortaToNote[TPeriod]) TraceData() string {
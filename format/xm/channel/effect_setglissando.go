@@ -0,0 +1,36 @@
+package channel
+
+import (
+	"fmt"
+
+	xmPanning "github.com/gotracker/playback/format/xm/panning"
+	xmVolume "github.com/gotracker/playback/format/xm/volume"
+	"github.com/gotracker/playback/index"
+	"github.com/gotracker/playback/period"
+	"github.com/gotracker/playback/player/machine"
+)
+
+// SetGlissando defines a set glissando control effect
+type SetGlissando[TPeriod period.Period] DataEffect // 'E3x'
+
+func (e SetGlissando[TPeriod]) String() string {
+	return fmt.Sprintf("E%0.2x", DataEffect(e))
+}
+
+func (e SetGlissando[TPeriod]) Tick(ch index.Channel, m machine.Machine[TPeriod, xmVolume.XmVolume, xmVolume.XmVolume, xmVolume.XmVolume, xmPanning.Panning], tick int) error {
+	if tick != 0 {
+		return nil
+	}
+
+	mem, err := machine.GetChannelMemory[*Memory](m, ch)
+	if err != nil {
+		return err
+	}
+
+	mem.SetGlissando((e & 0x0F) != 0)
+	return nil
+}
+
+func (e SetGlissando[TPeriod]) TraceData() string {
+	return e.String()
+}
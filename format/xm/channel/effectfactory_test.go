@@ -3,6 +3,8 @@ package channel
 import (
 	"testing"
 
+	xmfile "github.com/gotracker/goaudiofile/music/tracked/xm"
+
 	xmVolume "github.com/gotracker/playback/format/xm/volume"
 	"github.com/gotracker/playback/period"
 )
@@ -22,3 +24,27 @@ func TestEffectFactoryNoCommandReturnsNil(t *testing.T) {
 		t.Fatalf("expected zero volume for empty data, got %v", got)
 	}
 }
+
+func TestEffectFactoryGlissandoControl(t *testing.T) {
+	var mem Memory
+	d := Data[period.Amiga]{
+		What:            xmfile.ChannelFlagHasEffect | xmfile.ChannelFlagHasEffectParameter,
+		Effect:          0xE,
+		EffectParameter: 0x31,
+	}
+	eff := EffectFactory[period.Amiga](&mem, d)
+	if _, ok := eff.(SetGlissando[period.Amiga]); !ok {
+		t.Fatalf("expected E31 to produce SetGlissando, got %T", eff)
+	}
+}
+
+func TestMemoryGlissando(t *testing.T) {
+	var mem Memory
+	if mem.Glissando() {
+		t.Fatalf("expected glissando to be off by default")
+	}
+	mem.SetGlissando(true)
+	if !mem.Glissando() {
+		t.Fatalf("expected glissando to be on")
+	}
+}
@@ -27,6 +27,7 @@ type Memory struct {
 	extraFinePortaDown  memory.Value[DataEffect]
 
 	tremorMem tremor.Tremor
+	glissando bool
 
 	Shared *SharedMemory
 }
@@ -126,6 +127,16 @@ func (m *Memory) TremorMem() *tremor.Tremor {
 	return &m.tremorMem
 }
 
+// Glissando returns true if glissando control is enabled for tone portamento
+func (m *Memory) Glissando() bool {
+	return m.glissando
+}
+
+// SetGlissando sets the glissando control for tone portamento
+func (m *Memory) SetGlissando(enabled bool) {
+	m.glissando = enabled
+}
+
 func (m *Memory) Retrigger() {
 }
 
@@ -1,10 +1,13 @@
 package period
 
 import (
+	"github.com/gotracker/playback/format/common"
 	"github.com/gotracker/playback/format/xm/system"
 	"github.com/gotracker/playback/period"
 )
 
 var LinearConverter period.PeriodConverter[period.Linear] = period.LinearConverter{
-	System: system.XMSystem,
+	System:   system.XMSystem,
+	Table:    common.FT2LinearFrequencyTable{},
+	UseTable: true,
 }
@@ -175,6 +175,7 @@ func (v *xmVoice[TPeriod]) Setup(inst *instrument.Instrument[TPeriod, xmVolume.X
 			MixVolume:     xmVolume.DefaultXmMixingVolume,
 			WholeLoop:     d.Loop,
 			SustainLoop:   d.SustainLoop,
+			Interpolation: d.Interpolation,
 		})
 		v.voicer = &s
 
@@ -2,6 +2,7 @@ package index
 
 const (
 	InvalidChannel    = Channel(0xFFFF)
+	JamChannel        = Channel(0xFFFE) // the notes played live on top of a song
 	InvalidOPLChannel = OPLChannel(0xFF)
 )
 
@@ -33,19 +33,23 @@ type InstrumentIntf interface {
 
 // StaticValues are the static values associated with an instrument
 type StaticValues[TPeriod types.Period, TMixingVolume, TVolume types.Volume, TPanning types.Panning] struct {
-	PC                 period.PeriodConverter[TPeriod]
-	Filename           string
-	Name               string
-	ID                 ID
-	Volume             TVolume
-	MixingVolume       optional.Value[TMixingVolume]
-	Panning            optional.Value[TPanning]
-	RelativeNoteNumber int8
-	AutoVibrato        autovibrato.AutoVibratoConfig[TPeriod]
-	NewNoteAction      note.Action
-	Finetune           note.Finetune
-	VoiceFilter        filter.Info
-	PluginFilter       filter.Info
+	PC                   period.PeriodConverter[TPeriod]
+	Filename             string
+	Name                 string
+	ID                   ID
+	Volume               TVolume
+	MixingVolume         optional.Value[TMixingVolume]
+	Panning              optional.Value[TPanning]
+	RelativeNoteNumber   int8
+	AutoVibrato          autovibrato.AutoVibratoConfig[TPeriod]
+	NewNoteAction        note.Action
+	Finetune             note.Finetune
+	VoiceFilter          filter.Info
+	PluginFilter         filter.Info
+	VolumeSwing          float32 // random volume variation applied on attack, as a fraction of the volume (0..1)
+	PanningSwing         float32 // random panning variation applied on attack, as a fraction of the panning range (0..1)
+	DuplicateCheckType   note.DuplicateCheckType
+	DuplicateCheckAction note.Action
 }
 
 // Instrument is the mildly-decoded instrument/sample header
@@ -115,6 +119,16 @@ func (inst Instrument[TPeriod, TMixingVolume, TVolume, TPanning]) GetNewNoteActi
 	return inst.Static.NewNoteAction
 }
 
+// GetDuplicateCheckType returns the method used to find background notes duplicating a new note
+func (inst Instrument[TPeriod, TMixingVolume, TVolume, TPanning]) GetDuplicateCheckType() note.DuplicateCheckType {
+	return inst.Static.DuplicateCheckType
+}
+
+// GetDuplicateCheckAction returns the action to perform on background notes duplicating a new note
+func (inst Instrument[TPeriod, TMixingVolume, TVolume, TPanning]) GetDuplicateCheckAction() note.Action {
+	return inst.Static.DuplicateCheckAction
+}
+
 // GetData returns the instrument-specific data interface
 func (inst Instrument[TPeriod, TMixingVolume, TVolume, TPanning]) GetData() Data {
 	return inst.Inst
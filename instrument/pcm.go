@@ -26,6 +26,7 @@ type PCM[TMixingVolume, TVolume types.Volume, TPanning types.Panning] struct {
 	PanEnv               envelope.Envelope[TPanning]
 	PitchFiltMode        bool                                    // true = filter, false = pitch
 	PitchFiltEnv         envelope.Envelope[types.PitchFiltValue] // this is either pitch or filter
+	Interpolation        sampling.Interpolation
 }
 
 func (p PCM[TMixingVolume, TVolume, TPanning]) GetLength() sampling.Pos {
@@ -0,0 +1,127 @@
+package mixing
+
+import (
+	"math"
+	"math/rand"
+
+	"github.com/gotracker/playback/mixing/volume"
+)
+
+// NoiseShaping is the curve the quantization noise is shaped into by the dither
+type NoiseShaping uint8
+
+const (
+	// NoiseShapingNone leaves the quantization noise flat
+	NoiseShapingNone = NoiseShaping(iota)
+	// NoiseShapingSimple is a first-order filter that moves the noise towards the high frequencies
+	NoiseShapingSimple
+	// NoiseShapingLipshitz is Lipshitz's 5-tap minimally audible (E-weighted) curve
+	NoiseShapingLipshitz
+	// NoiseShapingFWeighted is Wannamaker's 9-tap F-weighted curve
+	NoiseShapingFWeighted
+)
+
+func (n NoiseShaping) String() string {
+	switch n {
+	case NoiseShapingNone:
+		return "none"
+	case NoiseShapingSimple:
+		return "simple"
+	case NoiseShapingLipshitz:
+		return "lipshitz"
+	case NoiseShapingFWeighted:
+		return "fweighted"
+	default:
+		return "unknown"
+	}
+}
+
+// the error feedback coefficients of the noise shaping curves, designed for 44.1kHz output
+var noiseShapingCoeffs = map[NoiseShaping][]float64{
+	NoiseShapingSimple:    {1},
+	NoiseShapingLipshitz:  {2.033, -2.165, 1.959, -1.590, 0.6149},
+	NoiseShapingFWeighted: {2.412, -3.370, 3.937, -4.174, 3.353, -2.205, 1.281, -0.569, 0.0847},
+}
+
+// DitherSettings are the parameters of the dither
+type DitherSettings struct {
+	TPDF         bool // add triangular probability density function dither noise of +/-1 LSB
+	NoiseShaping NoiseShaping
+	Seed         int64 // seed of the dither noise
+}
+
+type ditherChannelData struct {
+	err [9]float64 // the most recent quantization errors, newest first
+}
+
+// Dither quantizes the mix for integer output formats (8- and 16-bit), optionally adding TPDF
+// dither noise and shaping the quantization noise. The state of each output channel is kept
+// between renders, so a Dither should only be used with one stream.
+type Dither struct {
+	DitherSettings
+
+	coeffs   []float64
+	channels []ditherChannelData
+	rng      *rand.Rand
+}
+
+// NewDither creates a new Dither
+func NewDither(settings DitherSettings) *Dither {
+	return &Dither{
+		DitherSettings: settings,
+		coeffs:         noiseShapingCoeffs[settings.NoiseShaping],
+		rng:            rand.New(rand.NewSource(settings.Seed)),
+	}
+}
+
+// intSampleScale returns the scale volume.ToIntSample uses for the bits per sample provided,
+// for the sizes that are worth dithering
+func intSampleScale(bitsPerSample int) (float64, bool) {
+	switch bitsPerSample {
+	case 8:
+		return 128, true
+	case 16:
+		return 32678, true
+	default:
+		return 0, false
+	}
+}
+
+// Process applies the mixer volume to the mix, brings it to `channels` channels, then quantizes
+// it in place to the integer steps of an output multiplying samples by `scale`. The results are
+// set in the middle of their steps, so the output's truncation lands on the step chosen here.
+func (d *Dither) Process(data MixBuffer, channels int, mixerVolume volume.Volume, scale float64) {
+	for len(d.channels) < channels {
+		d.channels = append(d.channels, ditherChannelData{})
+	}
+
+	for i, samp := range data {
+		samp = samp.Apply(mixerVolume).ToChannels(channels)
+		samp.Channels = channels
+
+		for c := 0; c < channels; c++ {
+			cd := &d.channels[c]
+
+			x := max(min(float64(samp.StaticMatrix[c]), 1), -1) * scale
+			for j, k := range d.coeffs {
+				x -= k * cd.err[j]
+			}
+
+			q := x
+			if d.TPDF {
+				q += d.rng.Float64() - d.rng.Float64()
+			}
+			q = max(min(math.Round(q), scale-1), -scale)
+
+			copy(cd.err[1:], cd.err[:])
+			// limiting the error keeps the feedback from running away when the output clips
+			cd.err[0] = max(min(q-x, 1.5), -1.5)
+
+			if q != 0 {
+				q += math.Copysign(0.5, q)
+			}
+			samp.StaticMatrix[c] = volume.Volume(q / scale)
+		}
+		data[i] = samp
+	}
+}
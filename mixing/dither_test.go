@@ -0,0 +1,147 @@
+package mixing
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"testing"
+
+	"github.com/gotracker/playback/mixing/sampling"
+	"github.com/gotracker/playback/mixing/volume"
+)
+
+func monoBuffer(vals ...float64) MixBuffer {
+	buf := make(MixBuffer, len(vals))
+	for i, v := range vals {
+		buf[i] = volume.Matrix{StaticMatrix: volume.StaticMatrix{volume.Volume(v)}, Channels: 1}
+	}
+	return buf
+}
+
+func ditheredSteps(d *Dither, vals []float64, scale float64) []int {
+	buf := monoBuffer(vals...)
+	d.Process(buf, 1, 1, scale)
+	steps := make([]int, len(buf))
+	for i, s := range buf {
+		// truncate like the output formatting does
+		steps[i] = int(float64(s.StaticMatrix[0]) * scale)
+	}
+	return steps
+}
+
+func TestDitherRoundsWithoutNoise(t *testing.T) {
+	d := NewDither(DitherSettings{})
+	got := ditheredSteps(d, []float64{0.6 / 128, -0.6 / 128, 2.4 / 128, -2.4 / 128, 0, 2}, 128)
+	want := []int{1, -1, 2, -2, 0, 127}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("sample %d: got step %d want %d", i, got[i], want[i])
+		}
+	}
+}
+
+func TestDitherTPDFLinearizesQuantization(t *testing.T) {
+	d := NewDither(DitherSettings{TPDF: true, Seed: 1})
+	vals := make([]float64, 20000)
+	for i := range vals {
+		vals[i] = 0.3 / 32678
+	}
+
+	var sum float64
+	seen := map[int]bool{}
+	for _, s := range ditheredSteps(d, vals, 32678) {
+		sum += float64(s)
+		seen[s] = true
+	}
+	if mean := sum / float64(len(vals)); math.Abs(mean-0.3) > 0.03 {
+		t.Fatalf("expected the dithered output to average to the input, got %v", mean)
+	}
+	if len(seen) < 2 {
+		t.Fatalf("expected the dither to spread the output over several steps, got %v", seen)
+	}
+}
+
+func lowFrequencyNoise(shaping NoiseShaping) float64 {
+	d := NewDither(DitherSettings{TPDF: true, NoiseShaping: shaping, Seed: 2})
+	vals := make([]float64, 32768)
+	for i := range vals {
+		vals[i] = 0.25 * math.Sin(2*math.Pi*440*float64(i)/44100)
+	}
+	steps := ditheredSteps(d, vals, 32678)
+
+	// measure the error at a few low frequencies
+	var power float64
+	for _, freq := range []float64{100, 300, 700, 1000, 1500} {
+		var re, im float64
+		for i := range vals {
+			e := float64(steps[i]) - vals[i]*32678
+			w := 2 * math.Pi * freq * float64(i) / 44100
+			re += e * math.Cos(w)
+			im += e * math.Sin(w)
+		}
+		power += re*re + im*im
+	}
+	return power
+}
+
+func TestDitherNoiseShapingMovesNoiseUp(t *testing.T) {
+	flat := lowFrequencyNoise(NoiseShapingNone)
+	for _, shaping := range []NoiseShaping{NoiseShapingSimple, NoiseShapingLipshitz, NoiseShapingFWeighted} {
+		if shaped := lowFrequencyNoise(shaping); shaped >= flat/4 {
+			t.Fatalf("%v: expected less low frequency noise than flat dither: shaped=%v flat=%v", shaping, shaped, flat)
+		}
+	}
+}
+
+func TestDitherKeepsStateAcrossRenders(t *testing.T) {
+	vals := make([]float64, 64)
+	for i := range vals {
+		vals[i] = 0.1 * math.Sin(float64(i))
+	}
+	settings := DitherSettings{TPDF: true, NoiseShaping: NoiseShapingLipshitz, Seed: 3}
+
+	whole := ditheredSteps(NewDither(settings), vals, 32678)
+
+	d := NewDither(settings)
+	split := append(ditheredSteps(d, vals[:20], 32678), ditheredSteps(d, vals[20:], 32678)...)
+
+	for i := range whole {
+		if whole[i] != split[i] {
+			t.Fatalf("sample %d: expected the same result across renders, got %d want %d", i, split[i], whole[i])
+		}
+	}
+}
+
+func TestMixerFlattenUsesDither(t *testing.T) {
+	row := []ChannelData{
+		{
+			{
+				Data:      monoBuffer(0.6/32678, -2.6/32678),
+				PanMatrix: volume.Matrix{StaticMatrix: volume.StaticMatrix{1}, Channels: 1},
+				Volume:    1,
+			},
+		},
+	}
+
+	m := Mixer{Channels: 1, Dither: NewDither(DitherSettings{})}
+	out := m.Flatten(2, row, 1, sampling.Format16BitLESigned)
+
+	var got [2]int16
+	if err := binary.Read(bytes.NewReader(out), binary.LittleEndian, &got); err != nil {
+		t.Fatal(err)
+	}
+	if got != [2]int16{1, -3} {
+		t.Fatalf("expected the samples to be rounded by the dither, got %v", got)
+	}
+
+	// floating-point output isn't quantized
+	m.Dither = NewDither(DitherSettings{TPDF: true})
+	out = m.Flatten(2, row, 1, sampling.Format32BitLEFloat)
+	var f [2]float32
+	if err := binary.Read(bytes.NewReader(out), binary.LittleEndian, &f); err != nil {
+		t.Fatal(err)
+	}
+	if f[0] != float32(volume.Volume(0.6/32678)) {
+		t.Fatalf("expected float output to be left alone, got %v", f[0])
+	}
+}
@@ -0,0 +1,246 @@
+package mixing
+
+import (
+	"math"
+
+	"github.com/gotracker/playback/frequency"
+	"github.com/gotracker/playback/mixing/volume"
+)
+
+// FinalStageMode is the method the final stage uses to keep the mix within the output range
+type FinalStageMode uint8
+
+const (
+	// FinalStageHardClip clips anything above the ceiling
+	FinalStageHardClip = FinalStageMode(iota)
+	// FinalStageSoftClip saturates the mix smoothly into the ceiling with a tanh curve
+	FinalStageSoftClip
+	// FinalStageLimiter runs the mix through a true-peak look-ahead limiter
+	FinalStageLimiter
+)
+
+func (f FinalStageMode) String() string {
+	switch f {
+	case FinalStageHardClip:
+		return "hardclip"
+	case FinalStageSoftClip:
+		return "softclip"
+	case FinalStageLimiter:
+		return "limiter"
+	default:
+		return "unknown"
+	}
+}
+
+const (
+	finalStageDefaultReleaseMs   = 50
+	finalStageDefaultLookAheadMs = 5
+)
+
+// FinalStageSettings are the parameters of the final stage
+type FinalStageSettings struct {
+	Mode      FinalStageMode
+	Ceiling   float64 // in dBFS; values above 0 are treated as 0
+	Release   float64 // limiter release time in milliseconds; 0 uses 50ms
+	LookAhead float64 // limiter look-ahead time in milliseconds; 0 uses 5ms
+}
+
+// FinalStageStats are the statistics of a single render through the final stage
+type FinalStageStats struct {
+	Samples          int           // sample frames processed
+	ClippedSamples   int           // frames with at least one channel clipped (or saturated) at the ceiling
+	LimitedSamples   int           // frames the limiter reduced the gain of
+	Peak             volume.Volume // highest absolute level going into the final stage
+	MaxGainReduction float64       // highest gain reduction applied by the limiter, in dB
+}
+
+// FinalStage is the last stage of the mix bus. The mix is kept in floating point, with all its
+// headroom, up to this point; the final stage is what brings it into the range of the output.
+// The limiter keeps state between renders, so a FinalStage should only be used with one stream.
+type FinalStage struct {
+	FinalStageSettings
+
+	ceiling float64
+	limiter finalStageLimiter
+	stats   FinalStageStats
+}
+
+// NewFinalStage creates a new FinalStage for output at `sampleRate`
+func NewFinalStage(sampleRate frequency.Frequency, settings FinalStageSettings) *FinalStage {
+	f := FinalStage{
+		FinalStageSettings: settings,
+		ceiling:            math.Pow(10, min(settings.Ceiling, 0)/20),
+	}
+
+	release := settings.Release
+	if release <= 0 {
+		release = finalStageDefaultReleaseMs
+	}
+	lookAhead := settings.LookAhead
+	if lookAhead <= 0 {
+		lookAhead = finalStageDefaultLookAheadMs
+	}
+	f.limiter.setup(float64(sampleRate), lookAhead, release)
+	return &f
+}
+
+// Stats returns the statistics of the most recent render
+func (f *FinalStage) Stats() FinalStageStats {
+	return f.stats
+}
+
+// Process applies the mixer volume to the mix, brings it to `channels` channels, then runs it
+// through the final stage in place
+func (f *FinalStage) Process(data MixBuffer, channels int, mixerVolume volume.Volume) {
+	f.stats = FinalStageStats{
+		Samples: len(data),
+	}
+
+	for i, samp := range data {
+		samp = samp.Apply(mixerVolume).ToChannels(channels)
+		samp.Channels = channels
+
+		for c := 0; c < channels; c++ {
+			f.stats.Peak = max(f.stats.Peak, volume.Volume(math.Abs(float64(samp.StaticMatrix[c]))))
+		}
+
+		switch f.Mode {
+		case FinalStageSoftClip:
+			samp = f.softClip(samp)
+		case FinalStageLimiter:
+			samp = f.limit(samp)
+		default:
+			samp = f.hardClip(samp)
+		}
+		data[i] = samp
+	}
+}
+
+func (f *FinalStage) hardClip(samp volume.Matrix) volume.Matrix {
+	clipped := false
+	for c := 0; c < samp.Channels; c++ {
+		v := float64(samp.StaticMatrix[c])
+		if math.Abs(v) > f.ceiling {
+			samp.StaticMatrix[c] = volume.Volume(math.Copysign(f.ceiling, v))
+			clipped = true
+		}
+	}
+	if clipped {
+		f.stats.ClippedSamples++
+	}
+	return samp
+}
+
+func (f *FinalStage) softClip(samp volume.Matrix) volume.Matrix {
+	clipped := false
+	for c := 0; c < samp.Channels; c++ {
+		v := float64(samp.StaticMatrix[c])
+		if math.Abs(v) > f.ceiling {
+			clipped = true
+		}
+		samp.StaticMatrix[c] = volume.Volume(math.Tanh(v/f.ceiling) * f.ceiling)
+	}
+	if clipped {
+		f.stats.ClippedSamples++
+	}
+	return samp
+}
+
+func (f *FinalStage) limit(samp volume.Matrix) volume.Matrix {
+	out, gain := f.limiter.process(samp, f.ceiling)
+	if gain < 1 {
+		f.stats.LimitedSamples++
+		f.stats.MaxGainReduction = max(f.stats.MaxGainReduction, -20*math.Log10(gain))
+	}
+	// the limiter's gain curve can trail a very sudden peak slightly, so catch what's left
+	return f.hardClip(out)
+}
+
+type finalStageGainReq struct {
+	idx  int
+	gain float64
+}
+
+// finalStageLimiter is a true-peak look-ahead limiter: the mix is delayed by the look-ahead
+// time so that the gain can already be on its way down when a peak arrives. The peaks between
+// samples are estimated by 4x oversampling, and the gain is linked across all channels.
+type finalStageLimiter struct {
+	lookAhead int
+	attack    float64
+	release   float64
+	gain      float64
+
+	delay []volume.Matrix
+	pos   int
+
+	// a sliding window minimum of the gains required over the look-ahead time
+	reqs []finalStageGainReq
+	idx  int
+
+	hist [4]volume.StaticMatrix
+}
+
+func (l *finalStageLimiter) setup(rate, lookAheadMs, releaseMs float64) {
+	l.lookAhead = max(int(rate*lookAheadMs/1000), 1)
+	l.attack = 1 - math.Exp(-4/float64(l.lookAhead))
+	l.release = 1 - math.Exp(-1/(rate*releaseMs/1000))
+	l.gain = 1
+	l.delay = make([]volume.Matrix, l.lookAhead)
+}
+
+// catmullRom interpolates between p1 and p2 at `t`
+func catmullRom(p0, p1, p2, p3, t float64) float64 {
+	return p1 + 0.5*t*(p2-p0+t*(2*p0-5*p1+4*p2-p3+t*(3*(p1-p2)+p3-p0)))
+}
+
+// truePeak returns the highest absolute level of the newest sample and the points between the
+// two before it
+func (l *finalStageLimiter) truePeak(channels int) float64 {
+	var peak float64
+	for c := 0; c < channels; c++ {
+		p0 := float64(l.hist[0][c])
+		p1 := float64(l.hist[1][c])
+		p2 := float64(l.hist[2][c])
+		p3 := float64(l.hist[3][c])
+		peak = max(peak, math.Abs(p3))
+		for _, t := range [...]float64{0.25, 0.5, 0.75} {
+			peak = max(peak, math.Abs(catmullRom(p0, p1, p2, p3, t)))
+		}
+	}
+	return peak
+}
+
+func (l *finalStageLimiter) process(in volume.Matrix, ceiling float64) (volume.Matrix, float64) {
+	copy(l.hist[:], l.hist[1:])
+	l.hist[3] = in.StaticMatrix
+
+	req := 1.0
+	if peak := l.truePeak(in.Channels); peak > ceiling {
+		req = ceiling / peak
+	}
+
+	for len(l.reqs) > 0 && l.reqs[len(l.reqs)-1].gain >= req {
+		l.reqs = l.reqs[:len(l.reqs)-1]
+	}
+	l.reqs = append(l.reqs, finalStageGainReq{idx: l.idx, gain: req})
+	for l.reqs[0].idx <= l.idx-l.lookAhead-1 {
+		l.reqs = l.reqs[1:]
+	}
+	l.idx++
+
+	if target := l.reqs[0].gain; target < l.gain {
+		l.gain += (target - l.gain) * l.attack
+	} else {
+		l.gain += (target - l.gain) * l.release
+		if l.gain > 1-1e-6 {
+			l.gain = 1
+		}
+	}
+
+	out := l.delay[l.pos]
+	l.delay[l.pos] = in
+	l.pos = (l.pos + 1) % len(l.delay)
+
+	out.Channels = in.Channels
+	return out.Apply(volume.Volume(l.gain)), l.gain
+}
@@ -0,0 +1,140 @@
+package mixing
+
+import (
+	"math"
+	"testing"
+
+	"github.com/gotracker/playback/frequency"
+	"github.com/gotracker/playback/mixing/volume"
+)
+
+func stereoBuffer(vals ...volume.Volume) MixBuffer {
+	buf := make(MixBuffer, len(vals))
+	for i, v := range vals {
+		buf[i] = volume.Matrix{StaticMatrix: volume.StaticMatrix{v, -v / 2}, Channels: 2}
+	}
+	return buf
+}
+
+func TestFinalStageHardClip(t *testing.T) {
+	f := NewFinalStage(frequency.Frequency(44100), FinalStageSettings{Mode: FinalStageHardClip, Ceiling: -6})
+	buf := stereoBuffer(0.25, 2, -3)
+
+	f.Process(buf, 2, 0.5)
+
+	ceiling := volume.Volume(math.Pow(10, -6.0/20))
+	if buf[0].StaticMatrix[0] != 0.125 {
+		t.Fatalf("expected quiet samples to pass, got %v", buf[0].StaticMatrix[0])
+	}
+	if buf[1].StaticMatrix[0] != ceiling || buf[2].StaticMatrix[0] != -ceiling {
+		t.Fatalf("expected loud samples to be clipped to the ceiling, got %v %v", buf[1].StaticMatrix[0], buf[2].StaticMatrix[0])
+	}
+
+	stats := f.Stats()
+	if stats.Samples != 3 || stats.ClippedSamples != 2 || stats.Peak != 1.5 {
+		t.Fatalf("unexpected stats: %+v", stats)
+	}
+}
+
+func TestFinalStageSoftClip(t *testing.T) {
+	f := NewFinalStage(frequency.Frequency(44100), FinalStageSettings{Mode: FinalStageSoftClip})
+	buf := stereoBuffer(0.1, 4)
+
+	f.Process(buf, 2, 1)
+
+	if got := float64(buf[0].StaticMatrix[0]); math.Abs(got-math.Tanh(0.1)) > 1e-6 {
+		t.Fatalf("expected tanh saturation, got %v", got)
+	}
+	if got := buf[1].StaticMatrix[0]; got >= 1 || got < 0.99 {
+		t.Fatalf("expected loud samples to saturate just under the ceiling, got %v", got)
+	}
+	if f.Stats().ClippedSamples != 1 {
+		t.Fatalf("expected one saturated sample, got %+v", f.Stats())
+	}
+}
+
+func TestFinalStageLimiterHoldsCeilingWithLookAhead(t *testing.T) {
+	rate := frequency.Frequency(1000)
+	f := NewFinalStage(rate, FinalStageSettings{Mode: FinalStageLimiter, Ceiling: -1, LookAhead: 10, Release: 20})
+	ceiling := math.Pow(10, -1.0/20)
+
+	vals := make([]volume.Volume, 200)
+	for i := range vals {
+		vals[i] = 0.5
+	}
+	for i := 50; i < 60; i++ {
+		vals[i] = 2
+	}
+	buf := stereoBuffer(vals...)
+	f.Process(buf, 2, 1)
+
+	// the output is delayed by the look-ahead time
+	for i := 0; i < 10; i++ {
+		if buf[i].StaticMatrix[0] != 0 {
+			t.Fatalf("expected the look-ahead delay to start silent, got %v at %d", buf[i].StaticMatrix[0], i)
+		}
+	}
+	for i, s := range buf {
+		if math.Abs(float64(s.StaticMatrix[0])) > ceiling+1e-9 {
+			t.Fatalf("expected the output to stay under the ceiling, got %v at %d", s.StaticMatrix[0], i)
+		}
+		if !almostEqual(float64(s.StaticMatrix[1]), -float64(s.StaticMatrix[0])/2, 1e-6) {
+			t.Fatalf("expected the gain to be linked across channels at %d: %v", i, s.StaticMatrix)
+		}
+	}
+	if got := buf[199].StaticMatrix[0]; !almostEqual(float64(got), 0.5, 5e-3) {
+		t.Fatalf("expected the gain to recover after the peak, got %v", got)
+	}
+
+	stats := f.Stats()
+	if stats.LimitedSamples == 0 || stats.MaxGainReduction < 6 {
+		t.Fatalf("expected the limiter to reduce the gain by at least 6dB, got %+v", stats)
+	}
+	if stats.ClippedSamples > 2 {
+		t.Fatalf("expected the look-ahead to leave little for the safety clip, got %+v", stats)
+	}
+
+	f.Process(stereoBuffer(0.1, 0.1), 2, 1)
+	if latest := f.Stats(); latest.Samples != 2 || latest.MaxGainReduction >= 0.1 {
+		t.Fatalf("expected stats to cover the latest render only, got %+v", latest)
+	}
+}
+
+func TestFinalStageLimiterCatchesIntersamplePeaks(t *testing.T) {
+	f := NewFinalStage(frequency.Frequency(44100), FinalStageSettings{Mode: FinalStageLimiter})
+
+	// a tone at a quarter of the sample rate, sampled off its peaks, has inter-sample peaks
+	// about 3dB above its samples, so they go over the ceiling while the samples don't
+	vals := make([]volume.Volume, 2000)
+	for i := range vals {
+		vals[i] = volume.Volume(1.3 * math.Sin(math.Pi/2*float64(i)+math.Pi/4))
+	}
+	f.Process(stereoBuffer(vals...), 2, 1)
+
+	if f.Stats().Peak >= 1 || f.Stats().MaxGainReduction < 1 {
+		t.Fatalf("expected the inter-sample peaks to be limited, got %+v", f.Stats())
+	}
+}
+
+func TestMixerFlattenUsesFinalStage(t *testing.T) {
+	row := []ChannelData{
+		{
+			{
+				Data:      stereoBuffer(1.6),
+				PanMatrix: volume.Matrix{StaticMatrix: volume.StaticMatrix{1, 1}, Channels: 2},
+				Volume:    1,
+			},
+		},
+	}
+
+	m := Mixer{Channels: 2, FinalStage: NewFinalStage(frequency.Frequency(44100), FinalStageSettings{Mode: FinalStageHardClip, Ceiling: -6})}
+	out := m.FlattenToInts(2, 1, 16, row, 0.5)
+
+	want := volume.Volume(math.Pow(10, -6.0/20)).ToIntSample(16)
+	if out[0][0] != want || out[1][0] != volume.Volume(-0.4).ToIntSample(16) {
+		t.Fatalf("unexpected output: %v (want %d)", out, want)
+	}
+	if m.FinalStage.Stats().ClippedSamples != 1 {
+		t.Fatalf("expected the final stage to report the clip, got %+v", m.FinalStage.Stats())
+	}
+}
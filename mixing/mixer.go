@@ -8,6 +8,12 @@ import (
 // Mixer is a manager for mixing multiple single- and multi-channel samples into a single multi-channel output stream
 type Mixer struct {
 	Channels int
+	// FinalStage brings the mix into the output range; nil leaves it to the output formatting,
+	// which clips
+	FinalStage *FinalStage
+	// Dither quantizes the mix for 8- and 16-bit output; nil leaves it to the output formatting,
+	// which truncates
+	Dither *Dither
 }
 
 // NewMixBuffer returns a mixer buffer with a number of channels
@@ -22,6 +28,26 @@ func GetDefaultMixerVolume(numMixedChannels int) volume.Volume {
 	return 1.0 / volume.Volume(numMixedChannels)
 }
 
+// finalize runs the mix through the final stage, if there is one, and returns the mixer volume
+// that's still to be applied to it
+func (m Mixer) finalize(data MixBuffer, channels int, mixerVolume volume.Volume) volume.Volume {
+	if m.FinalStage == nil {
+		return mixerVolume
+	}
+	m.FinalStage.Process(data, channels, mixerVolume)
+	return 1
+}
+
+// dither quantizes the mix through the dither, if there is one, and returns the mixer volume
+// that's still to be applied to it
+func (m Mixer) dither(data MixBuffer, channels int, mixerVolume volume.Volume, scale float64) volume.Volume {
+	if m.Dither == nil {
+		return mixerVolume
+	}
+	m.Dither.Process(data, channels, mixerVolume, scale)
+	return 1
+}
+
 // Flatten will to a final saturation mix of all the row's channel data into a single output buffer
 func (m Mixer) Flatten(samplesLen int, row []ChannelData, mixerVolume volume.Volume, sampleFormat sampling.Format) []byte {
 	data := m.NewMixBuffer(samplesLen)
@@ -37,6 +63,10 @@ func (m Mixer) Flatten(samplesLen int, row []ChannelData, mixerVolume volume.Vol
 			}
 		}
 	}
+	mixerVolume = m.finalize(data, m.Channels, mixerVolume)
+	if q, ok := formatter.(sampling.Quantizer); ok {
+		mixerVolume = m.dither(data, m.Channels, mixerVolume, q.QuantizationScale())
+	}
 	return data.ToRenderData(samplesLen, m.Channels, mixerVolume, formatter)
 }
 
@@ -55,6 +85,10 @@ func (m Mixer) FlattenToInts(channels, samplesLen, bitsPerSample int, row []Chan
 			}
 		}
 	}
+	mixerVolume = m.finalize(data, channels, mixerVolume)
+	if scale, ok := intSampleScale(bitsPerSample); ok {
+		mixerVolume = m.dither(data, channels, mixerVolume, scale)
+	}
 	return data.ToIntStream(channels, samplesLen, bitsPerSample, mixerVolume)
 }
 
@@ -73,5 +107,9 @@ func (m Mixer) FlattenTo(resultBuffers [][]byte, channels, samplesLen int, row [
 			}
 		}
 	}
+	mixerVolume = m.finalize(data, channels, mixerVolume)
+	if q, ok := formatter.(sampling.Quantizer); ok {
+		mixerVolume = m.dither(data, channels, mixerVolume, q.QuantizationScale())
+	}
 	data.ToRenderDataWithBufs(resultBuffers, samplesLen, mixerVolume, formatter)
 }
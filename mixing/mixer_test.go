@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"testing"
 
+	"github.com/gotracker/playback/mixing/panning"
 	"github.com/gotracker/playback/mixing/sampling"
 	"github.com/gotracker/playback/mixing/volume"
 )
@@ -132,3 +133,56 @@ func int32ToBytes(in []int32) []byte {
 	}
 	return buf.Bytes()
 }
+
+func TestMixerFlattenTo24BitPacked(t *testing.T) {
+	pan := &fakePanMixer{matrix: volume.Matrix{StaticMatrix: volume.StaticMatrix{1, 1}, Channels: 2}}
+	row := []ChannelData{
+		{
+			{
+				Data: MixBuffer{
+					{StaticMatrix: volume.StaticMatrix{0.5, -0.5}, Channels: 2},
+				},
+				PanMatrix: pan,
+				Volume:    1,
+			},
+		},
+	}
+
+	m := Mixer{Channels: 2}
+	out := m.Flatten(1, row, 1, sampling.Format24BitLESigned)
+
+	expected := []byte{0x00, 0x00, 0x40, 0x00, 0x00, 0xC0}
+	if !bytes.Equal(out, expected) {
+		t.Fatalf("unexpected mixed data: got % X want % X", out, expected)
+	}
+}
+
+func TestMixerFlattenSurround(t *testing.T) {
+	row := []ChannelData{
+		{
+			{
+				Data: MixBuffer{
+					{StaticMatrix: volume.StaticMatrix{0.5}, Channels: 1},
+				},
+				PanMatrix: GetPanMixer(6).GetMixingMatrix(panning.CenterAhead, 1),
+				Volume:    1,
+			},
+		},
+	}
+
+	m := Mixer{Channels: 6}
+	out := m.Flatten(1, row, 1, sampling.Format16BitLESigned)
+	if len(out) != 12 {
+		t.Fatalf("expected 6 channels of 16-bit data, got %d bytes", len(out))
+	}
+	for c := 0; c < 6; c++ {
+		v := int16(out[c*2]) | int16(out[c*2+1])<<8
+		if c == volume.SurroundCenter {
+			if v < 16000 {
+				t.Fatalf("expected the center channel to carry the sound, got %d", v)
+			}
+		} else if v != 0 {
+			t.Fatalf("expected channel %d to be silent, got %d", c, v)
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package mixing
 
 import (
+	"github.com/gotracker/playback/frequency"
 	"github.com/gotracker/playback/mixing/panning"
 )
 
@@ -10,6 +11,21 @@ type PanMixer interface {
 	NumChannels() int
 }
 
+// VoicePanMixer is a PanMixer that pans each voice through state of its own, kept from one tick
+// to the next, rather than by applying a matrix of gains to it. Voices rendered without a
+// VoicePanner fall back to the matrix from GetMixingMatrix.
+type VoicePanMixer interface {
+	PanMixer
+	NewVoicePanner() VoicePanner
+}
+
+// VoicePanner pans a single voice
+type VoicePanner interface {
+	// PanBuffer pans the voice's buffer for a tick, which has been mixed at the center-ahead
+	// position, to `pan` in place
+	PanBuffer(buf MixBuffer, pan panning.Position, stereoSeparation float32, sampleRate frequency.Frequency)
+}
+
 // GetPanMixer returns the panning mixer that can generate a matrix
 // based on input pan value
 func GetPanMixer(channels int) PanMixer {
@@ -20,6 +36,10 @@ func GetPanMixer(channels int) PanMixer {
 		return PanMixerStereo
 	case 4:
 		return PanMixerQuad
+	case 6:
+		return PanMixer51
+	case 8:
+		return PanMixer71
 	}
 
 	return nil
@@ -0,0 +1,165 @@
+package mixing
+
+import (
+	"math"
+
+	"github.com/gotracker/playback/frequency"
+	"github.com/gotracker/playback/mixing/panning"
+	"github.com/gotracker/playback/mixing/volume"
+)
+
+const (
+	binauralDefaultWidth      = 30     // degrees
+	binauralDefaultHeadRadius = 0.0875 // meters
+	binauralSpeedOfSound      = 343.0  // meters per second
+	binauralHistory           = 256    // must be a power of 2 and longer than the largest interaural delay
+
+	// head shadow parameters of the spherical head model
+	binauralAlphaMin = 0.1
+	binauralThetaMin = 150 * math.Pi / 180
+)
+
+// BinauralSettings are the parameters of the binaural pan mixer
+type BinauralSettings struct {
+	Width      float32 // how far either side of center the stereo field reaches, in degrees; 0 uses 30
+	HeadRadius float32 // in meters; 0 uses 8.75cm
+}
+
+// PanMixerBinaural is a mixer that's specialized for rendering stereo audio content for
+// headphones, placing each voice around the listener's head
+var PanMixerBinaural PanMixer = NewBinauralPanMixer(BinauralSettings{})
+
+type panMixerBinaural struct {
+	BinauralSettings
+	front  float64
+	radius float64
+}
+
+// NewBinauralPanMixer returns a binaural panning mixer using the settings provided. Each voice
+// is rendered through a parametric spherical head model (Brown and Duda, 1998): the interaural
+// time difference follows Woodworth's formula and each ear gets the one-pole, one-zero head
+// shadow filter for its angle to the voice. Stereo samples are rendered as a point source.
+func NewBinauralPanMixer(settings BinauralSettings) PanMixer {
+	p := panMixerBinaural{
+		BinauralSettings: settings,
+		front:            deg(binauralDefaultWidth),
+		radius:           binauralDefaultHeadRadius,
+	}
+	if settings.Width > 0 {
+		p.front = deg(float64(min(settings.Width, 90)))
+	}
+	if settings.HeadRadius > 0 {
+		p.radius = float64(settings.HeadRadius)
+	}
+	return &p
+}
+
+// GetMixingMatrix returns the stereo matrix for the position, for anything that isn't rendered
+// through a voice panner
+func (p panMixerBinaural) GetMixingMatrix(pan panning.Position, stereoSeparation float32) panning.PanMixer {
+	return PanMixerStereo.GetMixingMatrix(pan, stereoSeparation)
+}
+
+func (p panMixerBinaural) NumChannels() int {
+	return 2
+}
+
+func (p *panMixerBinaural) NewVoicePanner() VoicePanner {
+	return &binauralVoicePanner{
+		mixer: p,
+	}
+}
+
+type binauralEar struct {
+	delay  float64 // in samples
+	x1, y1 float64
+}
+
+type binauralVoicePanner struct {
+	mixer   *panMixerBinaural
+	started bool
+	hist    [binauralHistory]float64
+	pos     int
+	ears    [2]binauralEar // left, right
+}
+
+// headShadowAlpha returns the high-frequency gain of the head shadow filter for an ear at
+// `theta` radians from the source
+func headShadowAlpha(theta float64) float64 {
+	return (1 + binauralAlphaMin/2) + (1-binauralAlphaMin/2)*math.Cos(theta/binauralThetaMin*math.Pi)
+}
+
+func (b *binauralVoicePanner) PanBuffer(buf MixBuffer, pan panning.Position, stereoSeparation float32, sampleRate frequency.Frequency) {
+	if len(buf) == 0 || sampleRate <= 0 {
+		return
+	}
+
+	rate := float64(sampleRate)
+	azimuth := narrowAzimuth(panAzimuth(float64(pan.Angle), b.mixer.front), stereoSeparation)
+
+	var d volume.Volume
+	if pan.Distance > 0 {
+		d = 1 / volume.Volume(pan.Distance*pan.Distance)
+	}
+
+	// the ear farther from the source hears it later, per Woodworth's formula
+	lateral := math.Asin(math.Sin(azimuth))
+	itd := b.mixer.radius / binauralSpeedOfSound * (math.Abs(lateral) + math.Sin(math.Abs(lateral))) * rate
+	itd = min(itd, binauralHistory-2)
+
+	var targets [2]float64
+	if lateral < 0 {
+		targets[0] = itd
+	} else {
+		targets[1] = itd
+	}
+
+	// the head shadow filters, bilinear transformed from H(s) = (alpha*s + beta) / (s + beta)
+	beta := 2 * binauralSpeedOfSound / b.mixer.radius
+	k := 2 * rate
+	var filters [2]struct{ b0, b1, a1 float64 }
+	for e, earAzimuth := range [2]float64{math.Pi / 2, -math.Pi / 2} {
+		theta := math.Abs(math.Remainder(azimuth-earAzimuth, 2*math.Pi))
+		alpha := headShadowAlpha(theta)
+		filters[e].b0 = (alpha*k + beta) / (k + beta)
+		filters[e].b1 = (beta - alpha*k) / (k + beta)
+		filters[e].a1 = (beta - k) / (k + beta)
+	}
+
+	var from [2]float64
+	for e := range b.ears {
+		if !b.started {
+			b.ears[e].delay = targets[e]
+		}
+		from[e] = b.ears[e].delay
+	}
+	b.started = true
+
+	n := float64(len(buf))
+	for i, samp := range buf {
+		in := samp.ToChannels(2)
+		b.hist[b.pos] = float64(in.StaticMatrix[0]+in.StaticMatrix[1]) * volume.StereoCoeff
+
+		var out volume.Matrix
+		out.Channels = 2
+		for e := range b.ears {
+			ear := &b.ears[e]
+			// glide the delay over the tick so changes of position don't click
+			ear.delay = from[e] + (targets[e]-from[e])*float64(i+1)/n
+
+			di := int(ear.delay)
+			frac := ear.delay - float64(di)
+			x0 := b.hist[(b.pos-di)&(binauralHistory-1)]
+			x1 := b.hist[(b.pos-di-1)&(binauralHistory-1)]
+			x := x0 + (x1-x0)*frac
+
+			f := &filters[e]
+			y := f.b0*x + f.b1*ear.x1 - f.a1*ear.y1
+			ear.x1, ear.y1 = x, y
+			out.StaticMatrix[e] = volume.Volume(y) * volume.StereoCoeff * d
+		}
+
+		b.pos = (b.pos + 1) & (binauralHistory - 1)
+		buf[i] = out
+	}
+}
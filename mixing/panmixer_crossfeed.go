@@ -0,0 +1,98 @@
+package mixing
+
+import (
+	"math"
+
+	"github.com/gotracker/playback/frequency"
+	"github.com/gotracker/playback/mixing/panning"
+	"github.com/gotracker/playback/mixing/volume"
+)
+
+const (
+	crossfeedDefaultCutoff = 700 // Hz
+	crossfeedDefaultFeed   = 4.5 // dB
+)
+
+// CrossfeedSettings are the parameters of the crossfeed pan mixer
+type CrossfeedSettings struct {
+	Cutoff float32 // cutoff of the crossfed low frequencies, in Hz; 0 uses 700Hz
+	Feed   float32 // how much louder the lows are in the near ear than the far ear, in dB; 0 uses 4.5dB
+}
+
+// PanMixerCrossfeed is a mixer that's specialized for mixing stereo audio content for
+// headphones, with a Bauer-style crossfeed
+var PanMixerCrossfeed PanMixer = NewCrossfeedPanMixer(CrossfeedSettings{})
+
+type panMixerCrossfeed struct {
+	CrossfeedSettings
+	cutoff float64
+	gain   float64
+}
+
+// NewCrossfeedPanMixer returns a crossfeed panning mixer using the settings provided. Voices
+// are panned as they are in stereo, then the low frequencies of each side are fed into the
+// other, the way they would reach both ears from a pair of speakers, so hard-panned voices
+// don't sound like they're inside one ear. A sound in the center is left unchanged.
+func NewCrossfeedPanMixer(settings CrossfeedSettings) PanMixer {
+	p := panMixerCrossfeed{
+		CrossfeedSettings: settings,
+		cutoff:            crossfeedDefaultCutoff,
+	}
+	if settings.Cutoff > 0 {
+		p.cutoff = float64(settings.Cutoff)
+	}
+	feed := float64(crossfeedDefaultFeed)
+	if settings.Feed > 0 {
+		feed = float64(settings.Feed)
+	}
+	// the near and far ears share the lows between them, so a mono signal comes out flat
+	p.gain = 1 / (1 + math.Pow(10, feed/20))
+	return &p
+}
+
+// GetMixingMatrix returns the stereo matrix for the position, for anything that isn't rendered
+// through a voice panner
+func (p panMixerCrossfeed) GetMixingMatrix(pan panning.Position, stereoSeparation float32) panning.PanMixer {
+	return PanMixerStereo.GetMixingMatrix(pan, stereoSeparation)
+}
+
+func (p panMixerCrossfeed) NumChannels() int {
+	return 2
+}
+
+func (p *panMixerCrossfeed) NewVoicePanner() VoicePanner {
+	return &crossfeedVoicePanner{
+		mixer: p,
+	}
+}
+
+type crossfeedVoicePanner struct {
+	mixer *panMixerCrossfeed
+	lp    [2]float64
+}
+
+func (c *crossfeedVoicePanner) PanBuffer(buf MixBuffer, pan panning.Position, stereoSeparation float32, sampleRate frequency.Frequency) {
+	if sampleRate <= 0 {
+		return
+	}
+
+	pm := PanMixerStereo.GetMixingMatrix(pan, stereoSeparation)
+	k := 1 - math.Exp(-2*math.Pi*c.mixer.cutoff/float64(sampleRate))
+	g := c.mixer.gain
+
+	for i, samp := range buf {
+		in := pm.ApplyToMatrix(samp.ToChannels(2))
+		l := float64(in.StaticMatrix[0])
+		r := float64(in.StaticMatrix[1])
+		c.lp[0] += k * (l - c.lp[0])
+		c.lp[1] += k * (r - c.lp[1])
+
+		buf[i] = volume.Matrix{
+			StaticMatrix: volume.StaticMatrix{
+				volume.Volume(l + g*(c.lp[1]-c.lp[0])),
+				volume.Volume(r + g*(c.lp[0]-c.lp[1])),
+			},
+			Channels: 2,
+		}
+	}
+}
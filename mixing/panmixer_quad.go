@@ -13,6 +13,14 @@ var PanMixerQuad PanMixer = &panMixerQuad{}
 type panMixerQuad struct{}
 
 func (p panMixerQuad) GetMixingMatrix(pan panning.Position, stereoSeparation float32) panning.PanMixer {
+	if pan == panning.SurroundPosition {
+		// surround is routed equally to the rear speakers
+		return volume.Matrix{
+			StaticMatrix: volume.StaticMatrix{0, 0, surroundScale, surroundScale},
+			Channels:     4,
+		}
+	}
+
 	pangle := float64(pan.Angle)
 	sf, cf := math.Sincos(pangle)
 	sr, cr := math.Sin(pangle+math.Pi/2.0), math.Cos(pangle-math.Pi/2.0)
@@ -0,0 +1,209 @@
+package mixing
+
+import (
+	"math"
+
+	"github.com/gotracker/playback/mixing/panning"
+	"github.com/gotracker/playback/mixing/volume"
+)
+
+// CenterPolicy selects how sounds panned to the front of a surround layout use the center speaker
+type CenterPolicy uint8
+
+const (
+	// CenterSpeaker pans across the center speaker like any other speaker
+	CenterSpeaker = CenterPolicy(iota)
+	// CenterPhantom leaves the center speaker silent, so the center image comes from the front
+	// left and right speakers, like it does in stereo
+	CenterPhantom
+	// CenterSpread splits the front image evenly (in power) between the center speaker and the
+	// phantom image of the front left and right speakers
+	CenterSpread
+)
+
+func (c CenterPolicy) String() string {
+	switch c {
+	case CenterSpeaker:
+		return "speaker"
+	case CenterPhantom:
+		return "phantom"
+	case CenterSpread:
+		return "spread"
+	default:
+		return "unknown"
+	}
+}
+
+// DefaultLFECrossover is the LFE crossover frequency used when none is set, in Hz
+const DefaultLFECrossover = 120
+
+// SurroundSettings are the parameters of the 5.1 and 7.1 pan mixers
+type SurroundSettings struct {
+	Center       CenterPolicy
+	LFESend      float32 // linear level of the send to the LFE channel; 0 disables it
+	LFECrossover float32 // cutoff of the low-pass filter on the LFE channel, in Hz; 0 uses DefaultLFECrossover
+}
+
+// surroundSpeaker is a speaker of a surround layout, placed at an azimuth in radians, with
+// positive values to the left of the listener
+type surroundSpeaker struct {
+	channel int
+	azimuth float64
+}
+
+func deg(d float64) float64 {
+	return d * math.Pi / 180
+}
+
+// speaker placements follow ITU-R BS.775 for 5.1 and ITU-R BS.2051 (System I) for 7.1.
+// They're listed in counter-clockwise order, starting from the right of the front stage.
+var (
+	surround51Speakers = []surroundSpeaker{
+		{volume.SurroundFrontRight, deg(-30)},
+		{volume.SurroundCenter, 0},
+		{volume.SurroundFrontLeft, deg(30)},
+		{volume.SurroundBackLeft, deg(110)},
+		{volume.SurroundBackRight, deg(-110)},
+	}
+	surround71Speakers = []surroundSpeaker{
+		{volume.SurroundFrontRight, deg(-30)},
+		{volume.SurroundCenter, 0},
+		{volume.SurroundFrontLeft, deg(30)},
+		{volume.SurroundSideLeft, deg(90)},
+		{volume.SurroundBackLeft, deg(135)},
+		{volume.SurroundBackRight, deg(-135)},
+		{volume.SurroundSideRight, deg(-90)},
+	}
+)
+
+// PanMixer51 is a mixer that's specialized for mixing 5.1 surround audio content
+var PanMixer51 PanMixer = NewSurroundPanMixer(6, SurroundSettings{})
+
+// PanMixer71 is a mixer that's specialized for mixing 7.1 surround audio content
+var PanMixer71 PanMixer = NewSurroundPanMixer(8, SurroundSettings{})
+
+type panMixerSurround struct {
+	SurroundSettings
+	channels int
+	speakers []surroundSpeaker
+	phantom  []surroundSpeaker // the layout without its center speaker
+}
+
+// NewSurroundPanMixer returns a 5.1 (6 channels) or 7.1 (8 channels) panning mixer using the
+// settings provided, or nil for any other number of channels
+func NewSurroundPanMixer(channels int, settings SurroundSettings) PanMixer {
+	var speakers []surroundSpeaker
+	switch channels {
+	case 6:
+		speakers = surround51Speakers
+	case 8:
+		speakers = surround71Speakers
+	default:
+		return nil
+	}
+
+	p := panMixerSurround{
+		SurroundSettings: settings,
+		channels:         channels,
+		speakers:         speakers,
+	}
+	for _, s := range speakers {
+		if s.channel != volume.SurroundCenter {
+			p.phantom = append(p.phantom, s)
+		}
+	}
+	return &p
+}
+
+// panAzimuth converts a panning position angle into an azimuth around the listener.
+// The stereo field (0 to pi/2) spans the front stage, from `front` radians on the right to
+// `front` radians on the left, and the angles beyond it continue around the back of the
+// listener, with panning.SurroundPosition directly behind.
+func panAzimuth(angle, front float64) float64 {
+	a := math.Mod(angle, 2*math.Pi)
+	if a < 0 {
+		a += 2 * math.Pi
+	}
+
+	switch {
+	case a <= math.Pi/2:
+		return (a/(math.Pi/2)*2 - 1) * front
+	case a <= math.Pi:
+		return front + (a-math.Pi/2)/(math.Pi/2)*(math.Pi-front)
+	default:
+		return -math.Pi + (a-math.Pi)/math.Pi*(math.Pi-front)
+	}
+}
+
+// narrowAzimuth narrows the sound field by the stereo separation, pulling a source at `azimuth`
+// towards the axis running from the front center to the back center. A source keeps to its own
+// half of the field, so the rear stage narrows towards the back instead of swinging to the front.
+func narrowAzimuth(azimuth float64, stereoSeparation float32) float64 {
+	sep := float64(min(max(stereoSeparation, 0), 1))
+	return math.Atan2(sep*math.Sin(azimuth), math.Cos(azimuth))
+}
+
+// vbap pans a source at `azimuth` between the pair of adjacent speakers around it, using
+// vector base amplitude panning (Pulkki, 1997) with the gains normalized to constant power
+func vbap(speakers []surroundSpeaker, azimuth float64) volume.StaticMatrix {
+	var out volume.StaticMatrix
+	for i, a := range speakers {
+		b := speakers[(i+1)%len(speakers)]
+		span := angleBetween(a.azimuth, b.azimuth)
+		offset := angleBetween(a.azimuth, azimuth)
+		if offset > span {
+			continue
+		}
+
+		ga := math.Sin(span - offset)
+		gb := math.Sin(offset)
+		norm := math.Hypot(ga, gb)
+		out[a.channel] = volume.Volume(ga / norm)
+		out[b.channel] = volume.Volume(gb / norm)
+		break
+	}
+	return out
+}
+
+// angleBetween returns the counter-clockwise angle from `from` to `to`, in [0, 2*pi)
+func angleBetween(from, to float64) float64 {
+	d := math.Mod(to-from, 2*math.Pi)
+	if d < 0 {
+		d += 2 * math.Pi
+	}
+	return d
+}
+
+func (p panMixerSurround) GetMixingMatrix(pan panning.Position, stereoSeparation float32) panning.PanMixer {
+	azimuth := narrowAzimuth(panAzimuth(float64(pan.Angle), deg(30)), stereoSeparation)
+
+	var gains volume.StaticMatrix
+	switch p.Center {
+	case CenterPhantom:
+		gains = vbap(p.phantom, azimuth)
+	case CenterSpread:
+		speaker := vbap(p.speakers, azimuth)
+		phantom := vbap(p.phantom, azimuth)
+		for i := range gains {
+			gains[i] = volume.Volume(math.Sqrt(float64(speaker[i]*speaker[i]+phantom[i]*phantom[i]) / 2))
+		}
+	default:
+		gains = vbap(p.speakers, azimuth)
+	}
+
+	var d volume.Volume
+	if pan.Distance > 0 {
+		d = 1 / volume.Volume(pan.Distance*pan.Distance)
+	}
+
+	mtx := volume.Matrix{
+		StaticMatrix: gains,
+		Channels:     p.channels,
+	}
+	mtx.StaticMatrix[volume.SurroundLFE] = volume.Volume(max(p.LFESend, 0))
+	return mtx.Apply(d)
+}
+
+func (p panMixerSurround) NumChannels() int {
+	return p.channels
+}
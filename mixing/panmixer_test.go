@@ -113,8 +113,302 @@ func TestPanMixerQuadAngleZero(t *testing.T) {
 	}
 }
 
+func TestPanMixerStereoSurroundInvertsPhase(t *testing.T) {
+	mixer := GetPanMixer(2)
+	pm := mixer.GetMixingMatrix(panning.SurroundPosition, 0.5)
+
+	matrix := pm.Apply(volume.Volume(1))
+	if matrix.Channels != 2 {
+		t.Fatalf("expected 2 channels, got %d", matrix.Channels)
+	}
+	l, r := float64(matrix.StaticMatrix[0]), float64(matrix.StaticMatrix[1])
+	if !almostEqual(l, -r, 1e-6) || l <= 0 {
+		t.Fatalf("expected equal-magnitude, opposite-phase output: L=%v R=%v", l, r)
+	}
+}
+
+func TestPanMixerQuadSurroundRoutesToRear(t *testing.T) {
+	mixer := GetPanMixer(4)
+	pm := mixer.GetMixingMatrix(panning.SurroundPosition, 1)
+
+	matrix := pm.Apply(volume.Volume(1))
+	expected := volume.StaticMatrix{0, 0, 1 / math.Sqrt2, 1 / math.Sqrt2}
+	for i := 0; i < 4; i++ {
+		if !almostEqual(float64(matrix.StaticMatrix[i]), float64(expected[i]), 1e-6) {
+			t.Fatalf("channel %d mismatch: got %v want %v", i, matrix.StaticMatrix[i], expected[i])
+		}
+	}
+}
+
 func TestGetPanMixerUnknownChannels(t *testing.T) {
 	if m := GetPanMixer(3); m != nil {
 		t.Fatalf("expected nil mixer for unsupported channel count, got %#v", m)
 	}
 }
+
+func surroundPower(m volume.Matrix) float64 {
+	var p float64
+	for i := 0; i < m.Channels; i++ {
+		if i == volume.SurroundLFE {
+			continue
+		}
+		p += float64(m.StaticMatrix[i] * m.StaticMatrix[i])
+	}
+	return p
+}
+
+func TestPanMixerSurroundSpeakerPositions(t *testing.T) {
+	tests := []struct {
+		name     string
+		channels int
+		pan      panning.Position
+		channel  int
+	}{
+		{"5.1 left", 6, panning.MakeStereoPosition(0, 0, 1), volume.SurroundFrontLeft},
+		{"5.1 right", 6, panning.MakeStereoPosition(1, 0, 1), volume.SurroundFrontRight},
+		{"5.1 center", 6, panning.CenterAhead, volume.SurroundCenter},
+		{"7.1 left", 8, panning.MakeStereoPosition(0, 0, 1), volume.SurroundFrontLeft},
+		{"7.1 center", 8, panning.CenterAhead, volume.SurroundCenter},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mixer := GetPanMixer(tt.channels)
+			if mixer == nil || mixer.NumChannels() != tt.channels {
+				t.Fatalf("expected a %d channel pan mixer", tt.channels)
+			}
+
+			matrix := mixer.GetMixingMatrix(tt.pan, 1).Apply(1)
+			if matrix.Channels != tt.channels {
+				t.Fatalf("expected %d channels, got %d", tt.channels, matrix.Channels)
+			}
+			for i := 0; i < matrix.Channels; i++ {
+				expected := 0.0
+				if i == tt.channel {
+					expected = 1
+				}
+				if !almostEqual(float64(matrix.StaticMatrix[i]), expected, 1e-5) {
+					t.Fatalf("channel %d mismatch: got %v want %v", i, matrix.StaticMatrix[i], expected)
+				}
+			}
+		})
+	}
+}
+
+func TestPanMixerSurroundIsConstantPower(t *testing.T) {
+	for _, channels := range []int{6, 8} {
+		mixer := GetPanMixer(channels)
+		for a := 0.0; a < 2*math.Pi; a += 0.1 {
+			matrix := mixer.GetMixingMatrix(panning.Position{Angle: float32(a), Distance: 1}, 1).Apply(1)
+			if p := surroundPower(matrix); !almostEqual(p, 1, 1e-5) {
+				t.Fatalf("%d channels, angle %v: expected unit power, got %v (%+v)", channels, a, p, matrix.StaticMatrix)
+			}
+		}
+	}
+}
+
+func TestPanMixerSurroundPairwise(t *testing.T) {
+	// halfway between center and left only uses those two speakers
+	mixer := GetPanMixer(6)
+	matrix := mixer.GetMixingMatrix(panning.MakeStereoPosition(0.25, 0, 1), 1).Apply(1)
+
+	l := float64(matrix.StaticMatrix[volume.SurroundFrontLeft])
+	c := float64(matrix.StaticMatrix[volume.SurroundCenter])
+	if !almostEqual(l, c, 1e-5) || !almostEqual(l, 1/math.Sqrt2, 1e-5) {
+		t.Fatalf("expected equal gains on left and center: L=%v C=%v", l, c)
+	}
+	if matrix.StaticMatrix[volume.SurroundFrontRight] != 0 || matrix.StaticMatrix[volume.SurroundBackLeft] != 0 {
+		t.Fatalf("expected only the left and center speakers: %+v", matrix.StaticMatrix)
+	}
+}
+
+func TestPanMixerSurroundBehindListener(t *testing.T) {
+	matrix := GetPanMixer(6).GetMixingMatrix(panning.SurroundPosition, 1).Apply(1)
+	ls := float64(matrix.StaticMatrix[volume.SurroundBackLeft])
+	rs := float64(matrix.StaticMatrix[volume.SurroundBackRight])
+	if !almostEqual(ls, rs, 1e-5) || !almostEqual(ls, 1/math.Sqrt2, 1e-5) {
+		t.Fatalf("expected 5.1 surround to split across the surround speakers: %+v", matrix.StaticMatrix)
+	}
+
+	matrix = GetPanMixer(8).GetMixingMatrix(panning.SurroundPosition, 1).Apply(1)
+	lb := float64(matrix.StaticMatrix[volume.SurroundBackLeft])
+	rb := float64(matrix.StaticMatrix[volume.SurroundBackRight])
+	if !almostEqual(lb, rb, 1e-5) || !almostEqual(lb, 1/math.Sqrt2, 1e-5) {
+		t.Fatalf("expected 7.1 surround to split across the back speakers: %+v", matrix.StaticMatrix)
+	}
+}
+
+func TestPanMixerSurroundCenterPolicy(t *testing.T) {
+	phantom := NewSurroundPanMixer(6, SurroundSettings{Center: CenterPhantom})
+	matrix := phantom.GetMixingMatrix(panning.CenterAhead, 1).Apply(1)
+	if matrix.StaticMatrix[volume.SurroundCenter] != 0 {
+		t.Fatalf("expected the phantom center to leave the center speaker silent: %+v", matrix.StaticMatrix)
+	}
+	if l, r := float64(matrix.StaticMatrix[volume.SurroundFrontLeft]), float64(matrix.StaticMatrix[volume.SurroundFrontRight]); !almostEqual(l, r, 1e-5) || !almostEqual(l, 1/math.Sqrt2, 1e-5) {
+		t.Fatalf("expected the phantom center between left and right: L=%v R=%v", l, r)
+	}
+
+	spread := NewSurroundPanMixer(6, SurroundSettings{Center: CenterSpread})
+	matrix = spread.GetMixingMatrix(panning.CenterAhead, 1).Apply(1)
+	if c := float64(matrix.StaticMatrix[volume.SurroundCenter]); !almostEqual(c, 1/math.Sqrt2, 1e-5) {
+		t.Fatalf("expected half the power in the center speaker, got %v", c)
+	}
+	if p := surroundPower(matrix); !almostEqual(p, 1, 1e-5) {
+		t.Fatalf("expected unit power, got %v", p)
+	}
+}
+
+func TestPanMixerSurroundLFESend(t *testing.T) {
+	mixer := NewSurroundPanMixer(8, SurroundSettings{LFESend: 0.5})
+	matrix := mixer.GetMixingMatrix(panning.Position{Angle: 2, Distance: 2}, 1).Apply(1)
+	if lfe := float64(matrix.StaticMatrix[volume.SurroundLFE]); !almostEqual(lfe, 0.125, 1e-6) {
+		t.Fatalf("expected the LFE send to follow the distance attenuation, got %v", lfe)
+	}
+
+	matrix = GetPanMixer(6).GetMixingMatrix(panning.CenterAhead, 1).Apply(1)
+	if matrix.StaticMatrix[volume.SurroundLFE] != 0 {
+		t.Fatalf("expected no LFE send by default")
+	}
+}
+
+func TestPanMixerSurroundSeparationNarrowsField(t *testing.T) {
+	matrix := GetPanMixer(6).GetMixingMatrix(panning.MakeStereoPosition(0, 0, 1), 0).Apply(1)
+	if c := float64(matrix.StaticMatrix[volume.SurroundCenter]); !almostEqual(c, 1, 1e-5) {
+		t.Fatalf("expected separation 0 to collapse to the center, got %+v", matrix.StaticMatrix)
+	}
+
+	// a source behind the listener stays behind
+	matrix = GetPanMixer(6).GetMixingMatrix(panning.SurroundPosition, 0.5).Apply(1)
+	ls := float64(matrix.StaticMatrix[volume.SurroundBackLeft])
+	rs := float64(matrix.StaticMatrix[volume.SurroundBackRight])
+	if !almostEqual(ls, rs, 1e-5) || !almostEqual(ls, 1/math.Sqrt2, 1e-5) {
+		t.Fatalf("expected surround at separation 0.5 to split across the surround speakers: %+v", matrix.StaticMatrix)
+	}
+	if matrix.StaticMatrix[volume.SurroundFrontLeft] != 0 || matrix.StaticMatrix[volume.SurroundCenter] != 0 {
+		t.Fatalf("expected surround at separation 0.5 to leave the front silent: %+v", matrix.StaticMatrix)
+	}
+}
+
+func centerBuffer(values ...float64) MixBuffer {
+	buf := make(MixBuffer, len(values))
+	for i, v := range values {
+		c := volume.Volume(v) * volume.StereoCoeff
+		buf[i] = volume.Matrix{StaticMatrix: volume.StaticMatrix{c, c}, Channels: 2}
+	}
+	return buf
+}
+
+func TestPanMixerBinauralInterauralDifferences(t *testing.T) {
+	pm := PanMixerBinaural.(VoicePanMixer)
+	if pm.NumChannels() != 2 {
+		t.Fatalf("expected a stereo pan mixer")
+	}
+
+	impulse := make([]float64, 64)
+	impulse[0] = 1
+	buf := centerBuffer(impulse...)
+	pm.NewVoicePanner().PanBuffer(buf, panning.MakeStereoPosition(0, 0, 1), 1, 44100)
+
+	peakAt := func(ch int) (int, float64) {
+		best, peak := 0, 0.0
+		for i, s := range buf {
+			if v := math.Abs(float64(s.StaticMatrix[ch])); v > peak {
+				best, peak = i, v
+			}
+		}
+		return best, peak
+	}
+	li, lp := peakAt(0)
+	ri, rp := peakAt(1)
+	if li != 0 {
+		t.Fatalf("expected the near (left) ear to hear the impulse first, got sample %d", li)
+	}
+	// 30 degrees to the left is about 0.26ms of interaural delay
+	if ri < 10 || ri > 13 {
+		t.Fatalf("expected the far (right) ear to be about 11 samples late, got %d", ri)
+	}
+	if rp >= lp {
+		t.Fatalf("expected the head to shadow the far ear: L=%v R=%v", lp, rp)
+	}
+}
+
+func TestPanMixerBinauralCenterAndContinuity(t *testing.T) {
+	pm := PanMixerBinaural.(VoicePanMixer)
+	signal := make([]float64, 200)
+	for i := range signal {
+		signal[i] = math.Sin(float64(i) * 0.3)
+	}
+
+	whole := centerBuffer(signal...)
+	pm.NewVoicePanner().PanBuffer(whole, panning.MakeStereoPosition(0.2, 0, 1), 1, 44100)
+
+	split := centerBuffer(signal...)
+	vp := pm.NewVoicePanner()
+	vp.PanBuffer(split[:70], panning.MakeStereoPosition(0.2, 0, 1), 1, 44100)
+	vp.PanBuffer(split[70:], panning.MakeStereoPosition(0.2, 0, 1), 1, 44100)
+	for i := range whole {
+		for c := 0; c < 2; c++ {
+			if !almostEqual(float64(whole[i].StaticMatrix[c]), float64(split[i].StaticMatrix[c]), 1e-6) {
+				t.Fatalf("expected the voice state to carry over between ticks, sample %d differs", i)
+			}
+		}
+	}
+
+	center := centerBuffer(signal...)
+	pm.NewVoicePanner().PanBuffer(center, panning.CenterAhead, 1, 44100)
+	for i, s := range center {
+		if !almostEqual(float64(s.StaticMatrix[0]), float64(s.StaticMatrix[1]), 1e-6) {
+			t.Fatalf("expected a centered voice to reach both ears equally, sample %d: %+v", i, s.StaticMatrix)
+		}
+	}
+}
+
+func TestPanMixerBinauralSeparationKeepsRearSources(t *testing.T) {
+	pm := PanMixerBinaural.(VoicePanMixer)
+	signal := make([]float64, 200)
+	for i := range signal {
+		signal[i] = math.Sin(float64(i) * 0.3)
+	}
+
+	// narrowing the field leaves a source directly behind the listener there, between the ears
+	buf := centerBuffer(signal...)
+	pm.NewVoicePanner().PanBuffer(buf, panning.SurroundPosition, 0.5, 44100)
+	for i, s := range buf {
+		if !almostEqual(float64(s.StaticMatrix[0]), float64(s.StaticMatrix[1]), 1e-6) {
+			t.Fatalf("expected surround at separation 0.5 to reach both ears equally, sample %d: %+v", i, s.StaticMatrix)
+		}
+	}
+}
+
+func TestPanMixerCrossfeed(t *testing.T) {
+	pm := PanMixerCrossfeed.(VoicePanMixer)
+
+	dc := make([]float64, 4410)
+	for i := range dc {
+		dc[i] = 1
+	}
+
+	left := centerBuffer(dc...)
+	pm.NewVoicePanner().PanBuffer(left, panning.MakeStereoPosition(0, 0, 1), 1, 44100)
+	last := left[len(left)-1]
+	l, r := float64(last.StaticMatrix[0]), float64(last.StaticMatrix[1])
+	if r <= 0 || r >= l {
+		t.Fatalf("expected the lows of a hard-panned voice to reach the far ear at a lower level: L=%v R=%v", l, r)
+	}
+	if !almostEqual(ampToDB(l/r), 4.5, 0.01) {
+		t.Fatalf("expected a 4.5dB feed, got %vdB", ampToDB(l/r))
+	}
+
+	center := centerBuffer(dc...)
+	ref := PanMixerStereo.GetMixingMatrix(panning.CenterAhead, 1).ApplyToMatrix(center[0])
+	pm.NewVoicePanner().PanBuffer(center, panning.CenterAhead, 1, 44100)
+	for i, s := range center {
+		if !almostEqual(float64(s.StaticMatrix[0]), float64(ref.StaticMatrix[0]), 1e-6) || !almostEqual(float64(s.StaticMatrix[1]), float64(ref.StaticMatrix[1]), 1e-6) {
+			t.Fatalf("expected a centered voice to be left unchanged, sample %d: %+v", i, s.StaticMatrix)
+		}
+	}
+}
+
+func ampToDB(v float64) float64 {
+	return 20 * math.Log10(v)
+}
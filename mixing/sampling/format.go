@@ -24,4 +24,16 @@ const (
 	Format64BitLEFloat
 	// Format64BitBEFloat is for big-endian, 64-bit floating-point data
 	Format64BitBEFloat
+	// Format24BitLESigned is for signed, little-endian, 24-bit data packed into 3 bytes
+	Format24BitLESigned
+	// Format24BitBESigned is for signed, big-endian, 24-bit data packed into 3 bytes
+	Format24BitBESigned
+	// Format24In32BitLESigned is for signed, little-endian, 24-bit data in the low 3 bytes of 4
+	Format24In32BitLESigned
+	// Format24In32BitBESigned is for signed, big-endian, 24-bit data in the low 3 bytes of 4
+	Format24In32BitBESigned
+	// Format32BitLESigned is for signed, little-endian, 32-bit integer data
+	Format32BitLESigned
+	// Format32BitBESigned is for signed, big-endian, 32-bit integer data
+	Format32BitBESigned
 )
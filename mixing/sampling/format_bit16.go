@@ -3,6 +3,7 @@ package sampling
 import (
 	"encoding/binary"
 	"io"
+	"math"
 
 	"github.com/gotracker/playback/mixing/volume"
 )
@@ -28,6 +29,11 @@ func (Sample16BitSigned) fromVolume(v volume.Volume) int16 {
 	return int16(v.ToIntSample(16))
 }
 
+// QuantizationScale returns the value a sample is multiplied by to get its integer value
+func (Sample16BitSigned) QuantizationScale() float64 {
+	return 32678
+}
+
 // Size returns the size of the sample in bytes
 func (Sample16BitSigned) Size() int {
 	return cSample16BitBytes
@@ -79,6 +85,11 @@ func (Sample16BitUnsigned) fromVolume(v volume.Volume) uint16 {
 	return uint16(v.ToUintSample(16))
 }
 
+// QuantizationScale returns the value a sample is multiplied by to get its integer value
+func (Sample16BitUnsigned) QuantizationScale() float64 {
+	return math.MaxInt16
+}
+
 // Size returns the size of the sample in bytes
 func (Sample16BitUnsigned) Size() int {
 	return cSample16BitBytes
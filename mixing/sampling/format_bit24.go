@@ -0,0 +1,134 @@
+package sampling
+
+import (
+	"encoding/binary"
+	"io"
+
+	"github.com/gotracker/playback/mixing/volume"
+)
+
+const (
+	cSample24BitDataCoeff   = 0x800000
+	cSample24BitVolumeCoeff = volume.Volume(1) / cSample24BitDataCoeff
+	cSample24BitBytes       = 3
+	cSample24In32BitBytes   = 4
+)
+
+// from24BitVolume returns the 24-bit integer value for the volume
+func from24BitVolume(v volume.Volume) int32 {
+	s := v.WithOverflowProtection() * cSample24BitDataCoeff
+	return int32(min(max(s, -cSample24BitDataCoeff), cSample24BitDataCoeff-1))
+}
+
+// Sample24BitSigned is a signed 24-bit sample, packed into 3 bytes
+type Sample24BitSigned struct {
+	byteOrder binary.ByteOrder
+}
+
+// volume returns the volume value for the sample
+func (Sample24BitSigned) volume(v int32) volume.Volume {
+	return volume.Volume(v) * cSample24BitVolumeCoeff
+}
+
+// Size returns the size of the sample in bytes
+func (Sample24BitSigned) Size() int {
+	return cSample24BitBytes
+}
+
+func (s Sample24BitSigned) put(data []byte, v int32) {
+	u := uint32(v)
+	if s.byteOrder == binary.BigEndian {
+		data[0], data[1], data[2] = byte(u>>16), byte(u>>8), byte(u)
+	} else {
+		data[0], data[1], data[2] = byte(u), byte(u>>8), byte(u>>16)
+	}
+}
+
+// ReadAt reads a value from the reader provided in the byte order provided
+func (s Sample24BitSigned) ReadAt(data []byte, ofs int64) (volume.Volume, error) {
+	if len(data) <= int(ofs)+(cSample24BitBytes-1) {
+		return 0, io.EOF
+	}
+	if ofs < 0 {
+		ofs = 0
+	}
+
+	b := data[ofs : ofs+cSample24BitBytes]
+	var u uint32
+	if s.byteOrder == binary.BigEndian {
+		u = uint32(b[0])<<16 | uint32(b[1])<<8 | uint32(b[2])
+	} else {
+		u = uint32(b[2])<<16 | uint32(b[1])<<8 | uint32(b[0])
+	}
+	// sign-extend from 24 bits
+	return s.volume(int32(u<<8) >> 8), nil
+}
+
+// WriteAt writes a value to the slice provided in the byte order provided
+func (s Sample24BitSigned) WriteAt(data []byte, ofs int64, v volume.Volume) error {
+	if len(data) <= int(ofs)+(cSample24BitBytes-1) {
+		return io.EOF
+	}
+	if ofs < 0 {
+		ofs = 0
+	}
+
+	s.put(data[ofs:], from24BitVolume(v))
+	return nil
+}
+
+// Write writes a value to the Writer provided in the byte order provided
+func (s Sample24BitSigned) Write(out io.Writer, v volume.Volume) error {
+	var b [cSample24BitBytes]byte
+	s.put(b[:], from24BitVolume(v))
+	_, err := out.Write(b[:])
+	return err
+}
+
+// Sample24In32BitSigned is a signed 24-bit sample, stored in the low 3 bytes of a 4-byte
+// container and sign-extended into the high byte
+type Sample24In32BitSigned struct {
+	byteOrder binary.ByteOrder
+}
+
+// volume returns the volume value for the sample
+func (Sample24In32BitSigned) volume(v int32) volume.Volume {
+	return volume.Volume(v) * cSample24BitVolumeCoeff
+}
+
+// Size returns the size of the sample in bytes
+func (Sample24In32BitSigned) Size() int {
+	return cSample24In32BitBytes
+}
+
+// ReadAt reads a value from the reader provided in the byte order provided
+func (s Sample24In32BitSigned) ReadAt(data []byte, ofs int64) (volume.Volume, error) {
+	if len(data) <= int(ofs)+(cSample24In32BitBytes-1) {
+		return 0, io.EOF
+	}
+	if ofs < 0 {
+		ofs = 0
+	}
+
+	// only the low 24 bits are significant
+	v := int32(s.byteOrder.Uint32(data[ofs:])<<8) >> 8
+	return s.volume(v), nil
+}
+
+// WriteAt writes a value to the slice provided in the byte order provided
+func (s Sample24In32BitSigned) WriteAt(data []byte, ofs int64, v volume.Volume) error {
+	if len(data) <= int(ofs)+(cSample24In32BitBytes-1) {
+		return io.EOF
+	}
+	if ofs < 0 {
+		ofs = 0
+	}
+
+	s.byteOrder.PutUint32(data[ofs:], uint32(from24BitVolume(v)))
+	return nil
+}
+
+// Write writes a value to the Writer provided in the byte order provided
+func (s Sample24In32BitSigned) Write(out io.Writer, v volume.Volume) error {
+	return binary.Write(out, s.byteOrder, from24BitVolume(v))
+}
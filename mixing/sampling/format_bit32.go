@@ -0,0 +1,66 @@
+package sampling
+
+import (
+	"encoding/binary"
+	"io"
+
+	"github.com/gotracker/playback/mixing/volume"
+)
+
+const (
+	cSample32BitDataCoeff   = 0x80000000
+	cSample32BitVolumeCoeff = volume.Volume(1) / cSample32BitDataCoeff
+	cSample32BitBytes       = 4
+)
+
+// Sample32BitSigned is a signed 32-bit integer sample
+type Sample32BitSigned struct {
+	byteOrder binary.ByteOrder
+}
+
+// volume returns the volume value for the sample
+func (Sample32BitSigned) volume(v int32) volume.Volume {
+	return volume.Volume(v) * cSample32BitVolumeCoeff
+}
+
+// fromVolume returns the sample value for the volume
+func (Sample32BitSigned) fromVolume(v volume.Volume) int32 {
+	s := v.WithOverflowProtection() * cSample32BitDataCoeff
+	return int32(min(max(s, -cSample32BitDataCoeff), cSample32BitDataCoeff-1))
+}
+
+// Size returns the size of the sample in bytes
+func (Sample32BitSigned) Size() int {
+	return cSample32BitBytes
+}
+
+// ReadAt reads a value from the reader provided in the byte order provided
+func (s Sample32BitSigned) ReadAt(data []byte, ofs int64) (volume.Volume, error) {
+	if len(data) <= int(ofs)+(cSample32BitBytes-1) {
+		return 0, io.EOF
+	}
+	if ofs < 0 {
+		ofs = 0
+	}
+
+	v := int32(s.byteOrder.Uint32(data[ofs:]))
+	return s.volume(v), nil
+}
+
+// WriteAt writes a value to the slice provided in the byte order provided
+func (s Sample32BitSigned) WriteAt(data []byte, ofs int64, v volume.Volume) error {
+	if len(data) <= int(ofs)+(cSample32BitBytes-1) {
+		return io.EOF
+	}
+	if ofs < 0 {
+		ofs = 0
+	}
+
+	s.byteOrder.PutUint32(data[ofs:], uint32(s.fromVolume(v)))
+	return nil
+}
+
+// Write writes a value to the Writer provided in the byte order provided
+func (s Sample32BitSigned) Write(out io.Writer, v volume.Volume) error {
+	return binary.Write(out, s.byteOrder, s.fromVolume(v))
+}
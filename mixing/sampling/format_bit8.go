@@ -3,6 +3,7 @@ package sampling
 import (
 	"encoding/binary"
 	"io"
+	"math"
 
 	"github.com/gotracker/playback/mixing/volume"
 )
@@ -26,6 +27,11 @@ func (Sample8BitSigned) fromVolume(v volume.Volume) int8 {
 	return int8(v.ToIntSample(8))
 }
 
+// QuantizationScale returns the value a sample is multiplied by to get its integer value
+func (Sample8BitSigned) QuantizationScale() float64 {
+	return 128
+}
+
 // Size returns the size of the sample in bytes
 func (Sample8BitSigned) Size() int {
 	return cSample8BitBytes
@@ -75,6 +81,11 @@ func (Sample8BitUnsigned) fromVolume(v volume.Volume) uint8 {
 	return uint8(v.ToUintSample(8))
 }
 
+// QuantizationScale returns the value a sample is multiplied by to get its integer value
+func (Sample8BitUnsigned) QuantizationScale() float64 {
+	return math.MaxInt8
+}
+
 // Size returns the size of the sample in bytes
 func (Sample8BitUnsigned) Size() int {
 	return cSample8BitBytes
@@ -14,6 +14,13 @@ type Formatter interface {
 	Write(out io.Writer, v volume.Volume) error
 }
 
+// Quantizer is a Formatter that stores samples as integers
+type Quantizer interface {
+	// QuantizationScale returns the value a sample is multiplied by to get its integer value,
+	// which is then truncated
+	QuantizationScale() float64
+}
+
 func GetFormatter(format Format) Formatter {
 	switch format {
 	default:
@@ -48,5 +55,23 @@ func GetFormatter(format Format) Formatter {
 	case Format64BitBEFloat:
 		// Format64BitBEFloat is for big-endian, 64-bit floating-point data
 		return Sample64BitFloat{byteOrder: binary.BigEndian}
+	case Format24BitLESigned:
+		// Format24BitLESigned is for signed, little-endian, 24-bit data packed into 3 bytes
+		return Sample24BitSigned{byteOrder: binary.LittleEndian}
+	case Format24BitBESigned:
+		// Format24BitBESigned is for signed, big-endian, 24-bit data packed into 3 bytes
+		return Sample24BitSigned{byteOrder: binary.BigEndian}
+	case Format24In32BitLESigned:
+		// Format24In32BitLESigned is for signed, little-endian, 24-bit data in the low 3 bytes of 4
+		return Sample24In32BitSigned{byteOrder: binary.LittleEndian}
+	case Format24In32BitBESigned:
+		// Format24In32BitBESigned is for signed, big-endian, 24-bit data in the low 3 bytes of 4
+		return Sample24In32BitSigned{byteOrder: binary.BigEndian}
+	case Format32BitLESigned:
+		// Format32BitLESigned is for signed, little-endian, 32-bit integer data
+		return Sample32BitSigned{byteOrder: binary.LittleEndian}
+	case Format32BitBESigned:
+		// Format32BitBESigned is for signed, big-endian, 32-bit integer data
+		return Sample32BitSigned{byteOrder: binary.BigEndian}
 	}
 }
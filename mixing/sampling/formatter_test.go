@@ -0,0 +1,100 @@
+package sampling
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/gotracker/playback/mixing/volume"
+)
+
+func TestIntegerFormatterByteLayout(t *testing.T) {
+	tests := []struct {
+		name     string
+		format   Format
+		v        volume.Volume
+		expected []byte
+	}{
+		{"24-bit LE", Format24BitLESigned, 0.5, []byte{0x00, 0x00, 0x40}},
+		{"24-bit BE", Format24BitBESigned, 0.5, []byte{0x40, 0x00, 0x00}},
+		{"24-bit LE negative", Format24BitLESigned, -1, []byte{0x00, 0x00, 0x80}},
+		{"24-bit LE full scale", Format24BitLESigned, 1, []byte{0xFF, 0xFF, 0x7F}},
+		{"24-in-32 LE", Format24In32BitLESigned, 0.5, []byte{0x00, 0x00, 0x40, 0x00}},
+		{"24-in-32 BE negative", Format24In32BitBESigned, -0.5, []byte{0xFF, 0xC0, 0x00, 0x00}},
+		{"32-bit LE", Format32BitLESigned, 0.5, []byte{0x00, 0x00, 0x00, 0x40}},
+		{"32-bit BE full scale", Format32BitBESigned, 1, []byte{0x7F, 0xFF, 0xFF, 0xFF}},
+		{"32-bit LE negative", Format32BitLESigned, -1, []byte{0x00, 0x00, 0x00, 0x80}},
+		{"16-bit LE unsigned", Format16BitLEUnsigned, 0, []byte{0x00, 0x80}},
+		{"16-bit BE unsigned", Format16BitBEUnsigned, 0, []byte{0x80, 0x00}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := GetFormatter(tt.format)
+			if f == nil {
+				t.Fatalf("no formatter for format %d", tt.format)
+			}
+			if f.Size() != len(tt.expected) {
+				t.Fatalf("unexpected size: got %d want %d", f.Size(), len(tt.expected))
+			}
+
+			var buf bytes.Buffer
+			if err := f.Write(&buf, tt.v); err != nil {
+				t.Fatalf("write failed: %v", err)
+			}
+			if !bytes.Equal(buf.Bytes(), tt.expected) {
+				t.Fatalf("unexpected Write bytes: got % X want % X", buf.Bytes(), tt.expected)
+			}
+
+			data := make([]byte, f.Size())
+			if err := f.WriteAt(data, 0, tt.v); err != nil {
+				t.Fatalf("write at failed: %v", err)
+			}
+			if !bytes.Equal(data, tt.expected) {
+				t.Fatalf("unexpected WriteAt bytes: got % X want % X", data, tt.expected)
+			}
+		})
+	}
+}
+
+func TestIntegerFormatterRoundTrip(t *testing.T) {
+	formats := []struct {
+		name   string
+		format Format
+		tol    volume.Volume
+	}{
+		{"24-bit LE", Format24BitLESigned, 1e-6},
+		{"24-bit BE", Format24BitBESigned, 1e-6},
+		{"24-in-32 LE", Format24In32BitLESigned, 1e-6},
+		{"24-in-32 BE", Format24In32BitBESigned, 1e-6},
+		{"32-bit LE", Format32BitLESigned, 1e-6},
+		{"32-bit BE", Format32BitBESigned, 1e-6},
+		{"16-bit LE unsigned", Format16BitLEUnsigned, 1e-4},
+		{"16-bit BE unsigned", Format16BitBEUnsigned, 1e-4},
+	}
+	values := []volume.Volume{0, 0.25, -0.25, 0.7, -0.7, -1}
+
+	for _, tt := range formats {
+		t.Run(tt.name, func(t *testing.T) {
+			f := GetFormatter(tt.format)
+			data := make([]byte, f.Size()*len(values))
+			for i, v := range values {
+				if err := f.WriteAt(data, int64(i*f.Size()), v); err != nil {
+					t.Fatalf("write at %d failed: %v", i, err)
+				}
+			}
+			for i, v := range values {
+				got, err := f.ReadAt(data, int64(i*f.Size()))
+				if err != nil {
+					t.Fatalf("read at %d failed: %v", i, err)
+				}
+				if d := got - v; d > tt.tol || d < -tt.tol {
+					t.Fatalf("value %d: got %v want %v", i, got, v)
+				}
+			}
+
+			if _, err := f.ReadAt(data, int64(len(data)-1)); err == nil {
+				t.Fatalf("expected an error reading past the end")
+			}
+		})
+	}
+}
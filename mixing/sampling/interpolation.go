@@ -0,0 +1,23 @@
+package sampling
+
+// Interpolation is the method used to reconstruct the sample data between sample points
+type Interpolation uint8
+
+const (
+	// InterpolationLinear blends linearly between neighboring sample points
+	InterpolationLinear = Interpolation(iota)
+	// InterpolationBLEP holds each sample point until the next one arrives, as the Amiga's
+	// Paula does, and smooths the resulting steps with band-limited step (BLEP) synthesis
+	InterpolationBLEP
+)
+
+func (i Interpolation) String() string {
+	switch i {
+	case InterpolationLinear:
+		return "linear"
+	case InterpolationBLEP:
+		return "blep"
+	default:
+		return "unknown"
+	}
+}
@@ -75,6 +75,10 @@ func (m Matrix) ToChannels(channels int) Matrix {
 		return m.AsStereo()
 	case 4:
 		return m.AsQuad()
+	case 6:
+		return m.As51()
+	case 8:
+		return m.As71()
 	default:
 		return Matrix{}
 	}
@@ -91,6 +95,8 @@ func (m Matrix) Sum() Volume {
 		return (m.StaticMatrix[0] + m.StaticMatrix[1]) / StereoCoeff
 	case 4:
 		return (m.StaticMatrix[0] + m.StaticMatrix[1] + m.StaticMatrix[2] + m.StaticMatrix[3]) / StereoCoeff
+	case 6, 8:
+		return m.AsStereo().Sum()
 	default:
 		c := Volume(1 / float64(m.Channels))
 
@@ -140,6 +146,18 @@ func (m Matrix) AsStereo() Matrix {
 			StaticMatrix: StaticMatrix{(m.StaticMatrix[0] + m.StaticMatrix[2]) / 2.0, (m.StaticMatrix[1] + m.StaticMatrix[3]) / 2.0},
 			Channels:     2,
 		}
+	case 6:
+		// ITU-R BS.775 downmix; the LFE channel is dropped
+		c := m.StaticMatrix[SurroundCenter] * StereoCoeff
+		return Matrix{
+			StaticMatrix: StaticMatrix{
+				m.StaticMatrix[SurroundFrontLeft] + c + m.StaticMatrix[SurroundBackLeft]*StereoCoeff,
+				m.StaticMatrix[SurroundFrontRight] + c + m.StaticMatrix[SurroundBackRight]*StereoCoeff,
+			},
+			Channels: 2,
+		}
+	case 8:
+		return m.As51().AsStereo()
 	default:
 		return Matrix{}
 	}
@@ -161,11 +179,88 @@ func (m Matrix) AsQuad() Matrix {
 		}
 	case 4:
 		return m
+	case 6:
+		// the center is folded into the front speakers; the LFE channel is dropped
+		c := m.StaticMatrix[SurroundCenter] * StereoCoeff
+		return Matrix{
+			StaticMatrix: StaticMatrix{
+				m.StaticMatrix[SurroundFrontLeft] + c,
+				m.StaticMatrix[SurroundFrontRight] + c,
+				m.StaticMatrix[SurroundBackLeft],
+				m.StaticMatrix[SurroundBackRight],
+			},
+			Channels: 4,
+		}
+	case 8:
+		return m.As51().AsQuad()
 	default:
 		return Matrix{}
 	}
 }
 
+// As51 returns the matrix as a 5.1 surround layout
+func (m Matrix) As51() Matrix {
+	switch m.Channels {
+	case 0:
+		return Matrix{}
+	case 1:
+		var out Matrix
+		out.StaticMatrix[SurroundCenter] = m.StaticMatrix[0]
+		out.Channels = 6
+		return out
+	case 2:
+		var out Matrix
+		out.StaticMatrix[SurroundFrontLeft] = m.StaticMatrix[0]
+		out.StaticMatrix[SurroundFrontRight] = m.StaticMatrix[1]
+		out.Channels = 6
+		return out
+	case 4:
+		var out Matrix
+		out.StaticMatrix[SurroundFrontLeft] = m.StaticMatrix[0]
+		out.StaticMatrix[SurroundFrontRight] = m.StaticMatrix[1]
+		out.StaticMatrix[SurroundBackLeft] = m.StaticMatrix[2]
+		out.StaticMatrix[SurroundBackRight] = m.StaticMatrix[3]
+		out.Channels = 6
+		return out
+	case 6:
+		return m
+	case 8:
+		// the side and back speakers are folded into the 5.1 surrounds
+		out := m
+		out.StaticMatrix[SurroundBackLeft] = m.StaticMatrix[SurroundBackLeft] + m.StaticMatrix[SurroundSideLeft]
+		out.StaticMatrix[SurroundBackRight] = m.StaticMatrix[SurroundBackRight] + m.StaticMatrix[SurroundSideRight]
+		out.StaticMatrix[SurroundSideLeft] = 0
+		out.StaticMatrix[SurroundSideRight] = 0
+		out.Channels = 6
+		return out
+	default:
+		return Matrix{}
+	}
+}
+
+// As71 returns the matrix as a 7.1 surround layout
+func (m Matrix) As71() Matrix {
+	switch m.Channels {
+	case 0:
+		return Matrix{}
+	case 8:
+		return m
+	default:
+		in := m.As51()
+		if in.Channels == 0 {
+			return Matrix{}
+		}
+		// the 5.1 surrounds sit closest to the 7.1 side speakers
+		out := in
+		out.StaticMatrix[SurroundBackLeft] = 0
+		out.StaticMatrix[SurroundBackRight] = 0
+		out.StaticMatrix[SurroundSideLeft] = in.StaticMatrix[SurroundBackLeft]
+		out.StaticMatrix[SurroundSideRight] = in.StaticMatrix[SurroundBackRight]
+		out.Channels = 8
+		return out
+	}
+}
+
 func (m Matrix) Lerp(other Matrix, t float32) Matrix {
 	if other.Channels == 0 || t <= 0 {
 		return m
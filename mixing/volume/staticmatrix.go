@@ -1,4 +1,18 @@
 package volume
 
-// StaticMatrix is an array of Volumes
-type StaticMatrix [4]Volume
+// StaticMatrix is an array of Volumes, large enough to hold a 7.1 surround layout
+type StaticMatrix [8]Volume
+
+// channel positions of the 5.1 and 7.1 surround layouts, in WAVE_FORMAT_EXTENSIBLE order.
+// 5.1 has its surround speakers at SurroundBackLeft and SurroundBackRight; 7.1 has those at the
+// back and adds the side speakers.
+const (
+	SurroundFrontLeft = iota
+	SurroundFrontRight
+	SurroundCenter
+	SurroundLFE
+	SurroundBackLeft
+	SurroundBackRight
+	SurroundSideLeft
+	SurroundSideRight
+)
@@ -1,6 +1,9 @@
 package volume
 
-import "testing"
+import (
+	"math"
+	"testing"
+)
 
 func TestWithOverflowProtectionClamps(t *testing.T) {
 	cases := []struct {
@@ -56,3 +59,32 @@ func TestApplyHelpers(t *testing.T) {
 		t.Fatalf("ApplyMultiple = %#v, want [0.5 -0.5]", out)
 	}
 }
+
+func TestMatrixSurroundConversions(t *testing.T) {
+	stereo := Matrix{StaticMatrix: StaticMatrix{0.25, 0.5}, Channels: 2}
+	s51 := stereo.ToChannels(6)
+	if s51.Channels != 6 || s51.StaticMatrix != (StaticMatrix{0.25, 0.5}) {
+		t.Fatalf("unexpected stereo to 5.1 upmix: %+v", s51)
+	}
+
+	quad := Matrix{StaticMatrix: StaticMatrix{0.1, 0.2, 0.3, 0.4}, Channels: 4}
+	s71 := quad.ToChannels(8)
+	if s71.Channels != 8 || s71.StaticMatrix != (StaticMatrix{0.1, 0.2, 0, 0, 0, 0, 0.3, 0.4}) {
+		t.Fatalf("unexpected quad to 7.1 upmix: %+v", s71)
+	}
+	if back := s71.ToChannels(4); back.StaticMatrix != quad.StaticMatrix {
+		t.Fatalf("expected 7.1 to fold back into quad: %+v", back)
+	}
+
+	mono := Matrix{StaticMatrix: StaticMatrix{1}, Channels: 1}
+	if c := mono.ToChannels(6); c.StaticMatrix != (StaticMatrix{0, 0, 1}) {
+		t.Fatalf("expected mono to upmix to the center: %+v", c)
+	}
+
+	// the center and surrounds fold into stereo at -3dB, the LFE is dropped
+	s := Matrix{StaticMatrix: StaticMatrix{0.5, 0, 1, 1, 1, 0}, Channels: 6}.AsStereo()
+	want := 0.5 + 2*StereoCoeff
+	if s.Channels != 2 || math.Abs(float64(s.StaticMatrix[0])-want) > 1e-6 || math.Abs(float64(s.StaticMatrix[1])-StereoCoeff) > 1e-6 {
+		t.Fatalf("unexpected 5.1 downmix: %+v", s)
+	}
+}
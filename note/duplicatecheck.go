@@ -0,0 +1,32 @@
+package note
+
+import "fmt"
+
+// DuplicateCheckType is the method used to determine if a background note is a duplicate of a new note
+type DuplicateCheckType uint8
+
+const (
+	// DuplicateCheckTypeOff disables duplicate checking
+	DuplicateCheckTypeOff = DuplicateCheckType(iota)
+	// DuplicateCheckTypeNote considers notes of the same instrument and semitone to be duplicates
+	DuplicateCheckTypeNote
+	// DuplicateCheckTypeSample considers notes of the same instrument and sample to be duplicates
+	DuplicateCheckTypeSample
+	// DuplicateCheckTypeInstrument considers notes of the same instrument to be duplicates
+	DuplicateCheckTypeInstrument
+)
+
+func (d DuplicateCheckType) String() string {
+	switch d {
+	case DuplicateCheckTypeOff:
+		return "DuplicateCheckTypeOff"
+	case DuplicateCheckTypeNote:
+		return "DuplicateCheckTypeNote"
+	case DuplicateCheckTypeSample:
+		return "DuplicateCheckTypeSample"
+	case DuplicateCheckTypeInstrument:
+		return "DuplicateCheckTypeInstrument"
+	default:
+		return fmt.Sprintf("Unknown[%d]", int(d))
+	}
+}
@@ -1,6 +1,7 @@
 package output
 
 import (
+	"github.com/gotracker/playback/index"
 	"github.com/gotracker/playback/mixing"
 	"github.com/gotracker/playback/mixing/volume"
 )
@@ -11,4 +12,9 @@ type PremixData struct {
 	Data        []mixing.ChannelData
 	MixerVolume volume.Volume
 	Userdata    interface{}
+	// Channels is only set when rendering stems, where each entry of Data is kept separate all
+	// the way to the output: it holds the tracker channel each entry of Data belongs to, or
+	// index.JamChannel for the notes played on the jam, or index.InvalidChannel for output that
+	// can't be split up by channel (such as a hardware synth)
+	Channels []index.Channel
 }
@@ -0,0 +1,150 @@
+package output
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/gotracker/playback/index"
+	"github.com/gotracker/playback/mixing"
+	"github.com/gotracker/playback/mixing/sampling"
+)
+
+var (
+	// ErrNotStems is returned when premix data that wasn't rendered as stems is written as stems
+	ErrNotStems = errors.New("premix data does not hold stems")
+	// ErrTooManyStems is returned when premix data holds more stems than an interleaved stem file
+	// was made for
+	ErrTooManyStems = errors.New("premix data holds more stems than the stem file")
+)
+
+// StemWriter writes stems (premix data rendered with sampler.Sampler.Stems set) to WAV files,
+// either one file per stem or one multi-channel file holding every stem. Each stem is mixed
+// down to the number of output channels on its own; the stems sum back to the normal mix, as
+// long as the sample format doesn't clip them (a floating-point format never does).
+type StemWriter struct {
+	sampleRate int
+	channels   int
+	format     sampling.Format
+	mixer      mixing.Mixer
+
+	// one file per stem
+	create func(stem int, ch index.Channel) (io.WriteSeeker, error)
+	files  []*WAVWriter
+
+	// one file for every stem
+	interleaved *WAVWriter
+	stems       int
+}
+
+// NewStemFiles returns a StemWriter that writes each stem to a WAV file of its own. `create`
+// opens the file for a stem the first time it's seen; `stem` is its position in the premix
+// data and `ch` is the tracker channel it belongs to.
+func NewStemFiles(sampleRate, channels int, format sampling.Format, create func(stem int, ch index.Channel) (io.WriteSeeker, error)) *StemWriter {
+	return &StemWriter{
+		sampleRate: sampleRate,
+		channels:   channels,
+		format:     format,
+		mixer:      mixing.Mixer{Channels: channels},
+		create:     create,
+	}
+}
+
+// NewInterleavedStemFile returns a StemWriter that writes `stems` stems of `channels` channels
+// each to a single WAV file of stems*channels channels, with the channels of the first stem
+// first in each sample frame. Stems missing from the premix data are written as silence.
+func NewInterleavedStemFile(w io.WriteSeeker, sampleRate, channels, stems int, format sampling.Format) (*StemWriter, error) {
+	wav, err := NewWAVWriter(w, sampleRate, channels*stems, format)
+	if err != nil {
+		return nil, err
+	}
+
+	return &StemWriter{
+		sampleRate:  sampleRate,
+		channels:    channels,
+		format:      format,
+		mixer:       mixing.Mixer{Channels: channels},
+		interleaved: wav,
+		stems:       stems,
+	}, nil
+}
+
+// Write mixes down and writes the stems of the premix data
+func (s *StemWriter) Write(premix *PremixData) error {
+	if premix == nil {
+		return nil
+	}
+	if premix.Channels == nil || len(premix.Channels) != len(premix.Data) {
+		return ErrNotStems
+	}
+
+	if s.interleaved != nil {
+		return s.writeInterleaved(premix)
+	}
+
+	for i, cdata := range premix.Data {
+		for len(s.files) <= i {
+			s.files = append(s.files, nil)
+		}
+		if s.files[i] == nil {
+			w, err := s.create(i, premix.Channels[i])
+			if err != nil {
+				return fmt.Errorf("could not create stem %d: %w", i, err)
+			}
+			s.files[i], err = NewWAVWriter(w, s.sampleRate, s.channels, s.format)
+			if err != nil {
+				return err
+			}
+		}
+
+		data := s.mixer.Flatten(premix.SamplesLen, []mixing.ChannelData{cdata}, premix.MixerVolume, s.format)
+		if _, err := s.files[i].Write(data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *StemWriter) writeInterleaved(premix *PremixData) error {
+	if len(premix.Data) > s.stems {
+		return ErrTooManyStems
+	}
+
+	frameSize := s.channels * sampling.GetFormatter(s.format).Size()
+	out := make([]byte, premix.SamplesLen*frameSize*s.stems)
+	for i, cdata := range premix.Data {
+		data := s.mixer.Flatten(premix.SamplesLen, []mixing.ChannelData{cdata}, premix.MixerVolume, s.format)
+		for f := 0; f < premix.SamplesLen; f++ {
+			copy(out[(f*s.stems+i)*frameSize:], data[f*frameSize:(f+1)*frameSize])
+		}
+	}
+
+	if len(premix.Data) < s.stems && s.format == sampling.Format8BitUnsigned {
+		// unsigned silence isn't zero
+		for i := len(premix.Data); i < s.stems; i++ {
+			for f := 0; f < premix.SamplesLen; f++ {
+				ofs := (f*s.stems + i) * frameSize
+				for b := ofs; b < ofs+frameSize; b++ {
+					out[b] = 0x80
+				}
+			}
+		}
+	}
+
+	_, err := s.interleaved.Write(out)
+	return err
+}
+
+// Close finishes off the WAV files. It doesn't close the underlying streams.
+func (s *StemWriter) Close() error {
+	var errs []error
+	if s.interleaved != nil {
+		errs = append(errs, s.interleaved.Close())
+	}
+	for _, f := range s.files {
+		if f != nil {
+			errs = append(errs, f.Close())
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,123 @@
+package output
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+	"testing"
+
+	"github.com/gotracker/playback/index"
+	"github.com/gotracker/playback/mixing"
+	"github.com/gotracker/playback/mixing/sampling"
+	"github.com/gotracker/playback/mixing/volume"
+)
+
+func stemPremix(values ...volume.Volume) *PremixData {
+	p := PremixData{
+		SamplesLen:  2,
+		MixerVolume: 1,
+	}
+	for i, v := range values {
+		p.Data = append(p.Data, mixing.ChannelData{
+			{
+				Data: mixing.MixBuffer{
+					{StaticMatrix: volume.StaticMatrix{v, v}, Channels: 2},
+					{StaticMatrix: volume.StaticMatrix{v, -v}, Channels: 2},
+				},
+				PanMatrix:  volume.Matrix{StaticMatrix: volume.StaticMatrix{1, 1}, Channels: 2},
+				Volume:     1,
+				SamplesLen: 2,
+			},
+		})
+		p.Channels = append(p.Channels, index.Channel(i))
+	}
+	return &p
+}
+
+func readFloats(t *testing.T, f *memFile) []float32 {
+	t.Helper()
+	size := binary.LittleEndian.Uint32(f.data[binary.LittleEndian.Uint32(f.data[16:])+24:])
+	start := len(f.data) - int(size)
+	var out []float32
+	for i := start; i < len(f.data); i += 4 {
+		out = append(out, math.Float32frombits(binary.LittleEndian.Uint32(f.data[i:])))
+	}
+	return out
+}
+
+func TestStemFilesWritesEachStem(t *testing.T) {
+	files := map[int]*memFile{}
+	var created []index.Channel
+	s := NewStemFiles(44100, 2, sampling.Format32BitLEFloat, func(stem int, ch index.Channel) (io.WriteSeeker, error) {
+		files[stem] = &memFile{}
+		created = append(created, ch)
+		return files[stem], nil
+	})
+
+	for i := 0; i < 2; i++ {
+		if err := s.Write(stemPremix(0.25, 0.5)); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if err := s.Close(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(created) != 2 || created[0] != 0 || created[1] != 1 {
+		t.Fatalf("expected a file per stem, created for %v", created)
+	}
+	for stem, v := range []float32{0.25, 0.5} {
+		got := readFloats(t, files[stem])
+		want := []float32{v, v, v, -v, v, v, v, -v}
+		if len(got) != len(want) {
+			t.Fatalf("stem %d: expected %d samples, got %d", stem, len(want), len(got))
+		}
+		for i := range want {
+			if got[i] != want[i] {
+				t.Fatalf("stem %d: unexpected samples %v", stem, got)
+			}
+		}
+	}
+}
+
+func TestInterleavedStemFile(t *testing.T) {
+	var f memFile
+	s, err := NewInterleavedStemFile(&f, 44100, 2, 3, sampling.Format32BitLEFloat)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := s.Write(stemPremix(0.25, 0.5)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := s.Close(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if ch := binary.LittleEndian.Uint16(f.data[22:]); ch != 6 {
+		t.Fatalf("expected 6 channels, got %d", ch)
+	}
+	got := readFloats(t, &f)
+	want := []float32{0.25, 0.25, 0.5, 0.5, 0, 0, 0.25, -0.25, 0.5, -0.5, 0, 0}
+	if len(got) != len(want) {
+		t.Fatalf("expected %d samples, got %d", len(want), len(got))
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("unexpected samples: got %v want %v", got, want)
+		}
+	}
+
+	if err := s.Write(stemPremix(0.1, 0.1, 0.1, 0.1)); !errors.Is(err, ErrTooManyStems) {
+		t.Fatalf("expected too many stems to be rejected, got %v", err)
+	}
+}
+
+func TestStemWriterRejectsMixedPremix(t *testing.T) {
+	s := NewStemFiles(44100, 2, sampling.Format16BitLESigned, nil)
+	p := stemPremix(0.5)
+	p.Channels = nil
+	if err := s.Write(p); !errors.Is(err, ErrNotStems) {
+		t.Fatalf("expected premix data without stems to be rejected, got %v", err)
+	}
+}
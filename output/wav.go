@@ -0,0 +1,184 @@
+package output
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+
+	"github.com/gotracker/playback/mixing/sampling"
+)
+
+var (
+	// ErrUnsupportedWAVFormat is returned when a sample format can't be stored in a WAV file
+	ErrUnsupportedWAVFormat = errors.New("sample format not supported by WAV")
+	// ErrWAVTooLarge is returned when the audio written doesn't fit in a WAV file
+	ErrWAVTooLarge = errors.New("audio data too large for WAV")
+)
+
+const (
+	wavFormatPCM        = 0x0001
+	wavFormatFloat      = 0x0003
+	wavFormatExtensible = 0xFFFE
+)
+
+// the tail of the KSDATAFORMAT_SUBTYPE_* GUIDs; the first 2 bytes are the format tag
+var wavSubFormatGUIDTail = [14]byte{0x00, 0x00, 0x00, 0x00, 0x10, 0x00, 0x80, 0x00, 0x00, 0xAA, 0x00, 0x38, 0x9B, 0x71}
+
+type wavFormatInfo struct {
+	tag       uint16
+	container int // bits per sample as stored
+	valid     int // significant bits per sample
+}
+
+var wavFormats = map[sampling.Format]wavFormatInfo{
+	sampling.Format8BitUnsigned:      {wavFormatPCM, 8, 8},
+	sampling.Format16BitLESigned:     {wavFormatPCM, 16, 16},
+	sampling.Format24BitLESigned:     {wavFormatPCM, 24, 24},
+	sampling.Format24In32BitLESigned: {wavFormatPCM, 32, 24},
+	sampling.Format32BitLESigned:     {wavFormatPCM, 32, 32},
+	sampling.Format32BitLEFloat:      {wavFormatFloat, 32, 32},
+	sampling.Format64BitLEFloat:      {wavFormatFloat, 64, 64},
+}
+
+// wavChannelMask returns the speaker positions of the output layouts the mixer supports
+func wavChannelMask(channels int) uint32 {
+	switch channels {
+	case 1:
+		return 0x4 // front center
+	case 2:
+		return 0x3 // front left, front right
+	case 4:
+		return 0x33 // front left, front right, back left, back right
+	case 6:
+		return 0x3F // 5.1
+	case 8:
+		return 0x63F // 7.1
+	default:
+		return 0
+	}
+}
+
+// WAVWriter writes PCM audio to a RIFF WAVE stream. The sizes in the header are only known
+// once all the audio has been written, so Close fills them in, which is why the stream has to
+// be seekable.
+type WAVWriter struct {
+	w          io.WriteSeeker
+	start      int64 // offset of the RIFF header
+	sizeOfs    int64 // offset of the data chunk's size field
+	dataLen    int64
+	blockAlign int
+}
+
+// NewWAVWriter writes the header of a WAV file holding audio of the format provided to `w`
+// and returns a WAVWriter for the audio data
+func NewWAVWriter(w io.WriteSeeker, sampleRate, channels int, format sampling.Format) (*WAVWriter, error) {
+	info, ok := wavFormats[format]
+	if !ok {
+		return nil, ErrUnsupportedWAVFormat
+	}
+	if channels <= 0 || channels > math.MaxUint16 {
+		return nil, errors.New("invalid number of channels")
+	}
+
+	blockAlign := channels * info.container / 8
+	extensible := channels > 2 || info.container > 16 || info.valid != info.container
+
+	var fmtChunk []byte
+	tag := info.tag
+	if extensible {
+		tag = wavFormatExtensible
+	}
+	fmtChunk = binary.LittleEndian.AppendUint16(fmtChunk, tag)
+	fmtChunk = binary.LittleEndian.AppendUint16(fmtChunk, uint16(channels))
+	fmtChunk = binary.LittleEndian.AppendUint32(fmtChunk, uint32(sampleRate))
+	fmtChunk = binary.LittleEndian.AppendUint32(fmtChunk, uint32(sampleRate*blockAlign))
+	fmtChunk = binary.LittleEndian.AppendUint16(fmtChunk, uint16(blockAlign))
+	fmtChunk = binary.LittleEndian.AppendUint16(fmtChunk, uint16(info.container))
+	switch {
+	case extensible:
+		fmtChunk = binary.LittleEndian.AppendUint16(fmtChunk, 22)
+		fmtChunk = binary.LittleEndian.AppendUint16(fmtChunk, uint16(info.valid))
+		fmtChunk = binary.LittleEndian.AppendUint32(fmtChunk, wavChannelMask(channels))
+		fmtChunk = binary.LittleEndian.AppendUint16(fmtChunk, info.tag)
+		fmtChunk = append(fmtChunk, wavSubFormatGUIDTail[:]...)
+	case info.tag != wavFormatPCM:
+		// formats other than PCM carry the (empty) extension size
+		fmtChunk = binary.LittleEndian.AppendUint16(fmtChunk, 0)
+	}
+
+	var header []byte
+	header = append(header, "RIFF"...)
+	header = binary.LittleEndian.AppendUint32(header, 0) // filled in by Close
+	header = append(header, "WAVE"...)
+	header = append(header, "fmt "...)
+	header = binary.LittleEndian.AppendUint32(header, uint32(len(fmtChunk)))
+	header = append(header, fmtChunk...)
+	header = append(header, "data"...)
+	header = binary.LittleEndian.AppendUint32(header, 0) // filled in by Close
+
+	start, err := w.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(header); err != nil {
+		return nil, err
+	}
+
+	return &WAVWriter{
+		w:          w,
+		start:      start,
+		sizeOfs:    start + int64(len(header)) - 4,
+		blockAlign: blockAlign,
+	}, nil
+}
+
+// BlockAlign returns the size of a sample frame (one sample for every channel) in bytes
+func (w *WAVWriter) BlockAlign() int {
+	return w.blockAlign
+}
+
+// Write writes audio data, already in the format of the file
+func (w *WAVWriter) Write(data []byte) (int, error) {
+	n, err := w.w.Write(data)
+	w.dataLen += int64(n)
+	return n, err
+}
+
+// Close fills in the sizes in the header. It doesn't close the underlying stream.
+func (w *WAVWriter) Close() error {
+	if w.dataLen%2 != 0 {
+		// chunks are padded to an even size
+		if _, err := w.w.Write([]byte{0}); err != nil {
+			return err
+		}
+	}
+	end, err := w.w.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return err
+	}
+	if end-w.start-8 > math.MaxUint32 {
+		return ErrWAVTooLarge
+	}
+
+	sizes := []struct {
+		ofs  int64
+		size int64
+	}{
+		{w.start + 4, end - w.start - 8},
+		{w.sizeOfs, w.dataLen},
+	}
+	for _, s := range sizes {
+		var b [4]byte
+		binary.LittleEndian.PutUint32(b[:], uint32(s.size))
+		if _, err := w.w.Seek(s.ofs, io.SeekStart); err != nil {
+			return err
+		}
+		if _, err := w.w.Write(b[:]); err != nil {
+			return err
+		}
+	}
+
+	_, err = w.w.Seek(end, io.SeekStart)
+	return err
+}
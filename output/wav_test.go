@@ -0,0 +1,130 @@
+package output
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/gotracker/playback/mixing/sampling"
+)
+
+// memFile is an in-memory io.WriteSeeker
+type memFile struct {
+	data []byte
+	pos  int64
+}
+
+func (m *memFile) Write(p []byte) (int, error) {
+	if end := int(m.pos) + len(p); end > len(m.data) {
+		m.data = append(m.data, make([]byte, end-len(m.data))...)
+	}
+	copy(m.data[m.pos:], p)
+	m.pos += int64(len(p))
+	return len(p), nil
+}
+
+func (m *memFile) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekStart:
+		m.pos = offset
+	case io.SeekCurrent:
+		m.pos += offset
+	case io.SeekEnd:
+		m.pos = int64(len(m.data)) + offset
+	}
+	return m.pos, nil
+}
+
+func TestWAVWriterPCMHeader(t *testing.T) {
+	var f memFile
+	w, err := NewWAVWriter(&f, 44100, 2, sampling.Format16BitLESigned)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if w.BlockAlign() != 4 {
+		t.Fatalf("expected a block align of 4, got %d", w.BlockAlign())
+	}
+	if _, err := w.Write([]byte{1, 2, 3, 4, 5, 6, 7, 8}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	d := f.data
+	if string(d[0:4]) != "RIFF" || string(d[8:12]) != "WAVE" || string(d[12:16]) != "fmt " {
+		t.Fatalf("unexpected header: %q", d[:16])
+	}
+	if size := binary.LittleEndian.Uint32(d[4:]); int(size) != len(d)-8 {
+		t.Fatalf("unexpected RIFF size: %d (file is %d bytes)", size, len(d))
+	}
+	if fmtSize := binary.LittleEndian.Uint32(d[16:]); fmtSize != 16 {
+		t.Fatalf("expected a plain PCM format chunk, got size %d", fmtSize)
+	}
+	if tag := binary.LittleEndian.Uint16(d[20:]); tag != wavFormatPCM {
+		t.Fatalf("expected PCM, got tag %#x", tag)
+	}
+	if ch, rate, bits := binary.LittleEndian.Uint16(d[22:]), binary.LittleEndian.Uint32(d[24:]), binary.LittleEndian.Uint16(d[34:]); ch != 2 || rate != 44100 || bits != 16 {
+		t.Fatalf("unexpected format: %d channels, %dHz, %d bits", ch, rate, bits)
+	}
+	if string(d[36:40]) != "data" || binary.LittleEndian.Uint32(d[40:]) != 8 || len(d) != 52 {
+		t.Fatalf("unexpected data chunk: %v", d[36:])
+	}
+}
+
+func TestWAVWriterExtensibleHeader(t *testing.T) {
+	var f memFile
+	w, err := NewWAVWriter(&f, 48000, 6, sampling.Format24In32BitLESigned)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := w.Write(make([]byte, 24)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	d := f.data
+	if fmtSize := binary.LittleEndian.Uint32(d[16:]); fmtSize != 40 {
+		t.Fatalf("expected an extensible format chunk, got size %d", fmtSize)
+	}
+	if tag := binary.LittleEndian.Uint16(d[20:]); tag != wavFormatExtensible {
+		t.Fatalf("expected the extensible format, got tag %#x", tag)
+	}
+	if bits, valid := binary.LittleEndian.Uint16(d[34:]), binary.LittleEndian.Uint16(d[38:]); bits != 32 || valid != 24 {
+		t.Fatalf("expected 24 bits in 32, got %d in %d", valid, bits)
+	}
+	if mask := binary.LittleEndian.Uint32(d[40:]); mask != 0x3F {
+		t.Fatalf("expected the 5.1 channel mask, got %#x", mask)
+	}
+	if sub := binary.LittleEndian.Uint16(d[44:]); sub != wavFormatPCM {
+		t.Fatalf("expected the PCM sub-format, got %#x", sub)
+	}
+	if string(d[60:64]) != "data" || binary.LittleEndian.Uint32(d[64:]) != 24 {
+		t.Fatalf("unexpected data chunk: %v", d[60:])
+	}
+}
+
+func TestWAVWriterPadsOddData(t *testing.T) {
+	var f memFile
+	w, _ := NewWAVWriter(&f, 8000, 1, sampling.Format8BitUnsigned)
+	w.Write([]byte{0x80, 0x81, 0x82})
+	if err := w.Close(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(f.data) != 44+4 || binary.LittleEndian.Uint32(f.data[40:]) != 3 {
+		t.Fatalf("expected 3 bytes of data padded to 4, got %d bytes", len(f.data)-44)
+	}
+	if size := binary.LittleEndian.Uint32(f.data[4:]); int(size) != len(f.data)-8 {
+		t.Fatalf("unexpected RIFF size: %d", size)
+	}
+}
+
+func TestWAVWriterUnsupportedFormat(t *testing.T) {
+	var f memFile
+	if _, err := NewWAVWriter(&f, 44100, 2, sampling.Format16BitBESigned); !errors.Is(err, ErrUnsupportedWAVFormat) {
+		t.Fatalf("expected big-endian data to be rejected, got %v", err)
+	}
+}
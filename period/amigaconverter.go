@@ -79,6 +79,42 @@ func (c AmigaConverter) AddDelta(p Amiga, delta Delta) (Amiga, error) {
 	return p.Add(delta, c.MinPeriod, c.MaxPeriod, c.SlideTo0Allowed), nil
 }
 
+// GetDelta returns the delta which, when added to `from`, results in `to`
+func (c AmigaConverter) GetDelta(from Amiga, to Amiga) Delta {
+	return Delta(int(from) - int(to))
+}
+
+// RoundToSemitone returns the period of the semitone nearest to `p`
+func (c AmigaConverter) RoundToSemitone(p Amiga) Amiga {
+	if p == 0 {
+		return p
+	}
+
+	best := p
+	bestDist := -1
+	for octave := 0; octave < 16; octave++ {
+		for key := 0; key < note.NumKeys; key++ {
+			keyPeriod, valid := c.System.GetSemitonePeriod(note.Key(key))
+			if !valid {
+				continue
+			}
+			sp := Amiga(keyPeriod) >> octave
+			if sp == 0 {
+				continue
+			}
+			dist := int(p) - int(sp)
+			if dist < 0 {
+				dist = -dist
+			}
+			if bestDist < 0 || dist < bestDist {
+				best = sp
+				bestDist = dist
+			}
+		}
+	}
+	return best
+}
+
 func (c AmigaConverter) clamp(p Amiga) Amiga {
 	return min(max(p, c.MinPeriod), c.MaxPeriod)
 }
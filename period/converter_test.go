@@ -3,6 +3,7 @@ package period
 import (
 	"testing"
 
+	"github.com/gotracker/playback/frequency"
 	"github.com/gotracker/playback/note"
 	"github.com/gotracker/playback/system"
 )
@@ -77,3 +78,92 @@ func TestAmigaConverterSamplerAddAndPeriod(t *testing.T) {
 		t.Fatalf("expected zero sampler add for invalid period")
 	}
 }
+
+func TestLinearConverterRoundToSemitone(t *testing.T) {
+	sys := system.ClockedSystem{
+		FinetunesPerOctave: 192,
+		FinetunesPerNote:   16,
+	}
+	conv := LinearConverter{System: sys}
+
+	cases := []struct {
+		in, want note.Finetune
+	}{
+		{in: 16 * 40, want: 16 * 40},
+		{in: 16*40 + 7, want: 16 * 40},
+		{in: 16*40 + 8, want: 16 * 41},
+		{in: 16*40 - 5, want: 16 * 40},
+		{in: 3, want: 16},
+		{in: 0, want: 0},
+	}
+	for _, c := range cases {
+		got := conv.RoundToSemitone(Linear{Finetune: c.in})
+		if got.Finetune != c.want {
+			t.Fatalf("RoundToSemitone(%d) = %d, want %d", c.in, got.Finetune, c.want)
+		}
+	}
+
+	from := Linear{Finetune: 16*40 + 7}
+	to := conv.RoundToSemitone(from)
+	if p, _ := conv.AddDelta(from, conv.GetDelta(from, to)); p != to {
+		t.Fatalf("expected delta to reach rounded period: got %v want %v", p, to)
+	}
+}
+
+func TestAmigaConverterRoundToSemitone(t *testing.T) {
+	sys := system.ClockedSystem{
+		SemitonePeriods: [note.NumKeys]uint16{1712, 1616, 1524, 1440, 1356, 1280, 1208, 1140, 1076, 1016, 960, 906},
+	}
+	conv := AmigaConverter{System: sys, MinPeriod: 1, MaxPeriod: 0xFFFF}
+
+	cases := []struct {
+		in, want Amiga
+	}{
+		{in: 428, want: 428}, // C-3
+		{in: 420, want: 428},
+		{in: 412, want: 404}, // C#-3
+		{in: 1700, want: 1712},
+		{in: 0, want: 0},
+	}
+	for _, c := range cases {
+		if got := conv.RoundToSemitone(c.in); got != c.want {
+			t.Fatalf("RoundToSemitone(%d) = %d, want %d", c.in, got, c.want)
+		}
+	}
+
+	from := Amiga(420)
+	to := conv.RoundToSemitone(from)
+	if p, _ := conv.AddDelta(from, conv.GetDelta(from, to)); p != to {
+		t.Fatalf("expected delta to reach rounded period: got %v want %v", p, to)
+	}
+}
+
+type stubFrequencyTable struct{ last int }
+
+func (s *stubFrequencyTable) GetFrequency(finetunes int) frequency.Frequency {
+	s.last = finetunes
+	return 3
+}
+
+func TestLinearConverterFrequencyTable(t *testing.T) {
+	sys := system.ClockedSystem{
+		BaseFinetunes:      4,
+		FinetunesPerOctave: 12,
+		FinetunesPerNote:   1,
+	}
+	table := &stubFrequencyTable{}
+	conv := LinearConverter{System: sys, Table: table, UseTable: true}
+
+	if freq := conv.GetFrequency(Linear{Finetune: 16}); freq != 3 || table.last != 12 {
+		t.Fatalf("expected the table to be used relative to the base finetune: freq=%v finetunes=%d", freq, table.last)
+	}
+
+	var sel FrequencyTableSelector[Linear] = conv
+	calc := sel.WithFrequencyTable(false)
+	if freq := calc.GetFrequency(Linear{Finetune: 16}); freq != 2 {
+		t.Fatalf("expected the frequency to be calculated with the table disabled, got %v", freq)
+	}
+	if !conv.UseTable {
+		t.Fatalf("expected the original converter to be left alone")
+	}
+}
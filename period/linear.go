@@ -5,10 +5,25 @@ import (
 
 	"github.com/heucuva/comparison"
 
+	"github.com/gotracker/playback/frequency"
 	"github.com/gotracker/playback/note"
 	"github.com/gotracker/playback/util"
 )
 
+// LinearFrequencyTable converts linear periods into frequencies through a precomputed lookup
+// table, the way the trackers that use linear slides do, instead of calculating a power of 2
+type LinearFrequencyTable interface {
+	// GetFrequency returns the frequency multiplier of the period `finetunes` away from the
+	// system's base finetune
+	GetFrequency(finetunes int) frequency.Frequency
+}
+
+// FrequencyTableSelector is a PeriodConverter that can switch between converting periods into
+// frequencies through a lookup table and calculating them
+type FrequencyTableSelector[TPeriod Period] interface {
+	WithFrequencyTable(enabled bool) PeriodConverter[TPeriod]
+}
+
 // Linear is a linear period, based on semitone and finetune values
 type Linear struct {
 	Finetune note.Finetune
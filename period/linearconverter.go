@@ -12,9 +12,16 @@ import (
 // definition. Useful in calculating resampling.
 type LinearConverter struct {
 	System system.ClockableSystem
+	// Table is the tracker's lookup table for converting periods into frequencies
+	Table LinearFrequencyTable
+	// UseTable has frequencies come from the Table instead of being calculated directly
+	UseTable bool
 }
 
-var _ PeriodConverter[Linear] = (*LinearConverter)(nil)
+var (
+	_ PeriodConverter[Linear]        = (*LinearConverter)(nil)
+	_ FrequencyTableSelector[Linear] = (*LinearConverter)(nil)
+)
 
 func (c LinearConverter) GetSystem() system.System {
 	return c.System
@@ -30,11 +37,20 @@ func (c LinearConverter) GetFrequency(p Linear) frequency.Frequency {
 	if p.Finetune == 0 {
 		return 0
 	}
+	if c.UseTable && c.Table != nil {
+		return c.Table.GetFrequency(int(p.Finetune - c.System.GetBaseFinetunes()))
+	}
 	pft := float64(p.Finetune-c.System.GetBaseFinetunes()) / float64(c.System.GetFinetunesPerOctave())
 	f := frequency.Frequency(math.Pow(2.0, pft))
 	return f
 }
 
+// WithFrequencyTable returns a copy of the converter that does (or does not) use the lookup table
+func (c LinearConverter) WithFrequencyTable(enabled bool) PeriodConverter[Linear] {
+	c.UseTable = enabled
+	return c
+}
+
 func (c LinearConverter) GetPeriod(n note.Note) Linear {
 	switch n.Type() {
 	case note.SpecialTypeEmpty, note.SpecialTypeRelease, note.SpecialTypeStop, note.SpecialTypeStopOrRelease:
@@ -66,3 +82,25 @@ func (c LinearConverter) PortaUp(p Linear, delta Delta) (Linear, error) {
 func (c LinearConverter) AddDelta(p Linear, delta Delta) (Linear, error) {
 	return p.Add(delta), nil
 }
+
+// GetDelta returns the delta which, when added to `from`, results in `to`
+func (c LinearConverter) GetDelta(from Linear, to Linear) Delta {
+	return Delta(int(to.Finetune) - int(from.Finetune))
+}
+
+// RoundToSemitone returns the period of the semitone nearest to `p`
+func (c LinearConverter) RoundToSemitone(p Linear) Linear {
+	fps := int(c.System.GetFinetunesPerSemitone())
+	if p.Finetune == 0 || fps <= 0 {
+		return p
+	}
+
+	st := (int(p.Finetune) + fps/2) / fps
+	if st == 0 {
+		// 0 means "not playing", so stay on the lowest semitone instead
+		st = 1
+	}
+	return Linear{
+		Finetune: note.Finetune(st * fps),
+	}
+}
@@ -14,6 +14,8 @@ type PeriodConverter[TPeriod Period] interface {
 	PortaDown(TPeriod, Delta) (TPeriod, error)
 	PortaUp(TPeriod, Delta) (TPeriod, error)
 	AddDelta(TPeriod, Delta) (TPeriod, error)
+	GetDelta(from TPeriod, to TPeriod) Delta
+	RoundToSemitone(TPeriod) TPeriod
 
 	GetSamplerAdd(TPeriod, frequency.Frequency, frequency.Frequency) float64
 	GetFrequency(TPeriod) frequency.Frequency
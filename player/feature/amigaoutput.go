@@ -0,0 +1,10 @@
+package feature
+
+import "github.com/gotracker/playback/filter"
+
+// AmigaOutput selects Amiga-accurate output for MOD and S3M songs: samples are played back
+// as Paula does, with band-limited steps at Paula's clock, and the output goes through the
+// filters of the selected model, including the "LED" filter switched by E0x (S0x)
+type AmigaOutput struct {
+	Model filter.AmigaModel
+}
@@ -0,0 +1,10 @@
+package feature
+
+import "github.com/gotracker/playback/player/metering"
+
+// Metering is a setting for receiving the levels, waveform and playing state of each channel
+// every tick, for VU meters and oscilloscopes
+type Metering struct {
+	Handler      func(tick metering.Tick)
+	ScopeSamples int // 0 = metering.DefaultScopeSamples
+}
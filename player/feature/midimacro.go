@@ -0,0 +1,8 @@
+package feature
+
+import "github.com/gotracker/playback/index"
+
+// MIDIMacroHandler is a setting for receiving MIDI macro messages that are not handled internally by the player
+type MIDIMacroHandler struct {
+	Handler func(ch index.Channel, msg []byte)
+}
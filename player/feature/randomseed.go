@@ -0,0 +1,7 @@
+package feature
+
+// RandomSeed is a setting for seeding the random number generator used by the player,
+// which is useful for producing reproducible renders
+type RandomSeed struct {
+	Seed int64
+}
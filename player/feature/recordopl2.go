@@ -0,0 +1,8 @@
+package feature
+
+import "github.com/gotracker/playback/player/oplrecord"
+
+// RecordOPL2 is a setting for capturing the OPL2 register stream into a recorder
+type RecordOPL2 struct {
+	Recorder *oplrecord.Recorder
+}
@@ -9,6 +9,7 @@ import (
 	"github.com/gotracker/playback/memory"
 	"github.com/gotracker/playback/mixing/sampling"
 	"github.com/gotracker/playback/note"
+	"github.com/gotracker/playback/period"
 	"github.com/gotracker/playback/player/machine/instruction"
 	"github.com/gotracker/playback/song"
 	"github.com/gotracker/playback/voice"
@@ -37,16 +38,19 @@ type channel[TPeriod Period, TGlobalVolume, TMixingVolume, TVolume Volume, TPann
 		Pos         optional.Value[sampling.Pos]
 		ActionTick  optional.Value[ActionTick]
 		TriggerNNA  bool
+		Semitone    note.Semitone
 	}
+	playing noteIdent // the note playing on the foreground voice
 	newNote NewNoteInfo[TPeriod, TMixingVolume, TVolume, TPanning]
 
 	surround      bool
 	filter        filter.Filter
 	filterEnabled bool
 	nna           note.Action
+	glissando     period.Delta // the part of the period delta the glissando snapped on this tick
 
 	cv        voice.RenderVoice[TPeriod, TGlobalVolume, TMixingVolume, TVolume, TPanning]
-	pastNotes []index.Channel
+	pastNotes []pastNoteInfo
 
 	instructions []instruction.Instruction
 }
@@ -0,0 +1,93 @@
+package machine
+
+import (
+	"github.com/heucuva/optional"
+
+	"github.com/gotracker/playback/index"
+	"github.com/gotracker/playback/instrument"
+	"github.com/gotracker/playback/voice"
+)
+
+// envelopeCarry holds the envelope positions of the outgoing note that the next note resumes from
+type envelopeCarry struct {
+	vol       optional.Value[int]
+	pan       optional.Value[int]
+	pitchFilt optional.Value[int]
+}
+
+// captureEnvelopeCarry records the envelope positions of the active voice for each envelope
+// of the incoming instrument that has the carry flag set
+func (c *channel[TPeriod, TGlobalVolume, TMixingVolume, TVolume, TPanning]) captureEnvelopeCarry() envelopeCarry {
+	var ec envelopeCarry
+	if c.cv == nil || c.cv.IsDone() || c.target.Inst == nil {
+		return ec
+	}
+
+	d, ok := c.target.Inst.GetData().(*instrument.PCM[TMixingVolume, TVolume, TPanning])
+	if !ok {
+		return ec
+	}
+
+	if d.VolEnv.Carry {
+		if volEnv, ok := c.cv.(voice.VolumeEnvelope[TGlobalVolume, TMixingVolume, TVolume]); ok {
+			ec.vol.Set(volEnv.GetVolumeEnvelopePosition())
+		}
+	}
+
+	if d.PanEnv.Carry {
+		if panEnv, ok := c.cv.(voice.PanEnvelope[TPanning]); ok {
+			ec.pan.Set(panEnv.GetPanEnvelopePosition())
+		}
+	}
+
+	if d.PitchFiltEnv.Carry {
+		if d.PitchFiltMode {
+			if filterEnv, ok := c.cv.(voice.FilterEnvelope); ok {
+				ec.pitchFilt.Set(filterEnv.GetFilterEnvelopePosition())
+			}
+		} else if pitchEnv, ok := c.cv.(voice.PitchEnvelope[TPeriod]); ok {
+			ec.pitchFilt.Set(pitchEnv.GetPitchEnvelopePosition())
+		}
+	}
+
+	return ec
+}
+
+// applyEnvelopeCarry resumes the envelopes of the active voice from the carried positions
+func (c *channel[TPeriod, TGlobalVolume, TMixingVolume, TVolume, TPanning]) applyEnvelopeCarry(ch index.Channel, m *machine[TPeriod, TGlobalVolume, TMixingVolume, TVolume, TPanning], ec envelopeCarry) error {
+	if pos, set := ec.vol.Get(); set {
+		if volEnv, ok := c.cv.(voice.VolumeEnvelope[TGlobalVolume, TMixingVolume, TVolume]); ok {
+			traceChannelValueChangeWithComment(m, ch, "volEnv.Pos", volEnv.GetVolumeEnvelopePosition(), pos, "applyEnvelopeCarry")
+			if err := volEnv.SetVolumeEnvelopePosition(pos); err != nil {
+				return err
+			}
+		}
+	}
+
+	if pos, set := ec.pan.Get(); set {
+		if panEnv, ok := c.cv.(voice.PanEnvelope[TPanning]); ok {
+			traceChannelValueChangeWithComment(m, ch, "panEnv.Pos", panEnv.GetPanEnvelopePosition(), pos, "applyEnvelopeCarry")
+			if err := panEnv.SetPanEnvelopePosition(pos); err != nil {
+				return err
+			}
+		}
+	}
+
+	if pos, set := ec.pitchFilt.Get(); set {
+		// the voice only applies the position to whichever of the two envelopes is active
+		if pitchEnv, ok := c.cv.(voice.PitchEnvelope[TPeriod]); ok {
+			traceChannelValueChangeWithComment(m, ch, "pitchEnv.Pos", pitchEnv.GetPitchEnvelopePosition(), pos, "applyEnvelopeCarry")
+			if err := pitchEnv.SetPitchEnvelopePosition(pos); err != nil {
+				return err
+			}
+		}
+		if filterEnv, ok := c.cv.(voice.FilterEnvelope); ok {
+			traceChannelValueChangeWithComment(m, ch, "filterEnv.Pos", filterEnv.GetFilterEnvelopePosition(), pos, "applyEnvelopeCarry")
+			if err := filterEnv.SetFilterEnvelopePosition(pos); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,66 @@
+package machine
+
+import (
+	"testing"
+
+	"github.com/gotracker/playback/instrument"
+)
+
+type envelopeVoice struct {
+	actionVoice
+	volPos int
+	panPos int
+}
+
+func (v *envelopeVoice) Setup(*instrument.Instrument[stubPeriod, stubGV, stubGV, stubPan]) error {
+	return nil
+}
+func (v *envelopeVoice) IsDone() bool                          { return false }
+func (v *envelopeVoice) IsVolumeEnvelopeEnabled() bool         { return true }
+func (v *envelopeVoice) EnableVolumeEnvelope(bool) error       { return nil }
+func (v *envelopeVoice) GetVolumeEnvelopePosition() int        { return v.volPos }
+func (v *envelopeVoice) SetVolumeEnvelopePosition(p int) error { v.volPos = p; return nil }
+func (v *envelopeVoice) GetCurrentVolumeEnvelope() stubGV      { return 1 }
+func (v *envelopeVoice) IsPanEnvelopeEnabled() bool            { return true }
+func (v *envelopeVoice) EnablePanEnvelope(bool) error          { return nil }
+func (v *envelopeVoice) GetPanEnvelopePosition() int           { return v.panPos }
+func (v *envelopeVoice) SetPanEnvelopePosition(p int) error    { v.panPos = p; return nil }
+func (v *envelopeVoice) GetCurrentPanEnvelope() stubPan        { return 0 }
+
+func TestEnvelopeCarryResumesFlaggedEnvelopes(t *testing.T) {
+	m := machine[stubPeriod, stubGV, stubGV, stubGV, stubPan]{}
+	var c channel[stubPeriod, stubGV, stubGV, stubGV, stubPan]
+
+	v := &envelopeVoice{volPos: 12, panPos: 7}
+	c.cv = v
+
+	pcm := &instrument.PCM[stubGV, stubGV, stubPan]{}
+	pcm.VolEnv.Carry = true
+	c.target.Inst = &instrument.Instrument[stubPeriod, stubGV, stubGV, stubPan]{Inst: pcm}
+
+	carry := c.captureEnvelopeCarry()
+
+	// a new note restarts the envelopes
+	v.volPos = 0
+	v.panPos = 0
+
+	if err := c.applyEnvelopeCarry(0, &m, carry); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if v.volPos != 12 {
+		t.Fatalf("expected volume envelope to resume at 12, got %d", v.volPos)
+	}
+	if v.panPos != 0 {
+		t.Fatalf("expected pan envelope without carry to restart, got %d", v.panPos)
+	}
+}
+
+func TestEnvelopeCarryIgnoredWithoutInstrument(t *testing.T) {
+	var c channel[stubPeriod, stubGV, stubGV, stubGV, stubPan]
+	c.cv = &envelopeVoice{volPos: 12}
+
+	carry := c.captureEnvelopeCarry()
+	if carry.vol.IsSet() || carry.pan.IsSet() || carry.pitchFilt.IsSet() {
+		t.Fatalf("expected nothing to be carried without an instrument")
+	}
+}
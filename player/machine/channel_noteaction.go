@@ -1,8 +1,13 @@
 package machine
 
 import (
+	"github.com/heucuva/optional"
+
+	"github.com/gotracker/playback/filter"
 	"github.com/gotracker/playback/index"
+	"github.com/gotracker/playback/instrument"
 	"github.com/gotracker/playback/note"
+	"github.com/gotracker/playback/song"
 	"github.com/gotracker/playback/voice"
 )
 
@@ -25,10 +30,13 @@ func (c *channel[TPeriod, TGlobalVolume, TMixingVolume, TVolume, TPanning]) DoNo
 	// perform new note action
 	if c.target.TriggerNNA && m.canPastNote() {
 		c.target.TriggerNNA = false
+
 		var pn voice.Voice
 		switch c.nna {
 		case note.ActionCut:
 			c.cv.Stop()
+		case note.ActionContinue:
+			pn = c.cv.Clone(true)
 		case note.ActionRelease:
 			pn = c.cv.Clone(true)
 			pn.Release()
@@ -41,29 +49,37 @@ func (c *channel[TPeriod, TGlobalVolume, TMixingVolume, TVolume, TPanning]) DoNo
 			pn.Release()
 			pn.Attack()
 
-		case note.ActionContinue:
-			fallthrough
 		default:
 			// nothing
 		}
 
 		if pn != nil {
-			c.addPastNote(m, pn.(voice.RenderVoice[TPeriod, TGlobalVolume, TMixingVolume, TVolume, TPanning]))
+			c.addPastNote(m, pn.(voice.RenderVoice[TPeriod, TGlobalVolume, TMixingVolume, TVolume, TPanning]), c.playing)
+		}
+
+		// the outgoing note is one of the past notes by now, so it's checked along with them
+		if na.Action == note.ActionRetrigger {
+			c.doDuplicateCheck(m)
 		}
 	}
 
 	switch na.Action {
 	case note.ActionCut:
 		c.cv.Stop()
+		eventCut(m, ch)
 		return nil
 
 	case note.ActionRelease:
 		c.cv.Release()
+		eventNoteOff(m, ch)
 
 	case note.ActionFadeout:
 		c.cv.Fadeout()
+		eventFadeout(m, ch)
 
 	case note.ActionRetrigger:
+		carry := c.captureEnvelopeCarry()
+
 		c.cv.Release()
 
 		if err := c.doSetupInstrument(ch, m); err != nil {
@@ -80,6 +96,18 @@ func (c *channel[TPeriod, TGlobalVolume, TMixingVolume, TVolume, TPanning]) DoNo
 
 		c.cv.Attack()
 
+		if err := c.applyEnvelopeCarry(ch, m, carry); err != nil {
+			return err
+		}
+
+		c.playing = noteIdent{
+			Semitone: c.target.Semitone,
+		}
+		if c.target.Inst != nil {
+			c.playing.ID = c.target.Inst.GetID()
+			eventNoteOn(m, ch, c.playing.ID, c.playing.Semitone)
+		}
+
 	case note.ActionContinue:
 		fallthrough
 	default:
@@ -119,9 +147,66 @@ func (c *channel[TPeriod, TGlobalVolume, TMixingVolume, TVolume, TPanning]) doSe
 			if err := c.cv.Setup(inst); err != nil {
 				return err
 			}
+
+			// a new instrument brings a new filter, but what an effect set on the channel stays,
+			// unless the instrument sets it itself
+			if err := c.restoreFilterMemory(ch, m, inst); err != nil {
+				return err
+			}
 		}
 	} else {
 		c.cv.Stop()
 	}
 	return nil
 }
+
+func (c *channel[TPeriod, TGlobalVolume, TMixingVolume, TVolume, TPanning]) restoreFilterMemory(ch index.Channel, m *machine[TPeriod, TGlobalVolume, TMixingVolume, TVolume, TPanning], inst *instrument.Instrument[TPeriod, TMixingVolume, TVolume, TPanning]) error {
+	fm, ok := c.memory.(song.FilterMemory)
+	if !ok {
+		return nil
+	}
+
+	// the cutoff and resonance the instrument enables replace what the channel remembers
+	instCutoff, instResonance := getInstrumentFilterValues(inst)
+	if cutoff, set := instCutoff.Get(); set {
+		fm.SetFilterCutoff(cutoff)
+	}
+	if resonance, set := instResonance.Get(); set {
+		fm.SetFilterResonance(resonance)
+	}
+
+	filtMod, ok := c.cv.(voice.FilterModulator)
+	if !ok {
+		return nil
+	}
+
+	if cutoff, set := fm.FilterCutoff().Get(); set && !instCutoff.IsSet() {
+		traceChannelWithComment(m, ch, "restoreFilterMemory", "cutoff=%d", cutoff)
+		if err := filtMod.SetFilterCutoff(cutoff); err != nil {
+			return err
+		}
+	}
+
+	if resonance, set := fm.FilterResonance().Get(); set && !instResonance.IsSet() {
+		traceChannelWithComment(m, ch, "restoreFilterMemory", "resonance=%d", resonance)
+		if err := filtMod.SetFilterResonance(resonance); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// getInstrumentFilterValues returns the filter cutoff and resonance an instrument enables, if any
+func getInstrumentFilterValues[TPeriod Period, TMixingVolume, TVolume Volume, TPanning Panning](inst *instrument.Instrument[TPeriod, TMixingVolume, TVolume, TPanning]) (cutoff, resonance optional.Value[uint8]) {
+	p, ok := inst.GetVoiceFilterInfo().Params.(filter.ITResonantFilterParams)
+	if !ok {
+		return
+	}
+	if p.Cutoff&0x80 != 0 {
+		cutoff.Set(p.Cutoff & 0x7F)
+	}
+	if p.Resonance&0x80 != 0 {
+		resonance.Set(p.Resonance & 0x7F)
+	}
+	return
+}
@@ -0,0 +1,115 @@
+package machine
+
+import (
+	"testing"
+
+	"github.com/heucuva/optional"
+
+	"github.com/gotracker/playback/filter"
+	"github.com/gotracker/playback/frequency"
+	"github.com/gotracker/playback/instrument"
+	"github.com/gotracker/playback/note"
+	"github.com/gotracker/playback/oscillator"
+	"github.com/gotracker/playback/player/machine/settings"
+	"github.com/gotracker/playback/player/render"
+)
+
+// filterMemory is channel memory that keeps the filter settings an effect set, as IT's does
+type filterMemory struct {
+	stubChannelMemory
+	cutoff    optional.Value[uint8]
+	resonance optional.Value[uint8]
+}
+
+func (m *filterMemory) FilterCutoff() optional.Value[uint8]    { return m.cutoff }
+func (m *filterMemory) SetFilterCutoff(cutoff uint8)           { m.cutoff.Set(cutoff) }
+func (m *filterMemory) FilterResonance() optional.Value[uint8] { return m.resonance }
+func (m *filterMemory) SetFilterResonance(resonance uint8)     { m.resonance.Set(resonance) }
+
+// filterVoice is a voice whose filter is replaced by the instrument's every time it's set up
+type filterVoice struct {
+	jamVoice
+	cutoff    uint8
+	resonance uint8
+}
+
+func (v *filterVoice) Setup(inst *instrument.Instrument[stubPeriod, stubGV, stubGV, stubPan]) error {
+	v.cutoff, v.resonance = 0x7F, 0
+	if p, ok := inst.GetVoiceFilterInfo().Params.(filter.ITResonantFilterParams); ok {
+		if p.Cutoff&0x80 != 0 {
+			v.cutoff = p.Cutoff & 0x7F
+		}
+		if p.Resonance&0x80 != 0 {
+			v.resonance = p.Resonance & 0x7F
+		}
+	}
+	return v.jamVoice.Setup(inst)
+}
+func (v *filterVoice) SetFilterCutoff(cutoff uint8) error       { v.cutoff = cutoff; return nil }
+func (v *filterVoice) SetFilterResonance(resonance uint8) error { v.resonance = resonance; return nil }
+
+func TestDoNoteActionKeepsTheFilterMemory(t *testing.T) {
+	m := machine[stubPeriod, stubGV, stubGV, stubGV, stubPan]{
+		ms: &settings.MachineSettings[stubPeriod, stubGV, stubGV, stubGV, stubPan]{
+			GetFilterFactory: func(string, frequency.Frequency, any) (filter.Filter, error) {
+				return nil, nil
+			},
+		},
+		actualOutputs: make([]render.Channel[stubPeriod], 1),
+	}
+
+	mem := &filterMemory{}
+	cv := &filterVoice{}
+	c := channel[stubPeriod, stubGV, stubGV, stubGV, stubPan]{
+		memory: mem,
+		cv:     cv,
+	}
+	for i := range c.osc {
+		c.osc[i] = oscillator.NewProtrackerOscillator()
+	}
+
+	playNote := func(id int, voiceFilter filter.Info) {
+		t.Helper()
+		c.prev.Inst = c.target.Inst
+		c.target.Inst = &instrument.Instrument[stubPeriod, stubGV, stubGV, stubPan]{
+			Static: instrument.StaticValues[stubPeriod, stubGV, stubGV, stubPan]{ID: stubInstID{id, 0}, VoiceFilter: voiceFilter},
+			Inst:   &instrument.PCM[stubGV, stubGV, stubPan]{},
+		}
+		c.target.ActionTick.Set(ActionTick{Action: note.ActionRetrigger})
+		if err := c.DoNoteAction(0, &m); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	playNote(1, filter.Info{})
+	if cv.cutoff != 0x7F || cv.resonance != 0 {
+		t.Fatalf("expected the instrument's filter without a macro, got cutoff %02X resonance %02X", cv.cutoff, cv.resonance)
+	}
+
+	// Z20 and Z85 with the default macros set the voice's filter and keep it in the memory
+	mem.cutoff.Set(0x20)
+	mem.resonance.Set(0x28)
+	cv.cutoff, cv.resonance = 0x20, 0x28
+
+	playNote(2, filter.Info{})
+	if cv.cutoff != 0x20 || cv.resonance != 0x28 {
+		t.Fatalf("expected the new note to keep the macro filter, got cutoff %02X resonance %02X", cv.cutoff, cv.resonance)
+	}
+
+	// an instrument with IFC enabled (but not IFR) overrides the macro cutoff and becomes the memory
+	playNote(3, filter.Info{
+		Name:   "itresonant",
+		Params: filter.ITResonantFilterParams{Cutoff: 0x80 | 0x50, Resonance: 0x10},
+	})
+	if cv.cutoff != 0x50 || cv.resonance != 0x28 {
+		t.Fatalf("expected the instrument's cutoff and the macro resonance, got cutoff %02X resonance %02X", cv.cutoff, cv.resonance)
+	}
+	if cutoff, _ := mem.cutoff.Get(); cutoff != 0x50 {
+		t.Fatalf("expected the instrument's cutoff to be remembered, got %02X", cutoff)
+	}
+
+	playNote(4, filter.Info{})
+	if cv.cutoff != 0x50 || cv.resonance != 0x28 {
+		t.Fatalf("expected the next note to keep the instrument's cutoff, got cutoff %02X resonance %02X", cv.cutoff, cv.resonance)
+	}
+}
@@ -80,6 +80,7 @@ func (c *channel[TPeriod, TGlobalVolume, TMixingVolume, TVolume, TPanning]) deco
 		case note.Normal:
 			// perform remap
 			n = note.Normal(st)
+			changeNote.Semitone.Set(st)
 		}
 		if p := m.ConvertToPeriod(n); !p.IsInvalid() {
 			changeNote.Period.Set(p)
@@ -5,12 +5,25 @@ import (
 	"sort"
 
 	"github.com/gotracker/playback/index"
+	"github.com/gotracker/playback/instrument"
 	"github.com/gotracker/playback/mixing/volume"
 	"github.com/gotracker/playback/note"
 	"github.com/gotracker/playback/voice"
 )
 
-func (c *channel[TPeriod, TGlobalVolume, TMixingVolume, TVolume, TPanning]) addPastNote(m *machine[TPeriod, TGlobalVolume, TMixingVolume, TVolume, TPanning], pn voice.Voice) {
+// noteIdent identifies the note that started a voice
+type noteIdent struct {
+	ID       instrument.ID
+	Semitone note.Semitone
+}
+
+// pastNoteInfo is a background voice playing on a virtual output channel
+type pastNoteInfo struct {
+	noteIdent
+	ch index.Channel
+}
+
+func (c *channel[TPeriod, TGlobalVolume, TMixingVolume, TVolume, TPanning]) addPastNote(m *machine[TPeriod, TGlobalVolume, TMixingVolume, TVolume, TPanning], pn voice.Voice, ident noteIdent) {
 	// try to find an empty spot in output channels
 	type pnVolChan struct {
 		vol volume.Volume
@@ -26,7 +39,10 @@ func (c *channel[TPeriod, TGlobalVolume, TMixingVolume, TVolume, TPanning]) addP
 			rc.StartVoice(pn, func() {
 				c.removePastNote(m, ch)
 			})
-			c.pastNotes = append(c.pastNotes, ch)
+			c.pastNotes = append(c.pastNotes, pastNoteInfo{
+				noteIdent: ident,
+				ch:        ch,
+			})
 			return
 		}
 
@@ -59,47 +75,97 @@ func (c *channel[TPeriod, TGlobalVolume, TMixingVolume, TVolume, TPanning]) addP
 	rc.StartVoice(pn, func() {
 		c.removePastNote(m, lowest)
 	})
-	c.pastNotes = append(c.pastNotes, lowest)
+	c.pastNotes = append(c.pastNotes, pastNoteInfo{
+		noteIdent: ident,
+		ch:        lowest,
+	})
 }
 
 func (c *channel[TPeriod, TGlobalVolume, TMixingVolume, TVolume, TPanning]) removePastNote(m *machine[TPeriod, TGlobalVolume, TMixingVolume, TVolume, TPanning], ch index.Channel) {
-	c.pastNotes = slices.DeleteFunc(c.pastNotes, func(i index.Channel) bool {
-		return i == ch
+	c.pastNotes = slices.DeleteFunc(c.pastNotes, func(pn pastNoteInfo) bool {
+		return pn.ch == ch
 	})
 }
 
 func (c *channel[TPeriod, TGlobalVolume, TMixingVolume, TVolume, TPanning]) doPastNoteAction(m *machine[TPeriod, TGlobalVolume, TMixingVolume, TVolume, TPanning], na note.Action) {
-	for _, ch := range c.pastNotes {
-		rc := &m.virtualOutputs[ch]
-		v := rc.GetVoice()
-		if v == nil {
+	// stopping a voice removes it from the past notes, so work from a copy
+	for _, pn := range slices.Clone(c.pastNotes) {
+		m.doVirtualOutputAction(pn.ch, na)
+	}
+}
+
+// doDuplicateCheck performs the duplicate check action of the new note's instrument
+// on the background voices that duplicate the new note, including the note it takes over from
+// once the new note action has moved that one into the background
+func (c *channel[TPeriod, TGlobalVolume, TMixingVolume, TVolume, TPanning]) doDuplicateCheck(m *machine[TPeriod, TGlobalVolume, TMixingVolume, TVolume, TPanning]) {
+	inst := c.target.Inst
+	if inst == nil {
+		return
+	}
+
+	dct := inst.GetDuplicateCheckType()
+	if dct == note.DuplicateCheckTypeOff {
+		return
+	}
+
+	instIdx, sampIdx := inst.GetID().GetIndexAndSample()
+	dca := inst.GetDuplicateCheckAction()
+	for _, pn := range slices.Clone(c.pastNotes) {
+		if pn.ID == nil {
 			continue
 		}
 
-		switch na {
-		case note.ActionCut:
-			rc.StopVoice()
-		case note.ActionRelease:
-			v.Release()
-		case note.ActionFadeout:
-			v.Release()
-			v.Fadeout()
-		case note.ActionRetrigger:
-			v.Release()
-			v.Attack()
-
-		case note.ActionContinue:
-			fallthrough
-		default:
-			// nothing
+		pnInstIdx, pnSampIdx := pn.ID.GetIndexAndSample()
+		if pnInstIdx != instIdx {
+			continue
+		}
+
+		var dup bool
+		switch dct {
+		case note.DuplicateCheckTypeNote:
+			dup = pn.Semitone == c.target.Semitone
+		case note.DuplicateCheckTypeSample:
+			dup = pnSampIdx == sampIdx
+		case note.DuplicateCheckTypeInstrument:
+			dup = true
+		}
+
+		if dup {
+			m.doVirtualOutputAction(pn.ch, dca)
 		}
 	}
 }
 
+func (m *machine[TPeriod, TGlobalVolume, TMixingVolume, TVolume, TPanning]) doVirtualOutputAction(ch index.Channel, na note.Action) {
+	rc := &m.virtualOutputs[ch]
+	v := rc.GetVoice()
+	if v == nil {
+		return
+	}
+
+	switch na {
+	case note.ActionCut:
+		rc.StopVoice()
+	case note.ActionRelease:
+		v.Release()
+	case note.ActionFadeout:
+		v.Release()
+		v.Fadeout()
+	case note.ActionRetrigger:
+		v.Release()
+		v.Attack()
+
+	case note.ActionContinue:
+		fallthrough
+	default:
+		// nothing
+	}
+}
+
 func (c *channel[TPeriod, TGlobalVolume, TMixingVolume, TVolume, TPanning]) updatePastNotes(m *machine[TPeriod, TGlobalVolume, TMixingVolume, TVolume, TPanning]) {
-	var updated []index.Channel
-	for _, ch := range c.pastNotes {
-		rc := &m.virtualOutputs[ch]
+	var updated []pastNoteInfo
+	for _, pn := range slices.Clone(c.pastNotes) {
+		rc := &m.virtualOutputs[pn.ch]
 		v := rc.GetVoice()
 		if v == nil {
 			continue
@@ -110,7 +176,7 @@ func (c *channel[TPeriod, TGlobalVolume, TMixingVolume, TVolume, TPanning]) upda
 			continue
 		}
 
-		updated = append(updated, ch)
+		updated = append(updated, pn)
 	}
 	c.pastNotes = updated
 }
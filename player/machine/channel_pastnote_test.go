@@ -0,0 +1,188 @@
+package machine
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/gotracker/playback/index"
+	"github.com/gotracker/playback/instrument"
+	"github.com/gotracker/playback/note"
+	"github.com/gotracker/playback/oscillator"
+	"github.com/gotracker/playback/player/render"
+	"github.com/gotracker/playback/voice"
+)
+
+type stubInstID struct{ inst, samp int }
+
+func (s stubInstID) IsEmpty() bool                 { return false }
+func (s stubInstID) GetIndexAndSample() (int, int) { return s.inst, s.samp }
+func (s stubInstID) String() string                { return fmt.Sprintf("%d(%d)", s.inst, s.samp) }
+
+type actionVoice struct {
+	doneVoice
+	released bool
+	faded    bool
+	stopped  bool
+}
+
+func (v *actionVoice) Release()     { v.released = true }
+func (v *actionVoice) Fadeout()     { v.faded = true }
+func (v *actionVoice) Stop()        { v.stopped = true }
+func (v *actionVoice) IsDone() bool { return v.stopped }
+
+func TestDuplicateCheckAppliesToMatchingPastNotes(t *testing.T) {
+	tests := []struct {
+		name    string
+		dct     note.DuplicateCheckType
+		matches []bool
+	}{
+		{"off", note.DuplicateCheckTypeOff, []bool{false, false, false, false}},
+		{"note", note.DuplicateCheckTypeNote, []bool{true, false, true, false}},
+		{"sample", note.DuplicateCheckTypeSample, []bool{true, true, false, false}},
+		{"instrument", note.DuplicateCheckTypeInstrument, []bool{true, true, true, false}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := machine[stubPeriod, stubGV, stubGV, stubGV, stubPan]{
+				virtualOutputs: make([]render.Channel[stubPeriod], 4),
+			}
+			var c channel[stubPeriod, stubGV, stubGV, stubGV, stubPan]
+
+			idents := []noteIdent{
+				{ID: stubInstID{1, 2}, Semitone: 48}, // same note, same sample
+				{ID: stubInstID{1, 2}, Semitone: 50}, // same sample
+				{ID: stubInstID{1, 3}, Semitone: 48}, // same instrument and note
+				{ID: stubInstID{2, 2}, Semitone: 48}, // different instrument
+			}
+			voices := make([]*actionVoice, len(idents))
+			for i, ident := range idents {
+				voices[i] = &actionVoice{}
+				c.addPastNote(&m, voices[i], ident)
+			}
+
+			inst := &instrument.Instrument[stubPeriod, stubGV, stubGV, stubPan]{
+				Static: instrument.StaticValues[stubPeriod, stubGV, stubGV, stubPan]{
+					ID:                   stubInstID{1, 2},
+					DuplicateCheckType:   tt.dct,
+					DuplicateCheckAction: note.ActionRelease,
+				},
+			}
+			c.target.Inst = inst
+			c.target.Semitone = 48
+
+			c.doDuplicateCheck(&m)
+
+			for i, v := range voices {
+				if v.released != tt.matches[i] {
+					t.Fatalf("past note %d: released = %v, want %v", i, v.released, tt.matches[i])
+				}
+			}
+		})
+	}
+}
+
+func TestDuplicateCheckCutRemovesPastNotes(t *testing.T) {
+	m := machine[stubPeriod, stubGV, stubGV, stubGV, stubPan]{
+		virtualOutputs: make([]render.Channel[stubPeriod], 2),
+	}
+	var c channel[stubPeriod, stubGV, stubGV, stubGV, stubPan]
+
+	dup := &actionVoice{}
+	other := &actionVoice{}
+	c.addPastNote(&m, dup, noteIdent{ID: stubInstID{1, 0}, Semitone: 60})
+	c.addPastNote(&m, other, noteIdent{ID: stubInstID{1, 0}, Semitone: 61})
+
+	c.target.Inst = &instrument.Instrument[stubPeriod, stubGV, stubGV, stubPan]{
+		Static: instrument.StaticValues[stubPeriod, stubGV, stubGV, stubPan]{
+			ID:                   stubInstID{1, 0},
+			DuplicateCheckType:   note.DuplicateCheckTypeNote,
+			DuplicateCheckAction: note.ActionCut,
+		},
+	}
+	c.target.Semitone = 60
+
+	c.doDuplicateCheck(&m)
+
+	if !dup.stopped || other.stopped {
+		t.Fatalf("expected only the duplicate to be cut: dup=%v other=%v", dup.stopped, other.stopped)
+	}
+	if len(c.pastNotes) != 1 || c.pastNotes[0].ch != index.Channel(1) {
+		t.Fatalf("expected the cut note to be removed from the past notes, got %+v", c.pastNotes)
+	}
+}
+
+// cloningVoice is a voice whose clones are voices of their own, as the background voices made by
+// new note actions are
+type cloningVoice struct {
+	jamVoice
+	clones []*cloningVoice
+}
+
+func (v *cloningVoice) Clone(bool) voice.Voice {
+	c := &cloningVoice{jamVoice: v.jamVoice}
+	v.clones = append(v.clones, c)
+	return c
+}
+
+func TestDoNoteActionDuplicateCheckIncludesTheOutgoingNote(t *testing.T) {
+	tests := []struct {
+		name     string
+		nna      note.Action
+		semitone note.Semitone
+		cut      bool
+	}{
+		{"continue, same note", note.ActionContinue, 48, true},
+		{"fade, same note", note.ActionFadeout, 48, true},
+		{"continue, other note", note.ActionContinue, 50, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := machine[stubPeriod, stubGV, stubGV, stubGV, stubPan]{
+				virtualOutputs: make([]render.Channel[stubPeriod], 2),
+			}
+			m.us.EnableNewNoteActions = true
+
+			inst := &instrument.Instrument[stubPeriod, stubGV, stubGV, stubPan]{
+				Static: instrument.StaticValues[stubPeriod, stubGV, stubGV, stubPan]{
+					ID:                   stubInstID{1, 0},
+					DuplicateCheckType:   note.DuplicateCheckTypeNote,
+					DuplicateCheckAction: note.ActionCut,
+				},
+			}
+			cv := &cloningVoice{}
+			c := channel[stubPeriod, stubGV, stubGV, stubGV, stubPan]{
+				memory:  stubChannelMemory{},
+				cv:      cv,
+				nna:     tt.nna,
+				playing: noteIdent{ID: inst.GetID(), Semitone: 48},
+			}
+			for i := range c.osc {
+				c.osc[i] = oscillator.NewProtrackerOscillator()
+			}
+			c.prev.Inst = inst
+			c.target.Inst = inst
+			c.target.Semitone = tt.semitone
+			c.target.TriggerNNA = true
+			c.target.ActionTick.Set(ActionTick{Action: note.ActionRetrigger})
+
+			if err := c.DoNoteAction(0, &m); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if len(cv.clones) != 1 {
+				t.Fatalf("expected the outgoing note to move into the background, got %d clones", len(cv.clones))
+			}
+			if old := cv.clones[0]; old.stopped != tt.cut {
+				t.Fatalf("expected the outgoing note to be cut: %v, got %v", tt.cut, old.stopped)
+			}
+			if cv.stopped || !cv.attacked {
+				t.Fatalf("expected the new note to play on the foreground voice")
+			}
+			if want := map[bool]int{true: 0, false: 1}[tt.cut]; len(c.pastNotes) != want {
+				t.Fatalf("expected %d past notes, got %+v", want, c.pastNotes)
+			}
+		})
+	}
+}
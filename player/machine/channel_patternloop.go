@@ -27,6 +27,7 @@ func (c *channel[TPeriod, TGlobalVolume, TMixingVolume, TVolume, TPanning]) doPa
 	c.patternLoop.Count = newCount
 
 	if doLoop {
+		eventPatternLoop(m, ch, c.patternLoop.Start, newCount, c.patternLoop.Total)
 		return m.SetRow(c.patternLoop.Start, false)
 	}
 
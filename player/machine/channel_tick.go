@@ -28,6 +28,7 @@ func (c *channel[TPeriod, TGlobalVolume, TMixingVolume, TVolume, TPanning]) RowS
 		traceChannelValueChangeWithComment(m, ch, "pd", pd, reset, "channel.RowStart")
 		freqMod.SetPeriodDelta(reset)
 	}
+	c.glissando = 0
 
 	for _, i := range c.instructions {
 		if err := m.DoInstructionRowStart(ch, i); err != nil {
@@ -46,6 +47,11 @@ func (c *channel[TPeriod, TGlobalVolume, TMixingVolume, TVolume, TPanning]) RowS
 		}
 	}
 
+	if st, set := info.Semitone.Get(); set {
+		traceChannelValueChangeWithComment(m, ch, "target.Semitone", c.target.Semitone, st, "channel.RowStart")
+		c.target.Semitone = st
+	}
+
 	if inst, set := info.Inst.Get(); set {
 		var prev, next instrument.ID
 		if c.target.Inst != nil {
@@ -56,6 +62,7 @@ func (c *channel[TPeriod, TGlobalVolume, TMixingVolume, TVolume, TPanning]) RowS
 		}
 
 		traceChannelValueChangeWithComment(m, ch, "target.Inst", prev, next, "channel.RowStart")
+		eventInstrumentChange(m, ch, prev, next)
 		c.target.Inst = inst
 	}
 
@@ -119,6 +126,16 @@ func (c *channel[TPeriod, TGlobalVolume, TMixingVolume, TVolume, TPanning]) RowS
 }
 
 func (c *channel[TPeriod, TGlobalVolume, TMixingVolume, TVolume, TPanning]) Tick(ch index.Channel, m *machine[TPeriod, TGlobalVolume, TMixingVolume, TVolume, TPanning]) error {
+	// the glissando works its snap out again every tick, so last tick's comes off first
+	if c.glissando != 0 {
+		if freqMod, ok := c.cv.(voice.FreqModulator[TPeriod]); ok {
+			pd := freqMod.GetPeriodDelta()
+			traceChannelValueChangeWithComment(m, ch, "pd", pd, pd-c.glissando, "channel.Tick")
+			freqMod.SetPeriodDelta(pd - c.glissando)
+		}
+		c.glissando = 0
+	}
+
 	for _, i := range c.instructions {
 		if err := m.DoInstructionTick(ch, i); err != nil {
 			return err
@@ -0,0 +1,204 @@
+package machine
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/heucuva/optional"
+
+	"github.com/gotracker/playback/index"
+	"github.com/gotracker/playback/mixing"
+	"github.com/gotracker/playback/mixing/volume"
+)
+
+// Controls is the live control surface of a machine: it's safe to use from any goroutine while
+// the machine plays on another. The changes are queued and applied at the next tick boundary,
+// so they take effect from the next tick rendered. They're kept apart from the state the song
+// itself controls, so the song's effects (such as channel mutes or global volume slides) don't
+// undo them. Channels played on the OPL2 chip only follow the global volume override.
+type Controls struct {
+	numChannels int
+
+	mu      sync.Mutex
+	pending []func(*controlState)
+}
+
+func newControls(numChannels int) *Controls {
+	return &Controls{
+		numChannels: numChannels,
+	}
+}
+
+func (c *Controls) enqueue(cmd func(*controlState)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.pending = append(c.pending, cmd)
+}
+
+func (c *Controls) enqueueChannel(ch index.Channel, cmd func(*controlState)) error {
+	if int(ch) >= c.numChannels {
+		return fmt.Errorf("invalid channel index: %d", ch)
+	}
+	c.enqueue(cmd)
+	return nil
+}
+
+// take returns the queued changes and empties the queue
+func (c *Controls) take() []func(*controlState) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	pending := c.pending
+	c.pending = nil
+	return pending
+}
+
+// SetChannelMute mutes or unmutes a channel
+func (c *Controls) SetChannelMute(ch index.Channel, muted bool) error {
+	return c.enqueueChannel(ch, func(s *controlState) {
+		s.channels[ch].muted = muted
+	})
+}
+
+// SetChannelSolo solos a channel or takes it out of the solo group. While any channel is soloed,
+// only the soloed channels are heard.
+func (c *Controls) SetChannelSolo(ch index.Channel, solo bool) error {
+	return c.enqueueChannel(ch, func(s *controlState) {
+		s.channels[ch].solo = solo
+	})
+}
+
+// ClearSolo takes every channel out of the solo group
+func (c *Controls) ClearSolo() {
+	c.enqueue(func(s *controlState) {
+		for i := range s.channels {
+			s.channels[i].solo = false
+		}
+	})
+}
+
+// SetChannelGain sets the gain applied to a channel on top of the volumes the song sets, where
+// 1 leaves the channel as it is
+func (c *Controls) SetChannelGain(ch index.Channel, gain volume.Volume) error {
+	if gain < 0 {
+		return fmt.Errorf("channel[%d] gain out of range: %v", ch, gain)
+	}
+	return c.enqueueChannel(ch, func(s *controlState) {
+		s.channels[ch].gain = gain
+	})
+}
+
+// SetGlobalVolumeOverride replaces the global volume of the song, including any changes its
+// effects make to it, until the override is cleared
+func (c *Controls) SetGlobalVolumeOverride(v volume.Volume) error {
+	if v < 0 || v > 1 {
+		return fmt.Errorf("global volume override out of range: %v", v)
+	}
+	c.enqueue(func(s *controlState) {
+		s.globalVolume.Set(v)
+	})
+	return nil
+}
+
+// ClearGlobalVolumeOverride returns the global volume to the control of the song
+func (c *Controls) ClearGlobalVolumeOverride() {
+	c.enqueue(func(s *controlState) {
+		s.globalVolume.Reset()
+	})
+}
+
+type controlChannel struct {
+	muted bool
+	solo  bool
+	gain  volume.Volume
+}
+
+// controlState is the state of the controls, as applied to the machine. Only the machine's
+// goroutine touches it.
+type controlState struct {
+	channels     []controlChannel
+	globalVolume optional.Value[volume.Volume]
+}
+
+func newControlState(numChannels int) controlState {
+	s := controlState{
+		channels: make([]controlChannel, numChannels),
+	}
+	for i := range s.channels {
+		s.channels[i].gain = 1
+	}
+	return s
+}
+
+// channelGains returns the gain of each channel, or nil when the controls leave every channel
+// as it is
+func (s *controlState) channelGains() []volume.Volume {
+	soloing := false
+	active := false
+	for _, c := range s.channels {
+		soloing = soloing || c.solo
+		active = active || c.muted || c.solo || c.gain != 1
+	}
+	if !active {
+		return nil
+	}
+
+	gains := make([]volume.Volume, len(s.channels))
+	for i, c := range s.channels {
+		if c.muted || (soloing && !c.solo) {
+			continue
+		}
+		gains[i] = c.gain
+	}
+	return gains
+}
+
+// Controls returns the live control surface of the machine
+func (m *machine[TPeriod, TGlobalVolume, TMixingVolume, TVolume, TPanning]) Controls() *Controls {
+	return m.controls
+}
+
+// applyControls applies the changes queued on the control surface
+func (m *machine[TPeriod, TGlobalVolume, TMixingVolume, TVolume, TPanning]) applyControls() {
+	if m.controls == nil {
+		return
+	}
+
+	pending := m.controls.take()
+	if len(pending) == 0 {
+		return
+	}
+
+	for _, cmd := range pending {
+		cmd(&m.control)
+	}
+	m.channelGains = m.control.channelGains()
+}
+
+// globalVolume returns the global volume in effect, which is the song's unless it's overridden
+func (m *machine[TPeriod, TGlobalVolume, TMixingVolume, TVolume, TPanning]) globalVolume() volume.Volume {
+	if v, set := m.control.globalVolume.Get(); set {
+		return v
+	}
+	return m.gv.ToVolume()
+}
+
+// applyChannelGains applies the gains of the control surface to the rendered voice data, with
+// the past-note voices following the channel that played them
+func (m *machine[TPeriod, TGlobalVolume, TMixingVolume, TVolume, TPanning]) applyChannelGains(mixData []mixing.Data) {
+	if m.channelGains == nil {
+		return
+	}
+
+	numChannels := min(len(m.actualOutputs), len(m.channelGains))
+	for i := 0; i < numChannels && i < len(mixData); i++ {
+		mixData[i].Volume *= m.channelGains[i]
+	}
+
+	for i := 0; i < numChannels && i < len(m.channels); i++ {
+		for _, pn := range m.channels[i].pastNotes {
+			if j := len(m.actualOutputs) + int(pn.ch); j < len(mixData) {
+				mixData[j].Volume *= m.channelGains[i]
+			}
+		}
+	}
+}
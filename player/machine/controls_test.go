@@ -0,0 +1,143 @@
+package machine
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/gotracker/playback/index"
+	"github.com/gotracker/playback/mixing"
+	"github.com/gotracker/playback/mixing/volume"
+	"github.com/gotracker/playback/player/machine/settings"
+	"github.com/gotracker/playback/player/render"
+	"github.com/gotracker/playback/player/sampler"
+)
+
+func newControlledMachine(channels int) machine[stubPeriod, stubGV, stubGV, stubGV, stubPan] {
+	return machine[stubPeriod, stubGV, stubGV, stubGV, stubPan]{
+		channels:      make([]channel[stubPeriod, stubGV, stubGV, stubGV, stubPan], channels),
+		actualOutputs: make([]render.Channel[stubPeriod], channels),
+		controls:      newControls(channels),
+		control:       newControlState(channels),
+	}
+}
+
+func TestControlsApplyAtTickBoundary(t *testing.T) {
+	m := newControlledMachine(4)
+	c := m.Controls()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func(ch index.Channel) {
+			defer wg.Done()
+			if err := c.SetChannelGain(ch, 0.5); err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		}(index.Channel(i))
+	}
+	wg.Wait()
+
+	if m.channelGains != nil {
+		t.Fatalf("expected the changes to wait for the tick boundary")
+	}
+
+	m.applyControls()
+	for i, g := range m.channelGains {
+		if g != 0.5 {
+			t.Fatalf("channel %d: expected a gain of 0.5, got %v", i, g)
+		}
+	}
+}
+
+func TestControlsMuteAndSolo(t *testing.T) {
+	m := newControlledMachine(4)
+	c := m.Controls()
+
+	c.SetChannelSolo(1, true)
+	c.SetChannelSolo(2, true)
+	c.SetChannelMute(2, true)
+	c.SetChannelGain(1, 0.25)
+	m.applyControls()
+
+	want := []volume.Volume{0, 0.25, 0, 0}
+	for i, g := range m.channelGains {
+		if g != want[i] {
+			t.Fatalf("expected gains %v, got %v", want, m.channelGains)
+		}
+	}
+
+	c.ClearSolo()
+	c.SetChannelMute(2, false)
+	c.SetChannelGain(1, 1)
+	m.applyControls()
+	if m.channelGains != nil {
+		t.Fatalf("expected the controls to leave the channels alone once reset, got %v", m.channelGains)
+	}
+}
+
+func TestControlsRejectInvalidValues(t *testing.T) {
+	c := newControls(2)
+
+	if err := c.SetChannelMute(2, true); err == nil {
+		t.Fatalf("expected an error for a channel out of range")
+	}
+	if err := c.SetChannelGain(0, -1); err == nil {
+		t.Fatalf("expected an error for a negative gain")
+	}
+	if err := c.SetGlobalVolumeOverride(1.5); err == nil {
+		t.Fatalf("expected an error for a global volume out of range")
+	}
+	if len(c.take()) != 0 {
+		t.Fatalf("expected nothing to be queued")
+	}
+}
+
+func TestApplyChannelGainsFollowsPastNotes(t *testing.T) {
+	m := newControlledMachine(2)
+	m.virtualOutputs = make([]render.Channel[stubPeriod], 2)
+	m.channels[1].pastNotes = []pastNoteInfo{{ch: 0}}
+
+	m.Controls().SetChannelMute(1, true)
+	m.applyControls()
+
+	mixData := []mixing.Data{{Volume: 1}, {Volume: 1}, {Volume: 1}, {Volume: 1}}
+	m.applyChannelGains(mixData)
+
+	want := []volume.Volume{1, 0, 0, 1}
+	for i, d := range mixData {
+		if d.Volume != want[i] {
+			t.Fatalf("entry %d: expected volume %v, got %v", i, want[i], d.Volume)
+		}
+	}
+}
+
+func TestGlobalVolumeOverride(t *testing.T) {
+	m := newControlledMachine(1)
+	m.globals = globals[stubGV]{tempo: 6, bpm: 6, gv: stubGV(0.5), mv: 1}
+	m.ms = &settings.MachineSettings[stubPeriod, stubGV, stubGV, stubGV, stubPan]{PeriodConverter: stubPeriodCalc{}}
+	m.songData = stubSongData{}
+
+	s := sampler.NewSampler(10, 2, 1, nil)
+
+	m.Controls().SetGlobalVolumeOverride(0.25)
+	m.applyControls()
+	frame, err := m.prepareRenderFrame(s)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if frame.premix.MixerVolume != 0.25 {
+		t.Fatalf("expected the override to replace the global volume, got %v", frame.premix.MixerVolume)
+	}
+
+	// the song changing its global volume doesn't undo the override
+	m.SetGlobalVolume(stubGV(1))
+	if v := m.globalVolume(); v != 0.25 {
+		t.Fatalf("expected the override to stay in effect, got %v", v)
+	}
+
+	m.Controls().ClearGlobalVolumeOverride()
+	m.applyControls()
+	if v := m.globalVolume(); v != 1 {
+		t.Fatalf("expected the song's global volume once the override is cleared, got %v", v)
+	}
+}
@@ -0,0 +1,104 @@
+package machine
+
+import (
+	"github.com/gotracker/playback/index"
+	"github.com/gotracker/playback/instrument"
+	"github.com/gotracker/playback/note"
+	"github.com/gotracker/playback/player/machine/instruction"
+)
+
+// EventInfo is the timing of an event
+type EventInfo struct {
+	Position Position
+	// SampleOffset is the number of samples rendered before the event; the event takes effect
+	// from the start of the next render. It doesn't move when the machine is only advanced.
+	SampleOffset int64
+}
+
+// Info returns the timing of the event
+func (e EventInfo) Info() EventInfo {
+	return e
+}
+
+// Event is a musical event raised by the machine. Use a type switch to tell them apart.
+type Event interface {
+	Info() EventInfo
+}
+
+// EventHandler receives the events of a machine, on the goroutine advancing it
+type EventHandler func(ev Event)
+
+// NoteOnEvent is raised when a note starts playing on a channel
+type NoteOnEvent struct {
+	EventInfo
+	Channel    index.Channel
+	Instrument instrument.ID // nil when the channel has no instrument
+	Semitone   note.Semitone
+}
+
+// NoteOffEvent is raised when the note playing on a channel is released (key off)
+type NoteOffEvent struct {
+	EventInfo
+	Channel index.Channel
+}
+
+// FadeoutEvent is raised when the note playing on a channel starts to fade out
+type FadeoutEvent struct {
+	EventInfo
+	Channel index.Channel
+}
+
+// CutEvent is raised when the note playing on a channel is cut
+type CutEvent struct {
+	EventInfo
+	Channel index.Channel
+}
+
+// InstrumentChangeEvent is raised when a channel is given a different instrument
+type InstrumentChangeEvent struct {
+	EventInfo
+	Channel    index.Channel
+	Previous   instrument.ID // nil when the channel had no instrument
+	Instrument instrument.ID // nil when the channel has no instrument
+}
+
+// EffectTriggeredEvent is raised at the start of a row for each effect on a channel
+type EffectTriggeredEvent struct {
+	EventInfo
+	Channel index.Channel
+	Effect  instruction.Instruction
+}
+
+// RowStartEvent is raised when a row starts
+type RowStartEvent struct {
+	EventInfo
+}
+
+// OrderStartEvent is raised when an order starts
+type OrderStartEvent struct {
+	EventInfo
+	Pattern index.Pattern
+}
+
+// TempoChangeEvent is raised when the tempo (ticks per row) changes
+type TempoChangeEvent struct {
+	EventInfo
+	Previous int
+	Tempo    int
+}
+
+// BPMChangeEvent is raised when the BPM changes
+type BPMChangeEvent struct {
+	EventInfo
+	Previous int
+	BPM      int
+}
+
+// PatternLoopEvent is raised when a channel's pattern loop jumps back to its start
+type PatternLoopEvent struct {
+	EventInfo
+	Channel index.Channel
+	Start   index.Row
+	Count   int // the number of the loop about to play, starting from 1
+	Total   int
+}
@@ -0,0 +1,467 @@
+package machine
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+
+	"github.com/heucuva/optional"
+
+	"github.com/gotracker/playback/instrument"
+	"github.com/gotracker/playback/mixing"
+	"github.com/gotracker/playback/mixing/panning"
+	"github.com/gotracker/playback/mixing/volume"
+	"github.com/gotracker/playback/note"
+	"github.com/gotracker/playback/period"
+	"github.com/gotracker/playback/player/render"
+	"github.com/gotracker/playback/song"
+	"github.com/gotracker/playback/voice"
+	"github.com/gotracker/playback/voice/types"
+)
+
+const (
+	// JamPolyphony is the number of notes the Jam can play at once; past that, a new note takes
+	// the place of the quietest one
+	JamPolyphony = 32
+	// JamVibratoRate is the rate of the vibrato of the notes played on the Jam, in Hz
+	JamVibratoRate = 5.0
+)
+
+// JamNote is a note played live on top of the song
+type JamNote struct {
+	Note       note.Note                        // must be a normal note
+	Instrument int                              // the instrument number, as the song's patterns refer to it
+	Volume     optional.Value[volume.Volume]    // applied to the instrument's default volume; default: 1
+	Pan        optional.Value[panning.Position] // default: the instrument's default panning
+}
+
+// JamVoice identifies a note started on the Jam
+type JamVoice int
+
+// Jam plays notes live on top of a song, such as to audition instruments or to play along on a
+// keyboard. The notes use the song's own instruments, envelopes, periods and filters and are
+// mixed with the song, on voices of their own, so they never disturb the song's channels. The
+// Jam is safe to use from any goroutine while the machine plays on another: the notes are queued
+// and started (or released or cut) at the next tick boundary. They aren't affected by the global
+// volume of the song or by the Controls. Instruments played on the OPL2 chip can't be jammed.
+type Jam struct {
+	songData song.Data
+
+	mu      sync.Mutex
+	nextID  JamVoice
+	pending []jamCommand
+}
+
+type jamCommandKind int
+
+const (
+	jamCommandNoteOn = jamCommandKind(iota)
+	jamCommandRelease
+	jamCommandCut
+	jamCommandReleaseAll
+	jamCommandCutAll
+	jamCommandPitchBend
+	jamCommandVibrato
+	jamCommandVolume
+	jamCommandPan
+)
+
+type jamCommand struct {
+	kind  jamCommandKind
+	id    JamVoice
+	note  JamNote
+	st    note.Semitone
+	value float32
+	pan   panning.Position
+}
+
+func newJam(songData song.Data) *Jam {
+	return &Jam{
+		songData: songData,
+	}
+}
+
+func (j *Jam) enqueue(cmd jamCommand) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.pending = append(j.pending, cmd)
+}
+
+// take returns the queued commands and empties the queue
+func (j *Jam) take() []jamCommand {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	pending := j.pending
+	j.pending = nil
+	return pending
+}
+
+// NoteOn starts a note and returns the voice playing it, for releasing or cutting it later
+func (j *Jam) NoteOn(n JamNote) (JamVoice, error) {
+	nn, ok := n.Note.(note.Normal)
+	if !ok {
+		return 0, fmt.Errorf("jam note must be a normal note: %v", n.Note)
+	}
+
+	if inst, _ := j.songData.GetInstrument(n.Instrument, note.Semitone(nn)); inst == nil {
+		return 0, fmt.Errorf("invalid instrument: %d", n.Instrument)
+	}
+
+	if v, set := n.Volume.Get(); set && (v < 0 || v > 1) {
+		return 0, fmt.Errorf("jam note volume out of range: %v", v)
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.nextID++
+	id := j.nextID
+	j.pending = append(j.pending, jamCommand{
+		kind: jamCommandNoteOn,
+		id:   id,
+		note: n,
+		st:   note.Semitone(nn),
+	})
+	return id, nil
+}
+
+// Release releases (keys off) a note, letting its envelopes play out
+func (j *Jam) Release(v JamVoice) {
+	j.enqueue(jamCommand{
+		kind: jamCommandRelease,
+		id:   v,
+	})
+}
+
+// Cut stops a note immediately
+func (j *Jam) Cut(v JamVoice) {
+	j.enqueue(jamCommand{
+		kind: jamCommandCut,
+		id:   v,
+	})
+}
+
+// ReleaseAll releases every note playing on the Jam
+func (j *Jam) ReleaseAll() {
+	j.enqueue(jamCommand{
+		kind: jamCommandReleaseAll,
+	})
+}
+
+// CutAll stops every note playing on the Jam
+func (j *Jam) CutAll() {
+	j.enqueue(jamCommand{
+		kind: jamCommandCutAll,
+	})
+}
+
+// SetPitchBend bends the pitch of a note by a number of semitones, which may be fractional and
+// is negative to bend down
+func (j *Jam) SetPitchBend(v JamVoice, semitones float32) {
+	j.enqueue(jamCommand{
+		kind:  jamCommandPitchBend,
+		id:    v,
+		value: semitones,
+	})
+}
+
+// SetVibrato sets the depth of the vibrato of a note, in semitones either side of its pitch,
+// where 0 turns the vibrato off
+func (j *Jam) SetVibrato(v JamVoice, depth float32) {
+	j.enqueue(jamCommand{
+		kind:  jamCommandVibrato,
+		id:    v,
+		value: depth,
+	})
+}
+
+// SetVolume changes the volume of a note, as applied to the instrument's default volume
+func (j *Jam) SetVolume(v JamVoice, vol volume.Volume) error {
+	if vol < 0 || vol > 1 {
+		return fmt.Errorf("jam note volume out of range: %v", vol)
+	}
+	j.enqueue(jamCommand{
+		kind:  jamCommandVolume,
+		id:    v,
+		value: float32(vol),
+	})
+	return nil
+}
+
+// SetPan changes the panning of a note
+func (j *Jam) SetPan(v JamVoice, pos panning.Position) {
+	j.enqueue(jamCommand{
+		kind: jamCommandPan,
+		id:   v,
+		pan:  pos,
+	})
+}
+
+// jamOutput is an output channel for the notes played on the Jam
+type jamOutput[TPeriod Period] struct {
+	render.Channel[TPeriod]
+	id       JamVoice // 0 when nothing is playing
+	gain     volume.Volume
+	semitone note.Semitone
+	bend     float32 // in semitones
+	vibrato  float32 // depth, in semitones
+	phase    float64 // of the vibrato, in radians
+}
+
+// Jam returns the surface for playing notes live on top of the song
+func (m *machine[TPeriod, TGlobalVolume, TMixingVolume, TVolume, TPanning]) Jam() *Jam {
+	return m.jam
+}
+
+// applyJam runs the commands queued on the Jam and clears away the notes that have finished
+func (m *machine[TPeriod, TGlobalVolume, TMixingVolume, TVolume, TPanning]) applyJam() {
+	if m.jam == nil {
+		return
+	}
+
+	for _, cmd := range m.jam.take() {
+		switch cmd.kind {
+		case jamCommandNoteOn:
+			if err := m.jamNoteOn(cmd.id, cmd.note, cmd.st); err != nil {
+				trace(m, fmt.Sprintf("jam note %d: %v", cmd.id, err))
+			}
+		case jamCommandRelease:
+			m.forEachJamOutput(cmd.id, func(o *jamOutput[TPeriod]) {
+				o.GetVoice().Release()
+			})
+		case jamCommandCut:
+			m.forEachJamOutput(cmd.id, func(o *jamOutput[TPeriod]) {
+				o.StopVoice()
+			})
+		case jamCommandReleaseAll:
+			m.forEachJamOutput(0, func(o *jamOutput[TPeriod]) {
+				o.GetVoice().Release()
+			})
+		case jamCommandCutAll:
+			m.forEachJamOutput(0, func(o *jamOutput[TPeriod]) {
+				o.StopVoice()
+			})
+		case jamCommandPitchBend:
+			m.forEachJamOutput(cmd.id, func(o *jamOutput[TPeriod]) {
+				o.bend = cmd.value
+			})
+		case jamCommandVibrato:
+			m.forEachJamOutput(cmd.id, func(o *jamOutput[TPeriod]) {
+				o.vibrato = cmd.value
+			})
+		case jamCommandVolume:
+			m.forEachJamOutput(cmd.id, func(o *jamOutput[TPeriod]) {
+				o.gain = volume.Volume(cmd.value)
+			})
+		case jamCommandPan:
+			m.forEachJamOutput(cmd.id, func(o *jamOutput[TPeriod]) {
+				if panMod, ok := o.GetVoice().(voice.PanModulator[TPanning]); ok {
+					panMod.SetPan(jamPanning[TPanning](cmd.pan))
+				}
+			})
+		}
+	}
+
+	m.forEachJamOutput(0, func(o *jamOutput[TPeriod]) {
+		if o.GetVoice().IsDone() {
+			o.StopVoice()
+		}
+	})
+}
+
+// forEachJamOutput calls fn for the output playing the note `id`, or for every output playing
+// a note when `id` is 0
+func (m *machine[TPeriod, TGlobalVolume, TMixingVolume, TVolume, TPanning]) forEachJamOutput(id JamVoice, fn func(o *jamOutput[TPeriod])) {
+	for i := range m.jamOutputs {
+		o := &m.jamOutputs[i]
+		if o.id == 0 || o.GetVoice() == nil || (id != 0 && o.id != id) {
+			continue
+		}
+		fn(o)
+	}
+}
+
+func (m *machine[TPeriod, TGlobalVolume, TMixingVolume, TVolume, TPanning]) jamNoteOn(id JamVoice, n JamNote, st note.Semitone) error {
+	if len(m.jamOutputs) == 0 {
+		return errors.New("no jam outputs")
+	}
+
+	ii, st := m.songData.GetInstrument(n.Instrument, st)
+	inst, _ := ii.(*instrument.Instrument[TPeriod, TMixingVolume, TVolume, TPanning])
+	if inst == nil {
+		return fmt.Errorf("invalid instrument: %d", n.Instrument)
+	}
+
+	pcm, ok := inst.GetData().(*instrument.PCM[TMixingVolume, TVolume, TPanning])
+	if !ok {
+		return fmt.Errorf("unsupported instrument type: %T", inst.GetData())
+	}
+
+	pan := types.GetPanDefault[TPanning]()
+	if p, set := pcm.Panning.Get(); set {
+		pan = p
+	}
+	if pos, set := n.Pan.Get(); set {
+		pan = jamPanning[TPanning](pos)
+	}
+
+	v := m.ms.VoiceFactory.NewVoice(voice.VoiceConfig[TPeriod, TGlobalVolume, TMixingVolume, TVolume, TPanning]{
+		PC:            m.ms.PeriodConverter,
+		InitialVolume: inst.GetDefaultVolume(),
+		InitialMixing: types.GetMaxVolume[TMixingVolume](),
+		PanEnabled:    true,
+		InitialPan:    pan,
+		Random:        m.rng,
+	})
+	if err := v.Setup(inst); err != nil {
+		return err
+	}
+
+	info := inst.GetPluginFilterInfo()
+	pluginFilter, err := m.ms.GetFilterFactory(info.Name, inst.SampleRate, info.Params)
+	if err != nil {
+		return err
+	}
+
+	if freqMod, ok := v.(voice.FreqModulator[TPeriod]); ok {
+		if p := m.ConvertToPeriod(note.Normal(st)); !p.IsInvalid() {
+			freqMod.SetPeriod(p)
+		}
+	}
+	if panMod, ok := v.(voice.PanModulator[TPanning]); ok {
+		panMod.SetPan(pan)
+	}
+	if pitchPanMod, ok := v.(voice.PitchPanModulator[TPanning]); ok {
+		pitchPanMod.SetPitchPanNote(st)
+	}
+
+	v.Attack()
+
+	o := m.getFreeJamOutput()
+	o.StartVoice(v, func() {
+		o.id = 0
+	})
+	o.id = id
+	o.gain = volume.Volume(1)
+	if g, set := n.Volume.Get(); set {
+		o.gain = g
+	}
+	o.semitone = st
+	o.bend = 0
+	o.vibrato = 0
+	o.phase = 0
+	o.PluginFilter = pluginFilter
+	o.GlobalVolume = volume.Volume(1)
+	return nil
+}
+
+// getFreeJamOutput returns an output that isn't playing anything, or the quietest one when
+// every output is playing
+func (m *machine[TPeriod, TGlobalVolume, TMixingVolume, TVolume, TPanning]) getFreeJamOutput() *jamOutput[TPeriod] {
+	type jamVolChan struct {
+		vol volume.Volume
+		i   int
+	}
+	var candidates []jamVolChan
+	for i := range m.jamOutputs {
+		o := &m.jamOutputs[i]
+		v := o.GetVoice()
+		if o.id == 0 || v == nil {
+			return o
+		}
+
+		vc := jamVolChan{
+			vol: o.gain,
+			i:   i,
+		}
+		if ampMod, ok := v.(voice.AmpModulator[TGlobalVolume, TMixingVolume, TVolume]); ok {
+			vc.vol *= ampMod.GetFinalVolume()
+		}
+		candidates = append(candidates, vc)
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].vol < candidates[j].vol
+	})
+	return &m.jamOutputs[candidates[0].i]
+}
+
+// jamPanning converts a stereo position to the panning of the song
+func jamPanning[TPanning Panning](pos panning.Position) TPanning {
+	pmax := float32(types.GetPanMax[TPanning]())
+	p := panning.FromStereoPosition(pos, 0, pmax)
+	return TPanning(min(max(p, 0), pmax))
+}
+
+// renderJam renders the notes playing on the Jam
+func (m *machine[TPeriod, TGlobalVolume, TMixingVolume, TVolume, TPanning]) renderJam(frame renderFrame) (mixing.ChannelData, error) {
+	var jamData mixing.ChannelData
+	for i := range m.jamOutputs {
+		o := &m.jamOutputs[i]
+		if o.id == 0 || o.GetVoice() == nil {
+			continue
+		}
+
+		m.updateJamPitch(o, frame)
+
+		data, err := o.RenderAndTick(m.ms.PeriodConverter, frame.centerAheadPan, frame.details)
+		if err != nil {
+			return nil, err
+		}
+
+		if data != nil {
+			data.Volume *= o.gain
+			jamData = append(jamData, *data)
+		}
+	}
+	return jamData, nil
+}
+
+// updateJamPitch applies the pitch bend and the vibrato of a note for the tick about to render
+func (m *machine[TPeriod, TGlobalVolume, TMixingVolume, TVolume, TPanning]) updateJamPitch(o *jamOutput[TPeriod], frame renderFrame) {
+	freqMod, ok := o.GetVoice().(voice.FreqModulator[TPeriod])
+	if !ok {
+		return
+	}
+
+	offset := float64(o.bend)
+	if o.vibrato != 0 {
+		offset += float64(o.vibrato) * math.Sin(o.phase)
+		if frame.details.SampleRate > 0 {
+			o.phase += 2 * math.Pi * JamVibratoRate * float64(frame.details.Samples) / float64(frame.details.SampleRate)
+			o.phase = math.Mod(o.phase, 2*math.Pi)
+		}
+	}
+
+	freqMod.SetPeriodDelta(m.getSemitoneDelta(o.semitone, offset))
+}
+
+// getSemitoneDelta returns the period delta that moves the pitch of a semitone by `offset`
+// semitones, interpolating between the semitones either side for a fractional offset
+func (m *machine[TPeriod, TGlobalVolume, TMixingVolume, TVolume, TPanning]) getSemitoneDelta(st note.Semitone, offset float64) period.Delta {
+	if offset == 0 {
+		return 0
+	}
+
+	pc := m.ms.PeriodConverter
+	base := m.ConvertToPeriod(note.Normal(st))
+	if base.IsInvalid() {
+		return 0
+	}
+
+	deltaTo := func(semitones int) float64 {
+		target := min(max(int(st)+semitones, 0), int(note.UnchangedSemitone)-1)
+		p := m.ConvertToPeriod(note.Normal(target))
+		if p.IsInvalid() {
+			return 0
+		}
+		return float64(pc.GetDelta(base, p))
+	}
+
+	whole := math.Floor(offset)
+	lo := deltaTo(int(whole))
+	hi := deltaTo(int(whole) + 1)
+	d := lo + (hi-lo)*(offset-whole)
+	return period.Delta(min(max(math.Round(d), math.MinInt16), math.MaxInt16))
+}
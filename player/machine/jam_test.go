@@ -0,0 +1,324 @@
+package machine
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/heucuva/optional"
+
+	"github.com/gotracker/playback/filter"
+	"github.com/gotracker/playback/frequency"
+	"github.com/gotracker/playback/index"
+	"github.com/gotracker/playback/instrument"
+	"github.com/gotracker/playback/mixing"
+	"github.com/gotracker/playback/mixing/panning"
+	"github.com/gotracker/playback/mixing/volume"
+	"github.com/gotracker/playback/note"
+	"github.com/gotracker/playback/output"
+	"github.com/gotracker/playback/period"
+	"github.com/gotracker/playback/player/machine/settings"
+	"github.com/gotracker/playback/player/sampler"
+	"github.com/gotracker/playback/system"
+	"github.com/gotracker/playback/voice"
+	"github.com/gotracker/playback/voice/mixer"
+	"github.com/gotracker/playback/voice/types"
+)
+
+func (stubGV) GetMax() stubGV       { return 1 }
+func (stubPan) GetDefault() stubPan { return 0.5 }
+func (stubPan) GetMax() stubPan     { return 1 }
+
+type jamVoice struct {
+	errorRenderVoice
+	inst      *instrument.Instrument[stubPeriod, stubGV, stubGV, stubPan]
+	periodSet bool
+	pan       stubPan
+	attacked  bool
+	released  bool
+	stopped   bool
+}
+
+func (v *jamVoice) Setup(inst *instrument.Instrument[stubPeriod, stubGV, stubGV, stubPan]) error {
+	v.inst = inst
+	return nil
+}
+func (v *jamVoice) Attack()                                   { v.attacked = true }
+func (v *jamVoice) Release()                                  { v.released = true }
+func (v *jamVoice) Stop()                                     { v.stopped = true }
+func (v *jamVoice) IsDone() bool                              { return v.stopped }
+func (v *jamVoice) GetPeriod() stubPeriod                     { return stubPeriod{} }
+func (v *jamVoice) SetPeriod(stubPeriod) error                { v.periodSet = true; return nil }
+func (v *jamVoice) GetPeriodDelta() period.Delta              { return 0 }
+func (v *jamVoice) SetPeriodDelta(period.Delta) error         { return nil }
+func (v *jamVoice) GetPan() stubPan                           { return v.pan }
+func (v *jamVoice) SetPan(pan stubPan) error                  { v.pan = pan; return nil }
+func (v *jamVoice) GetPanDelta() types.PanDelta               { return 0 }
+func (v *jamVoice) SetPanDelta(types.PanDelta) error          { return nil }
+func (v *jamVoice) GetFinalVolume() volume.Volume             { return 1 }
+func (v *jamVoice) GetFinalPan() panning.Position             { return v.pan.ToPosition() }
+func (v *jamVoice) GetFinalPeriod() (stubPeriod, error)       { return stubPeriod{}, nil }
+func (v *jamVoice) SetPlaybackRate(frequency.Frequency) error { return nil }
+
+type jamVoiceFactory struct {
+	voices []*jamVoice
+}
+
+func (f *jamVoiceFactory) NewVoice(config voice.VoiceConfig[stubPeriod, stubGV, stubGV, stubGV, stubPan]) voice.RenderVoice[stubPeriod, stubGV, stubGV, stubGV, stubPan] {
+	v := &jamVoice{pan: config.InitialPan}
+	f.voices = append(f.voices, v)
+	return v
+}
+
+type jamSongData struct{ stubSongData }
+
+func (jamSongData) GetInstrument(i int, st note.Semitone) (instrument.InstrumentIntf, note.Semitone) {
+	if i != 1 {
+		return nil, st
+	}
+	return &instrument.Instrument[stubPeriod, stubGV, stubGV, stubPan]{
+		Inst: &instrument.PCM[stubGV, stubGV, stubPan]{},
+	}, st
+}
+
+func newJamMachine(outputs int) (*machine[stubPeriod, stubGV, stubGV, stubGV, stubPan], *jamVoiceFactory) {
+	vf := &jamVoiceFactory{}
+	m := &machine[stubPeriod, stubGV, stubGV, stubGV, stubPan]{
+		ms: &settings.MachineSettings[stubPeriod, stubGV, stubGV, stubGV, stubPan]{
+			PeriodConverter: stubPeriodCalc{},
+			VoiceFactory:    vf,
+			GetFilterFactory: func(string, frequency.Frequency, any) (filter.Filter, error) {
+				return nil, nil
+			},
+		},
+		songData:   jamSongData{},
+		jam:        newJam(jamSongData{}),
+		jamOutputs: make([]jamOutput[stubPeriod], outputs),
+	}
+	return m, vf
+}
+
+func TestJamNoteOnPlaysOnTopOfTheSong(t *testing.T) {
+	m, vf := newJamMachine(2)
+
+	var pan optional.Value[panning.Position]
+	pan.Set(panning.MakeStereoPosition(1, 0, 1))
+	var vol optional.Value[volume.Volume]
+	vol.Set(0.5)
+	if _, err := m.Jam().NoteOn(JamNote{Note: note.Normal(48), Instrument: 1, Volume: vol, Pan: pan}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(vf.voices) != 0 {
+		t.Fatalf("expected the note to wait for the tick boundary")
+	}
+
+	m.applyJam()
+	if len(vf.voices) != 1 {
+		t.Fatalf("expected a voice for the note, got %d", len(vf.voices))
+	}
+	v := vf.voices[0]
+	if v.inst == nil || !v.periodSet || !v.attacked {
+		t.Fatalf("expected the voice to be set up and attacked, got %+v", v)
+	}
+	if v.pan != 1 {
+		t.Fatalf("expected the voice to be panned right, got %v", v.pan)
+	}
+
+	panMixer := mixing.GetPanMixer(2)
+	details := mixer.Details{
+		Mix:              &mixing.Mixer{Channels: 2},
+		Panmixer:         panMixer,
+		SampleRate:       10,
+		StereoSeparation: 1,
+		Samples:          4,
+	}
+	frame := renderFrame{
+		details:        details,
+		centerAheadPan: panMixer.GetMixingMatrix(panning.CenterAhead, details.StereoSeparation),
+	}
+	data, err := m.renderJam(frame)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(data) != 1 || data[0].Volume != 0.5 {
+		t.Fatalf("expected the note to be rendered at its volume, got %+v", data)
+	}
+}
+
+func TestJamKeepsItsStemWhileSilent(t *testing.T) {
+	m, _ := newJamMachine(2)
+	m.globals = globals[stubGV]{bpm: 6, tempo: 6, gv: 1, mv: 1}
+	synth := &stubHardwareSynth{}
+	m.hardwareSynths = []hardwareSynth{synth}
+
+	s := sampler.NewSampler(10, 2, 1, nil)
+	s.Stems = true
+
+	render := func() *output.PremixData {
+		t.Helper()
+		premix, err := m.render(s)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		want := []index.Channel{index.JamChannel, index.InvalidChannel}
+		if len(premix.Data) != len(want) || !reflect.DeepEqual(premix.Channels, want) {
+			t.Fatalf("expected the jam stem and then the OPL stem, got %d stems for %v", len(premix.Data), premix.Channels)
+		}
+		return premix
+	}
+
+	// nothing is playing on the jam yet, but its stem holds its place ahead of the OPL's
+	if premix := render(); len(premix.Data[0]) != 0 || len(premix.Data[1]) != 1 {
+		t.Fatalf("expected a silent jam stem and the OPL stem, got %+v", premix.Data)
+	}
+
+	if _, err := m.Jam().NoteOn(JamNote{Note: note.Normal(48), Instrument: 1}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	m.applyJam()
+
+	if premix := render(); len(premix.Data[0]) != 1 || len(premix.Data[1]) != 1 {
+		t.Fatalf("expected the jam note in the jam stem and the OPL in its own, got %+v", premix.Data)
+	}
+	if synth.called != 2 {
+		t.Fatalf("expected the OPL to render on both ticks, got %d", synth.called)
+	}
+}
+
+func TestJamRejectsInvalidNotes(t *testing.T) {
+	m, _ := newJamMachine(1)
+	j := m.Jam()
+
+	if _, err := j.NoteOn(JamNote{Note: note.ReleaseNote{}, Instrument: 1}); err == nil {
+		t.Fatalf("expected an error for a note that isn't a normal note")
+	}
+	if _, err := j.NoteOn(JamNote{Note: note.Normal(48), Instrument: 2}); err == nil {
+		t.Fatalf("expected an error for an invalid instrument")
+	}
+	var vol optional.Value[volume.Volume]
+	vol.Set(2)
+	if _, err := j.NoteOn(JamNote{Note: note.Normal(48), Instrument: 1, Volume: vol}); err == nil {
+		t.Fatalf("expected an error for a volume out of range")
+	}
+	if len(j.take()) != 0 {
+		t.Fatalf("expected nothing to be queued")
+	}
+}
+
+func TestJamReleaseAndCut(t *testing.T) {
+	m, vf := newJamMachine(2)
+	j := m.Jam()
+
+	first, _ := j.NoteOn(JamNote{Note: note.Normal(48), Instrument: 1})
+	second, _ := j.NoteOn(JamNote{Note: note.Normal(50), Instrument: 1})
+	m.applyJam()
+
+	j.Release(first)
+	j.Cut(second)
+	m.applyJam()
+
+	if !vf.voices[0].released || vf.voices[0].stopped {
+		t.Fatalf("expected the first note to be released, got %+v", vf.voices[0])
+	}
+	if !vf.voices[1].stopped || m.jamOutputs[1].id != 0 {
+		t.Fatalf("expected the second note to be cut and its output freed")
+	}
+
+	// a note that has finished gives up its output
+	vf.voices[0].stopped = true
+	m.applyJam()
+	if m.jamOutputs[0].id != 0 {
+		t.Fatalf("expected the finished note to give up its output")
+	}
+
+	j.NoteOn(JamNote{Note: note.Normal(48), Instrument: 1})
+	j.NoteOn(JamNote{Note: note.Normal(50), Instrument: 1})
+	j.CutAll()
+	m.applyJam()
+	for i, o := range m.jamOutputs {
+		if o.id != 0 {
+			t.Fatalf("output %d: expected every note to be cut", i)
+		}
+	}
+}
+
+func TestJamStealsTheQuietestVoice(t *testing.T) {
+	m, vf := newJamMachine(2)
+	j := m.Jam()
+
+	var quiet optional.Value[volume.Volume]
+	quiet.Set(0.25)
+	j.NoteOn(JamNote{Note: note.Normal(48), Instrument: 1})
+	second, _ := j.NoteOn(JamNote{Note: note.Normal(50), Instrument: 1, Volume: quiet})
+	third, _ := j.NoteOn(JamNote{Note: note.Normal(52), Instrument: 1})
+	m.applyJam()
+
+	if len(vf.voices) != 3 || !vf.voices[1].stopped || vf.voices[0].stopped {
+		t.Fatalf("expected the quietest note to make way for the new one")
+	}
+	if m.jamOutputs[1].id != third {
+		t.Fatalf("expected the new note on the output of the quietest one, got %d", m.jamOutputs[1].id)
+	}
+
+	// the note that was stolen no longer has an output to release
+	j.Release(second)
+	m.applyJam()
+	if vf.voices[2].released {
+		t.Fatalf("expected releasing the stolen note to leave the new note alone")
+	}
+}
+
+func TestJamChangesPlayingNotes(t *testing.T) {
+	m, vf := newJamMachine(1)
+	j := m.Jam()
+
+	id, _ := j.NoteOn(JamNote{Note: note.Normal(48), Instrument: 1})
+	m.applyJam()
+
+	j.SetPitchBend(id, -1.5)
+	j.SetVibrato(id, 0.25)
+	if err := j.SetVolume(id, 0.5); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	j.SetPan(id, panning.MakeStereoPosition(0, 0, 1))
+	if err := j.SetVolume(id, -1); err == nil {
+		t.Fatalf("expected an error for a volume out of range")
+	}
+	m.applyJam()
+
+	o := m.jamOutputs[0]
+	if o.bend != -1.5 || o.vibrato != 0.25 || o.gain != 0.5 {
+		t.Fatalf("expected the note to follow the changes, got bend %v, vibrato %v, gain %v", o.bend, o.vibrato, o.gain)
+	}
+	if vf.voices[0].pan != 0 {
+		t.Fatalf("expected the voice to be panned left, got %v", vf.voices[0].pan)
+	}
+}
+
+func TestGetSemitoneDelta(t *testing.T) {
+	m := machine[period.Linear, stubGV, stubGV, stubGV, stubPan]{
+		ms: &settings.MachineSettings[period.Linear, stubGV, stubGV, stubGV, stubPan]{
+			PeriodConverter: period.LinearConverter{
+				System: system.ClockedSystem{
+					FinetunesPerNote:   64,
+					FinetunesPerOctave: 768,
+				},
+			},
+		},
+	}
+
+	tests := []struct {
+		offset float64
+		want   period.Delta
+	}{
+		{0, 0},
+		{2, 128},
+		{-1, -64},
+		{0.5, 32},
+		{-0.25, -16},
+	}
+	for _, tt := range tests {
+		if got := m.getSemitoneDelta(48, tt.offset); got != tt.want {
+			t.Fatalf("offset %v: expected a delta of %d, got %d", tt.offset, tt.want, got)
+		}
+	}
+}
@@ -3,6 +3,7 @@ package machine
 import (
 	"errors"
 	"fmt"
+	"math/rand"
 
 	"github.com/gotracker/opl2"
 	"github.com/gotracker/playback/mixing/sampling"
@@ -18,6 +19,7 @@ import (
 	"github.com/gotracker/playback/player/render"
 	"github.com/gotracker/playback/player/sampler"
 	"github.com/gotracker/playback/song"
+	"github.com/gotracker/playback/voice"
 	"github.com/gotracker/playback/voice/oscillator"
 	"github.com/gotracker/playback/voice/types"
 )
@@ -48,6 +50,17 @@ type MachineTicker interface {
 	Tick(s *sampler.Sampler) error
 	Advance() error
 	Render(s *sampler.Sampler) error
+
+	// Subscribe adds a handler for the musical events of the machine and returns a function
+	// that removes it
+	Subscribe(handler EventHandler) func()
+	// Controls returns the control surface for changing playback from other goroutines
+	Controls() *Controls
+	// Jam returns the surface for playing notes live on top of the song
+	Jam() *Jam
+	// Snapshot returns the playing state of the song and its channels as left by the last
+	// advance
+	Snapshot() Snapshot
 }
 
 type Machine[TPeriod Period, TGlobalVolume, TMixingVolume, TVolume Volume, TPanning Panning] interface {
@@ -69,6 +82,7 @@ type Machine[TPeriod Period, TGlobalVolume, TMixingVolume, TVolume Volume, TPann
 	SetOrder(o index.Order) error
 	SetRow(r index.Row, breakOrder bool) error
 	SetFilterOnAllChannelsByFilterName(name string, enabled bool, params any) error
+	SetLEDFilter(enabled bool) error
 	GetPosition() Position
 
 	// Single Row
@@ -93,6 +107,9 @@ type Machine[TPeriod Period, TGlobalVolume, TMixingVolume, TVolume Volume, TPann
 	SetChannelPanningDelta(ch index.Channel, d types.PanDelta) error
 	SetChannelSurround(ch index.Channel, enabled bool) error
 	SetChannelFilter(ch index.Channel, f filter.Filter) error
+	SetChannelFilterCutoff(ch index.Channel, cutoff uint8) error
+	SetChannelFilterResonance(ch index.Channel, resonance uint8) error
+	SendChannelMIDIMacro(ch index.Channel, msg []byte) error
 	ChannelStopOrRelease(ch index.Channel) error
 	ChannelStop(ch index.Channel) error
 	ChannelRelease(ch index.Channel) error
@@ -103,6 +120,7 @@ type Machine[TPeriod Period, TGlobalVolume, TMixingVolume, TVolume Volume, TPann
 	SetPatternLoops(ch index.Channel, count int) error
 	StartChannelPortaToNote(ch index.Channel) error
 	DoChannelPortaToNote(ch index.Channel, delta period.Delta) error
+	DoChannelGlissando(ch index.Channel) error
 	DoChannelPortaDown(ch index.Channel, delta period.Delta) error
 	DoChannelPortaUp(ch index.Channel, delta period.Delta) error
 	DoChannelArpeggio(ch index.Channel, delta int8) error
@@ -133,15 +151,33 @@ type machine[TPeriod Period, TGlobalVolume, TMixingVolume, TVolume Volume, TPann
 	channels  []channel[TPeriod, TGlobalVolume, TMixingVolume, TVolume, TPanning]
 	pastNotes []pastNote[TPeriod]
 
-	ticker ticker
-	age    int
+	ticker          ticker
+	age             int
+	samplesRendered int64
+
+	subscribers      []eventSubscriber
+	nextSubscriberID int
+
+	controls     *Controls
+	control      controlState
+	channelGains []volume.Volume // nil when the controls leave every channel as it is
+
+	jam        *Jam
+	jamOutputs []jamOutput[TPeriod]
 
 	songData       song.Data
 	ms             *settings.MachineSettings[TPeriod, TGlobalVolume, TMixingVolume, TVolume, TPanning]
 	us             settings.UserSettings
 	opl2           *opl2.Chip
+	opl2Writer     voice.OPL2Chip
 	opl2Enabled    bool
+	rng            *rand.Rand
 	hardwareSynths []hardwareSynth
+	outputFilter   filter.Filter
+
+	// copies of the post-mix chain for each stem, when rendering stems
+	stemChains      []filter.Chain
+	stemChainSource filter.Chain
 
 	rowStringer render.RowStringer
 	// 1:1 with channels
@@ -258,6 +294,7 @@ func (m *machine[TPeriod, TGlobalVolume, TMixingVolume, TVolume, TPanning]) upda
 			}
 
 			c.instructions = insts
+			eventEffectTriggered(m, ch, insts)
 		}
 		return true, nil
 	})
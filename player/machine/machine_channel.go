@@ -219,6 +219,9 @@ func (m *machine[TPeriod, TGlobalVolume, TMixingVolume, TVolume, TPanning]) SetC
 	return withChannel(m, ch, func(c *channel[TPeriod, TGlobalVolume, TMixingVolume, TVolume, TPanning]) error {
 		traceChannelValueChangeWithComment(m, ch, "surround", c.surround, enabled, "SetChannelSurround")
 		c.surround = enabled
+		if surMod, ok := c.cv.(voice.SurroundModulator); ok {
+			return surMod.SetSurround(enabled)
+		}
 		return nil
 	})
 }
@@ -231,16 +234,48 @@ func (m *machine[TPeriod, TGlobalVolume, TMixingVolume, TVolume, TPanning]) SetC
 	})
 }
 
+func (m *machine[TPeriod, TGlobalVolume, TMixingVolume, TVolume, TPanning]) SetChannelFilterCutoff(ch index.Channel, cutoff uint8) error {
+	return withChannel(m, ch, func(c *channel[TPeriod, TGlobalVolume, TMixingVolume, TVolume, TPanning]) error {
+		if filtMod, ok := c.cv.(voice.FilterModulator); ok {
+			traceChannelWithComment(m, ch, "SetChannelFilterCutoff", "cutoff=%d", cutoff)
+			return filtMod.SetFilterCutoff(cutoff)
+		}
+		return nil
+	})
+}
+
+func (m *machine[TPeriod, TGlobalVolume, TMixingVolume, TVolume, TPanning]) SetChannelFilterResonance(ch index.Channel, resonance uint8) error {
+	return withChannel(m, ch, func(c *channel[TPeriod, TGlobalVolume, TMixingVolume, TVolume, TPanning]) error {
+		if filtMod, ok := c.cv.(voice.FilterModulator); ok {
+			traceChannelWithComment(m, ch, "SetChannelFilterResonance", "resonance=%d", resonance)
+			return filtMod.SetFilterResonance(resonance)
+		}
+		return nil
+	})
+}
+
+func (m *machine[TPeriod, TGlobalVolume, TMixingVolume, TVolume, TPanning]) SendChannelMIDIMacro(ch index.Channel, msg []byte) error {
+	return withChannel(m, ch, func(c *channel[TPeriod, TGlobalVolume, TMixingVolume, TVolume, TPanning]) error {
+		traceChannelWithComment(m, ch, "SendChannelMIDIMacro", "msg=% X", msg)
+		if m.us.MIDIMacroHandler != nil {
+			m.us.MIDIMacroHandler(ch, msg)
+		}
+		return nil
+	})
+}
+
 func (m *machine[TPeriod, TGlobalVolume, TMixingVolume, TVolume, TPanning]) ChannelStopOrRelease(ch index.Channel) error {
 	return withChannel(m, ch, func(c *channel[TPeriod, TGlobalVolume, TMixingVolume, TVolume, TPanning]) error {
 		traceChannel(m, ch, "ChannelStopOrRelease")
 		var n note.StopOrReleaseNote
 		if c.target.Inst != nil && c.target.Inst.IsReleaseNote(n) {
 			c.cv.Release()
+			eventNoteOff(m, ch)
 			return nil
 		}
 
 		c.cv.Stop()
+		eventCut(m, ch)
 		return nil
 	})
 }
@@ -249,6 +284,7 @@ func (m *machine[TPeriod, TGlobalVolume, TMixingVolume, TVolume, TPanning]) Chan
 	return withChannel(m, ch, func(c *channel[TPeriod, TGlobalVolume, TMixingVolume, TVolume, TPanning]) error {
 		traceChannel(m, ch, "ChannelStop")
 		c.cv.Stop()
+		eventCut(m, ch)
 		return nil
 	})
 }
@@ -257,6 +293,7 @@ func (m *machine[TPeriod, TGlobalVolume, TMixingVolume, TVolume, TPanning]) Chan
 	return withChannel(m, ch, func(c *channel[TPeriod, TGlobalVolume, TMixingVolume, TVolume, TPanning]) error {
 		traceChannel(m, ch, "ChannelRelease")
 		c.cv.Release()
+		eventNoteOff(m, ch)
 		return nil
 	})
 }
@@ -265,6 +302,7 @@ func (m *machine[TPeriod, TGlobalVolume, TMixingVolume, TVolume, TPanning]) Chan
 	return withChannel(m, ch, func(c *channel[TPeriod, TGlobalVolume, TMixingVolume, TVolume, TPanning]) error {
 		traceChannel(m, ch, "ChannelFadeout")
 		c.cv.Fadeout()
+		eventFadeout(m, ch)
 		return nil
 	})
 }
@@ -363,6 +401,26 @@ func (m *machine[TPeriod, TGlobalVolume, TMixingVolume, TVolume, TPanning]) DoCh
 	})
 }
 
+func (m *machine[TPeriod, TGlobalVolume, TMixingVolume, TVolume, TPanning]) DoChannelGlissando(ch index.Channel) error {
+	return withChannel(m, ch, func(c *channel[TPeriod, TGlobalVolume, TMixingVolume, TVolume, TPanning]) error {
+		if freqMod, ok := c.cv.(voice.FreqModulator[TPeriod]); ok {
+			// the underlying period continues to slide smoothly - only the output is snapped to the semitone.
+			// Periods are relative to the instrument, whose sample rate carries its finetune and relative
+			// note, so the semitones of the period table are the instrument's own.
+			p := freqMod.GetPeriod()
+			sp := m.ms.PeriodConverter.RoundToSemitone(p)
+			d := m.ms.PeriodConverter.GetDelta(p, sp)
+
+			// the snap goes on top of whatever else moves the pitch this tick, such as a vibrato
+			pd := freqMod.GetPeriodDelta()
+			traceChannelValueChangeWithComment(m, ch, "pd", pd, pd+d, "DoChannelGlissando")
+			c.glissando += d
+			return freqMod.SetPeriodDelta(pd + d)
+		}
+		return nil
+	})
+}
+
 func (m *machine[TPeriod, TGlobalVolume, TMixingVolume, TVolume, TPanning]) DoChannelPortaDown(ch index.Channel, delta period.Delta) error {
 	return withChannel(m, ch, func(c *channel[TPeriod, TGlobalVolume, TMixingVolume, TVolume, TPanning]) error {
 		if freqMod, ok := c.cv.(voice.FreqModulator[TPeriod]); ok {
@@ -0,0 +1,68 @@
+package machine
+
+import (
+	"testing"
+
+	"github.com/gotracker/playback/period"
+	"github.com/gotracker/playback/player/machine/settings"
+)
+
+// snapPeriodCalc is a period converter whose semitone is always `snap` away
+type snapPeriodCalc struct {
+	stubPeriodCalc
+	snap period.Delta
+}
+
+func (c snapPeriodCalc) GetDelta(stubPeriod, stubPeriod) period.Delta { return c.snap }
+
+// deltaVoice is a voice that keeps its period delta
+type deltaVoice struct {
+	jamVoice
+	pd period.Delta
+}
+
+func (v *deltaVoice) GetPeriodDelta() period.Delta        { return v.pd }
+func (v *deltaVoice) SetPeriodDelta(d period.Delta) error { v.pd = d; return nil }
+
+func TestDoChannelGlissandoAddsToThePeriodDelta(t *testing.T) {
+	cv := &deltaVoice{}
+	m := machine[stubPeriod, stubGV, stubGV, stubGV, stubPan]{
+		ms: &settings.MachineSettings[stubPeriod, stubGV, stubGV, stubGV, stubPan]{
+			PeriodConverter: snapPeriodCalc{snap: 3},
+		},
+		channels: make([]channel[stubPeriod, stubGV, stubGV, stubGV, stubPan], 1),
+	}
+	c := &m.channels[0]
+	c.cv = cv
+
+	// a vibrato moves the pitch first, then the glissando snaps on top of it
+	cv.pd = 10
+	if err := m.DoChannelGlissando(0); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cv.pd != 13 {
+		t.Fatalf("expected the glissando to add to the vibrato, got a delta of %d", cv.pd)
+	}
+
+	// the next tick takes the snap off before working it out again
+	if err := c.Tick(0, &m); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cv.pd != 10 {
+		t.Fatalf("expected the snap to come off on the next tick, got a delta of %d", cv.pd)
+	}
+	for i := 0; i < 2; i++ {
+		if err := m.DoChannelGlissando(0); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if err := c.Tick(0, &m); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if err := m.DoChannelGlissando(0); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cv.pd != 13 {
+		t.Fatalf("expected the snap not to build up over the ticks, got a delta of %d", cv.pd)
+	}
+}
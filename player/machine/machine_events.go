@@ -0,0 +1,180 @@
+package machine
+
+import (
+	"reflect"
+
+	"github.com/gotracker/playback/index"
+	"github.com/gotracker/playback/instrument"
+	"github.com/gotracker/playback/note"
+	"github.com/gotracker/playback/player/machine/instruction"
+)
+
+type eventSubscriber struct {
+	id      int
+	handler EventHandler
+}
+
+// Subscribe adds a handler for the events of the machine and returns a function that removes it
+func (m *machine[TPeriod, TGlobalVolume, TMixingVolume, TVolume, TPanning]) Subscribe(handler EventHandler) func() {
+	if handler == nil {
+		return func() {}
+	}
+
+	m.nextSubscriberID++
+	id := m.nextSubscriberID
+	m.subscribers = append(m.subscribers, eventSubscriber{
+		id:      id,
+		handler: handler,
+	})
+
+	return func() {
+		for i, s := range m.subscribers {
+			if s.id == id {
+				// copy, so a dispatch in progress keeps the list it started with
+				m.subscribers = append(m.subscribers[:i:i], m.subscribers[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+func (m *machine[TPeriod, TGlobalVolume, TMixingVolume, TVolume, TPanning]) eventInfo() EventInfo {
+	return EventInfo{
+		Position:     m.ticker.current,
+		SampleOffset: m.samplesRendered,
+	}
+}
+
+func (m *machine[TPeriod, TGlobalVolume, TMixingVolume, TVolume, TPanning]) emit(ev Event) {
+	for _, s := range m.subscribers {
+		s.handler(ev)
+	}
+}
+
+// the event functions below are cheap to call when nothing is subscribed, so they can sit next
+// to the tracing calls on the hot paths
+
+func eventNoteOn[TPeriod Period, TGlobalVolume, TMixingVolume, TVolume Volume, TPanning Panning](m *machine[TPeriod, TGlobalVolume, TMixingVolume, TVolume, TPanning], ch index.Channel, id instrument.ID, st note.Semitone) {
+	if len(m.subscribers) == 0 {
+		return
+	}
+	m.emit(NoteOnEvent{
+		EventInfo:  m.eventInfo(),
+		Channel:    ch,
+		Instrument: id,
+		Semitone:   st,
+	})
+}
+
+func eventNoteOff[TPeriod Period, TGlobalVolume, TMixingVolume, TVolume Volume, TPanning Panning](m *machine[TPeriod, TGlobalVolume, TMixingVolume, TVolume, TPanning], ch index.Channel) {
+	if len(m.subscribers) == 0 {
+		return
+	}
+	m.emit(NoteOffEvent{
+		EventInfo: m.eventInfo(),
+		Channel:   ch,
+	})
+}
+
+func eventFadeout[TPeriod Period, TGlobalVolume, TMixingVolume, TVolume Volume, TPanning Panning](m *machine[TPeriod, TGlobalVolume, TMixingVolume, TVolume, TPanning], ch index.Channel) {
+	if len(m.subscribers) == 0 {
+		return
+	}
+	m.emit(FadeoutEvent{
+		EventInfo: m.eventInfo(),
+		Channel:   ch,
+	})
+}
+
+func eventCut[TPeriod Period, TGlobalVolume, TMixingVolume, TVolume Volume, TPanning Panning](m *machine[TPeriod, TGlobalVolume, TMixingVolume, TVolume, TPanning], ch index.Channel) {
+	if len(m.subscribers) == 0 {
+		return
+	}
+	m.emit(CutEvent{
+		EventInfo: m.eventInfo(),
+		Channel:   ch,
+	})
+}
+
+func eventInstrumentChange[TPeriod Period, TGlobalVolume, TMixingVolume, TVolume Volume, TPanning Panning](m *machine[TPeriod, TGlobalVolume, TMixingVolume, TVolume, TPanning], ch index.Channel, prev, next instrument.ID) {
+	if len(m.subscribers) == 0 || reflect.DeepEqual(prev, next) {
+		return
+	}
+	m.emit(InstrumentChangeEvent{
+		EventInfo:  m.eventInfo(),
+		Channel:    ch,
+		Previous:   prev,
+		Instrument: next,
+	})
+}
+
+func eventEffectTriggered[TPeriod Period, TGlobalVolume, TMixingVolume, TVolume Volume, TPanning Panning](m *machine[TPeriod, TGlobalVolume, TMixingVolume, TVolume, TPanning], ch index.Channel, effects []instruction.Instruction) {
+	if len(m.subscribers) == 0 {
+		return
+	}
+	for _, effect := range effects {
+		m.emit(EffectTriggeredEvent{
+			EventInfo: m.eventInfo(),
+			Channel:   ch,
+			Effect:    effect,
+		})
+	}
+}
+
+func eventRowStart[TPeriod Period, TGlobalVolume, TMixingVolume, TVolume Volume, TPanning Panning](m *machine[TPeriod, TGlobalVolume, TMixingVolume, TVolume, TPanning]) {
+	if len(m.subscribers) == 0 {
+		return
+	}
+	m.emit(RowStartEvent{
+		EventInfo: m.eventInfo(),
+	})
+}
+
+func eventOrderStart[TPeriod Period, TGlobalVolume, TMixingVolume, TVolume Volume, TPanning Panning](m *machine[TPeriod, TGlobalVolume, TMixingVolume, TVolume, TPanning]) {
+	if len(m.subscribers) == 0 {
+		return
+	}
+	ev := OrderStartEvent{
+		EventInfo: m.eventInfo(),
+		Pattern:   index.InvalidPattern,
+	}
+	if orders := m.songData.GetOrderList(); int(m.ticker.current.Order) < len(orders) {
+		ev.Pattern = orders[m.ticker.current.Order]
+	}
+	m.emit(ev)
+}
+
+func eventTempoChange[TPeriod Period, TGlobalVolume, TMixingVolume, TVolume Volume, TPanning Panning](m *machine[TPeriod, TGlobalVolume, TMixingVolume, TVolume, TPanning], prev, tempo int) {
+	if len(m.subscribers) == 0 || prev == tempo {
+		return
+	}
+	m.emit(TempoChangeEvent{
+		EventInfo: m.eventInfo(),
+		Previous:  prev,
+		Tempo:     tempo,
+	})
+}
+
+func eventBPMChange[TPeriod Period, TGlobalVolume, TMixingVolume, TVolume Volume, TPanning Panning](m *machine[TPeriod, TGlobalVolume, TMixingVolume, TVolume, TPanning], prev, bpm int) {
+	if len(m.subscribers) == 0 || prev == bpm {
+		return
+	}
+	m.emit(BPMChangeEvent{
+		EventInfo: m.eventInfo(),
+		Previous:  prev,
+		BPM:       bpm,
+	})
+}
+
+func eventPatternLoop[TPeriod Period, TGlobalVolume, TMixingVolume, TVolume Volume, TPanning Panning](m *machine[TPeriod, TGlobalVolume, TMixingVolume, TVolume, TPanning], ch index.Channel, start index.Row, count, total int) {
+	if len(m.subscribers) == 0 {
+		return
+	}
+	m.emit(PatternLoopEvent{
+		EventInfo: m.eventInfo(),
+		Channel:   ch,
+		Start:     start,
+		Count:     count,
+		Total:     total,
+	})
+}
@@ -0,0 +1,219 @@
+package machine
+
+import (
+	"testing"
+
+	"github.com/gotracker/playback/index"
+	"github.com/gotracker/playback/note"
+	"github.com/gotracker/playback/player/machine/settings"
+	"github.com/gotracker/playback/player/sampler"
+	"github.com/gotracker/playback/song"
+	"github.com/gotracker/playback/voice"
+)
+
+// keyVoice is a channel voice that only supports being released, faded out and stopped
+type keyVoice struct {
+	voice.RenderVoice[stubPeriod, stubGV, stubGV, stubGV, stubPan]
+	released bool
+	faded    bool
+	stopped  bool
+}
+
+func (v *keyVoice) Release() { v.released = true }
+func (v *keyVoice) Fadeout() { v.faded = true }
+func (v *keyVoice) Stop()    { v.stopped = true }
+
+type eventRecorder struct {
+	events []Event
+}
+
+func (r *eventRecorder) handle(ev Event) {
+	r.events = append(r.events, ev)
+}
+
+func TestSubscribeReceivesSequencingEvents(t *testing.T) {
+	pat := song.Pattern{song.Row(0), song.Row(1)}
+	m := machine[stubPeriod, stubGV, stubGV, stubGV, stubPan]{
+		globals:  globals[stubGV]{tempo: 1, bpm: 6, gv: stubGV(1), mv: 1},
+		ms:       &settings.MachineSettings[stubPeriod, stubGV, stubGV, stubGV, stubPan]{PeriodConverter: stubPeriodCalc{}},
+		songData: playSongData{pat: pat},
+	}
+
+	var rec eventRecorder
+	m.Subscribe(rec.handle)
+
+	if err := initTick(&m.ticker, &m, tickerSettings{SongLoopCount: -1}); err != nil {
+		t.Fatalf("initTick error: %v", err)
+	}
+
+	s := sampler.NewSampler(10, 2, 1, nil)
+	for i := 0; i < 2; i++ {
+		if err := m.Tick(s); err != nil {
+			t.Fatalf("tick %d error: %v", i, err)
+		}
+	}
+
+	var (
+		orders []OrderStartEvent
+		rows   []RowStartEvent
+	)
+	for _, ev := range rec.events {
+		switch e := ev.(type) {
+		case OrderStartEvent:
+			orders = append(orders, e)
+		case RowStartEvent:
+			rows = append(rows, e)
+		default:
+			t.Fatalf("unexpected event: %#v", ev)
+		}
+	}
+
+	if len(orders) != 2 || orders[0].Pattern != 0 || orders[0].SampleOffset != 0 {
+		t.Fatalf("unexpected order start events: %+v", orders)
+	}
+	if orders[1].Position.Order != 0 || orders[1].SampleOffset != 10 {
+		t.Fatalf("expected the song to loop back to the first order, got %+v", orders[1])
+	}
+
+	wantRows := []struct {
+		row    index.Row
+		offset int64
+	}{
+		{0, 0},
+		{1, 0},  // raised while advancing, ahead of the first render
+		{0, 10}, // after a second of audio at 10Hz
+	}
+	if len(rows) != len(wantRows) {
+		t.Fatalf("expected %d row start events, got %+v", len(wantRows), rows)
+	}
+	for i, want := range wantRows {
+		if rows[i].Position.Row != want.row || rows[i].Info().SampleOffset != want.offset {
+			t.Fatalf("row start event %d: got %+v, want row %d at offset %d", i, rows[i], want.row, want.offset)
+		}
+	}
+}
+
+func TestSubscribeTempoAndBPMChanges(t *testing.T) {
+	m := machine[stubPeriod, stubGV, stubGV, stubGV, stubPan]{
+		globals: globals[stubGV]{tempo: 6, bpm: 125},
+	}
+
+	var rec eventRecorder
+	unsubscribe := m.Subscribe(rec.handle)
+
+	if err := m.SetTempo(3); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := m.SetTempo(3); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := m.SlideBPM(5); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	unsubscribe()
+	if err := m.SetBPM(100); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(rec.events) != 2 {
+		t.Fatalf("expected a tempo and a BPM change, got %+v", rec.events)
+	}
+	if e, ok := rec.events[0].(TempoChangeEvent); !ok || e.Previous != 6 || e.Tempo != 3 {
+		t.Fatalf("unexpected tempo change: %#v", rec.events[0])
+	}
+	if e, ok := rec.events[1].(BPMChangeEvent); !ok || e.Previous != 125 || e.BPM != 130 {
+		t.Fatalf("unexpected BPM change: %#v", rec.events[1])
+	}
+}
+
+func TestChannelNoteEvents(t *testing.T) {
+	m := machine[stubPeriod, stubGV, stubGV, stubGV, stubPan]{
+		channels: make([]channel[stubPeriod, stubGV, stubGV, stubGV, stubPan], 2),
+	}
+	for i := range m.channels {
+		m.channels[i].cv = &keyVoice{}
+	}
+	m.ticker.current = Position{Order: 1, Row: 2, Tick: 3}
+
+	var rec eventRecorder
+	m.Subscribe(rec.handle)
+
+	if err := m.ChannelRelease(1); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := m.ChannelFadeout(1); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := m.ChannelStop(0); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	c := &m.channels[0]
+	c.target.ActionTick.Set(ActionTick{Action: note.ActionRelease, Tick: 3})
+	if err := c.DoNoteAction(0, &m); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(rec.events) != 4 {
+		t.Fatalf("expected 4 events, got %+v", rec.events)
+	}
+	if e, ok := rec.events[0].(NoteOffEvent); !ok || e.Channel != 1 || e.Position != m.ticker.current {
+		t.Fatalf("unexpected release event: %#v", rec.events[0])
+	}
+	if e, ok := rec.events[1].(FadeoutEvent); !ok || e.Channel != 1 {
+		t.Fatalf("unexpected fadeout event: %#v", rec.events[1])
+	}
+	if e, ok := rec.events[2].(CutEvent); !ok || e.Channel != 0 {
+		t.Fatalf("unexpected cut event: %#v", rec.events[2])
+	}
+	if e, ok := rec.events[3].(NoteOffEvent); !ok || e.Channel != 0 {
+		t.Fatalf("expected the note action to raise a release event, got %#v", rec.events[3])
+	}
+}
+
+func TestPatternLoopEvent(t *testing.T) {
+	m := machine[stubPeriod, stubGV, stubGV, stubGV, stubPan]{}
+	m.ticker.current.Row = 4
+
+	var rec eventRecorder
+	m.Subscribe(rec.handle)
+
+	var c channel[stubPeriod, stubGV, stubGV, stubGV, stubPan]
+	c.patternLoop.Start = 1
+	c.patternLoop.End = 4
+	c.patternLoop.Total = 2
+
+	for i := 0; i < 3; i++ {
+		if err := c.doPatternLoop(2, &m); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	if len(rec.events) != 2 {
+		t.Fatalf("expected an event for each jump back, got %+v", rec.events)
+	}
+	for i, ev := range rec.events {
+		e, ok := ev.(PatternLoopEvent)
+		if !ok || e.Channel != 2 || e.Start != 1 || e.Count != i+1 || e.Total != 2 {
+			t.Fatalf("unexpected pattern loop event %d: %#v", i, ev)
+		}
+	}
+}
+
+func TestInstrumentChangeEventSkipsSameInstrument(t *testing.T) {
+	m := machine[stubPeriod, stubGV, stubGV, stubGV, stubPan]{}
+
+	var rec eventRecorder
+	m.Subscribe(rec.handle)
+
+	eventInstrumentChange(&m, 0, stubInstID{1, 1}, stubInstID{1, 1})
+	eventInstrumentChange(&m, 0, nil, stubInstID{2, 1})
+
+	if len(rec.events) != 1 {
+		t.Fatalf("expected a single instrument change, got %+v", rec.events)
+	}
+	if e := rec.events[0].(InstrumentChangeEvent); e.Previous != nil || e.Instrument != (stubInstID{2, 1}) {
+		t.Fatalf("unexpected instrument change: %#v", e)
+	}
+}
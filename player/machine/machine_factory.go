@@ -2,13 +2,14 @@ package machine
 
 import (
 	"fmt"
+	"math/rand"
 	"reflect"
-
-	"github.com/gotracker/opl2"
+	"time"
 
 	"github.com/gotracker/playback/index"
 	"github.com/gotracker/playback/mixing/volume"
 	"github.com/gotracker/playback/note"
+	"github.com/gotracker/playback/period"
 	"github.com/gotracker/playback/player/machine/settings"
 	"github.com/gotracker/playback/player/render"
 	"github.com/gotracker/playback/song"
@@ -65,9 +66,18 @@ func RegisterMachine[TPeriod Period, TGlobalVolume, TMixingVolume, TVolume Volum
 		m.songData = songData
 		m.us = us
 
+		seed := time.Now().UnixNano()
+		if s, set := us.RandomSeed.Get(); set {
+			seed = s
+		}
+		m.rng = rand.New(rand.NewSource(seed))
+
 		// Apply quirks overrides (user profile or flag overrides)
 		msCopy := *m.ms
 		msCopy.Quirks = resolveQuirks(msCopy.Quirks, us)
+		if sel, ok := msCopy.PeriodConverter.(period.FrequencyTableSelector[TPeriod]); ok {
+			msCopy.PeriodConverter = sel.WithFrequencyTable(msCopy.Quirks.LinearFrequencyTable)
+		}
 		m.ms = &msCopy
 
 		order := songData.GetInitialOrder()
@@ -113,10 +123,21 @@ func RegisterMachine[TPeriod Period, TGlobalVolume, TMixingVolume, TVolume Volum
 			return nil, err
 		}
 
+		if info := m.ms.OutputFilter; info.Name != "" {
+			m.outputFilter, err = m.ms.GetFilterFactory(info.Name, sys.GetCommonRate(), info.Params)
+			if err != nil {
+				return nil, err
+			}
+		}
+
 		channels := songData.GetNumChannels()
 
 		m.channels = make([]channel[TPeriod, TGlobalVolume, TMixingVolume, TVolume, TPanning], channels)
 		m.actualOutputs = make([]render.Channel[TPeriod], channels)
+		m.controls = newControls(channels)
+		m.control = newControlState(channels)
+		m.jam = newJam(songData)
+		m.jamOutputs = make([]jamOutput[TPeriod], JamPolyphony)
 
 		m.opl2Enabled = songData.IsOPL2Enabled()
 
@@ -141,8 +162,8 @@ func RegisterMachine[TPeriod Period, TGlobalVolume, TMixingVolume, TVolume Volum
 
 				rc.OutputFilter = filt
 			}
-			rc.GetOPL2Chip = func() *opl2.Chip {
-				return m.opl2
+			rc.GetOPL2Chip = func() voice.OPL2Chip {
+				return m.opl2Writer
 			}
 
 			initialVolume, err := song.GetChannelInitialVolume[TVolume](cs)
@@ -165,13 +186,14 @@ func RegisterMachine[TPeriod Period, TGlobalVolume, TMixingVolume, TVolume Volum
 			c := &m.channels[ch]
 			c.enabled = cs.IsEnabled()
 			c.cv = m.ms.VoiceFactory.NewVoice(voice.VoiceConfig[TPeriod, TGlobalVolume, TMixingVolume, TVolume, TPanning]{
-				PC:               ms.PeriodConverter,
+				PC:               m.ms.PeriodConverter,
 				OPLChannel:       cs.GetOPLChannel(),
 				InitialVolume:    initialVolume,
 				InitialMixing:    initialMixing,
 				PanEnabled:       cs.IsPanEnabled(),
 				InitialPan:       initialPan,
 				Vol0Optimization: cs.GetVol0OptimizationSettings(),
+				Random:           m.rng,
 			})
 			c.memory = cs.GetMemory()
 			rc.StartVoice(c.cv, func() {}) // can't remove this channel, as it's hard-wired into actual
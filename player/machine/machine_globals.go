@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 
+	"github.com/gotracker/playback/filter"
 	"github.com/gotracker/playback/index"
 	"github.com/gotracker/playback/mixing/volume"
 )
@@ -19,6 +20,8 @@ type globals[TGlobalVolume Volume] struct {
 
 	patternLoopStart index.Row
 	patternLoopCount int
+
+	ledFilter bool
 }
 
 func (m *machine[TPeriod, TGlobalVolume, TMixingVolume, TVolume, TPanning]) SetTempo(tempo int) error {
@@ -27,6 +30,7 @@ func (m *machine[TPeriod, TGlobalVolume, TMixingVolume, TVolume, TPanning]) SetT
 	}
 
 	traceValueChangeWithComment(m, "tempo", m.tempo, tempo, "SetTempo")
+	eventTempoChange(m, m.tempo, tempo)
 	m.tempo = tempo
 
 	return nil
@@ -38,6 +42,7 @@ func (m *machine[TPeriod, TGlobalVolume, TMixingVolume, TVolume, TPanning]) SetB
 	}
 
 	traceValueChangeWithComment(m, "bpm", m.bpm, bpm, "SetBPM")
+	eventBPMChange(m, m.bpm, bpm)
 	m.bpm = bpm
 
 	return nil
@@ -54,6 +59,7 @@ func (m *machine[TPeriod, TGlobalVolume, TMixingVolume, TVolume, TPanning]) Slid
 	}
 
 	traceValueChangeWithComment(m, "bpm", m.bpm, bpm, "SlideBPM")
+	eventBPMChange(m, m.bpm, bpm)
 	m.bpm = bpm
 
 	return nil
@@ -161,3 +167,16 @@ func (m *machine[TPeriod, TGlobalVolume, TMixingVolume, TVolume, TPanning]) SetF
 		return true, nil
 	})
 }
+
+// SetLEDFilter switches the "LED" stage of the channel output filters that have one
+func (m *machine[TPeriod, TGlobalVolume, TMixingVolume, TVolume, TPanning]) SetLEDFilter(enabled bool) error {
+	traceValueChangeWithComment(m, "ledFilter", m.ledFilter, enabled, "SetLEDFilter")
+	m.ledFilter = enabled
+
+	for i := range m.actualOutputs {
+		if led, ok := m.actualOutputs[i].OutputFilter.(filter.LEDFilter); ok {
+			led.SetLED(enabled)
+		}
+	}
+	return nil
+}
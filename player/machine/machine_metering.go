@@ -0,0 +1,61 @@
+package machine
+
+import (
+	"github.com/gotracker/playback/frequency"
+	"github.com/gotracker/playback/index"
+	"github.com/gotracker/playback/mixing"
+	"github.com/gotracker/playback/player/metering"
+	"github.com/gotracker/playback/player/render"
+	"github.com/gotracker/playback/voice"
+)
+
+// meterVoice returns the playing state of a channel's voice. It has to be read ahead of the
+// render, as rendering ticks the voice on to the state of the next tick.
+func (m *machine[TPeriod, TGlobalVolume, TMixingVolume, TVolume, TPanning]) meterVoice(ch index.Channel, rc *render.Channel[TPeriod]) metering.Channel {
+	mc := metering.Channel{
+		Channel: ch,
+	}
+
+	v := rc.GetVoice()
+	if v == nil || v.IsDone() {
+		return mc
+	}
+	mc.Active = true
+
+	if int(ch) < len(m.channels) {
+		if id := m.channels[ch].playing.ID; id != nil && !id.IsEmpty() {
+			mc.Instrument = id
+		}
+	}
+
+	if fm, ok := v.(voice.FreqModulator[TPeriod]); ok {
+		// an error here is reported by the render
+		if p, err := fm.GetFinalPeriod(); err == nil && !p.IsInvalid() {
+			mc.Period = p
+			// the rate the sample plays at, as in the snapshots
+			mc.Frequency = frequency.Frequency(m.ms.PeriodConverter.GetSamplerAdd(p, v.GetSampleRate(), 1))
+		}
+	}
+
+	if pm, ok := v.(voice.PanModulator[TPanning]); ok {
+		mc.Pan = pm.GetFinalPan()
+	}
+
+	return mc
+}
+
+// reportMetering measures the rendered voice data of each channel and hands the results to the
+// metering handler
+func (m *machine[TPeriod, TGlobalVolume, TMixingVolume, TVolume, TPanning]) reportMetering(frame renderFrame, mixData []mixing.Data, meters []metering.Channel) {
+	stems, _ := m.groupStems(mixData)
+	for i := range meters {
+		meters[i].Measure(stems[i], frame.details.Samples, frame.details.Mix.Channels, m.us.Metering.ScopeSamples)
+	}
+
+	m.us.Metering.Handler(metering.Tick{
+		Order:    frame.renderRow.Order,
+		Row:      frame.renderRow.Row,
+		Tick:     frame.renderRow.Tick,
+		Channels: meters,
+	})
+}
@@ -8,6 +8,7 @@ import (
 	"github.com/gotracker/playback/mixing"
 	"github.com/gotracker/playback/mixing/panning"
 	"github.com/gotracker/playback/mixing/volume"
+	"github.com/gotracker/playback/player/oplrecord"
 	"github.com/gotracker/playback/player/sampler"
 	"github.com/gotracker/playback/voice"
 	"github.com/gotracker/playback/voice/mixer"
@@ -19,29 +20,38 @@ func (m *machine[TPeriod, TGlobalVolume, TMixingVolume, TVolume, TPanning]) setu
 	}
 
 	o := opl2.NewChip(uint32(s.SampleRate), false)
-	o.WriteReg(0x01, 0x20) // enable all waveforms
-	o.WriteReg(0x04, 0x00) // clear timer flags
-	o.WriteReg(0x08, 0x40) // clear CSW and set NOTE-SEL
-	o.WriteReg(0xBD, 0x00) // set default notes
 	m.opl2 = o
 
+	// route register writes through the recorder, if one was requested
+	var chip voice.OPL2Chip = o
+	if rec := m.us.OPL2Recorder; rec != nil {
+		chip = rec.Attach(o, s.SampleRate)
+	}
+	m.opl2Writer = chip
+
+	chip.WriteReg(0x01, 0x20) // enable all waveforms
+	chip.WriteReg(0x04, 0x00) // clear timer flags
+	chip.WriteReg(0x08, 0x40) // clear CSW and set NOTE-SEL
+	chip.WriteReg(0xBD, 0x00) // set default notes
+
 	for i := range m.actualOutputs {
 		rc := &m.actualOutputs[i]
 		if v, _ := rc.GetVoice().(voice.VoiceOPL2er); v != nil {
-			v.SetOPL2Chip(m.opl2)
+			v.SetOPL2Chip(m.opl2Writer)
 		}
 	}
 
 	for i := range m.virtualOutputs {
 		rc := &m.virtualOutputs[i]
 		if v, _ := rc.GetVoice().(voice.VoiceOPL2er); v != nil {
-			v.SetOPL2Chip(m.opl2)
+			v.SetOPL2Chip(m.opl2Writer)
 		}
 	}
 
 	m.hardwareSynths = append(m.hardwareSynths, opl2Synth{
 		chip: m.opl2,
-		gv:   func() volume.Volume { return m.gv.ToVolume() },
+		rec:  m.us.OPL2Recorder,
+		gv:   func() volume.Volume { return m.globalVolume() },
 	})
 
 	return nil
@@ -49,6 +59,7 @@ func (m *machine[TPeriod, TGlobalVolume, TMixingVolume, TVolume, TPanning]) setu
 
 type opl2Synth struct {
 	chip *opl2.Chip
+	rec  *oplrecord.Recorder
 	gv   func() volume.Volume
 }
 
@@ -60,6 +71,10 @@ func (o opl2Synth) RenderTick(centerAheadPan panning.PanMixer, details mixer.Det
 		chip.GenerateBlock2(uint(details.Samples), opl2data)
 	}
 
+	if o.rec != nil {
+		o.rec.Advance(details.Samples)
+	}
+
 	for i, s := range opl2data {
 		sv := volume.Volume(s) / 32768.0
 		data[i].Assign(1, []volume.Volume{sv})
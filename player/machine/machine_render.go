@@ -3,12 +3,14 @@ package machine
 import (
 	"fmt"
 
+	"github.com/gotracker/playback/filter"
 	"github.com/gotracker/playback/frequency"
 	"github.com/gotracker/playback/index"
 	"github.com/gotracker/playback/mixing"
 	"github.com/gotracker/playback/mixing/panning"
 	"github.com/gotracker/playback/mixing/volume"
 	"github.com/gotracker/playback/output"
+	"github.com/gotracker/playback/player/metering"
 	"github.com/gotracker/playback/player/render"
 	"github.com/gotracker/playback/player/sampler"
 	"github.com/gotracker/playback/voice/mixer"
@@ -25,8 +27,21 @@ func (m *machine[TPeriod, TGlobalVolume, TMixingVolume, TVolume, TPanning]) rend
 		return nil, err
 	}
 
-	if len(mixData) > 0 {
-		frame.premix.Data = append(frame.premix.Data, mixData)
+	jamData, err := m.renderJam(frame)
+	if err != nil {
+		return nil, err
+	}
+
+	if frame.stems {
+		frame.premix.Data, frame.premix.Channels = m.groupStems(mixData)
+		if m.jam != nil {
+			// the notes played on the jam are a stem of their own, kept while nothing is playing
+			// so the stems after it don't move when a note starts
+			frame.premix.Data = append(frame.premix.Data, jamData)
+			frame.premix.Channels = append(frame.premix.Channels, index.JamChannel)
+		}
+	} else if len(mixData)+len(jamData) > 0 {
+		frame.premix.Data = append(frame.premix.Data, append(mixData, jamData...))
 	}
 
 	if err := m.mixHardwareSynths(frame, &frame.premix); err != nil {
@@ -35,6 +50,17 @@ func (m *machine[TPeriod, TGlobalVolume, TMixingVolume, TVolume, TPanning]) rend
 
 	m.normalizePremix(&frame)
 
+	if frame.stems {
+		// whatever was added after the channels can't be split up by channel
+		for len(frame.premix.Channels) < len(frame.premix.Data) {
+			frame.premix.Channels = append(frame.premix.Channels, index.InvalidChannel)
+		}
+	}
+
+	m.applyPostMix(&frame, s.PostMixChain())
+
+	m.samplesRendered += int64(frame.premix.SamplesLen)
+
 	return &frame.premix, nil
 }
 
@@ -43,6 +69,7 @@ type renderFrame struct {
 	premix         output.PremixData
 	details        mixer.Details
 	centerAheadPan panning.PanMixer
+	stems          bool
 }
 
 func (m *machine[TPeriod, TGlobalVolume, TMixingVolume, TVolume, TPanning]) prepareRenderFrame(s *sampler.Sampler) (renderFrame, error) {
@@ -59,7 +86,7 @@ func (m *machine[TPeriod, TGlobalVolume, TMixingVolume, TVolume, TPanning]) prep
 
 	premix := output.PremixData{
 		SamplesLen:  int(float64(s.SampleRate) * tickDuration.Seconds()),
-		MixerVolume: m.gv.ToVolume() * m.mv,
+		MixerVolume: m.globalVolume() * m.mv,
 		Userdata:    &renderRow,
 	}
 
@@ -84,16 +111,27 @@ func (m *machine[TPeriod, TGlobalVolume, TMixingVolume, TVolume, TPanning]) prep
 		premix:         premix,
 		details:        details,
 		centerAheadPan: centerAheadPan,
+		stems:          s.Stems,
 	}, nil
 }
 
 func (m *machine[TPeriod, TGlobalVolume, TMixingVolume, TVolume, TPanning]) renderVoices(frame renderFrame) ([]mixing.Data, error) {
 	var mixData []mixing.Data
 
+	meter := m.us.Metering.Handler != nil
+	var meters []metering.Channel
+	if meter {
+		meters = make([]metering.Channel, len(m.actualOutputs))
+	}
+
 	for i := range m.actualOutputs {
 		rc := &m.actualOutputs[i]
 
-		rc.GlobalVolume = m.gv.ToVolume()
+		rc.GlobalVolume = m.globalVolume()
+
+		if meter {
+			meters[i] = m.meterVoice(index.Channel(i), rc)
+		}
 
 		rc.GetVoice().DumpState(index.Channel(i), m.us.Tracer)
 		data, err := rc.RenderAndTick(m.ms.PeriodConverter, frame.centerAheadPan, frame.details)
@@ -119,7 +157,7 @@ func (m *machine[TPeriod, TGlobalVolume, TMixingVolume, TVolume, TPanning]) rend
 
 		var data *mixing.Data
 		if rc.GetVoice() != nil {
-			rc.GlobalVolume = m.gv.ToVolume()
+			rc.GlobalVolume = m.globalVolume()
 
 			//rc.GetVoice().DumpState(index.Channel(i), m.us.Tracer)
 			var err error
@@ -142,6 +180,12 @@ func (m *machine[TPeriod, TGlobalVolume, TMixingVolume, TVolume, TPanning]) rend
 		}
 	}
 
+	m.applyChannelGains(mixData)
+
+	if meter {
+		m.reportMetering(frame, mixData, meters)
+	}
+
 	return mixData, nil
 }
 
@@ -182,3 +226,77 @@ func (m *machine[TPeriod, TGlobalVolume, TMixingVolume, TVolume, TPanning]) norm
 	})
 	return
 }
+
+// applyPostMix mixes the premix data down and runs it through the output filter, which models
+// the output stage of the sound hardware, then through the master chain. Both have to see the
+// final mix, including the mixer volume.
+func (m *machine[TPeriod, TGlobalVolume, TMixingVolume, TVolume, TPanning]) applyPostMix(frame *renderFrame, master filter.Chain) {
+	var chain filter.Chain
+	if m.outputFilter != nil {
+		chain = append(chain, m.outputFilter)
+	}
+	chain = append(chain, master...)
+	if len(chain) == 0 {
+		return
+	}
+
+	chain.SetPlaybackRate(frame.details.SampleRate)
+
+	if frame.stems {
+		m.applyStemPostMix(frame, chain)
+		return
+	}
+
+	mixBuffer := mixDownPremix(frame, frame.premix.Data)
+	for i, samp := range mixBuffer {
+		mixBuffer[i] = chain.Filter(samp)
+	}
+
+	frame.premix.MixerVolume = 1
+	frame.premix.Data = []mixing.ChannelData{
+		postMixData(frame, mixBuffer),
+	}
+}
+
+// mixDownPremix mixes the premix data provided down to the output channels, applying the
+// mixer volume
+func mixDownPremix(frame *renderFrame, premix []mixing.ChannelData) mixing.MixBuffer {
+	channels := frame.details.Mix.Channels
+	mixBuffer := frame.details.Mix.NewMixBuffer(frame.premix.SamplesLen)
+	for _, cdata := range premix {
+		for _, data := range cdata {
+			if data.Flush != nil {
+				data.Flush()
+			}
+			if len(data.Data) > 0 {
+				mixBuffer.Add(data.Pos, data.Data, data.PanMatrix.Apply(data.Volume))
+			}
+		}
+	}
+
+	for i, samp := range mixBuffer {
+		samp = samp.ToChannels(channels).Apply(frame.premix.MixerVolume)
+		samp.Channels = channels
+		mixBuffer[i] = samp
+	}
+	return mixBuffer
+}
+
+// postMixData returns the premix entry for a mixed down buffer
+func postMixData(frame *renderFrame, mixBuffer mixing.MixBuffer) mixing.ChannelData {
+	channels := frame.details.Mix.Channels
+	passThrough := volume.Matrix{Channels: channels}
+	for i := 0; i < channels; i++ {
+		passThrough.StaticMatrix[i] = 1
+	}
+
+	return mixing.ChannelData{
+		mixing.Data{
+			Data:       mixBuffer,
+			PanMatrix:  passThrough,
+			Volume:     volume.Volume(1),
+			Pos:        0,
+			SamplesLen: frame.premix.SamplesLen,
+		},
+	}
+}
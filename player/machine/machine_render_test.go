@@ -2,6 +2,7 @@ package machine
 
 import (
 	"errors"
+	"math"
 	"reflect"
 	"testing"
 	"time"
@@ -18,6 +19,7 @@ import (
 	"github.com/gotracker/playback/output"
 	"github.com/gotracker/playback/period"
 	"github.com/gotracker/playback/player/machine/settings"
+	"github.com/gotracker/playback/player/metering"
 	"github.com/gotracker/playback/player/render"
 	"github.com/gotracker/playback/player/sampler"
 	"github.com/gotracker/playback/song"
@@ -55,6 +57,8 @@ func (stubPeriodCalc) PortaToNote(p stubPeriod, _ period.Delta, _ stubPeriod) (s
 func (stubPeriodCalc) PortaDown(p stubPeriod, _ period.Delta) (stubPeriod, error) { return p, nil }
 func (stubPeriodCalc) PortaUp(p stubPeriod, _ period.Delta) (stubPeriod, error)   { return p, nil }
 func (stubPeriodCalc) AddDelta(p stubPeriod, _ period.Delta) (stubPeriod, error)  { return p, nil }
+func (stubPeriodCalc) GetDelta(stubPeriod, stubPeriod) period.Delta               { return 0 }
+func (stubPeriodCalc) RoundToSemitone(p stubPeriod) stubPeriod                    { return p }
 func (stubPeriodCalc) GetSamplerAdd(stubPeriod, frequency.Frequency, frequency.Frequency) float64 {
 	return 0
 }
@@ -916,3 +920,360 @@ func TestMixHardwareSynthsAllowsMismatchedSampleLens(t *testing.T) {
 		t.Fatalf("expected appended data to keep synth samples len 3, got %d", got)
 	}
 }
+
+type halvingFilter struct {
+	rate frequency.Frequency
+	seen []volume.Matrix
+}
+
+func (f *halvingFilter) Filter(dry volume.Matrix) volume.Matrix {
+	f.seen = append(f.seen, dry)
+	return dry.Apply(0.5)
+}
+func (f *halvingFilter) SetPlaybackRate(rate frequency.Frequency) { f.rate = rate }
+func (f *halvingFilter) UpdateEnv(uint8)                          {}
+func (f *halvingFilter) Clone() filter.Filter                     { c := *f; return &c }
+
+func TestApplyPostMixRunsOutputFilterOverFinalMix(t *testing.T) {
+	f := &halvingFilter{}
+	m := machine[stubPeriod, stubGV, stubGV, stubGV, stubPan]{
+		outputFilter: f,
+	}
+
+	details := mixer.Details{
+		Mix:        &mixing.Mixer{Channels: 2},
+		SampleRate: 44100,
+		Samples:    2,
+	}
+
+	left := volume.Matrix{StaticMatrix: volume.StaticMatrix{1, 0}, Channels: 2}
+	right := volume.Matrix{StaticMatrix: volume.StaticMatrix{0, 1}, Channels: 2}
+	mono := func(v volume.Volume) volume.Matrix {
+		return volume.Matrix{StaticMatrix: volume.StaticMatrix{v}, Channels: 1}
+	}
+	flushed := false
+	frame := renderFrame{
+		details: details,
+		premix: output.PremixData{
+			SamplesLen:  details.Samples,
+			MixerVolume: 0.5,
+			Data: []mixing.ChannelData{
+				{mixing.Data{Data: mixing.MixBuffer{mono(0.4), mono(0.2)}, PanMatrix: left, Volume: 1, SamplesLen: 2, Flush: func() { flushed = true }}},
+				{mixing.Data{Data: mixing.MixBuffer{mono(0.8), mono(0.6)}, PanMatrix: right, Volume: 0.5, SamplesLen: 2}},
+			},
+		},
+	}
+
+	m.applyPostMix(&frame, nil)
+
+	if !flushed {
+		t.Fatalf("expected channel data to be flushed")
+	}
+	if f.rate != details.SampleRate {
+		t.Fatalf("expected the filter to run at the output rate, got %v", f.rate)
+	}
+	if len(f.seen) != 2 || f.seen[0].Channels != 2 {
+		t.Fatalf("expected the filter to see 2 stereo samples, got %+v", f.seen)
+	}
+	if f.seen[0].StaticMatrix != (volume.StaticMatrix{0.2, 0.2}) {
+		t.Fatalf("expected the filter to see the mix after the mixer volume, got %v", f.seen[0].StaticMatrix)
+	}
+
+	if frame.premix.MixerVolume != 1 {
+		t.Fatalf("expected the mixer volume to be consumed, got %v", frame.premix.MixerVolume)
+	}
+	if len(frame.premix.Data) != 1 || len(frame.premix.Data[0]) != 1 {
+		t.Fatalf("expected the premix to be replaced by the filtered mix")
+	}
+	mixed := mixing.Mixer{Channels: 2}.FlattenToInts(2, details.Samples, 16, frame.premix.Data, frame.premix.MixerVolume)
+	want := (volume.Volume(0.1)).ToIntSample(16)
+	if mixed[0][0] != want || mixed[1][0] != want {
+		t.Fatalf("expected the filtered mix to pass through unchanged, got %v", mixed)
+	}
+}
+
+func TestApplyPostMixWithoutFiltersLeavesPremix(t *testing.T) {
+	m := machine[stubPeriod, stubGV, stubGV, stubGV, stubPan]{}
+
+	data := []mixing.ChannelData{{mixing.Data{SamplesLen: 1}}, {mixing.Data{SamplesLen: 1}}}
+	frame := renderFrame{
+		details: mixer.Details{Mix: &mixing.Mixer{Channels: 2}, SampleRate: 44100, Samples: 1},
+		premix:  output.PremixData{SamplesLen: 1, MixerVolume: 0.5, Data: data},
+	}
+
+	m.applyPostMix(&frame, nil)
+
+	if len(frame.premix.Data) != 2 || frame.premix.MixerVolume != 0.5 {
+		t.Fatalf("expected premix to be left alone, got %+v", frame.premix)
+	}
+}
+
+type offsetFilter struct {
+	halvingFilter
+	offset volume.Volume
+}
+
+func (f *offsetFilter) Filter(dry volume.Matrix) volume.Matrix {
+	f.seen = append(f.seen, dry)
+	for i := 0; i < dry.Channels; i++ {
+		dry.StaticMatrix[i] += f.offset
+	}
+	return dry
+}
+
+func TestApplyPostMixRunsMasterChainAfterOutputFilter(t *testing.T) {
+	out := &halvingFilter{}
+	master := &offsetFilter{offset: 0.25}
+	m := machine[stubPeriod, stubGV, stubGV, stubGV, stubPan]{
+		outputFilter: out,
+	}
+
+	details := mixer.Details{
+		Mix:        &mixing.Mixer{Channels: 1},
+		SampleRate: 48000,
+		Samples:    1,
+	}
+	frame := renderFrame{
+		details: details,
+		premix: output.PremixData{
+			SamplesLen:  1,
+			MixerVolume: 1,
+			Data: []mixing.ChannelData{
+				{mixing.Data{Data: mixing.MixBuffer{{StaticMatrix: volume.StaticMatrix{0.5}, Channels: 1}}, PanMatrix: volume.Matrix{StaticMatrix: volume.StaticMatrix{1}, Channels: 1}, Volume: 1, SamplesLen: 1}},
+			},
+		},
+	}
+
+	m.applyPostMix(&frame, filter.Chain{master})
+
+	if master.rate != details.SampleRate {
+		t.Fatalf("expected the master chain to run at the output rate, got %v", master.rate)
+	}
+	if len(master.seen) != 1 || master.seen[0].StaticMatrix[0] != 0.25 {
+		t.Fatalf("expected the master chain to see the output filter's result, got %+v", master.seen)
+	}
+	if got := frame.premix.Data[0][0].Data[0].StaticMatrix[0]; got != 0.5 {
+		t.Fatalf("expected the premix to carry the master chain's result, got %v", got)
+	}
+}
+
+func TestApplyPostMixRunsMasterChainWithoutOutputFilter(t *testing.T) {
+	master := &halvingFilter{}
+	m := machine[stubPeriod, stubGV, stubGV, stubGV, stubPan]{}
+
+	frame := renderFrame{
+		details: mixer.Details{Mix: &mixing.Mixer{Channels: 2}, SampleRate: 44100, Samples: 3},
+		premix:  output.PremixData{SamplesLen: 3, MixerVolume: 1},
+	}
+
+	m.applyPostMix(&frame, filter.Chain{master})
+
+	if len(master.seen) != 3 {
+		t.Fatalf("expected the master chain to process every sample, got %d", len(master.seen))
+	}
+	if len(frame.premix.Data) != 1 || len(frame.premix.Data[0][0].Data) != 3 {
+		t.Fatalf("expected the premix to be replaced by the processed mix")
+	}
+}
+
+func TestGroupStemsFoldsPastNotesIntoTheirChannel(t *testing.T) {
+	m := machine[stubPeriod, stubGV, stubGV, stubGV, stubPan]{
+		actualOutputs:  make([]render.Channel[stubPeriod], 2),
+		virtualOutputs: make([]render.Channel[stubPeriod], 2),
+		channels:       make([]channel[stubPeriod, stubGV, stubGV, stubGV, stubPan], 2),
+	}
+	m.channels[1].pastNotes = []pastNoteInfo{{ch: 1}}
+
+	mixData := []mixing.Data{{Volume: 1}, {Volume: 2}, {Volume: 3}, {Volume: 4}}
+	stems, channels := m.groupStems(mixData)
+
+	if len(stems) != 2 || len(channels) != 2 || channels[0] != 0 || channels[1] != 1 {
+		t.Fatalf("expected a stem per channel, got %d stems for %v", len(stems), channels)
+	}
+	if len(stems[0]) != 1 || stems[0][0].Volume != 1 {
+		t.Fatalf("expected channel 0 to hold only its own voice, got %+v", stems[0])
+	}
+	if len(stems[1]) != 2 || stems[1][0].Volume != 2 || stems[1][1].Volume != 4 {
+		t.Fatalf("expected channel 1 to hold its own voice and its past note, got %+v", stems[1])
+	}
+}
+
+// clipFilter is a filter that isn't linear
+type clipFilter struct {
+	halvingFilter
+}
+
+func (f *clipFilter) Filter(dry volume.Matrix) volume.Matrix {
+	for i := 0; i < dry.Channels; i++ {
+		dry.StaticMatrix[i] = min(dry.StaticMatrix[i], 0.5)
+	}
+	return dry
+}
+
+func TestApplyPostMixStemsSumToTheMix(t *testing.T) {
+	stemFrame := func(stems bool) renderFrame {
+		mono := func(v volume.Volume) volume.Matrix {
+			return volume.Matrix{StaticMatrix: volume.StaticMatrix{v}, Channels: 1}
+		}
+		pan := volume.Matrix{StaticMatrix: volume.StaticMatrix{1, 1}, Channels: 2}
+		return renderFrame{
+			details: mixer.Details{Mix: &mixing.Mixer{Channels: 2}, SampleRate: 44100, Samples: 2},
+			premix: output.PremixData{
+				SamplesLen:  2,
+				MixerVolume: 0.5,
+				Data: []mixing.ChannelData{
+					{mixing.Data{Data: mixing.MixBuffer{mono(0.8), mono(0.2)}, PanMatrix: pan, Volume: 1, SamplesLen: 2}},
+					{mixing.Data{Data: mixing.MixBuffer{mono(0.6), mono(-0.2)}, PanMatrix: pan, Volume: 1, SamplesLen: 2}},
+				},
+				Channels: []index.Channel{0, 1},
+			},
+			stems: stems,
+		}
+	}
+
+	var full, split machine[stubPeriod, stubGV, stubGV, stubGV, stubPan]
+	fullFrame := stemFrame(false)
+	full.applyPostMix(&fullFrame, filter.Chain{&offsetFilter{offset: 0.1}, &clipFilter{}})
+	splitFrame := stemFrame(true)
+	split.applyPostMix(&splitFrame, filter.Chain{&offsetFilter{offset: 0.1}, &clipFilter{}})
+
+	if len(splitFrame.premix.Data) != 2 || len(splitFrame.premix.Channels) != 2 {
+		t.Fatalf("expected the stems to stay separate, got %d entries", len(splitFrame.premix.Data))
+	}
+	if splitFrame.premix.MixerVolume != 1 {
+		t.Fatalf("expected the mixer volume to be consumed, got %v", splitFrame.premix.MixerVolume)
+	}
+
+	want := fullFrame.premix.Data[0][0].Data
+	for j := range want {
+		var sum volume.StaticMatrix
+		for _, stem := range splitFrame.premix.Data {
+			for c := 0; c < 2; c++ {
+				sum[c] += stem[0].Data[j].StaticMatrix[c]
+			}
+		}
+		for c := 0; c < 2; c++ {
+			if math.Abs(float64(sum[c]-want[j].StaticMatrix[c])) > 1e-6 {
+				t.Fatalf("sample %d: expected the stems to sum to %v, got %v", j, want[j].StaticMatrix, sum)
+			}
+		}
+	}
+}
+
+func TestRenderVoicesReportsMetering(t *testing.T) {
+	var got []metering.Tick
+	m := machine[stubPeriod, stubGV, stubGV, stubGV, stubPan]{
+		ms: &settings.MachineSettings[stubPeriod, stubGV, stubGV, stubGV, stubPan]{
+			PeriodConverter: stubPeriodCalc{},
+		},
+		channels: make([]channel[stubPeriod, stubGV, stubGV, stubGV, stubPan], 2),
+	}
+	m.us.Metering.Handler = func(tick metering.Tick) { got = append(got, tick) }
+
+	panMixer := mixing.GetPanMixer(2)
+	details := mixer.Details{
+		Mix:              &mixing.Mixer{Channels: 2},
+		Panmixer:         panMixer,
+		SampleRate:       10,
+		StereoSeparation: 1,
+		Samples:          4,
+	}
+
+	done := render.Channel[stubPeriod]{}
+	done.StartVoice(&doneVoice{}, nil)
+	playing := render.Channel[stubPeriod]{}
+	playing.StartVoice(&errorRenderVoice{}, nil)
+	m.actualOutputs = []render.Channel[stubPeriod]{done, playing}
+
+	frame := renderFrame{
+		renderRow:      render.RowRender{Order: 1, Row: 2, Tick: 3},
+		details:        details,
+		centerAheadPan: panMixer.GetMixingMatrix(panning.CenterAhead, details.StereoSeparation),
+	}
+
+	if _, err := m.renderVoices(frame); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(got) != 1 {
+		t.Fatalf("expected one report for the tick, got %d", len(got))
+	}
+	tick := got[0]
+	if tick.Order != 1 || tick.Row != 2 || tick.Tick != 3 {
+		t.Fatalf("unexpected position: %+v", tick)
+	}
+	if len(tick.Channels) != 2 || tick.Channels[0].Channel != 0 || tick.Channels[1].Channel != 1 {
+		t.Fatalf("expected a report for each channel, got %+v", tick.Channels)
+	}
+	if tick.Channels[0].Active || !tick.Channels[1].Active {
+		t.Fatalf("expected only the second channel to be active, got %+v", tick.Channels)
+	}
+	if len(tick.Channels[1].Scope) != details.Samples || tick.Channels[1].Peak.Channels != 2 {
+		t.Fatalf("expected the active channel to be measured, got %+v", tick.Channels[1])
+	}
+}
+
+// samplerAddCalc is a period converter whose voices play their samples at 3 times their rate
+type samplerAddCalc struct {
+	stubPeriodCalc
+}
+
+func (samplerAddCalc) GetSamplerAdd(_ stubPeriod, instRate, outRate frequency.Frequency) float64 {
+	return float64(instRate * 3 / outRate)
+}
+func (samplerAddCalc) GetFrequency(stubPeriod) frequency.Frequency { return 1 }
+
+func TestMeterVoiceFrequencyMatchesTheSnapshot(t *testing.T) {
+	m, _ := newJamMachine(0)
+	m.ms.PeriodConverter = samplerAddCalc{}
+
+	v := &jamVoice{}
+	m.channels = make([]channel[stubPeriod, stubGV, stubGV, stubGV, stubPan], 1)
+	m.channels[0].cv = v
+	rc := render.Channel[stubPeriod]{}
+	rc.StartVoice(v, nil)
+
+	mc := m.meterVoice(0, &rc)
+	cs := m.Snapshot().Channels[0]
+	if mc.Frequency != 3 || mc.Frequency != cs.Frequency {
+		t.Fatalf("expected the meter to report the rate the sample plays at, as the snapshot does: meter %v, snapshot %v", mc.Frequency, cs.Frequency)
+	}
+}
+
+func TestRenderVoicesWithoutMeteringDoesNotMeasure(t *testing.T) {
+	m := machine[stubPeriod, stubGV, stubGV, stubGV, stubPan]{
+		ms: &settings.MachineSettings[stubPeriod, stubGV, stubGV, stubGV, stubPan]{
+			PeriodConverter: stubPeriodCalc{},
+		},
+	}
+
+	details := mixer.Details{
+		Mix:        &mixing.Mixer{Channels: 2},
+		Panmixer:   mixing.GetPanMixer(2),
+		SampleRate: 10,
+		Samples:    4,
+	}
+	frame := renderFrame{
+		details:        details,
+		centerAheadPan: details.Panmixer.GetMixingMatrix(panning.CenterAhead, 1),
+	}
+	ch := render.Channel[stubPeriod]{}
+	ch.StartVoice(&errorRenderVoice{}, nil)
+	m.actualOutputs = []render.Channel[stubPeriod]{ch}
+
+	allocs := testing.AllocsPerRun(10, func() {
+		if _, err := m.renderVoices(frame); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+
+	m.us.Metering.Handler = func(metering.Tick) {}
+	metered := testing.AllocsPerRun(10, func() {
+		if _, err := m.renderVoices(frame); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+	if metered <= allocs {
+		t.Fatalf("expected metering to be skipped when disabled (%v allocations without, %v with)", allocs, metered)
+	}
+}
@@ -0,0 +1,63 @@
+package machine
+
+import (
+	"github.com/gotracker/playback/frequency"
+	"github.com/gotracker/playback/index"
+	"github.com/gotracker/playback/voice"
+)
+
+// Snapshot returns the playing state of the song and its channels as left by the last advance
+func (m *machine[TPeriod, TGlobalVolume, TMixingVolume, TVolume, TPanning]) Snapshot() Snapshot {
+	s := Snapshot{
+		Position:     m.ticker.current,
+		Tempo:        m.tempo,
+		BPM:          m.bpm,
+		TickDuration: m.songData.GetTickDuration(m.bpm),
+		Channels:     make([]ChannelSnapshot, len(m.channels)),
+	}
+
+	for i := range m.channels {
+		s.Channels[i] = m.snapshotChannel(index.Channel(i))
+	}
+	return s
+}
+
+func (m *machine[TPeriod, TGlobalVolume, TMixingVolume, TVolume, TPanning]) snapshotChannel(ch index.Channel) ChannelSnapshot {
+	cs := ChannelSnapshot{
+		Channel: ch,
+	}
+
+	c := &m.channels[ch]
+	v := c.cv
+	if v == nil {
+		return cs
+	}
+
+	if ampMod, ok := v.(voice.AmpModulator[TGlobalVolume, TMixingVolume, TVolume]); ok {
+		cs.Volume = ampMod.GetVolume().ToVolume()
+		cs.MixingVolume = ampMod.GetMixingVolume().ToVolume()
+	}
+
+	if pm, ok := v.(voice.PanModulator[TPanning]); ok {
+		cs.Pan = pm.GetFinalPan()
+	}
+
+	if v.IsDone() {
+		return cs
+	}
+	cs.Active = true
+
+	if id := c.playing.ID; id != nil && !id.IsEmpty() {
+		cs.Instrument = id
+	}
+
+	if fm, ok := v.(voice.FreqModulator[TPeriod]); ok {
+		if p, err := fm.GetFinalPeriod(); err == nil && !p.IsInvalid() {
+			// the rate the sample plays at, as the converters leave the instrument's rate out of
+			// their frequencies
+			cs.Frequency = frequency.Frequency(m.ms.PeriodConverter.GetSamplerAdd(p, v.GetSampleRate(), 1))
+		}
+	}
+
+	return cs
+}
@@ -0,0 +1,37 @@
+package machine
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gotracker/playback/mixing/panning"
+)
+
+func TestSnapshotReadsChannelsWithoutRendering(t *testing.T) {
+	m, _ := newJamMachine(0)
+	m.tempo, m.bpm = 6, 125
+	m.ticker.current = Position{Order: 1, Row: 2, Tick: 3}
+
+	v := &jamVoice{pan: 1}
+	m.channels = make([]channel[stubPeriod, stubGV, stubGV, stubGV, stubPan], 2)
+	m.channels[0].cv = v
+
+	s := m.Snapshot()
+	if s.Position != m.ticker.current || s.Tempo != 6 || s.BPM != 125 || s.TickDuration != time.Second {
+		t.Fatalf("unexpected timing: %+v", s)
+	}
+	if len(s.Channels) != 2 {
+		t.Fatalf("expected a snapshot of every channel, got %d", len(s.Channels))
+	}
+	if c := s.Channels[0]; !c.Active || c.Pan != panning.MakeStereoPosition(1, 0, 1) {
+		t.Fatalf("unexpected playing channel: %+v", c)
+	}
+	if c := s.Channels[1]; c.Active || c.Channel != 1 {
+		t.Fatalf("expected the channel with no voice to be idle, got %+v", c)
+	}
+
+	v.Stop()
+	if c := m.Snapshot().Channels[0]; c.Active {
+		t.Fatalf("expected the stopped voice to be idle, got %+v", c)
+	}
+}
@@ -0,0 +1,134 @@
+package machine
+
+import (
+	"math"
+	"reflect"
+
+	"github.com/gotracker/playback/filter"
+	"github.com/gotracker/playback/index"
+	"github.com/gotracker/playback/mixing"
+	"github.com/gotracker/playback/mixing/volume"
+)
+
+// groupStems groups the rendered voice data by the tracker channel it belongs to, with the
+// past-note voices folded into the channel that played them
+func (m *machine[TPeriod, TGlobalVolume, TMixingVolume, TVolume, TPanning]) groupStems(mixData []mixing.Data) ([]mixing.ChannelData, []index.Channel) {
+	numChannels := len(m.actualOutputs)
+	stems := make([]mixing.ChannelData, numChannels)
+	channels := make([]index.Channel, numChannels)
+	for i := range stems {
+		channels[i] = index.Channel(i)
+		if i < len(mixData) {
+			stems[i] = mixing.ChannelData{mixData[i]}
+		}
+	}
+
+	// every voice playing on a virtual output belongs to the past notes of a channel
+	for i := range m.channels {
+		if i >= numChannels {
+			break
+		}
+		for _, pn := range m.channels[i].pastNotes {
+			if j := numChannels + int(pn.ch); j < len(mixData) {
+				stems[i] = append(stems[i], mixData[j])
+			}
+		}
+	}
+
+	return stems, channels
+}
+
+// applyStemPostMix runs the post-mix chain over the full mix, as usual, and over each stem
+// through a copy of the chain of its own, so the effects that are linear (filters, EQ, reverb)
+// split cleanly between the stems. What the chain does that isn't linear (such as limiting)
+// leaves a difference between the sum of the stems and the full mix; that difference is shared
+// out between the stems by their level, so the stems always sum back to the full mix.
+func (m *machine[TPeriod, TGlobalVolume, TMixingVolume, TVolume, TPanning]) applyStemPostMix(frame *renderFrame, chain filter.Chain) {
+	channels := frame.details.Mix.Channels
+	data := frame.premix.Data
+
+	stems := make([]mixing.MixBuffer, len(data))
+	for i := range data {
+		stems[i] = mixDownPremix(frame, data[i:i+1])
+	}
+
+	full := frame.details.Mix.NewMixBuffer(frame.premix.SamplesLen)
+	for j := range full {
+		full[j].Channels = channels
+		for _, stem := range stems {
+			full[j].Accumulate(stem[j])
+		}
+		full[j] = chain.Filter(full[j])
+	}
+
+	stemChains := m.getStemChains(chain, len(stems))
+	for i, stem := range stems {
+		stemChains[i].SetPlaybackRate(frame.details.SampleRate)
+		for j, samp := range stem {
+			stem[j] = stemChains[i].Filter(samp)
+		}
+	}
+
+	for j, out := range full {
+		var sum, weight volume.StaticMatrix
+		for _, stem := range stems {
+			for c := 0; c < channels; c++ {
+				sum[c] += stem[j].StaticMatrix[c]
+				weight[c] += volume.Volume(math.Abs(float64(stem[j].StaticMatrix[c])))
+			}
+		}
+
+		for c := 0; c < channels; c++ {
+			diff := out.StaticMatrix[c] - sum[c]
+			if diff == 0 {
+				continue
+			}
+			for _, stem := range stems {
+				share := 1 / volume.Volume(len(stems))
+				if weight[c] > 0 {
+					share = volume.Volume(math.Abs(float64(stem[j].StaticMatrix[c]))) / weight[c]
+				}
+				stem[j].StaticMatrix[c] += diff * share
+			}
+		}
+	}
+
+	frame.premix.MixerVolume = 1
+	for i, stem := range stems {
+		data[i] = postMixData(frame, stem)
+	}
+}
+
+// getStemChains returns a copy of the post-mix chain for each stem. The copies keep their state
+// from one render to the next, and are made again when the chain or the number of stems changes.
+func (m *machine[TPeriod, TGlobalVolume, TMixingVolume, TVolume, TPanning]) getStemChains(chain filter.Chain, stems int) []filter.Chain {
+	if len(m.stemChains) == stems && sameFilters(m.stemChainSource, chain) {
+		return m.stemChains
+	}
+
+	m.stemChainSource = append(filter.Chain(nil), chain...)
+	m.stemChains = make([]filter.Chain, stems)
+	for i := range m.stemChains {
+		for _, f := range chain {
+			m.stemChains[i] = append(m.stemChains[i], f.Clone())
+		}
+	}
+	return m.stemChains
+}
+
+// sameFilters reports whether two chains hold the same filters
+func sameFilters(a, b filter.Chain) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		ta := reflect.TypeOf(a[i])
+		if ta != reflect.TypeOf(b[i]) {
+			return false
+		}
+		if ta != nil && ta.Comparable() && a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
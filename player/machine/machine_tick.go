@@ -21,6 +21,9 @@ func (m *machine[TPeriod, TGlobalVolume, TMixingVolume, TVolume, TPanning]) Tick
 
 // Advance progresses song sequencing and channel state without rendering audio.
 func (m *machine[TPeriod, TGlobalVolume, TMixingVolume, TVolume, TPanning]) Advance() error {
+	m.applyControls()
+	m.applyJam()
+
 	if err := m.songData.ForEachChannel(true, func(ch index.Channel) (bool, error) {
 		c := &m.channels[ch]
 		if err := c.DoNoteAction(ch, m); err != nil {
@@ -45,6 +48,9 @@ func (m *machine[TPeriod, TGlobalVolume, TMixingVolume, TVolume, TPanning]) Rend
 		return errors.New("sampler is nil")
 	}
 
+	m.applyControls()
+	m.applyJam()
+
 	if m.opl2Enabled && m.opl2 == nil && m.ms.OPL2Enabled {
 		if err := m.setupOPL2(s); err != nil {
 			return err
@@ -76,6 +82,8 @@ func (m *machine[TPeriod, TGlobalVolume, TMixingVolume, TVolume, TPanning]) onTi
 }
 
 func (m *machine[TPeriod, TGlobalVolume, TMixingVolume, TVolume, TPanning]) onOrderStart() error {
+	eventOrderStart(m)
+
 	return m.songData.ForEachChannel(true, func(ch index.Channel) (bool, error) {
 		c := &m.channels[ch]
 		if err := c.OrderStart(ch, m); err != nil {
@@ -94,6 +102,7 @@ func (m *machine[TPeriod, TGlobalVolume, TMixingVolume, TVolume, TPanning]) onRo
 	m.rowStringer = m.songData.GetRowRenderStringer(rowData, len(m.channels), m.us.LongChannelOutput)
 
 	trace(m, m.rowStringer.String())
+	eventRowStart(m)
 
 	if err := m.singleRowRowStart(); err != nil {
 		return err
@@ -15,6 +15,7 @@ type ActionTick struct {
 
 type NewNoteInfo[TPeriod Period, TMixingVolume, TVolume Volume, TPanning Panning] struct {
 	Period        optional.Value[TPeriod]
+	Semitone      optional.Value[note.Semitone]
 	Inst          optional.Value[*instrument.Instrument[TPeriod, TMixingVolume, TVolume, TPanning]]
 	Pos           optional.Value[sampling.Pos]
 	MixVol        optional.Value[TMixingVolume]
@@ -26,6 +27,7 @@ type NewNoteInfo[TPeriod Period, TMixingVolume, TVolume Volume, TPanning Panning
 
 func (n *NewNoteInfo[TPeriod, TMixingVolume, TVolume, TPanning]) Reset() {
 	n.Period.Reset()
+	n.Semitone.Reset()
 	n.Inst.Reset()
 	n.Pos.Reset()
 	n.MixVol.Reset()
@@ -37,6 +39,7 @@ func (n *NewNoteInfo[TPeriod, TMixingVolume, TVolume, TPanning]) Reset() {
 
 func (n NewNoteInfo[TPeriod, TMixingVolume, TVolume, TPanning]) IsSet() bool {
 	return n.Period.IsSet() ||
+		n.Semitone.IsSet() ||
 		n.Inst.IsSet() ||
 		n.Pos.IsSet() ||
 		n.MixVol.IsSet() ||
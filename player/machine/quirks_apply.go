@@ -31,6 +31,10 @@ func resolveQuirks(base settings.MachineQuirks, us settings.UserSettings) settin
 		q.DoNotProcessEffectsOnMutedChannels = value
 		customized = true
 	}
+	if value, ok := us.Quirks.LinearFrequencyTableOverride.Get(); ok {
+		q.LinearFrequencyTable = value
+		customized = true
+	}
 
 	// keep profile label meaningful after overrides
 	if prof, ok := us.Quirks.Profile.Get(); ok {
@@ -23,6 +23,7 @@ type MachineSettings[TPeriod Period, TGlobalVolume, TMixingVolume, TVolume Volum
 	GetTremoloFactory   func() (oscillator.Oscillator, error)
 	GetPanbrelloFactory func() (oscillator.Oscillator, error)
 	VoiceFactory        voice.VoiceFactory[TPeriod, TGlobalVolume, TMixingVolume, TVolume, TPanning]
+	OutputFilter        filter.Info // run over the final mix, to model the output stage of the sound hardware
 	OPL2Enabled         bool
 	ModLimits           bool
 	Quirks              MachineQuirks
@@ -33,4 +34,5 @@ type MachineQuirks struct {
 	PreviousPeriodUsesModifiedPeriod   bool
 	PortaToNoteUsesModifiedPeriod      bool
 	DoNotProcessEffectsOnMutedChannels bool
+	LinearFrequencyTable               bool // linear periods are converted to frequencies via the tracker's lookup table
 }
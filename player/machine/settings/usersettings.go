@@ -4,12 +4,20 @@ import (
 	"github.com/heucuva/optional"
 
 	"github.com/gotracker/playback/index"
+	"github.com/gotracker/playback/player/metering"
+	"github.com/gotracker/playback/player/oplrecord"
 	"github.com/gotracker/playback/tracing"
 )
 
 type UserSettings struct {
-	Tracer        tracing.TracerWithClose
+	Tracer       tracing.TracerWithClose
+	OPL2Recorder *oplrecord.Recorder
+	// MIDIMacroHandler receives MIDI macro messages not handled internally (nil = discard)
+	MIDIMacroHandler func(ch index.Channel, msg []byte)
+	// Metering receives the levels and waveform of each channel every tick (nil Handler = disabled)
+	Metering      metering.Settings
 	SongLoopCount int
+	RandomSeed    optional.Value[int64] // default: based on the current time
 	Quirks        QuirksUserSettings
 	Start         struct {
 		Order optional.Value[index.Order] // default: based on song
@@ -34,19 +42,22 @@ type QuirksUserSettings struct {
 	PreviousPeriodUsesModifiedPeriodOverride   QuirkOverride[bool]
 	PortaToNoteUsesModifiedPeriodOverride      QuirkOverride[bool]
 	DoNotProcessEffectsOnMutedChannelsOverride QuirkOverride[bool]
+	LinearFrequencyTableOverride               QuirkOverride[bool]
 }
 
 // Reset applies the defaults
 //
-//	NOTE: does not reset the Tracer value
+//	NOTE: does not reset the Tracer, OPL2Recorder, MIDIMacroHandler, or Metering values
 func (s *UserSettings) Reset() {
-	// don't touch the Tracer here
+	// don't touch the Tracer, OPL2Recorder, MIDIMacroHandler, or Metering here
 	s.SongLoopCount = 0
+	s.RandomSeed.Reset()
 	s.Quirks.Profile.Reset()
 	s.Quirks.LinearSlidesOverride = QuirkOverride[bool]{}
 	s.Quirks.PreviousPeriodUsesModifiedPeriodOverride = QuirkOverride[bool]{}
 	s.Quirks.PortaToNoteUsesModifiedPeriodOverride = QuirkOverride[bool]{}
 	s.Quirks.DoNotProcessEffectsOnMutedChannelsOverride = QuirkOverride[bool]{}
+	s.Quirks.LinearFrequencyTableOverride = QuirkOverride[bool]{}
 	s.Start.Order.Reset()
 	s.Start.Row.Reset()
 	s.Start.Tempo = 0
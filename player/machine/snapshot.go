@@ -0,0 +1,33 @@
+package machine
+
+import (
+	"time"
+
+	"github.com/gotracker/playback/frequency"
+	"github.com/gotracker/playback/index"
+	"github.com/gotracker/playback/instrument"
+	"github.com/gotracker/playback/mixing/panning"
+	"github.com/gotracker/playback/mixing/volume"
+)
+
+// Snapshot is the playing state of the machine as left by the last advance, read without
+// rendering. Envelopes and auto-vibrato only move on when the machine renders, so the final
+// pitch and pan of a voice that is only advanced follow its effects alone.
+type Snapshot struct {
+	Position     Position
+	Tempo        int // ticks per row
+	BPM          int
+	TickDuration time.Duration
+	Channels     []ChannelSnapshot // one for every channel of the song, in order
+}
+
+// ChannelSnapshot is the playing state of a single channel
+type ChannelSnapshot struct {
+	Channel      index.Channel
+	Active       bool                // the channel's voice is playing
+	Instrument   instrument.ID       // the instrument of the note playing, or nil
+	Frequency    frequency.Frequency // the rate the voice plays its sample at, or 0 when it isn't playing
+	Volume       volume.Volume       // the volume of the note, before the envelopes
+	MixingVolume volume.Volume       // the volume of the channel
+	Pan          panning.Position
+}
@@ -0,0 +1,105 @@
+package metering
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/gotracker/playback/frequency"
+	"github.com/gotracker/playback/index"
+	"github.com/gotracker/playback/mixing"
+	"github.com/gotracker/playback/mixing/panning"
+	"github.com/gotracker/playback/mixing/volume"
+	"github.com/gotracker/playback/period"
+)
+
+// DefaultScopeSamples is the length of the oscilloscope waveform when none is set
+const DefaultScopeSamples = 64
+
+// Settings enables the analysis of each channel on the render path, for VU meters and
+// oscilloscopes. When Handler is nil, nothing is measured.
+type Settings struct {
+	// Handler receives the analysis of every channel once per tick. The Tick is its own to keep.
+	Handler func(tick Tick)
+	// ScopeSamples is the length of the waveform of each channel; 0 uses DefaultScopeSamples
+	ScopeSamples int
+}
+
+// InstrumentID identifies an instrument; it holds an instrument.ID, which this package can't
+// refer to, as the instrument package depends on the player features
+type InstrumentID interface {
+	IsEmpty() bool
+	GetIndexAndSample() (int, int)
+	fmt.Stringer
+}
+
+// Tick is the analysis of all the channels over a single tick
+type Tick struct {
+	Order    int
+	Row      int
+	Tick     int
+	Channels []Channel // one for every channel of the song, in order
+}
+
+// Channel is the analysis of a single channel over a tick. The levels and the waveform are of
+// what the channel adds to the mix (including the past notes it's still playing), before the
+// mixer volume and the post-mix effects. Channels played on the OPL2 chip have no levels.
+type Channel struct {
+	Channel    index.Channel
+	Active     bool                // the channel's voice is playing
+	Instrument InstrumentID        // the instrument of the note playing, or nil
+	Period     period.Period       // the final period of the voice, or nil when it isn't playing
+	Frequency  frequency.Frequency // the rate the voice plays its sample at, or 0 when it isn't playing
+	Pan        panning.Position
+	Peak       volume.Matrix   // the highest absolute level of each output channel
+	RMS        volume.Matrix   // the root mean square level of each output channel
+	Scope      []volume.Volume // the waveform, averaged across the output channels and decimated
+}
+
+// Measure sets the levels and the waveform of the channel from the voice data provided, mixed
+// down to `channels` output channels over `samples` samples
+func (c *Channel) Measure(data mixing.ChannelData, samples, channels, scopeSamples int) {
+	if scopeSamples <= 0 {
+		scopeSamples = DefaultScopeSamples
+	}
+
+	buf := make(mixing.MixBuffer, samples)
+	for _, d := range data {
+		if len(d.Data) == 0 {
+			continue
+		}
+		mtx := d.PanMatrix.Apply(d.Volume)
+		for i, samp := range d.Data {
+			if pos := d.Pos + i; pos < samples {
+				buf[pos].Accumulate(mtx.ApplyToMatrix(samp))
+			}
+		}
+	}
+
+	c.Peak = volume.Matrix{Channels: channels}
+	c.RMS = volume.Matrix{Channels: channels}
+	var sumSquares [len(volume.StaticMatrix{})]float64
+	for i, samp := range buf {
+		samp = samp.ToChannels(channels)
+		buf[i] = samp
+		for ch := 0; ch < channels; ch++ {
+			v := samp.StaticMatrix[ch]
+			c.Peak.StaticMatrix[ch] = max(c.Peak.StaticMatrix[ch], volume.Volume(math.Abs(float64(v))))
+			sumSquares[ch] += float64(v) * float64(v)
+		}
+	}
+	if samples > 0 {
+		for ch := 0; ch < channels; ch++ {
+			c.RMS.StaticMatrix[ch] = volume.Volume(math.Sqrt(sumSquares[ch] / float64(samples)))
+		}
+	}
+
+	c.Scope = make([]volume.Volume, min(scopeSamples, samples))
+	for i := range c.Scope {
+		samp := buf[i*samples/len(c.Scope)]
+		var v volume.Volume
+		for ch := 0; ch < channels; ch++ {
+			v += samp.StaticMatrix[ch]
+		}
+		c.Scope[i] = v / volume.Volume(channels)
+	}
+}
@@ -0,0 +1,80 @@
+package metering
+
+import (
+	"math"
+	"testing"
+
+	"github.com/gotracker/playback/mixing"
+	"github.com/gotracker/playback/mixing/volume"
+)
+
+func monoData(pan volume.Matrix, vol volume.Volume, values ...volume.Volume) mixing.Data {
+	d := mixing.Data{
+		PanMatrix:  pan,
+		Volume:     vol,
+		SamplesLen: len(values),
+	}
+	for _, v := range values {
+		d.Data = append(d.Data, volume.Matrix{StaticMatrix: volume.StaticMatrix{v}, Channels: 1})
+	}
+	return d
+}
+
+func TestMeasureLevels(t *testing.T) {
+	left := volume.Matrix{StaticMatrix: volume.StaticMatrix{1, 0}, Channels: 2}
+
+	var c Channel
+	c.Measure(mixing.ChannelData{monoData(left, 0.5, 1, -1, 1, -1)}, 4, 2, 0)
+
+	if c.Peak.Channels != 2 || c.Peak.StaticMatrix[0] != 0.5 || c.Peak.StaticMatrix[1] != 0 {
+		t.Fatalf("unexpected peak: %+v", c.Peak)
+	}
+	if math.Abs(float64(c.RMS.StaticMatrix[0]-0.5)) > 1e-6 || c.RMS.StaticMatrix[1] != 0 {
+		t.Fatalf("unexpected RMS: %+v", c.RMS)
+	}
+	if len(c.Scope) != 4 || c.Scope[0] != 0.25 || c.Scope[1] != -0.25 {
+		t.Fatalf("expected the waveform to be averaged across the output channels, got %v", c.Scope)
+	}
+}
+
+func TestMeasureSumsPastNotes(t *testing.T) {
+	center := volume.Matrix{StaticMatrix: volume.StaticMatrix{1, 1}, Channels: 2}
+
+	var c Channel
+	c.Measure(mixing.ChannelData{
+		monoData(center, 1, 0.25, 0.25),
+		monoData(center, 1, 0.5, -0.25),
+	}, 2, 2, 0)
+
+	if c.Peak.StaticMatrix[0] != 0.75 {
+		t.Fatalf("expected the voices of the channel to be summed, got %+v", c.Peak)
+	}
+	if len(c.Scope) != 2 || c.Scope[0] != 0.75 || c.Scope[1] != 0 {
+		t.Fatalf("unexpected waveform: %v", c.Scope)
+	}
+}
+
+func TestMeasureDecimatesScope(t *testing.T) {
+	center := volume.Matrix{StaticMatrix: volume.StaticMatrix{1}, Channels: 1}
+	values := make([]volume.Volume, 100)
+	for i := range values {
+		values[i] = volume.Volume(i) / 100
+	}
+
+	var c Channel
+	c.Measure(mixing.ChannelData{monoData(center, 1, values...)}, len(values), 1, 10)
+
+	if len(c.Scope) != 10 {
+		t.Fatalf("expected 10 waveform samples, got %d", len(c.Scope))
+	}
+	for i, v := range c.Scope {
+		if want := volume.Volume(i*10) / 100; v != want {
+			t.Fatalf("waveform sample %d: got %v want %v", i, v, want)
+		}
+	}
+
+	c.Measure(nil, 0, 1, 10)
+	if len(c.Scope) != 0 || c.RMS.StaticMatrix[0] != 0 {
+		t.Fatalf("expected an empty tick to measure nothing, got %+v", c)
+	}
+}
@@ -0,0 +1,201 @@
+package midi
+
+import (
+	"errors"
+	"io"
+
+	"github.com/heucuva/optional"
+
+	"github.com/gotracker/playback/mixing/panning"
+	"github.com/gotracker/playback/mixing/volume"
+	"github.com/gotracker/playback/note"
+	"github.com/gotracker/playback/player/machine"
+)
+
+// Jam plays the notes of a Bridge; a machine's Jam is one
+type Jam interface {
+	NoteOn(n machine.JamNote) (machine.JamVoice, error)
+	Release(v machine.JamVoice)
+	SetPitchBend(v machine.JamVoice, semitones float32)
+	SetVibrato(v machine.JamVoice, depth float32)
+	SetVolume(v machine.JamVoice, vol volume.Volume) error
+	SetPan(v machine.JamVoice, pos panning.Position)
+}
+
+var _ Jam = (*machine.Jam)(nil)
+
+type playingNote struct {
+	voice    machine.JamVoice
+	velocity volume.Volume
+}
+
+type channelState struct {
+	instrument int
+	volume     volume.Volume // from the volume controller
+	pan        optional.Value[panning.Position]
+	bend       float32 // in semitones
+	vibrato    float32 // in semitones
+	notes      map[uint8]playingNote
+}
+
+// Bridge plays MIDI messages, such as those of a keyboard controller, on the instruments of a
+// song, through a Jam. It follows note on and off (with the velocity setting the volume of the
+// note), pitch bend, the mod wheel (as vibrato), the volume and pan controllers and program
+// changes. A Bridge isn't safe to use from more than one goroutine at a time.
+type Bridge struct {
+	// ErrorHandler, if set, is told of the messages Play skips because they can't be played, such
+	// as a note on for an instrument the song doesn't have
+	ErrorHandler func(msg Message, err error)
+
+	jam      Jam
+	mapping  Mapping
+	channels [NumChannels]channelState
+}
+
+// NewBridge returns a Bridge playing on jam, through the mapping
+func NewBridge(jam Jam, mapping Mapping) *Bridge {
+	b := &Bridge{
+		jam:     jam,
+		mapping: mapping,
+	}
+	for i := range b.channels {
+		b.channels[i] = channelState{
+			instrument: mapping.Channels[i].Instrument,
+			volume:     volume.Volume(1),
+			notes:      make(map[uint8]playingNote),
+		}
+	}
+	return b
+}
+
+// Play plays the messages of a raw MIDI byte stream until it ends, when it returns nil, or
+// until it can't be read. A message that can't be played is skipped and handed to the
+// ErrorHandler, so a program change to a missing instrument doesn't end the stream
+func (b *Bridge) Play(r io.Reader) error {
+	d := NewDecoder(r)
+	for {
+		msg, err := d.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		if err := b.Handle(msg); err != nil && b.ErrorHandler != nil {
+			b.ErrorHandler(msg, err)
+		}
+	}
+}
+
+// Handle plays a MIDI message
+func (b *Bridge) Handle(msg Message) error {
+	if int(msg.Channel) >= NumChannels {
+		return nil
+	}
+	cm := b.mapping.Channels[msg.Channel]
+	if !cm.Enabled {
+		return nil
+	}
+	cs := &b.channels[msg.Channel]
+
+	switch msg.Kind {
+	case KindNoteOn:
+		if msg.Data2 == 0 {
+			// a note on with no velocity is a note off
+			b.noteOff(cs, msg.Data1)
+			return nil
+		}
+		return b.noteOn(cs, cm, msg.Data1, msg.Data2)
+
+	case KindNoteOff:
+		b.noteOff(cs, msg.Data1)
+
+	case KindControlChange:
+		return b.controlChange(cs, msg.Data1, msg.Data2)
+
+	case KindProgramChange:
+		cs.instrument = cm.getProgramInstrument(msg.Data1)
+
+	case KindPitchBend:
+		bend := msg.PitchBend()
+		if bend < 0 {
+			cs.bend = float32(bend) / 8192 * b.mapping.getBendRange()
+		} else {
+			cs.bend = float32(bend) / 8191 * b.mapping.getBendRange()
+		}
+		for _, pn := range cs.notes {
+			b.jam.SetPitchBend(pn.voice, cs.bend)
+		}
+	}
+	return nil
+}
+
+func (b *Bridge) noteOn(cs *channelState, cm ChannelMapping, key, velocity uint8) error {
+	st, ok := cm.getSemitone(key)
+	if !ok {
+		return nil
+	}
+
+	// the same key struck again lets go of the note it was playing
+	b.noteOff(cs, key)
+
+	vel := volume.Volume(velocity) / 127
+	n := machine.JamNote{
+		Note:       note.Normal(st),
+		Instrument: cs.instrument,
+		Pan:        cs.pan,
+	}
+	n.Volume.Set(vel * cs.volume)
+
+	v, err := b.jam.NoteOn(n)
+	if err != nil {
+		return err
+	}
+	if cs.bend != 0 {
+		b.jam.SetPitchBend(v, cs.bend)
+	}
+	if cs.vibrato != 0 {
+		b.jam.SetVibrato(v, cs.vibrato)
+	}
+
+	cs.notes[key] = playingNote{
+		voice:    v,
+		velocity: vel,
+	}
+	return nil
+}
+
+func (b *Bridge) noteOff(cs *channelState, key uint8) {
+	pn, found := cs.notes[key]
+	if !found {
+		return
+	}
+	b.jam.Release(pn.voice)
+	delete(cs.notes, key)
+}
+
+func (b *Bridge) controlChange(cs *channelState, controller, value uint8) error {
+	switch controller {
+	case ControllerModWheel:
+		cs.vibrato = float32(value) / 127 * b.mapping.getModDepth()
+		for _, pn := range cs.notes {
+			b.jam.SetVibrato(pn.voice, cs.vibrato)
+		}
+
+	case ControllerVolume:
+		cs.volume = volume.Volume(value) / 127
+		for _, pn := range cs.notes {
+			if err := b.jam.SetVolume(pn.voice, pn.velocity*cs.volume); err != nil {
+				return err
+			}
+		}
+
+	case ControllerPan:
+		pos := panning.MakeStereoPosition(float32(value), 0, 127)
+		cs.pan.Set(pos)
+		for _, pn := range cs.notes {
+			b.jam.SetPan(pn.voice, pos)
+		}
+	}
+	return nil
+}
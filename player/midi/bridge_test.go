@@ -0,0 +1,173 @@
+package midi
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/gotracker/playback/mixing/panning"
+	"github.com/gotracker/playback/mixing/volume"
+	"github.com/gotracker/playback/note"
+	"github.com/gotracker/playback/player/machine"
+)
+
+type fakeVoice struct {
+	note     machine.JamNote
+	released bool
+	bend     float32
+	vibrato  float32
+	volume   volume.Volume
+	pan      panning.Position
+}
+
+type fakeJam struct {
+	voices  []*fakeVoice
+	err     error
+	missing map[int]bool // instruments the song doesn't have
+}
+
+func (j *fakeJam) NoteOn(n machine.JamNote) (machine.JamVoice, error) {
+	if j.err != nil {
+		return 0, j.err
+	}
+	if j.missing[n.Instrument] {
+		return 0, errors.New("no such instrument")
+	}
+	v := &fakeVoice{note: n}
+	v.volume, _ = n.Volume.Get()
+	v.pan, _ = n.Pan.Get()
+	j.voices = append(j.voices, v)
+	return machine.JamVoice(len(j.voices)), nil
+}
+
+func (j *fakeJam) voice(v machine.JamVoice) *fakeVoice {
+	return j.voices[v-1]
+}
+
+func (j *fakeJam) Release(v machine.JamVoice)                 { j.voice(v).released = true }
+func (j *fakeJam) SetPitchBend(v machine.JamVoice, s float32) { j.voice(v).bend = s }
+func (j *fakeJam) SetVibrato(v machine.JamVoice, d float32)   { j.voice(v).vibrato = d }
+func (j *fakeJam) SetVolume(v machine.JamVoice, vol volume.Volume) error {
+	j.voice(v).volume = vol
+	return nil
+}
+func (j *fakeJam) SetPan(v machine.JamVoice, pos panning.Position) { j.voice(v).pan = pos }
+
+func TestBridgePlaysNotes(t *testing.T) {
+	jam := &fakeJam{}
+	mapping := DefaultMapping()
+	mapping.Channels[0].Programs = map[uint8]int{4: 9}
+	b := NewBridge(jam, mapping)
+
+	stream := []byte{
+		0xC0, 4, // program change to the mapped instrument
+		0x90, 60, 127, // middle C at full velocity
+		0xC0, 2, // program change to an unmapped program
+		0x90, 72, 64, // note on, running status
+		0x80, 60, 0, // note off
+		72, 0, // note off, running status
+	}
+	if err := b.Play(bytes.NewReader(stream)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(jam.voices) != 2 {
+		t.Fatalf("expected two notes, got %d", len(jam.voices))
+	}
+	first, second := jam.voices[0], jam.voices[1]
+	if first.note.Note != note.Normal(48) || first.note.Instrument != 9 || first.volume != 1 {
+		t.Fatalf("unexpected first note: %+v", first.note)
+	}
+	if second.note.Note != note.Normal(60) || second.note.Instrument != 3 || second.volume != volume.Volume(64)/127 {
+		t.Fatalf("unexpected second note: %+v", second.note)
+	}
+	if !first.released || !second.released {
+		t.Fatalf("expected both notes to be released")
+	}
+}
+
+func TestBridgeFollowsControllers(t *testing.T) {
+	jam := &fakeJam{}
+	b := NewBridge(jam, DefaultMapping())
+
+	stream := []byte{
+		0xE0, 0x00, 0x00, // full bend down, before the note
+		0x90, 60, 127,
+		0xB0, ControllerModWheel, 127,
+		ControllerVolume, 0,
+		ControllerPan, 127,
+	}
+	if err := b.Play(bytes.NewReader(stream)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	v := jam.voices[0]
+	if v.bend != -DefaultBendRange {
+		t.Fatalf("expected the note to start bent down, got %v", v.bend)
+	}
+	if v.vibrato != DefaultModDepth {
+		t.Fatalf("expected the mod wheel to set the vibrato, got %v", v.vibrato)
+	}
+	if v.volume != 0 {
+		t.Fatalf("expected the volume controller to silence the note, got %v", v.volume)
+	}
+	if v.pan != panning.MakeStereoPosition(1, 0, 1) {
+		t.Fatalf("expected the note to be panned right, got %v", v.pan)
+	}
+
+	if err := b.Handle(Message{Kind: KindPitchBend, Channel: 0, Data1: 0x7F, Data2: 0x7F}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if v.bend != DefaultBendRange {
+		t.Fatalf("expected a full bend up, got %v", v.bend)
+	}
+}
+
+func TestBridgeSkipsDisabledChannelsAndRetriggers(t *testing.T) {
+	jam := &fakeJam{}
+	mapping := DefaultMapping()
+	mapping.Channels[1].Enabled = false
+	b := NewBridge(jam, mapping)
+
+	b.Handle(Message{Kind: KindNoteOn, Channel: 1, Data1: 60, Data2: 100})
+	if len(jam.voices) != 0 {
+		t.Fatalf("expected the disabled channel to be ignored")
+	}
+
+	b.Handle(Message{Kind: KindNoteOn, Channel: 0, Data1: 60, Data2: 100})
+	b.Handle(Message{Kind: KindNoteOn, Channel: 0, Data1: 60, Data2: 100})
+	if len(jam.voices) != 2 || !jam.voices[0].released || jam.voices[1].released {
+		t.Fatalf("expected striking a key again to release its previous note")
+	}
+
+	jam.err = errors.New("no such instrument")
+	if err := b.Handle(Message{Kind: KindNoteOn, Channel: 0, Data1: 62, Data2: 100}); err == nil {
+		t.Fatalf("expected the jam's error")
+	}
+}
+
+func TestBridgePlaysPastMissingInstruments(t *testing.T) {
+	jam := &fakeJam{missing: map[int]bool{6: true}}
+	b := NewBridge(jam, DefaultMapping())
+	var skipped []Message
+	b.ErrorHandler = func(msg Message, err error) {
+		skipped = append(skipped, msg)
+	}
+
+	stream := []byte{
+		0xC0, 5, // program change to an instrument the song doesn't have
+		0x90, 60, 100, // note on, which can't be played
+		0xC0, 0, // program change back to the first instrument
+		0x90, 62, 100, // note on
+	}
+	if err := b.Play(bytes.NewReader(stream)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(skipped) != 1 || skipped[0].Kind != KindNoteOn || skipped[0].Data1 != 60 {
+		t.Fatalf("expected the unplayable note to be reported, got %+v", skipped)
+	}
+	if len(jam.voices) != 1 || jam.voices[0].note.Instrument != 1 {
+		t.Fatalf("expected the stream to keep playing after the unplayable note")
+	}
+}
@@ -0,0 +1,152 @@
+package midi
+
+import (
+	"bufio"
+	"io"
+)
+
+// Decoder reads MIDI channel messages from a raw MIDI 1.0 byte stream, such as the one read
+// from a MIDI device. It follows running status and skips system exclusive, system common and
+// real-time messages, as well as any data bytes it can't place and any message cut short by
+// the status of another.
+type Decoder struct {
+	r       io.ByteReader
+	running Kind
+	channel uint8
+
+	held    uint8 // a status byte read too early, to be read again
+	hasHeld bool
+}
+
+// NewDecoder returns a Decoder reading from r
+func NewDecoder(r io.Reader) *Decoder {
+	br, ok := r.(io.ByteReader)
+	if !ok {
+		br = bufio.NewReader(r)
+	}
+	return &Decoder{
+		r: br,
+	}
+}
+
+func (d *Decoder) readByte() (uint8, error) {
+	if d.hasHeld {
+		d.hasHeld = false
+		return d.held, nil
+	}
+	return d.r.ReadByte()
+}
+
+func (d *Decoder) hold(b uint8) {
+	d.held, d.hasHeld = b, true
+}
+
+// Next returns the next channel message of the stream. It returns io.EOF at the end of the
+// stream, or io.ErrUnexpectedEOF when the stream ends part-way through a message.
+func (d *Decoder) Next() (Message, error) {
+	for {
+		b, err := d.readByte()
+		if err != nil {
+			return Message{}, err
+		}
+
+		switch {
+		case b >= 0xF8:
+			// real-time messages can turn up anywhere, even inside another message, and don't
+			// disturb the running status
+			continue
+
+		case b >= 0xF0:
+			// system exclusive and system common messages cancel the running status
+			d.running = 0
+			if err := d.skipSystem(b); err != nil {
+				return Message{}, err
+			}
+			continue
+
+		case b >= 0x80:
+			d.running = Kind(b & 0xF0)
+			d.channel = b & 0x0F
+			var ok bool
+			if b, ok, err = d.readData(); err != nil {
+				return Message{}, err
+			} else if !ok {
+				continue
+			}
+
+		case d.running == 0:
+			// a data byte with no status to go with it
+			continue
+		}
+
+		msg := Message{
+			Kind:    d.running,
+			Channel: d.channel,
+			Data1:   b,
+		}
+		if d.running.dataLen() == 2 {
+			var ok bool
+			if msg.Data2, ok, err = d.readData(); err != nil {
+				return Message{}, err
+			} else if !ok {
+				continue
+			}
+		}
+		return msg, nil
+	}
+}
+
+// readData reads a data byte of a message, passing over any real-time messages in the way. It
+// returns false when a status byte cuts the message short.
+func (d *Decoder) readData() (uint8, bool, error) {
+	for {
+		b, err := d.readByte()
+		if err == io.EOF {
+			return 0, false, io.ErrUnexpectedEOF
+		} else if err != nil {
+			return 0, false, err
+		}
+
+		switch {
+		case b >= 0xF8:
+			continue
+		case b >= 0x80:
+			d.hold(b)
+			return 0, false, nil
+		}
+		return b, true, nil
+	}
+}
+
+// skipSystem skips the rest of a system exclusive or system common message
+func (d *Decoder) skipSystem(status uint8) error {
+	var n int
+	switch status {
+	case 0xF0:
+		// system exclusive runs until the end of exclusive, or until another status byte
+		for {
+			b, err := d.readByte()
+			if err != nil {
+				return err
+			}
+			if b == 0xF7 {
+				return nil
+			}
+			if b >= 0x80 && b < 0xF8 {
+				d.hold(b)
+				return nil
+			}
+		}
+	case 0xF1, 0xF3:
+		n = 1
+	case 0xF2:
+		n = 2
+	}
+
+	for i := 0; i < n; i++ {
+		if _, ok, err := d.readData(); err != nil || !ok {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,96 @@
+package midi
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"reflect"
+	"testing"
+)
+
+func decodeAll(t *testing.T, data []byte) ([]Message, error) {
+	t.Helper()
+	d := NewDecoder(bytes.NewReader(data))
+	var msgs []Message
+	for {
+		msg, err := d.Next()
+		if err != nil {
+			return msgs, err
+		}
+		msgs = append(msgs, msg)
+	}
+}
+
+func TestDecoderReadsChannelMessages(t *testing.T) {
+	data := []byte{
+		0x91, 60, 100, // note on, channel 1
+		62, 90, // running status
+		0xF8,              // real-time clock between messages
+		0x81, 60, 0xFE, 0, // note off, with active sensing inside it
+		0xC2, 5, // program change
+		7,                // running status with one data byte
+		0xE0, 0x00, 0x40, // pitch bend, centered
+	}
+
+	msgs, err := decodeAll(t, data)
+	if !errors.Is(err, io.EOF) {
+		t.Fatalf("expected io.EOF, got %v", err)
+	}
+
+	want := []Message{
+		{Kind: KindNoteOn, Channel: 1, Data1: 60, Data2: 100},
+		{Kind: KindNoteOn, Channel: 1, Data1: 62, Data2: 90},
+		{Kind: KindNoteOff, Channel: 1, Data1: 60, Data2: 0},
+		{Kind: KindProgramChange, Channel: 2, Data1: 5},
+		{Kind: KindProgramChange, Channel: 2, Data1: 7},
+		{Kind: KindPitchBend, Channel: 0, Data1: 0x00, Data2: 0x40},
+	}
+	if !reflect.DeepEqual(msgs, want) {
+		t.Fatalf("expected %v, got %v", want, msgs)
+	}
+	if bend := msgs[5].PitchBend(); bend != 0 {
+		t.Fatalf("expected a centered pitch bend, got %d", bend)
+	}
+}
+
+func TestDecoderSkipsSystemMessages(t *testing.T) {
+	data := []byte{
+		0x90, 60, 100,
+		0xF0, 0x7E, 0x7F, 0x09, 0x01, 0xF7, // system exclusive
+		64, 100, // running status was cancelled by the system exclusive
+		0xF2, 0x10, 0x20, // song position pointer
+		0xB0, 7, 127,
+	}
+
+	msgs, err := decodeAll(t, data)
+	if !errors.Is(err, io.EOF) {
+		t.Fatalf("expected io.EOF, got %v", err)
+	}
+
+	want := []Message{
+		{Kind: KindNoteOn, Channel: 0, Data1: 60, Data2: 100},
+		{Kind: KindControlChange, Channel: 0, Data1: 7, Data2: 127},
+	}
+	if !reflect.DeepEqual(msgs, want) {
+		t.Fatalf("expected %v, got %v", want, msgs)
+	}
+}
+
+func TestDecoderDropsMessagesCutShort(t *testing.T) {
+	// a note on cut short by a control change
+	msgs, err := decodeAll(t, []byte{0x90, 60, 0xB0, 10, 0})
+	if !errors.Is(err, io.EOF) {
+		t.Fatalf("expected io.EOF, got %v", err)
+	}
+	want := []Message{
+		{Kind: KindControlChange, Channel: 0, Data1: 10, Data2: 0},
+	}
+	if !reflect.DeepEqual(msgs, want) {
+		t.Fatalf("expected %v, got %v", want, msgs)
+	}
+
+	// a stream that ends part-way through a message
+	if _, err := decodeAll(t, []byte{0x90, 60}); !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Fatalf("expected io.ErrUnexpectedEOF, got %v", err)
+	}
+}
@@ -0,0 +1,420 @@
+package midi
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/gotracker/playback/frequency"
+	"github.com/gotracker/playback/index"
+	"github.com/gotracker/playback/instrument"
+	"github.com/gotracker/playback/mixing/panning"
+	"github.com/gotracker/playback/mixing/volume"
+	"github.com/gotracker/playback/player/machine"
+	"github.com/gotracker/playback/song"
+)
+
+// TrackLayout is how ExportSMF lays the notes of a song out over the tracks of the file
+type TrackLayout int
+
+const (
+	// TrackPerChannel gives every channel of the song its own track, with a program change
+	// whenever the channel plays a different instrument
+	TrackPerChannel = TrackLayout(iota)
+	// TrackPerInstrument gives every instrument of the song its own track. Notes played on it
+	// from several channels at once share its pitch bend and controllers, which follow the
+	// channel that played a note on it last.
+	TrackPerInstrument
+)
+
+// ErrSongDoesNotEnd is returned by ExportSMF for a song that's still playing after the most ticks
+// it exports
+var ErrSongDoesNotEnd = errors.New("song does not end")
+
+const (
+	// DefaultPPQ is the ticks per quarter note of an exported file when none is set
+	DefaultPPQ = 96
+	// DefaultRowsPerBeat is the rows of the song to a quarter note when none is set
+	DefaultRowsPerBeat = 4
+	// DefaultExportBendRange is the bend, in semitones, of a full pitch bend in an exported file
+	// when none is set
+	DefaultExportBendRange = 12
+	// DefaultMaxExportTicks is the most ticks of a song an export plays through when no limit is
+	// set, which is a little under 6 hours at the usual 50 ticks a second
+	DefaultMaxExportTicks = 1 << 20
+)
+
+// the controllers an export sets besides the ones the Bridge follows
+const (
+	controllerDataEntry    = 6
+	controllerExpression   = 11
+	controllerDataEntryLSB = 38
+	controllerRPNLSB       = 100
+	controllerRPNMSB       = 101
+)
+
+const bendCenter = 0x2000
+
+// ExportSettings is how ExportSMF writes a song out
+type ExportSettings struct {
+	Layout      TrackLayout
+	PPQ         int // the ticks per quarter note of the file; 0 uses DefaultPPQ
+	RowsPerBeat int // the rows of the song to a quarter note; 0 uses DefaultRowsPerBeat
+	BendRange   int // the bend, in semitones, of a full pitch bend; 0 uses DefaultExportBendRange
+	MaxTicks    int // the most ticks of the song to play through; 0 uses DefaultMaxExportTicks
+}
+
+func (s ExportSettings) withDefaults() (ExportSettings, error) {
+	if s.PPQ == 0 {
+		s.PPQ = DefaultPPQ
+	}
+	if s.RowsPerBeat == 0 {
+		s.RowsPerBeat = DefaultRowsPerBeat
+	}
+	if s.BendRange == 0 {
+		s.BendRange = DefaultExportBendRange
+	}
+	if s.MaxTicks == 0 {
+		s.MaxTicks = DefaultMaxExportTicks
+	}
+
+	switch {
+	case s.Layout != TrackPerChannel && s.Layout != TrackPerInstrument:
+		return s, fmt.Errorf("unknown track layout: %d", s.Layout)
+	case s.PPQ < 0 || s.PPQ > 0x7FFF:
+		return s, fmt.Errorf("ticks per quarter note out of range: %d", s.PPQ)
+	case s.RowsPerBeat < 0:
+		return s, fmt.Errorf("rows per beat out of range: %d", s.RowsPerBeat)
+	case s.BendRange < 0 || s.BendRange > 127:
+		return s, fmt.Errorf("bend range out of range: %d", s.BendRange)
+	case s.MaxTicks < 0:
+		return s, fmt.Errorf("most ticks out of range: %d", s.MaxTicks)
+	}
+	return s, nil
+}
+
+// ExportSMF advances m through to the end of its song and writes the notes it plays out to w as
+// a type 1 Standard MIDI File. The first track holds the tempo, which follows the tempo and the
+// BPM of the song; the rest hold the notes, laid out as the settings ask.
+//
+// A note lasts until it's released, faded out or cut, or until its channel plays another. Its
+// volume at the start sets its velocity and later changes to it set the expression controller,
+// while the volume of its channel sets the volume controller and its panning the pan controller.
+// Slides and vibrato of its pitch are approximated with pitch bend. As with the Bridge, C-4
+// is MIDI note 60.
+//
+// Every track of notes gets a MIDI channel of its own, passing over channel 10, which General
+// MIDI keeps for percussion. A file only has 15 of those, so from the 16th track on the tracks
+// share them in turn, and tracks sharing a channel share its program, pitch bend and controllers
+// too. Songs with more channels or instruments than that export best with the layout that makes
+// the fewer tracks.
+//
+// m must be a fresh machine that is only advanced, never rendered, and its song must end, so
+// its SongLoopCount can't be negative. A song that's still playing after the settings' MaxTicks
+// ticks, as one that jumps back on itself forever does, is abandoned with ErrSongDoesNotEnd.
+func ExportSMF(w io.Writer, m machine.MachineTicker, settings ExportSettings) error {
+	settings, err := settings.withDefaults()
+	if err != nil {
+		return err
+	}
+
+	e := &exporter{
+		settings: settings,
+		tracks:   make(map[int]*exportTrack),
+		tempo:    -1,
+	}
+	if name := m.GetName(); name != "" {
+		e.conductor.addMeta(0, metaTrackName, []byte(name))
+	}
+
+	unsubscribe := m.Subscribe(func(ev machine.Event) {
+		e.pending = append(e.pending, ev)
+	})
+	defer unsubscribe()
+
+	for ticks := 0; ; ticks++ {
+		if ticks >= settings.MaxTicks {
+			return fmt.Errorf("%w within %d ticks", ErrSongDoesNotEnd, settings.MaxTicks)
+		}
+		if err := m.Advance(); errors.Is(err, song.ErrStopSong) {
+			break
+		} else if err != nil {
+			return err
+		}
+		e.tick(m.Snapshot())
+	}
+	e.finish()
+
+	return writeSMF(w, settings.PPQ, e.getTracks())
+}
+
+type exportTrack struct {
+	smfTrack
+	key     int   // the channel or the instrument the track is for
+	channel uint8 // the MIDI channel
+	program int   // -1 until a program change
+	owner   index.Channel
+
+	// the last values sent, or -1
+	bend       int
+	volume     int
+	expression int
+	pan        int
+}
+
+func (t *exportTrack) setController(time int64, controller uint8, value int, last *int) {
+	if *last == value {
+		return
+	}
+	t.add(time, uint8(KindControlChange)|t.channel, controller, uint8(value))
+	*last = value
+}
+
+func (t *exportTrack) setBend(time int64, value int) {
+	if t.bend == value {
+		return
+	}
+	t.add(time, uint8(KindPitchBend)|t.channel, uint8(value&0x7F), uint8(value>>7))
+	t.bend = value
+}
+
+type exportNote struct {
+	track      *exportTrack
+	key        uint8
+	playing    bool
+	baseFreq   frequency.Frequency
+	baseVolume volume.Volume
+}
+
+type exporter struct {
+	settings  ExportSettings
+	conductor smfTrack
+	tracks    map[int]*exportTrack
+	notes     []exportNote // one for every channel of the song
+	pending   []machine.Event
+	time      float64 // in ticks of the file
+	tempo     int     // the microseconds per quarter note last written, or -1
+}
+
+func (e *exporter) now() int64 {
+	return int64(math.Round(e.time))
+}
+
+// tick writes out the events raised while advancing the machine by a tick, with the playing
+// state it was left in
+func (e *exporter) tick(s machine.Snapshot) {
+	if len(e.notes) < len(s.Channels) {
+		e.notes = append(e.notes, make([]exportNote, len(s.Channels)-len(e.notes))...)
+	}
+
+	quarter := float64(e.settings.RowsPerBeat*s.Tempo) * float64(s.TickDuration) / float64(time.Microsecond)
+	if tempo := min(max(int(math.Round(quarter)), 1), 0xFFFFFF); tempo != e.tempo {
+		e.conductor.addTempo(e.now(), tempo)
+		e.tempo = tempo
+	}
+
+	for _, ev := range e.pending {
+		switch ev := ev.(type) {
+		case machine.NoteOnEvent:
+			if int(ev.Channel) < len(s.Channels) {
+				e.noteOn(ev, s.Channels[ev.Channel])
+			}
+		case machine.NoteOffEvent:
+			e.noteOff(ev.Channel)
+		case machine.FadeoutEvent:
+			e.noteOff(ev.Channel)
+		case machine.CutEvent:
+			e.noteOff(ev.Channel)
+		}
+	}
+	e.pending = e.pending[:0]
+
+	for _, cs := range s.Channels {
+		e.updateControllers(cs)
+	}
+
+	if s.Tempo > 0 {
+		e.time += float64(e.settings.PPQ) / float64(e.settings.RowsPerBeat*s.Tempo)
+	}
+}
+
+func (e *exporter) noteOn(ev machine.NoteOnEvent, cs machine.ChannelSnapshot) {
+	e.noteOff(ev.Channel)
+
+	key := int(ev.Semitone) + 12
+	if key < 0 || key > 127 {
+		return
+	}
+
+	t := e.getTrack(ev.Channel, ev.Instrument)
+	if t == nil {
+		return
+	}
+	now := e.now()
+
+	if e.settings.Layout == TrackPerChannel && ev.Instrument != nil && !ev.Instrument.IsEmpty() {
+		if program := getProgram(ev.Instrument); program != t.program {
+			t.add(now, uint8(KindProgramChange)|t.channel, uint8(program))
+			t.program = program
+		}
+	}
+
+	t.owner = ev.Channel
+	t.setBend(now, bendCenter)
+	t.add(now, uint8(KindNoteOn)|t.channel, uint8(key), uint8(max(toMIDIValue(cs.Volume), 1)))
+
+	e.notes[ev.Channel] = exportNote{
+		track:      t,
+		key:        uint8(key),
+		playing:    true,
+		baseFreq:   cs.Frequency,
+		baseVolume: cs.Volume,
+	}
+}
+
+func (e *exporter) noteOff(ch index.Channel) {
+	if int(ch) >= len(e.notes) {
+		return
+	}
+	n := &e.notes[ch]
+	if !n.playing {
+		return
+	}
+	n.track.add(e.now(), uint8(KindNoteOff)|n.track.channel, n.key, 0)
+	n.playing = false
+}
+
+func (e *exporter) updateControllers(cs machine.ChannelSnapshot) {
+	n := &e.notes[cs.Channel]
+	if !n.playing || n.track.owner != cs.Channel {
+		return
+	}
+	t := n.track
+	now := e.now()
+
+	t.setController(now, ControllerVolume, toMIDIValue(cs.MixingVolume), &t.volume)
+
+	base := n.baseVolume
+	if base <= 0 {
+		base = 1
+	}
+	t.setController(now, controllerExpression, toMIDIValue(cs.Volume/base), &t.expression)
+
+	if cs.Pan.Distance != 0 {
+		pan := math.Round(float64(panning.FromStereoPosition(cs.Pan, 0, 127)))
+		t.setController(now, ControllerPan, min(max(int(pan), 0), 127), &t.pan)
+	}
+
+	if n.baseFreq > 0 && cs.Frequency > 0 {
+		semitones := 12 * math.Log2(float64(cs.Frequency/n.baseFreq))
+		bend := bendCenter + int(math.Round(semitones/float64(e.settings.BendRange)*bendCenter))
+		t.setBend(now, min(max(bend, 0), 0x3FFF))
+	}
+}
+
+// getTrack returns the track for a note played on a channel with an instrument, adding it when
+// it's the first, or nil when the note has no track
+func (e *exporter) getTrack(ch index.Channel, id instrument.ID) *exportTrack {
+	var (
+		key  int
+		name string
+	)
+	switch e.settings.Layout {
+	case TrackPerChannel:
+		key = int(ch)
+		name = fmt.Sprintf("Channel %d", ch+1)
+	case TrackPerInstrument:
+		if id == nil || id.IsEmpty() {
+			return nil
+		}
+		key = getProgram(id)
+		name = fmt.Sprintf("Instrument %d", key+1)
+	}
+
+	if t, found := e.tracks[key]; found {
+		return t
+	}
+
+	t := &exportTrack{
+		key:        key,
+		channel:    getTrackChannel(len(e.tracks)),
+		program:    -1,
+		bend:       -1,
+		volume:     -1,
+		expression: -1,
+		pan:        -1,
+	}
+	t.addMeta(0, metaTrackName, []byte(name))
+
+	// set the bend range, then close the registered parameter
+	cc := uint8(KindControlChange) | t.channel
+	t.add(0, cc, controllerRPNMSB, 0)
+	t.add(0, cc, controllerRPNLSB, 0)
+	t.add(0, cc, controllerDataEntry, uint8(e.settings.BendRange))
+	t.add(0, cc, controllerDataEntryLSB, 0)
+	t.add(0, cc, controllerRPNMSB, 0x7F)
+	t.add(0, cc, controllerRPNLSB, 0x7F)
+
+	if e.settings.Layout == TrackPerInstrument {
+		t.add(0, uint8(KindProgramChange)|t.channel, uint8(key))
+		t.program = key
+	}
+
+	e.tracks[key] = t
+	return t
+}
+
+// finish lets go of the notes still playing at the end of the song and ends every track there
+func (e *exporter) finish() {
+	for ch := range e.notes {
+		e.noteOff(index.Channel(ch))
+	}
+
+	end := e.now()
+	e.conductor.end = end
+	for _, t := range e.tracks {
+		t.end = end
+	}
+}
+
+// getTracks returns the tracks of the file: the tempo, then the notes in channel or instrument
+// order
+func (e *exporter) getTracks() []*smfTrack {
+	noteTracks := make([]*exportTrack, 0, len(e.tracks))
+	for _, t := range e.tracks {
+		noteTracks = append(noteTracks, t)
+	}
+	sort.Slice(noteTracks, func(i, j int) bool {
+		return noteTracks[i].key < noteTracks[j].key
+	})
+
+	tracks := []*smfTrack{&e.conductor}
+	for _, t := range noteTracks {
+		tracks = append(tracks, &t.smfTrack)
+	}
+	return tracks
+}
+
+// getTrackChannel returns the MIDI channel of the n-th track of notes, passing over channel 10,
+// which General MIDI keeps for percussion. There are 15 of those, so the 16th track shares the
+// first track's channel, and so on.
+func getTrackChannel(n int) uint8 {
+	ch := uint8(n % (NumChannels - 1))
+	if ch >= 9 {
+		ch++
+	}
+	return ch
+}
+
+// getProgram returns the program of an instrument, which is its index in the song
+func getProgram(id instrument.ID) int {
+	idx, _ := id.GetIndexAndSample()
+	return min(max(idx, 0), 127)
+}
+
+func toMIDIValue(v volume.Volume) int {
+	return min(max(int(math.Round(float64(v)*127)), 0), 127)
+}
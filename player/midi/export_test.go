@@ -0,0 +1,298 @@
+package midi
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/gotracker/playback/format"
+	"github.com/gotracker/playback/frequency"
+	"github.com/gotracker/playback/index"
+	"github.com/gotracker/playback/mixing/panning"
+	"github.com/gotracker/playback/mixing/volume"
+	"github.com/gotracker/playback/player/feature"
+	"github.com/gotracker/playback/player/machine"
+	"github.com/gotracker/playback/player/machine/settings"
+	"github.com/gotracker/playback/song"
+)
+
+type fakeID int
+
+func (id fakeID) IsEmpty() bool                 { return false }
+func (id fakeID) GetIndexAndSample() (int, int) { return int(id), 0 }
+func (id fakeID) String() string                { return fmt.Sprint(int(id)) }
+
+type fakeTick struct {
+	events   []machine.Event
+	channels []machine.ChannelSnapshot
+}
+
+// fakeMachine plays a script of ticks, one for every advance
+type fakeMachine struct {
+	machine.MachineTicker
+	ticks   []fakeTick
+	current int
+	handler machine.EventHandler
+}
+
+func (m *fakeMachine) GetName() string { return "song" }
+
+func (m *fakeMachine) Subscribe(handler machine.EventHandler) func() {
+	m.handler = handler
+	return func() { m.handler = nil }
+}
+
+func (m *fakeMachine) Advance() error {
+	if m.current >= len(m.ticks) {
+		return song.ErrStopSong
+	}
+	for _, ev := range m.ticks[m.current].events {
+		m.handler(ev)
+	}
+	m.current++
+	return nil
+}
+
+func (m *fakeMachine) Snapshot() machine.Snapshot {
+	return machine.Snapshot{
+		Tempo:        6,
+		BPM:          125,
+		TickDuration: 20 * time.Millisecond,
+		Channels:     m.ticks[m.current-1].channels,
+	}
+}
+
+func playing(ch index.Channel, freq frequency.Frequency, vol volume.Volume) machine.ChannelSnapshot {
+	return machine.ChannelSnapshot{
+		Channel:      ch,
+		Active:       true,
+		Frequency:    freq,
+		Volume:       vol,
+		MixingVolume: 1,
+		Pan:          panning.CenterAhead,
+	}
+}
+
+func TestExportSMFWritesNotes(t *testing.T) {
+	up := frequency.Frequency(440 * math.Pow(2, 1.0/12))
+	m := &fakeMachine{
+		ticks: []fakeTick{
+			{
+				events:   []machine.Event{machine.NoteOnEvent{Channel: 0, Instrument: fakeID(2), Semitone: 48}},
+				channels: []machine.ChannelSnapshot{playing(0, 440, 0.5), {Channel: 1}},
+			},
+			{
+				// a slide up a semitone, and a note on the other channel
+				events:   []machine.Event{machine.NoteOnEvent{Channel: 1, Instrument: fakeID(0), Semitone: 60}},
+				channels: []machine.ChannelSnapshot{playing(0, up, 0.25), playing(1, 220, 1)},
+			},
+			{
+				events:   []machine.Event{machine.NoteOffEvent{Channel: 0}},
+				channels: []machine.ChannelSnapshot{{Channel: 0}, playing(1, 220, 1)},
+			},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := ExportSMF(&buf, m, ExportSettings{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	f := readSMF(t, buf.Bytes())
+	if f.format != 1 || f.division != DefaultPPQ || len(f.tracks) != 3 {
+		t.Fatalf("expected a tempo track and a track per channel, got %+v", f)
+	}
+
+	// 4 rows to the beat at 6 ticks to the row, 20ms to the tick
+	wantTempo := []smfEvent{
+		{time: 0, data: []byte{0xFF, metaTrackName, 4, 's', 'o', 'n', 'g'}},
+		{time: 0, data: []byte{0xFF, metaTempo, 3, 0x07, 0x53, 0x00}},
+		{time: 12, data: []byte{0xFF, metaEndOfTrack, 0}},
+	}
+	if !reflect.DeepEqual(f.tracks[0], wantTempo) {
+		t.Fatalf("expected %v, got %v", wantTempo, f.tracks[0])
+	}
+
+	// 4 file ticks to a tick of the song; a semitone is 1/12 of the default bend range up
+	bend := bendCenter + int(math.Round(bendCenter/12.0))
+	wantNotes := []smfEvent{
+		{time: 0, data: []byte{0xFF, metaTrackName, 9, 'C', 'h', 'a', 'n', 'n', 'e', 'l', ' ', '1'}},
+		{time: 0, data: []byte{0xB0, controllerRPNMSB, 0}},
+		{time: 0, data: []byte{0xB0, controllerRPNLSB, 0}},
+		{time: 0, data: []byte{0xB0, controllerDataEntry, DefaultExportBendRange}},
+		{time: 0, data: []byte{0xB0, controllerDataEntryLSB, 0}},
+		{time: 0, data: []byte{0xB0, controllerRPNMSB, 0x7F}},
+		{time: 0, data: []byte{0xB0, controllerRPNLSB, 0x7F}},
+		{time: 0, data: []byte{0xC0, 2}},
+		{time: 0, data: []byte{0xE0, 0x00, 0x40}},
+		{time: 0, data: []byte{0x90, 60, 64}},
+		{time: 0, data: []byte{0xB0, ControllerVolume, 127}},
+		{time: 0, data: []byte{0xB0, controllerExpression, 127}},
+		{time: 0, data: []byte{0xB0, ControllerPan, 64}},
+		{time: 4, data: []byte{0xB0, controllerExpression, 64}},
+		{time: 4, data: []byte{0xE0, uint8(bend & 0x7F), uint8(bend >> 7)}},
+		{time: 8, data: []byte{0x80, 60, 0}},
+		{time: 12, data: []byte{0xFF, metaEndOfTrack, 0}},
+	}
+	if !reflect.DeepEqual(f.tracks[1], wantNotes) {
+		t.Fatalf("expected %v, got %v", wantNotes, f.tracks[1])
+	}
+
+	// the second channel's note plays on its own MIDI channel until the end of the song
+	second := f.tracks[2]
+	if last := second[len(second)-2]; last.time != 12 || !bytes.Equal(last.data, []byte{0x81, 72, 0}) {
+		t.Fatalf("expected the second note to be let go at the end of the song, got %v", last)
+	}
+}
+
+func TestExportSMFByInstrument(t *testing.T) {
+	m := &fakeMachine{
+		ticks: []fakeTick{
+			{
+				events: []machine.Event{
+					machine.NoteOnEvent{Channel: 0, Instrument: fakeID(3), Semitone: 48},
+					machine.NoteOnEvent{Channel: 1, Instrument: fakeID(3), Semitone: 52},
+					machine.NoteOnEvent{Channel: 2, Instrument: nil, Semitone: 52},
+				},
+				channels: []machine.ChannelSnapshot{playing(0, 440, 1), playing(1, 550, 1), playing(2, 550, 1)},
+			},
+			{
+				events:   []machine.Event{machine.CutEvent{Channel: 0}, machine.FadeoutEvent{Channel: 1}},
+				channels: []machine.ChannelSnapshot{{Channel: 0}, {Channel: 1}, playing(2, 550, 1)},
+			},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := ExportSMF(&buf, m, ExportSettings{Layout: TrackPerInstrument}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	f := readSMF(t, buf.Bytes())
+	if len(f.tracks) != 2 {
+		t.Fatalf("expected a single track of notes, got %d", len(f.tracks)-1)
+	}
+
+	var ons, offs []byte
+	for _, ev := range f.tracks[1] {
+		switch Kind(ev.data[0] & 0xF0) {
+		case KindProgramChange:
+			if ev.data[1] != 3 || ev.time != 0 {
+				t.Fatalf("unexpected program change: %v", ev)
+			}
+		case KindNoteOn:
+			ons = append(ons, ev.data[1])
+		case KindNoteOff:
+			offs = append(offs, ev.data[1])
+			if ev.time != 4 {
+				t.Fatalf("expected the notes to end on the second tick, got %v", ev)
+			}
+		}
+	}
+	if !bytes.Equal(ons, []byte{60, 64}) || !bytes.Equal(offs, []byte{60, 64}) {
+		t.Fatalf("unexpected notes: on %v, off %v", ons, offs)
+	}
+}
+
+func TestExportSMFRejectsBadSettings(t *testing.T) {
+	for _, s := range []ExportSettings{
+		{Layout: TrackLayout(2)},
+		{PPQ: -1},
+		{RowsPerBeat: -1},
+		{BendRange: 128},
+		{MaxTicks: -1},
+	} {
+		if err := ExportSMF(&bytes.Buffer{}, &fakeMachine{}, s); err == nil {
+			t.Fatalf("expected an error for %+v", s)
+		}
+	}
+}
+
+// endlessMachine plays its script of ticks over and over
+type endlessMachine struct {
+	fakeMachine
+}
+
+func (m *endlessMachine) Advance() error {
+	if m.current >= len(m.ticks) {
+		m.current = 0
+	}
+	return m.fakeMachine.Advance()
+}
+
+func TestExportSMFStopsASongThatDoesNotEnd(t *testing.T) {
+	m := &endlessMachine{fakeMachine{
+		ticks: []fakeTick{
+			{
+				events:   []machine.Event{machine.NoteOnEvent{Channel: 0, Instrument: fakeID(0), Semitone: 48}},
+				channels: []machine.ChannelSnapshot{playing(0, 440, 1)},
+			},
+			{
+				events:   []machine.Event{machine.NoteOffEvent{Channel: 0}},
+				channels: []machine.ChannelSnapshot{{Channel: 0}},
+			},
+		},
+	}}
+
+	var buf bytes.Buffer
+	if err := ExportSMF(&buf, m, ExportSettings{MaxTicks: 100}); !errors.Is(err, ErrSongDoesNotEnd) {
+		t.Fatalf("expected the export to give up on the song, got %v", err)
+	}
+	if buf.Len() != 0 {
+		t.Fatalf("expected nothing to be written, got %d bytes", buf.Len())
+	}
+	if m.current != 2 {
+		t.Fatalf("expected the export to stop after 100 ticks, stopped on tick %d of the script", m.current)
+	}
+}
+
+func TestExportSMFPlaysASong(t *testing.T) {
+	features := []feature.Feature{feature.SongLoop{Count: 0}}
+	songData, songFormat, err := format.Load("../../test/ode_to_protracker.mod", features)
+	if err != nil {
+		t.Fatalf("could not load the song: %v", err)
+	}
+	var us settings.UserSettings
+	if err := songFormat.ConvertFeaturesToSettings(&us, features); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	m, err := machine.NewMachine(songData, us)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := ExportSMF(&buf, m, ExportSettings{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	f := readSMF(t, buf.Bytes())
+	if len(f.tracks) < 2 {
+		t.Fatalf("expected tracks of notes, got %d tracks", len(f.tracks))
+	}
+	for i, track := range f.tracks[1:] {
+		held := make(map[uint8]int)
+		var notes int
+		for _, ev := range track {
+			switch Kind(ev.data[0] & 0xF0) {
+			case KindNoteOn:
+				held[ev.data[1]]++
+				notes++
+			case KindNoteOff:
+				held[ev.data[1]]--
+			}
+		}
+		if notes == 0 {
+			t.Fatalf("expected track %d to have notes", i+1)
+		}
+		for key, n := range held {
+			if n != 0 {
+				t.Fatalf("expected every note of track %d to end, but key %d is left at %d", i+1, key, n)
+			}
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package midi
+
+import "github.com/gotracker/playback/note"
+
+const (
+	// NumChannels is the number of channels of a MIDI stream
+	NumChannels = 16
+
+	// DefaultBendRange is the bend, in semitones, of a full pitch bend when none is set
+	DefaultBendRange = 2
+	// DefaultModDepth is the depth, in semitones, of the vibrato of the mod wheel at its top
+	// when none is set
+	DefaultModDepth = 0.5
+)
+
+// ChannelMapping is how the notes of a MIDI channel are played on the song's instruments
+type ChannelMapping struct {
+	Enabled bool
+	// Instrument is the song instrument played until a program change picks another, as
+	// numbered in the song's patterns
+	Instrument int
+	// Programs is the song instrument picked by each program change; a program missing from it
+	// picks the instrument numbered one past it, so program 0 picks instrument 1
+	Programs map[uint8]int
+	// Transpose is added to the notes, in semitones. Untransposed, MIDI note 60 (middle C)
+	// plays C-4.
+	Transpose int
+}
+
+// Mapping maps the channels of a MIDI stream onto the instruments of a song
+type Mapping struct {
+	Channels  [NumChannels]ChannelMapping
+	BendRange float32 // the bend, in semitones, of a full pitch bend; 0 uses DefaultBendRange
+	ModDepth  float32 // the vibrato depth, in semitones, of the mod wheel at its top; 0 uses DefaultModDepth
+}
+
+// DefaultMapping returns a Mapping that plays every MIDI channel on the song's first instrument
+// until a program change picks another
+func DefaultMapping() Mapping {
+	var m Mapping
+	for i := range m.Channels {
+		m.Channels[i] = ChannelMapping{
+			Enabled:    true,
+			Instrument: 1,
+		}
+	}
+	return m
+}
+
+func (m Mapping) getBendRange() float32 {
+	if m.BendRange == 0 {
+		return DefaultBendRange
+	}
+	return m.BendRange
+}
+
+func (m Mapping) getModDepth() float32 {
+	if m.ModDepth == 0 {
+		return DefaultModDepth
+	}
+	return m.ModDepth
+}
+
+// getProgramInstrument returns the song instrument picked by a program change
+func (c ChannelMapping) getProgramInstrument(program uint8) int {
+	if inst, found := c.Programs[program]; found {
+		return inst
+	}
+	return int(program) + 1
+}
+
+// getSemitone returns the semitone played by a MIDI note, or false when it's out of range
+func (c ChannelMapping) getSemitone(key uint8) (note.Semitone, bool) {
+	st := int(key) - 12 + c.Transpose
+	if st < 0 || st >= int(note.UnchangedSemitone) {
+		return 0, false
+	}
+	return note.Semitone(st), true
+}
@@ -0,0 +1,72 @@
+package midi
+
+import "fmt"
+
+// Kind is the kind of a MIDI channel message
+type Kind uint8
+
+const (
+	KindNoteOff         = Kind(0x80)
+	KindNoteOn          = Kind(0x90)
+	KindPolyPressure    = Kind(0xA0)
+	KindControlChange   = Kind(0xB0)
+	KindProgramChange   = Kind(0xC0)
+	KindChannelPressure = Kind(0xD0)
+	KindPitchBend       = Kind(0xE0)
+)
+
+func (k Kind) String() string {
+	switch k {
+	case KindNoteOff:
+		return "NoteOff"
+	case KindNoteOn:
+		return "NoteOn"
+	case KindPolyPressure:
+		return "PolyPressure"
+	case KindControlChange:
+		return "ControlChange"
+	case KindProgramChange:
+		return "ProgramChange"
+	case KindChannelPressure:
+		return "ChannelPressure"
+	case KindPitchBend:
+		return "PitchBend"
+	default:
+		return fmt.Sprintf("Kind(%#02x)", uint8(k))
+	}
+}
+
+// dataLen returns the number of data bytes that follow the status of a message of the kind
+func (k Kind) dataLen() int {
+	switch k {
+	case KindProgramChange, KindChannelPressure:
+		return 1
+	default:
+		return 2
+	}
+}
+
+// The controllers the Bridge follows
+const (
+	ControllerModWheel = 1
+	ControllerVolume   = 7
+	ControllerPan      = 10
+)
+
+// Message is a MIDI channel message
+type Message struct {
+	Kind    Kind
+	Channel uint8 // 0-15
+	Data1   uint8 // the key, controller or program
+	Data2   uint8 // the velocity, pressure or controller value; unused for a single data byte
+}
+
+// PitchBend returns the amount of a pitch bend message, from -8192 (full bend down) to 8191
+// (full bend up)
+func (m Message) PitchBend() int {
+	return (int(m.Data1) | int(m.Data2)<<7) - 8192
+}
+
+func (m Message) String() string {
+	return fmt.Sprintf("%v ch=%d %d %d", m.Kind, m.Channel, m.Data1, m.Data2)
+}
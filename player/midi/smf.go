@@ -0,0 +1,95 @@
+package midi
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+)
+
+// the meta events of a Standard MIDI File
+const (
+	metaTrackName  = 0x03
+	metaEndOfTrack = 0x2F
+	metaTempo      = 0x51
+)
+
+type smfEvent struct {
+	time int64 // in ticks from the start of the file
+	data []byte
+}
+
+// smfTrack is a track of a Standard MIDI File. Its events must be added in time order.
+type smfTrack struct {
+	events []smfEvent
+	end    int64 // the time of the end of the track, when it's after the last event
+}
+
+func (t *smfTrack) add(time int64, data ...byte) {
+	t.events = append(t.events, smfEvent{
+		time: time,
+		data: data,
+	})
+}
+
+func (t *smfTrack) addMeta(time int64, kind uint8, data []byte) {
+	ev := append([]byte{0xFF, kind}, appendVLQ(nil, uint32(len(data)))...)
+	t.add(time, append(ev, data...)...)
+}
+
+func (t *smfTrack) addTempo(time int64, microsPerQuarter int) {
+	t.addMeta(time, metaTempo, []byte{
+		uint8(microsPerQuarter >> 16),
+		uint8(microsPerQuarter >> 8),
+		uint8(microsPerQuarter),
+	})
+}
+
+func (t *smfTrack) encode() []byte {
+	var buf []byte
+	var last int64
+	for _, ev := range t.events {
+		buf = appendVLQ(buf, uint32(ev.time-last))
+		buf = append(buf, ev.data...)
+		last = ev.time
+	}
+	buf = appendVLQ(buf, uint32(max(t.end-last, 0)))
+	return append(buf, 0xFF, metaEndOfTrack, 0x00)
+}
+
+// writeSMF writes the tracks out as a type 1 Standard MIDI File, with `division` ticks per
+// quarter note
+func writeSMF(w io.Writer, division int, tracks []*smfTrack) error {
+	if division <= 0 || division > 0x7FFF {
+		return errors.New("division out of range")
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("MThd")
+	header := []uint16{1, uint16(len(tracks)), uint16(division)}
+	_ = binary.Write(&buf, binary.BigEndian, uint32(len(header)*2))
+	_ = binary.Write(&buf, binary.BigEndian, header)
+
+	for _, t := range tracks {
+		data := t.encode()
+		buf.WriteString("MTrk")
+		_ = binary.Write(&buf, binary.BigEndian, uint32(len(data)))
+		buf.Write(data)
+	}
+
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// appendVLQ appends v as a variable-length quantity, 7 bits to a byte with the most significant
+// bits first
+func appendVLQ(b []byte, v uint32) []byte {
+	var tmp [5]byte
+	n := len(tmp) - 1
+	tmp[n] = uint8(v & 0x7F)
+	for v >>= 7; v != 0; v >>= 7 {
+		n--
+		tmp[n] = uint8(v&0x7F) | 0x80
+	}
+	return append(b, tmp[n:]...)
+}
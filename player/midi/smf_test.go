@@ -0,0 +1,125 @@
+package midi
+
+import (
+	"bytes"
+	"encoding/binary"
+	"reflect"
+	"testing"
+)
+
+type smfFile struct {
+	format   uint16
+	division uint16
+	tracks   [][]smfEvent
+}
+
+// readSMF reads back a Standard MIDI File without running status, as writeSMF writes them
+func readSMF(t *testing.T, data []byte) smfFile {
+	t.Helper()
+
+	readChunk := func(kind string) []byte {
+		t.Helper()
+		if len(data) < 8 || string(data[:4]) != kind {
+			t.Fatalf("expected a %s chunk", kind)
+		}
+		n := binary.BigEndian.Uint32(data[4:8])
+		chunk := data[8 : 8+n]
+		data = data[8+n:]
+		return chunk
+	}
+
+	header := readChunk("MThd")
+	f := smfFile{
+		format:   binary.BigEndian.Uint16(header[0:2]),
+		division: binary.BigEndian.Uint16(header[4:6]),
+	}
+	numTracks := int(binary.BigEndian.Uint16(header[2:4]))
+
+	readVLQ := func(b []byte) (uint32, []byte) {
+		var v uint32
+		for {
+			v = v<<7 | uint32(b[0]&0x7F)
+			more := b[0]&0x80 != 0
+			b = b[1:]
+			if !more {
+				return v, b
+			}
+		}
+	}
+
+	for i := 0; i < numTracks; i++ {
+		b := readChunk("MTrk")
+		var (
+			events []smfEvent
+			now    int64
+		)
+		for len(b) > 0 {
+			var delta uint32
+			delta, b = readVLQ(b)
+			now += int64(delta)
+
+			var n int
+			if b[0] == 0xFF {
+				l, rest := readVLQ(b[2:])
+				n = len(b) - len(rest) + int(l)
+			} else {
+				n = 1 + Kind(b[0]&0xF0).dataLen()
+			}
+			events = append(events, smfEvent{time: now, data: b[:n]})
+			b = b[n:]
+		}
+		f.tracks = append(f.tracks, events)
+	}
+
+	if len(data) != 0 {
+		t.Fatalf("unexpected data after the tracks")
+	}
+	return f
+}
+
+func TestAppendVLQ(t *testing.T) {
+	for v, want := range map[uint32][]byte{
+		0x00:       {0x00},
+		0x7F:       {0x7F},
+		0x80:       {0x81, 0x00},
+		0x2000:     {0xC0, 0x00},
+		0x1FFFFF:   {0xFF, 0xFF, 0x7F},
+		0x0FFFFFFF: {0xFF, 0xFF, 0xFF, 0x7F},
+	} {
+		if got := appendVLQ(nil, v); !bytes.Equal(got, want) {
+			t.Fatalf("expected %#v to encode as % X, got % X", v, want, got)
+		}
+	}
+}
+
+func TestWriteSMF(t *testing.T) {
+	var conductor, notes smfTrack
+	conductor.addTempo(0, 500000)
+	notes.add(0, 0x90, 60, 100)
+	notes.add(200, 0x80, 60, 0)
+
+	var buf bytes.Buffer
+	if err := writeSMF(&buf, 96, []*smfTrack{&conductor, &notes}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	f := readSMF(t, buf.Bytes())
+	if f.format != 1 || f.division != 96 || len(f.tracks) != 2 {
+		t.Fatalf("unexpected header: %+v", f)
+	}
+	want := []smfEvent{
+		{time: 0, data: []byte{0x90, 60, 100}},
+		{time: 200, data: []byte{0x80, 60, 0}},
+		{time: 200, data: []byte{0xFF, metaEndOfTrack, 0x00}},
+	}
+	if !reflect.DeepEqual(f.tracks[1], want) {
+		t.Fatalf("expected %v, got %v", want, f.tracks[1])
+	}
+	if tempo := f.tracks[0][0].data; !bytes.Equal(tempo, []byte{0xFF, metaTempo, 3, 0x07, 0xA1, 0x20}) {
+		t.Fatalf("unexpected tempo event: % X", tempo)
+	}
+
+	if err := writeSMF(&buf, 0, nil); err == nil {
+		t.Fatalf("expected an error for a division of 0")
+	}
+}
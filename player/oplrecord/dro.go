@@ -0,0 +1,112 @@
+package oplrecord
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+)
+
+const (
+	droHardwareOPL2 = 0
+	droHardwareOPL3 = 2
+
+	droFormatInterleaved = 0
+	droCompressionNone   = 0
+
+	droShortDelayCode = 0x7E // delay of (value+1) milliseconds
+	droLongDelayCode  = 0x7F // delay of (value+1)*256 milliseconds
+	droMaxCodemapLen  = droShortDelayCode
+	droBankFlag       = 0x80
+)
+
+// ErrDROCodemapOverflow is returned when too many distinct registers were written to fit in a DRO codemap
+var ErrDROCodemapOverflow = errors.New("too many distinct registers for DRO codemap")
+
+// WriteDRO exports the recording as a DOSBox Raw OPL (DRO) v2.0 file
+func (r *Recorder) WriteDRO(w io.Writer) error {
+	writes, length, rate, err := r.snapshot()
+	if err != nil {
+		return err
+	}
+
+	var (
+		codemap []uint8
+		codes   = make(map[uint8]uint8)
+		isOPL3  bool
+	)
+	for _, wr := range writes {
+		if wr.Reg >= 0x100 {
+			isOPL3 = true
+		}
+		reg := uint8(wr.Reg)
+		if _, found := codes[reg]; found {
+			continue
+		}
+		if len(codemap) >= droMaxCodemapLen {
+			return ErrDROCodemapOverflow
+		}
+		codes[reg] = uint8(len(codemap))
+		codemap = append(codemap, reg)
+	}
+
+	var (
+		data  bytes.Buffer
+		pairs uint32
+		curMS int64
+	)
+	emitDelay := func(ms int64) {
+		for ms > 256 {
+			n := min(ms/256, 256)
+			data.Write([]byte{droLongDelayCode, uint8(n - 1)})
+			pairs++
+			ms -= n * 256
+		}
+		if ms > 0 {
+			data.Write([]byte{droShortDelayCode, uint8(ms - 1)})
+			pairs++
+		}
+	}
+
+	for _, wr := range writes {
+		at := rescale(wr.Sample, rate, 1000)
+		emitDelay(at - curMS)
+		curMS = at
+
+		code := codes[uint8(wr.Reg)]
+		if wr.Reg >= 0x100 {
+			code |= droBankFlag
+		}
+		data.Write([]byte{code, wr.Val})
+		pairs++
+	}
+	totalMS := rescale(length, rate, 1000)
+	emitDelay(totalMS - curMS)
+
+	hw := uint8(droHardwareOPL2)
+	if isOPL3 {
+		hw = droHardwareOPL3
+	}
+
+	var hdr bytes.Buffer
+	hdr.WriteString("DBRAWOPL")
+	_ = binary.Write(&hdr, binary.LittleEndian, uint16(2)) // version major
+	_ = binary.Write(&hdr, binary.LittleEndian, uint16(0)) // version minor
+	_ = binary.Write(&hdr, binary.LittleEndian, pairs)
+	_ = binary.Write(&hdr, binary.LittleEndian, uint32(totalMS))
+	hdr.Write([]byte{
+		hw,
+		droFormatInterleaved,
+		droCompressionNone,
+		droShortDelayCode,
+		droLongDelayCode,
+		uint8(len(codemap)),
+	})
+	hdr.Write(codemap)
+
+	if _, err := hdr.WriteTo(w); err != nil {
+		return err
+	}
+	_, err = data.WriteTo(w)
+	return err
+}
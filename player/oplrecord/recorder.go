@@ -0,0 +1,129 @@
+package oplrecord
+
+import (
+	"errors"
+	"sync"
+)
+
+// Chip is the register-write side of an OPL chip
+type Chip interface {
+	WriteReg(reg uint32, val uint8)
+}
+
+// RegWrite is a single captured register write
+type RegWrite struct {
+	Sample int64 // output sample position at which the write occurred
+	Reg    uint32
+	Val    uint8
+}
+
+// Recorder captures a stream of OPL register writes with sample-accurate timestamps
+// so it may be exported into a register dump format (VGM or DRO).
+//
+// The recorder is driven by the player: writes are stamped with the current sample
+// position, and the position is moved forward each time the chip renders a block.
+type Recorder struct {
+	mu         sync.Mutex
+	sampleRate int
+	pos        int64
+	writes     []RegWrite
+	target     Chip
+}
+
+// ErrNoSampleRate is returned when exporting a recording that has no sample rate configured
+var ErrNoSampleRate = errors.New("recorder sample rate is not configured")
+
+// NewRecorder returns a new, empty OPL register recorder
+func NewRecorder() *Recorder {
+	return &Recorder{}
+}
+
+// Attach resets the recorder and configures it to forward writes to `target`.
+// The returned Chip should be used in place of the target.
+func (r *Recorder) Attach(target Chip, sampleRate int) Chip {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.target = target
+	r.sampleRate = sampleRate
+	r.pos = 0
+	r.writes = nil
+	return r
+}
+
+// WriteReg records the register write, then forwards it on to the attached chip
+func (r *Recorder) WriteReg(reg uint32, val uint8) {
+	r.mu.Lock()
+	r.writes = append(r.writes, RegWrite{
+		Sample: r.pos,
+		Reg:    reg,
+		Val:    val,
+	})
+	target := r.target
+	r.mu.Unlock()
+
+	if target != nil {
+		target.WriteReg(reg, val)
+	}
+}
+
+// Advance moves the recorder's sample position forward by `samples`
+func (r *Recorder) Advance(samples int) {
+	if samples <= 0 {
+		return
+	}
+
+	r.mu.Lock()
+	r.pos += int64(samples)
+	r.mu.Unlock()
+}
+
+// GetSampleRate returns the sample rate the recording timestamps are based upon
+func (r *Recorder) GetSampleRate() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.sampleRate
+}
+
+// GetLength returns the total length of the recording in output samples
+func (r *Recorder) GetLength() int64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.pos
+}
+
+// GetWrites returns a copy of the captured register writes
+func (r *Recorder) GetWrites() []RegWrite {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]RegWrite(nil), r.writes...)
+}
+
+// IsOPL3 returns true when any write targets the second register bank
+func (r *Recorder) IsOPL3() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, w := range r.writes {
+		if w.Reg >= 0x100 {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *Recorder) snapshot() ([]RegWrite, int64, int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.sampleRate <= 0 {
+		return nil, 0, 0, ErrNoSampleRate
+	}
+	return append([]RegWrite(nil), r.writes...), r.pos, r.sampleRate, nil
+}
+
+// rescale converts a sample position at `fromRate` into one at `toRate`
+func rescale(pos int64, fromRate, toRate int) int64 {
+	if fromRate == toRate {
+		return pos
+	}
+	return (pos*int64(toRate) + int64(fromRate)/2) / int64(fromRate)
+}
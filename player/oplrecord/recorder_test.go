@@ -0,0 +1,170 @@
+package oplrecord
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"testing"
+)
+
+type stubChip struct {
+	regs []uint32
+	vals []uint8
+}
+
+func (c *stubChip) WriteReg(reg uint32, val uint8) {
+	c.regs = append(c.regs, reg)
+	c.vals = append(c.vals, val)
+}
+
+func TestRecorderForwardsAndStampsWrites(t *testing.T) {
+	chip := &stubChip{}
+	r := NewRecorder()
+	w := r.Attach(chip, 44100)
+
+	w.WriteReg(0x20, 0x01)
+	r.Advance(100)
+	w.WriteReg(0xB0, 0x22)
+
+	if len(chip.regs) != 2 || chip.regs[1] != 0xB0 || chip.vals[1] != 0x22 {
+		t.Fatalf("expected writes to be forwarded to chip, got regs=%v vals=%v", chip.regs, chip.vals)
+	}
+
+	writes := r.GetWrites()
+	if len(writes) != 2 {
+		t.Fatalf("expected 2 writes, got %d", len(writes))
+	}
+	if writes[0].Sample != 0 || writes[1].Sample != 100 {
+		t.Fatalf("unexpected timestamps: %+v", writes)
+	}
+	if r.GetLength() != 100 {
+		t.Fatalf("expected length 100, got %d", r.GetLength())
+	}
+}
+
+func TestRecorderExportWithoutSampleRate(t *testing.T) {
+	r := NewRecorder()
+	var buf bytes.Buffer
+	if err := r.WriteVGM(&buf); !errors.Is(err, ErrNoSampleRate) {
+		t.Fatalf("expected ErrNoSampleRate, got %v", err)
+	}
+	if err := r.WriteDRO(&buf); !errors.Is(err, ErrNoSampleRate) {
+		t.Fatalf("expected ErrNoSampleRate, got %v", err)
+	}
+}
+
+func TestRecorderWriteVGM(t *testing.T) {
+	r := NewRecorder()
+	w := r.Attach(nil, 22050)
+	w.WriteReg(0x20, 0x01)
+	r.Advance(10) // 20 samples at 44.1kHz
+	w.WriteReg(0xA0, 0x44)
+	r.Advance(22050)
+
+	var buf bytes.Buffer
+	if err := r.WriteVGM(&buf); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	out := buf.Bytes()
+
+	if string(out[0:4]) != "Vgm " {
+		t.Fatalf("bad ident: %q", out[0:4])
+	}
+	if eof := binary.LittleEndian.Uint32(out[0x04:]); int(eof) != len(out)-4 {
+		t.Fatalf("eof offset = %d, want %d", eof, len(out)-4)
+	}
+	if ver := binary.LittleEndian.Uint32(out[0x08:]); ver != 0x151 {
+		t.Fatalf("version = %#x, want 0x151", ver)
+	}
+	if total := binary.LittleEndian.Uint32(out[0x18:]); total != 44120 {
+		t.Fatalf("total samples = %d, want 44120", total)
+	}
+	if clk := binary.LittleEndian.Uint32(out[0x50:]); clk != YM3812ClockRate {
+		t.Fatalf("YM3812 clock = %d", clk)
+	}
+
+	data := out[0x34+binary.LittleEndian.Uint32(out[0x34:]):]
+	want := []byte{
+		0x5A, 0x20, 0x01,
+		0x61, 20, 0,
+		0x5A, 0xA0, 0x44,
+		0x61, 0x44, 0xAC, // 44100
+		0x66,
+	}
+	if !bytes.Equal(data, want) {
+		t.Fatalf("unexpected data:\n got %x\nwant %x", data, want)
+	}
+}
+
+func TestRecorderWriteVGMOPL3(t *testing.T) {
+	r := NewRecorder()
+	w := r.Attach(nil, 44100)
+	w.WriteReg(0x105, 0x01)
+	w.WriteReg(0x20, 0x02)
+
+	var buf bytes.Buffer
+	if err := r.WriteVGM(&buf); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	out := buf.Bytes()
+	if clk := binary.LittleEndian.Uint32(out[0x5C:]); clk != YMF262ClockRate {
+		t.Fatalf("YMF262 clock = %d", clk)
+	}
+	data := out[0x80:]
+	want := []byte{0x5F, 0x05, 0x01, 0x5E, 0x20, 0x02, 0x66}
+	if !bytes.Equal(data, want) {
+		t.Fatalf("unexpected data:\n got %x\nwant %x", data, want)
+	}
+}
+
+func TestRecorderWriteDRO(t *testing.T) {
+	r := NewRecorder()
+	w := r.Attach(nil, 1000)
+	w.WriteReg(0x20, 0x01)
+	r.Advance(5)
+	w.WriteReg(0x40, 0x3F)
+	w.WriteReg(0x20, 0x02)
+	r.Advance(600)
+
+	var buf bytes.Buffer
+	if err := r.WriteDRO(&buf); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	out := buf.Bytes()
+
+	if string(out[0:8]) != "DBRAWOPL" {
+		t.Fatalf("bad ident: %q", out[0:8])
+	}
+	if major, minor := binary.LittleEndian.Uint16(out[8:]), binary.LittleEndian.Uint16(out[10:]); major != 2 || minor != 0 {
+		t.Fatalf("version = %d.%d, want 2.0", major, minor)
+	}
+	if ms := binary.LittleEndian.Uint32(out[0x10:]); ms != 605 {
+		t.Fatalf("length ms = %d, want 605", ms)
+	}
+	if hw := out[0x14]; hw != droHardwareOPL2 {
+		t.Fatalf("hardware = %d, want OPL2", hw)
+	}
+	if out[0x17] != droShortDelayCode || out[0x18] != droLongDelayCode {
+		t.Fatalf("unexpected delay codes: %#x %#x", out[0x17], out[0x18])
+	}
+	cmLen := int(out[0x19])
+	if cmLen != 2 || out[0x1A] != 0x20 || out[0x1B] != 0x40 {
+		t.Fatalf("unexpected codemap: %x", out[0x1A:0x1A+cmLen])
+	}
+
+	data := out[0x1A+cmLen:]
+	want := []byte{
+		0x00, 0x01,
+		droShortDelayCode, 4,
+		0x01, 0x3F,
+		0x00, 0x02,
+		droLongDelayCode, 1, // 512ms
+		droShortDelayCode, 87, // 88ms
+	}
+	if !bytes.Equal(data, want) {
+		t.Fatalf("unexpected data:\n got %x\nwant %x", data, want)
+	}
+	if pairs := binary.LittleEndian.Uint32(out[0x0C:]); int(pairs) != len(want)/2 {
+		t.Fatalf("pairs = %d, want %d", pairs, len(want)/2)
+	}
+}
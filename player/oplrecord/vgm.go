@@ -0,0 +1,107 @@
+package oplrecord
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+)
+
+const (
+	vgmVersion    = 0x00000151
+	vgmSampleRate = 44100
+	vgmHeaderSize = 0x80
+
+	// YM3812ClockRate is the master clock of the OPL2 (as found on an AdLib card)
+	YM3812ClockRate = 3579545
+	// YMF262ClockRate is the master clock of the OPL3 (as found on a SoundBlaster 16)
+	YMF262ClockRate = 14318180
+)
+
+const (
+	vgmCmdYM3812Write     = 0x5A
+	vgmCmdYMF262Port0     = 0x5E
+	vgmCmdYMF262Port1     = 0x5F
+	vgmCmdWaitN           = 0x61
+	vgmCmdWait735         = 0x62
+	vgmCmdWait882         = 0x63
+	vgmCmdEndOfSoundData  = 0x66
+	vgmCmdWaitShortPrefix = 0x70
+)
+
+// WriteVGM exports the recording as a VGM v1.51 file.
+// A YM3812 (OPL2) stream is produced, unless writes to the second register bank
+// were captured, in which case a YMF262 (OPL3) stream is produced instead.
+func (r *Recorder) WriteVGM(w io.Writer) error {
+	writes, length, rate, err := r.snapshot()
+	if err != nil {
+		return err
+	}
+
+	isOPL3 := false
+	for _, wr := range writes {
+		if wr.Reg >= 0x100 {
+			isOPL3 = true
+			break
+		}
+	}
+
+	var data bytes.Buffer
+	var cur int64
+	for _, wr := range writes {
+		at := rescale(wr.Sample, rate, vgmSampleRate)
+		vgmWait(&data, at-cur)
+		cur = at
+
+		switch {
+		case !isOPL3:
+			data.Write([]byte{vgmCmdYM3812Write, uint8(wr.Reg), wr.Val})
+		case wr.Reg >= 0x100:
+			data.Write([]byte{vgmCmdYMF262Port1, uint8(wr.Reg), wr.Val})
+		default:
+			data.Write([]byte{vgmCmdYMF262Port0, uint8(wr.Reg), wr.Val})
+		}
+	}
+	total := rescale(length, rate, vgmSampleRate)
+	vgmWait(&data, total-cur)
+	data.WriteByte(vgmCmdEndOfSoundData)
+
+	var hdr [vgmHeaderSize]byte
+	copy(hdr[0x00:], "Vgm ")
+	binary.LittleEndian.PutUint32(hdr[0x04:], uint32(vgmHeaderSize+data.Len()-0x04))
+	binary.LittleEndian.PutUint32(hdr[0x08:], vgmVersion)
+	binary.LittleEndian.PutUint32(hdr[0x18:], uint32(total))
+	binary.LittleEndian.PutUint32(hdr[0x34:], vgmHeaderSize-0x34)
+	if isOPL3 {
+		binary.LittleEndian.PutUint32(hdr[0x5C:], YMF262ClockRate)
+	} else {
+		binary.LittleEndian.PutUint32(hdr[0x50:], YM3812ClockRate)
+	}
+
+	if _, err := w.Write(hdr[:]); err != nil {
+		return err
+	}
+	_, err = data.WriteTo(w)
+	return err
+}
+
+// vgmWait emits the most compact sequence of wait commands covering `samples`
+func vgmWait(buf *bytes.Buffer, samples int64) {
+	for samples > 0 {
+		switch {
+		case samples <= 16:
+			buf.WriteByte(vgmCmdWaitShortPrefix | uint8(samples-1))
+			samples = 0
+		case samples == 735:
+			buf.WriteByte(vgmCmdWait735)
+			samples = 0
+		case samples == 882:
+			buf.WriteByte(vgmCmdWait882)
+			samples = 0
+		default:
+			n := min(samples, 0xFFFF)
+			buf.WriteByte(vgmCmdWaitN)
+			_ = binary.Write(buf, binary.LittleEndian, uint16(n))
+			samples -= n
+		}
+	}
+}
@@ -20,6 +20,7 @@ func init() {
 			PreviousPeriodUsesModifiedPeriod:   false,
 			PortaToNoteUsesModifiedPeriod:      false,
 			DoNotProcessEffectsOnMutedChannels: false,
+			LinearFrequencyTable:               true,
 		},
 		MachineDefaults: XMMachineDefaults{
 			AmigaPeriod:      xmPeriod.AmigaConverter,
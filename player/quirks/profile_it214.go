@@ -20,6 +20,7 @@ func init() {
 			PreviousPeriodUsesModifiedPeriod:   false,
 			PortaToNoteUsesModifiedPeriod:      false,
 			DoNotProcessEffectsOnMutedChannels: false,
+			LinearFrequencyTable:               true,
 		},
 		MachineDefaults: ITMachineDefaults{
 			AmigaPeriod:      itPeriod.AmigaConverter,
@@ -15,6 +15,7 @@ func init() {
 			PreviousPeriodUsesModifiedPeriod:   false,
 			PortaToNoteUsesModifiedPeriod:      false,
 			DoNotProcessEffectsOnMutedChannels: false,
+			LinearFrequencyTable:               false,
 		},
 	})
 }
@@ -20,6 +20,7 @@ func init() {
 			PreviousPeriodUsesModifiedPeriod:   true,
 			PortaToNoteUsesModifiedPeriod:      true,
 			DoNotProcessEffectsOnMutedChannels: true,
+			LinearFrequencyTable:               false,
 		},
 		MachineDefaults: S3MMachineDefaults{
 			AmigaPeriod:      s3mPeriod.S3MAmigaConverter,
@@ -1,8 +1,6 @@
 package render
 
 import (
-	"github.com/gotracker/opl2"
-
 	"github.com/gotracker/playback/filter"
 	"github.com/gotracker/playback/mixing"
 	"github.com/gotracker/playback/mixing/panning"
@@ -21,11 +19,14 @@ type ChannelIntf interface {
 type Channel[TPeriod period.Period] struct {
 	PluginFilter filter.Filter
 	OutputFilter filter.Filter
-	GetOPL2Chip  func() *opl2.Chip
+	GetOPL2Chip  func() voice.OPL2Chip
 	GlobalVolume volume.Volume // this is the channel's version of the GlobalVolume
 
 	v    voice.Voice
 	vrem func() // function to call when voice is stopped/removed
+
+	panner      mixing.VoicePanner
+	pannerOwner mixing.VoicePanMixer
 }
 
 func (c *Channel[TPeriod]) RenderAndTick(pc period.PeriodConverter[TPeriod], centerAheadPan panning.PanMixer, details mixer.Details) (*mixing.Data, error) {
@@ -56,6 +57,22 @@ func (c *Channel[TPeriod]) StartVoice(v voice.Voice, vrem func()) {
 	c.StopVoice()
 	c.v = v
 	c.vrem = vrem
+	c.panner = nil
+}
+
+// GetVoicePanner returns the voice panner of the pan mixer for the channel's voice, creating
+// it when the voice or the pan mixer changes
+func (c *Channel[TPeriod]) GetVoicePanner(pm mixing.PanMixer) mixing.VoicePanner {
+	vpm, ok := pm.(mixing.VoicePanMixer)
+	if !ok {
+		c.panner, c.pannerOwner = nil, nil
+		return nil
+	}
+
+	if c.panner == nil || c.pannerOwner != vpm {
+		c.panner, c.pannerOwner = vpm.NewVoicePanner(), vpm
+	}
+	return c.panner
 }
 
 func (c *Channel[TPeriod]) StopVoice() {
@@ -5,6 +5,7 @@ import (
 
 	"github.com/gotracker/playback/filter"
 	"github.com/gotracker/playback/frequency"
+	"github.com/gotracker/playback/mixing"
 	"github.com/gotracker/playback/mixing/volume"
 	"github.com/gotracker/playback/period"
 )
@@ -40,3 +41,27 @@ func TestChannelApplyFilterPipeline(t *testing.T) {
 		t.Fatalf("unexpected filtered value: %v", wet.StaticMatrix[0])
 	}
 }
+
+func TestChannelGetVoicePanner(t *testing.T) {
+	var ch Channel[period.Linear]
+	if ch.GetVoicePanner(mixing.PanMixerStereo) != nil {
+		t.Fatalf("expected no voice panner for a matrix pan mixer")
+	}
+
+	vp := ch.GetVoicePanner(mixing.PanMixerBinaural)
+	if vp == nil {
+		t.Fatalf("expected a voice panner for the binaural pan mixer")
+	}
+	if ch.GetVoicePanner(mixing.PanMixerBinaural) != vp {
+		t.Fatalf("expected the voice panner to be kept between ticks")
+	}
+	if ch.GetVoicePanner(mixing.PanMixerCrossfeed) == vp {
+		t.Fatalf("expected a new voice panner when the pan mixer changes")
+	}
+
+	vp = ch.GetVoicePanner(mixing.PanMixerCrossfeed)
+	ch.StartVoice(nil, nil)
+	if ch.GetVoicePanner(mixing.PanMixerCrossfeed) == vp {
+		t.Fatalf("expected a new voice panner for a new voice")
+	}
+}
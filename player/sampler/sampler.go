@@ -1,8 +1,10 @@
 package sampler
 
 import (
+	"github.com/gotracker/playback/filter"
 	"github.com/gotracker/playback/frequency"
 	"github.com/gotracker/playback/mixing"
+	"github.com/gotracker/playback/mixing/volume"
 	"github.com/gotracker/playback/output"
 )
 
@@ -11,14 +13,31 @@ import (
 // Concurrency and ownership:
 // - OnGenerate runs synchronously during rendering; long-running callbacks will stall playback.
 // - Mixer should be configured before playback starts; do not mutate it while playback or callbacks run.
-// - External goroutines must not race with Sampler state (SampleRate, StereoSeparation, Mixer).
+// - MasterChain should be configured before playback starts as well; its effects keep state between renders.
+// - External goroutines must not race with Sampler state (SampleRate, StereoSeparation, Surround, PanMixer, Mixer).
+// - Live mute, solo and volume changes from other goroutines go through the machine's Controls instead.
 type Sampler struct {
 	SampleRate       int
 	BaseClockRate    frequency.Frequency
 	OnGenerate       func(premix *output.PremixData)
 	StereoSeparation float32
+	// MasterChain is the master bus: an ordered chain of effects run over the final mix (after
+	// the voices are summed, before it's formatted for output). Any filter.Filter can be used,
+	// including the built-in EQ, reverb, limiter and DC blocker filters.
+	MasterChain filter.Chain
+	// Surround holds the settings of the 5.1 and 7.1 pan mixers, which are used when the sampler
+	// has 6 or 8 output channels
+	Surround mixing.SurroundSettings
+	// PanMixer replaces the pan mixer picked for the number of output channels, such as
+	// mixing.PanMixerBinaural or mixing.PanMixerCrossfeed for headphones on stereo output. It's
+	// ignored when it doesn't produce as many channels as the sampler outputs.
+	PanMixer mixing.PanMixer
+	// Stems keeps the output of each tracker channel, including its past-note voices, separate
+	// in the premix data; see output.PremixData.Channels
+	Stems bool
 
-	mixer mixing.Mixer
+	mixer        mixing.Mixer
+	lfeCrossover *filter.LFECrossoverFilter
 }
 
 // NewSampler returns a new sampler object based on the input settings
@@ -34,6 +53,11 @@ func NewSampler(samplesPerSec, channels int, stereoSeparation float32, onGenerat
 	return &s
 }
 
+// AddMasterEffect appends an effect to the end of the master chain
+func (s *Sampler) AddMasterEffect(f filter.Filter) {
+	s.MasterChain = append(s.MasterChain, f)
+}
+
 // Mixer returns a pointer to the current mixer object
 func (s *Sampler) Mixer() *mixing.Mixer {
 	return &s.mixer
@@ -47,5 +71,30 @@ func (s *Sampler) MixerConfig() mixing.Mixer {
 // GetPanMixer returns the panning mixer that can generate a matrix
 // based on input pan value
 func (s *Sampler) GetPanMixer() mixing.PanMixer {
+	if s.PanMixer != nil && s.PanMixer.NumChannels() == s.mixer.Channels {
+		return s.PanMixer
+	}
+	if pm := mixing.NewSurroundPanMixer(s.mixer.Channels, s.Surround); pm != nil {
+		return pm
+	}
 	return mixing.GetPanMixer(s.mixer.Channels)
 }
+
+// PostMixChain returns the effects run over the final mix: the LFE crossover, when the
+// surround layout sends to the LFE channel, followed by the master chain
+func (s *Sampler) PostMixChain() filter.Chain {
+	if s.Surround.LFESend <= 0 || mixing.NewSurroundPanMixer(s.mixer.Channels, s.Surround) == nil {
+		return s.MasterChain
+	}
+
+	freq := float64(s.Surround.LFECrossover)
+	if freq <= 0 {
+		freq = mixing.DefaultLFECrossover
+	}
+	if s.lfeCrossover == nil || s.lfeCrossover.Frequency != freq {
+		s.lfeCrossover = filter.NewLFECrossoverFilter(volume.SurroundLFE, freq)
+	}
+
+	chain := filter.Chain{s.lfeCrossover}
+	return append(chain, s.MasterChain...)
+}
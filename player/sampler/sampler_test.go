@@ -1,6 +1,12 @@
 package sampler
 
-import "testing"
+import (
+	"testing"
+
+	"github.com/gotracker/playback/filter"
+	"github.com/gotracker/playback/mixing"
+	"github.com/gotracker/playback/mixing/volume"
+)
 
 func TestSamplerMixerAccessors(t *testing.T) {
 	s := NewSampler(44100, 2, 0.5, nil)
@@ -39,3 +45,60 @@ func TestSamplerGetPanMixer(t *testing.T) {
 		t.Fatalf("expected nil pan mixer for unsupported channel count")
 	}
 }
+
+func TestSamplerAddMasterEffect(t *testing.T) {
+	s := NewSampler(44100, 2, 1, nil)
+	eq := filter.NewEQFilter()
+	limiter := filter.NewLimiterFilter(filter.LimiterFilterSettings{})
+
+	s.AddMasterEffect(eq)
+	s.AddMasterEffect(limiter)
+
+	if len(s.MasterChain) != 2 || s.MasterChain[0] != filter.Filter(eq) || s.MasterChain[1] != filter.Filter(limiter) {
+		t.Fatalf("expected effects to be added in order, got %v", s.MasterChain)
+	}
+}
+
+func TestSamplerSurroundPanMixer(t *testing.T) {
+	s := NewSampler(44100, 6, 1, nil)
+	if pm := s.GetPanMixer(); pm == nil || pm.NumChannels() != 6 {
+		t.Fatalf("expected a 5.1 pan mixer")
+	}
+	if len(s.PostMixChain()) != 0 {
+		t.Fatalf("expected no LFE crossover without an LFE send")
+	}
+
+	s.Surround = mixing.SurroundSettings{LFESend: 0.5, LFECrossover: 80}
+	eq := filter.NewEQFilter()
+	s.AddMasterEffect(eq)
+
+	chain := s.PostMixChain()
+	if len(chain) != 2 || chain[1] != filter.Filter(eq) {
+		t.Fatalf("expected the LFE crossover ahead of the master chain, got %v", chain)
+	}
+	lfe, ok := chain[0].(*filter.LFECrossoverFilter)
+	if !ok || lfe.Frequency != 80 || lfe.Channel != volume.SurroundLFE {
+		t.Fatalf("unexpected LFE crossover: %#v", chain[0])
+	}
+	if again := s.PostMixChain(); again[0] != chain[0] {
+		t.Fatalf("expected the LFE crossover to keep its state between renders")
+	}
+
+	s.mixer.Channels = 2
+	if len(s.PostMixChain()) != 1 {
+		t.Fatalf("expected no LFE crossover on a stereo layout")
+	}
+}
+
+func TestSamplerPanMixerOverride(t *testing.T) {
+	s := NewSampler(44100, 2, 1, nil)
+	s.PanMixer = mixing.PanMixerBinaural
+	if s.GetPanMixer() != mixing.PanMixerBinaural {
+		t.Fatalf("expected the pan mixer override to be used")
+	}
+
+	s.mixer.Channels = 6
+	if pm := s.GetPanMixer(); pm == nil || pm.NumChannels() != 6 {
+		t.Fatalf("expected the override to be ignored for a different channel count")
+	}
+}
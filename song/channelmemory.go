@@ -1,6 +1,17 @@
 package song
 
+import "github.com/heucuva/optional"
+
 type ChannelMemory interface {
 	Retrigger()
 	StartOrder0()
 }
+
+// FilterMemory is implemented by channel memory that keeps the filter cutoff and resonance an
+// effect or an instrument last set, so they carry over to the voices of the notes that follow
+type FilterMemory interface {
+	FilterCutoff() optional.Value[uint8]
+	SetFilterCutoff(cutoff uint8)
+	FilterResonance() optional.Value[uint8]
+	SetFilterResonance(resonance uint8)
+}
@@ -19,6 +19,8 @@ type PeriodCalculator[TPeriod period.Period] interface {
 	PortaDown(TPeriod, period.Delta) (TPeriod, error)
 	PortaUp(TPeriod, period.Delta) (TPeriod, error)
 	AddDelta(TPeriod, period.Delta) (TPeriod, error)
+	GetDelta(from TPeriod, to TPeriod) period.Delta
+	RoundToSemitone(TPeriod) TPeriod
 
 	GetSamplerAdd(TPeriod, frequency.Frequency, frequency.Frequency) float64
 	GetFrequency(TPeriod) frequency.Frequency
@@ -0,0 +1,197 @@
+package component
+
+import (
+	"math"
+	"math/cmplx"
+	"sync"
+
+	"github.com/gotracker/playback/mixing/sampling"
+	"github.com/gotracker/playback/mixing/volume"
+)
+
+const (
+	blepZeroCrossings = 16 // zero crossings on either side of the windowed sinc the step is built from
+	blepOversample    = 32 // table entries per output sample
+	blepSamples       = 16 // output samples covered by a single step correction
+	blepRingSize      = 32 // must be a power of 2 and no less than blepSamples
+	blepMaxSteps      = 8  // steps tracked per output sample; anything beyond is skipped over
+	blepMaxJump       = 64 // position changes larger than this are treated as discontinuities
+)
+
+var (
+	blepTableOnce sync.Once
+	blepTable     []float64
+)
+
+// getBLEPTable returns the residual of the minimum-phase band-limited step: the difference
+// between an ideal step and its band-limited version, indexed in 1/blepOversample output samples
+func getBLEPTable() []float64 {
+	blepTableOnce.Do(func() {
+		blepTable = makeMinBLEPResidual()
+	})
+	return blepTable
+}
+
+// makeMinBLEPResidual builds a MinBLEP (Brandt, 2001): a Blackman-windowed sinc is converted to
+// minimum phase through its real cepstrum, so the step responds without any lookahead,
+// then it is integrated into a step
+func makeMinBLEPResidual() []float64 {
+	n := 2*blepZeroCrossings*blepOversample + 1
+	fftSize := 1
+	for fftSize < n*4 {
+		fftSize <<= 1
+	}
+
+	buf := make([]complex128, fftSize)
+	var gain float64
+	for i := 0; i < n; i++ {
+		x := float64(i-n/2) / blepOversample
+		s := 1.0
+		if x != 0 {
+			s = math.Sin(math.Pi*x) / (math.Pi * x)
+		}
+		t := float64(i) / float64(n-1)
+		w := 0.42 - 0.5*math.Cos(2*math.Pi*t) + 0.08*math.Cos(4*math.Pi*t)
+		buf[i] = complex(s*w, 0)
+		gain += s * w
+	}
+
+	// real cepstrum; the magnitude floor (-100dB) keeps the stopband nulls from dominating it
+	fft(buf, false)
+	for i, v := range buf {
+		buf[i] = complex(math.Log(max(cmplx.Abs(v), 1e-5)), 0)
+	}
+	fft(buf, true)
+
+	// fold the cepstrum onto the causal half to get the minimum-phase spectrum
+	for i := 1; i < fftSize/2; i++ {
+		buf[i] *= 2
+	}
+	for i := fftSize/2 + 1; i < fftSize; i++ {
+		buf[i] = 0
+	}
+	fft(buf, false)
+	for i, v := range buf {
+		buf[i] = cmplx.Exp(v)
+	}
+	fft(buf, true)
+
+	table := make([]float64, blepSamples*blepOversample+1)
+	var step float64
+	for i := range table {
+		step += real(buf[i])
+		table[i] = 1 - step/gain
+	}
+
+	// taper off what's left of the ringing so the end of the correction doesn't click
+	taper := len(table) * 3 / 4
+	for i := taper; i < len(table); i++ {
+		t := float64(i-taper) / float64(len(table)-1-taper)
+		table[i] *= 0.5 * (1 + math.Cos(math.Pi*t))
+	}
+	return table
+}
+
+// fft is an in-place radix-2 fast Fourier transform. The length of `x` must be a power of 2.
+func fft(x []complex128, inverse bool) {
+	n := len(x)
+	for i, j := 1, 0; i < n; i++ {
+		bit := n >> 1
+		for ; j&bit != 0; bit >>= 1 {
+			j ^= bit
+		}
+		j ^= bit
+		if i < j {
+			x[i], x[j] = x[j], x[i]
+		}
+	}
+
+	sign := -1.0
+	if inverse {
+		sign = 1.0
+	}
+	for size := 2; size <= n; size <<= 1 {
+		half := size / 2
+		w := cmplx.Rect(1, sign*2*math.Pi/float64(size))
+		for start := 0; start < n; start += size {
+			wk := complex(1, 0)
+			for k := 0; k < half; k++ {
+				a := x[start+k]
+				b := x[start+k+half] * wk
+				x[start+k] = a + b
+				x[start+k+half] = a - b
+				wk *= w
+			}
+		}
+	}
+
+	if inverse {
+		scale := complex(1/float64(n), 0)
+		for i := range x {
+			x[i] *= scale
+		}
+	}
+}
+
+// blepState holds a sample stream as a series of steps, like Paula does, along with the
+// band-limited corrections for the steps that are still playing out
+type blepState struct {
+	started bool
+	lastPos float64
+	held    volume.Matrix
+	ring    [blepRingSize]volume.StaticMatrix
+	ringPos int
+}
+
+// addStep moves the held value to `next`, with the step having occurred `phase` output samples ago
+func (b *blepState) addStep(next volume.Matrix, phase float64) {
+	table := getBLEPTable()
+	channels := max(next.Channels, b.held.Channels)
+
+	var delta volume.StaticMatrix
+	for c := 0; c < channels; c++ {
+		delta[c] = b.held.StaticMatrix[c] - next.StaticMatrix[c]
+	}
+
+	for j := 0; j < blepSamples; j++ {
+		f := (float64(j) + phase) * blepOversample
+		i := int(f)
+		if i+1 >= len(table) {
+			break
+		}
+		r := volume.Volume(table[i] + (table[i+1]-table[i])*(f-float64(i)))
+		slot := &b.ring[(b.ringPos+j)&(blepRingSize-1)]
+		for c := 0; c < channels; c++ {
+			slot[c] += delta[c] * r
+		}
+	}
+
+	b.held = next
+	b.held.Channels = channels
+}
+
+// next advances the stream to `pos`, stepping to each sample point reached along the way
+// (as provided by `read`), and returns the band-limited output
+func (b *blepState) next(pos sampling.Pos, read func(pos int) volume.Matrix) volume.Matrix {
+	cur := float64(pos.Pos) + float64(pos.Frac)
+	if !b.started || cur < b.lastPos || cur-b.lastPos > blepMaxJump {
+		// there's no prior position to time the step against, so it lands right here
+		b.addStep(read(pos.Pos), 0)
+		b.started = true
+	} else if delta := cur - b.lastPos; delta > 0 {
+		first := max(int(math.Floor(b.lastPos))+1, pos.Pos-blepMaxSteps+1)
+		for k := first; k <= pos.Pos; k++ {
+			b.addStep(read(k), (cur-float64(k))/delta)
+		}
+	}
+	b.lastPos = cur
+
+	out := b.held
+	slot := &b.ring[b.ringPos]
+	for c := 0; c < out.Channels; c++ {
+		out.StaticMatrix[c] += slot[c]
+	}
+	*slot = volume.StaticMatrix{}
+	b.ringPos = (b.ringPos + 1) & (blepRingSize - 1)
+	return out
+}
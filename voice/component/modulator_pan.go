@@ -13,7 +13,8 @@ import (
 type PanModulator[TPanning types.Panning] struct {
 	settings PanModulatorSettings[TPanning]
 	unkeyed  struct {
-		pan TPanning
+		pan      TPanning
+		surround bool
 	}
 	keyed struct {
 		delta types.PanDelta
@@ -57,6 +58,21 @@ func (p PanModulator[TPanning]) GetPan() TPanning {
 	return p.unkeyed.pan
 }
 
+// SetSurround enables or disables surround (rear) positioning
+func (p *PanModulator[TPanning]) SetSurround(enabled bool) error {
+	if !p.settings.Enabled {
+		return nil
+	}
+
+	p.unkeyed.surround = enabled
+	return p.updateFinal()
+}
+
+// IsSurround returns true if surround (rear) positioning is enabled
+func (p PanModulator[TPanning]) IsSurround() bool {
+	return p.unkeyed.surround
+}
+
 // SetPanDelta sets the current panning delta
 func (p *PanModulator[TPanning]) SetPanDelta(d types.PanDelta) error {
 	if !p.settings.Enabled {
@@ -78,13 +94,19 @@ func (p PanModulator[TPanning]) GetFinalPan() panning.Position {
 }
 
 func (p PanModulator[TPanning]) DumpState(ch index.Channel, t tracing.Tracer, comment string) {
-	t.TraceChannelWithComment(ch, fmt.Sprintf("pan{%v} delta{%v}",
+	t.TraceChannelWithComment(ch, fmt.Sprintf("pan{%v} surround{%v} delta{%v}",
 		p.unkeyed.pan,
+		p.unkeyed.surround,
 		p.keyed.delta,
 	), comment)
 }
 
 func (p *PanModulator[TPanning]) updateFinal() error {
+	if p.unkeyed.surround {
+		// surround overrides both the pan position and any pan delta (panbrello, etc.)
+		p.final = panning.SurroundPosition
+		return nil
+	}
 	p.final = types.AddPanningDelta(p.unkeyed.pan, p.keyed.delta).ToPosition()
 	return nil
 }
@@ -0,0 +1,41 @@
+package component
+
+import (
+	"testing"
+
+	itPanning "github.com/gotracker/playback/format/it/panning"
+	"github.com/gotracker/playback/mixing/panning"
+)
+
+func TestPanModulatorSurroundOverridesPanAndDelta(t *testing.T) {
+	var p PanModulator[itPanning.Panning]
+	p.Setup(PanModulatorSettings[itPanning.Panning]{
+		Enabled:    true,
+		InitialPan: itPanning.DefaultPanningLeft,
+	})
+
+	if err := p.SetSurround(true); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := p.SetPanDelta(8); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := p.GetFinalPan(); got != panning.SurroundPosition {
+		t.Fatalf("expected surround position, got %+v", got)
+	}
+
+	// surround persists across a (keyed) reset
+	if err := p.Reset(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !p.IsSurround() {
+		t.Fatalf("expected surround to persist across reset")
+	}
+
+	if err := p.SetSurround(false); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got, want := p.GetFinalPan(), itPanning.DefaultPanningLeft.ToPosition(); got != want {
+		t.Fatalf("expected pan position %+v, got %+v", want, got)
+	}
+}
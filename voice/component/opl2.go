@@ -8,6 +8,7 @@ import (
 	"github.com/gotracker/playback/mixing/volume"
 	"github.com/gotracker/playback/period"
 	"github.com/gotracker/playback/tracing"
+	"github.com/gotracker/playback/voice"
 	"github.com/gotracker/playback/voice/types"
 )
 
@@ -34,7 +35,7 @@ func (o OPL2Registers) Clone() OPL2Registers {
 
 // OPL2 is an OPL2 component
 type OPL2[TPeriod types.Period, TMixingVolume, TVolume types.Volume] struct {
-	chip            *opl2.Chip
+	chip            voice.OPL2Chip
 	channel         int
 	reg             OPL2Registers
 	baseFreq        frequency.Frequency
@@ -44,7 +45,7 @@ type OPL2[TPeriod types.Period, TMixingVolume, TVolume types.Volume] struct {
 }
 
 // Setup sets up the OPL2 component
-func (o *OPL2[TPeriod, TMixingVolume, TVolume]) Setup(chip *opl2.Chip, channel int, reg OPL2Registers, pc period.PeriodConverter[TPeriod], baseFreq frequency.Frequency, defaultVolume TVolume) {
+func (o *OPL2[TPeriod, TMixingVolume, TVolume]) Setup(chip voice.OPL2Chip, channel int, reg OPL2Registers, pc period.PeriodConverter[TPeriod], baseFreq frequency.Frequency, defaultVolume TVolume) {
 	o.chip = chip
 	o.channel = channel
 	o.reg = reg
@@ -19,6 +19,7 @@ type Sampler[TPeriod types.Period, TMixingVolume, TVolume types.Volume] struct {
 	unkeyed struct {
 		pos    sampling.Pos
 		mixVol volume.Volume
+		blep   blepState
 	}
 	keyed struct {
 		loopsEnabled bool
@@ -33,6 +34,7 @@ type SamplerSettings[TPeriod types.Period, TMixingVolume, TVolume types.Volume]
 	MixVolume     TMixingVolume
 	WholeLoop     loop.Loop
 	SustainLoop   loop.Loop
+	Interpolation sampling.Interpolation
 }
 
 // Setup sets up the sampler
@@ -40,6 +42,7 @@ func (s *Sampler[TPeriod, TMixingVolume, TVolume]) Setup(settings SamplerSetting
 	s.settings = settings
 	s.unkeyed.pos = sampling.Pos{}
 	s.unkeyed.mixVol = settings.MixVolume.ToVolume()
+	s.unkeyed.blep = blepState{}
 	s.Reset()
 }
 
@@ -99,6 +102,10 @@ func (s Sampler[TPeriod, TMixingVolume, TVolume]) GetNumChannels() int {
 
 // GetSample returns a multi-channel sample at the specified position
 func (s *Sampler[TPeriod, TMixingVolume, TVolume]) GetSample(pos sampling.Pos) volume.Matrix {
+	if s.settings.Interpolation == sampling.InterpolationBLEP {
+		return s.unkeyed.blep.next(pos, s.getConvertedSample).Apply(s.unkeyed.mixVol)
+	}
+
 	v0 := s.getConvertedSample(pos.Pos)
 	if v0.Channels == 0 {
 		if s.canLoop() {
@@ -63,3 +63,37 @@ func TestSamplerFadeoutPastEndWithoutLoop(t *testing.T) {
 		t.Fatalf("unexpected fadeout result: got %+v want %+v", got, want)
 	}
 }
+
+func TestSamplerBLEPBandLimitsSteps(t *testing.T) {
+	data := make([]volume.Matrix, 64)
+	for i := range data {
+		data[i] = volume.Matrix{StaticMatrix: volume.StaticMatrix{1}, Channels: 1}
+	}
+	samp := pcm.NewSampleNative(data, len(data), 1)
+
+	var s Sampler[types.Period, testVolume, testVolume]
+	s.Setup(SamplerSettings[types.Period, testVolume, testVolume]{
+		Sample:        samp,
+		DefaultVolume: testVolume(1),
+		MixVolume:     testVolume(1),
+		Interpolation: sampling.InterpolationBLEP,
+	})
+
+	var out []volume.Volume
+	pos := sampling.Pos{}
+	for i := 0; i < blepSamples+4; i++ {
+		out = append(out, s.GetSample(pos).StaticMatrix[0])
+		pos.Add(0.75)
+	}
+
+	// the step up from silence is smoothed instead of landing immediately...
+	if out[0] > 0.1 {
+		t.Fatalf("expected the initial step to be band-limited, got %v", out[0])
+	}
+	// ...and settles exactly on the held value once the correction has played out
+	for i := blepSamples; i < len(out); i++ {
+		if !almostEqualVol(out[i], 1, 1e-6) {
+			t.Fatalf("sample %d: expected the held value, got %v", i, out[i])
+		}
+	}
+}
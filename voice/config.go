@@ -1,7 +1,7 @@
 package voice
 
 import (
-	"github.com/gotracker/opl2"
+	"math/rand"
 
 	"github.com/gotracker/playback/index"
 	"github.com/gotracker/playback/period"
@@ -17,11 +17,12 @@ type (
 
 type VoiceConfig[TPeriod Period, TGlobalVolume, TMixingVolume, TVolume Volume, TPanning Panning] struct {
 	PC               period.PeriodConverter[TPeriod]
-	OPLChip          *opl2.Chip
+	OPLChip          OPL2Chip
 	OPLChannel       index.OPLChannel
 	InitialVolume    TVolume
 	InitialMixing    TMixingVolume
 	PanEnabled       bool
 	InitialPan       TPanning
 	Vol0Optimization vol0optimization.Vol0OptimizationSettings
+	Random           *rand.Rand
 }
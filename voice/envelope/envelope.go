@@ -7,6 +7,7 @@ import (
 // Envelope is an envelope for instruments
 type Envelope[T any] struct {
 	Enabled bool
+	Carry   bool // a new note resumes from the position of the previous note's envelope
 	Loop    loop.Loop
 	Sustain loop.Loop
 	Length  int
@@ -1,6 +1,7 @@
 package mixer
 
 import (
+	"github.com/gotracker/playback/mixing"
 	"github.com/gotracker/playback/mixing/sampling"
 	"github.com/gotracker/playback/mixing/volume"
 )
@@ -21,3 +22,10 @@ func (o *Output) GetSample(pos sampling.Pos) volume.Matrix {
 type ApplyFilter interface {
 	ApplyFilter(dry volume.Matrix) volume.Matrix
 }
+
+// VoicePannerProvider is an output that keeps the state of the voice panner for its voice
+type VoicePannerProvider interface {
+	// GetVoicePanner returns the voice panner of the pan mixer for the voice, or nil if the pan
+	// mixer doesn't pan voices individually
+	GetVoicePanner(pm mixing.PanMixer) mixing.VoicePanner
+}
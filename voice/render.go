@@ -62,9 +62,19 @@ func RenderAndTick[TPeriod Period](in Voice, pc period.PeriodConverter[TPeriod],
 
 	mixBuffer := details.Mix.NewMixBuffer(details.Samples)
 	mixBuffer.MixInSample(sampleData)
+
+	panMatrix := details.Panmixer.GetMixingMatrix(pan, details.StereoSeparation)
+	if vpp, ok := out.(mixer.VoicePannerProvider); ok {
+		if vp := vpp.GetVoicePanner(details.Panmixer); vp != nil {
+			// the voice panner has already put the voice where it belongs
+			vp.PanBuffer(mixBuffer, pan, details.StereoSeparation, details.SampleRate)
+			panMatrix = passThroughMatrix(details.Panmixer.NumChannels())
+		}
+	}
+
 	data := &mixing.Data{
 		Data:       mixBuffer,
-		PanMatrix:  details.Panmixer.GetMixingMatrix(pan, details.StereoSeparation),
+		PanMatrix:  panMatrix,
 		Volume:     volume.Volume(1.0),
 		Pos:        0,
 		SamplesLen: details.Samples,
@@ -77,3 +87,11 @@ func RenderAndTick[TPeriod Period](in Voice, pc period.PeriodConverter[TPeriod],
 
 	return data, nil
 }
+
+func passThroughMatrix(channels int) volume.Matrix {
+	m := volume.Matrix{Channels: channels}
+	for i := 0; i < channels; i++ {
+		m.StaticMatrix[i] = 1
+	}
+	return m
+}
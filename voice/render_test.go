@@ -41,6 +41,10 @@ func (s stubPeriodConverter) PortaUp(p stubPeriod, d period.Delta) (stubPeriod,
 func (s stubPeriodConverter) AddDelta(p stubPeriod, d period.Delta) (stubPeriod, error) {
 	return p, nil
 }
+func (s stubPeriodConverter) GetDelta(from stubPeriod, to stubPeriod) period.Delta {
+	return period.Delta(to - from)
+}
+func (s stubPeriodConverter) RoundToSemitone(p stubPeriod) stubPeriod { return p }
 func (s stubPeriodConverter) GetSamplerAdd(p stubPeriod, _ frequency.Frequency, _ frequency.Frequency) float64 {
 	return s.samplerAdd
 }
@@ -278,3 +282,62 @@ func TestRenderAndTickRendersAndUpdatesPosition(t *testing.T) {
 		t.Fatalf("unexpected second sample mix: %+v", second.StaticMatrix)
 	}
 }
+
+type stubVoicePanner struct {
+	pan   panning.Position
+	calls int
+}
+
+func (p *stubVoicePanner) PanBuffer(buf mixing.MixBuffer, pan panning.Position, _ float32, _ frequency.Frequency) {
+	p.calls++
+	p.pan = pan
+	for i := range buf {
+		buf[i] = buf[i].Apply(2)
+	}
+}
+
+type stubVoicePannerFilter struct {
+	stubFilter
+	panner *stubVoicePanner
+}
+
+func (f *stubVoicePannerFilter) GetVoicePanner(pm mixing.PanMixer) mixing.VoicePanner {
+	return f.panner
+}
+
+func TestRenderAndTickUsesVoicePanner(t *testing.T) {
+	panMixer := &stubDetailPanMixer{matrix: &stubPanMatrix{factorL: 1, factorR: 1}}
+	out := &stubVoicePannerFilter{panner: &stubVoicePanner{}}
+
+	v := &stubRenderSampler{
+		active:     true,
+		sampleRate: 10,
+		period:     stubPeriod(4),
+		pan:        panning.Position{Angle: 0.3, Distance: 1},
+	}
+
+	mix := mixing.Mixer{Channels: 2}
+	details := mixer.Details{
+		Mix:        &mix,
+		Panmixer:   panMixer,
+		SampleRate: 20,
+		Samples:    2,
+	}
+
+	data, err := RenderAndTick[stubPeriod](v, stubPeriodConverter{samplerAdd: 1}, &stubPanMatrix{factorL: 1, factorR: 1}, details, out)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if out.panner.calls != 1 || out.panner.pan != v.pan {
+		t.Fatalf("expected the voice panner to pan the buffer once at the final pan, got %d calls at %+v", out.panner.calls, out.panner.pan)
+	}
+
+	pm, ok := data.PanMatrix.(volume.Matrix)
+	if !ok || pm.Channels != 2 || pm.StaticMatrix[0] != 1 || pm.StaticMatrix[1] != 1 {
+		t.Fatalf("expected a pass-through pan matrix, got %#v", data.PanMatrix)
+	}
+	// first sample: dry {1,-1}, filter halves to {0.5,-0.5}, voice panner doubles -> {1,-1}
+	if first := data.Data[0]; first.StaticMatrix[0] != 1 || first.StaticMatrix[1] != -1 {
+		t.Fatalf("unexpected first sample mix: %+v", first.StaticMatrix)
+	}
+}
@@ -1,8 +1,6 @@
 package voice
 
 import (
-	"github.com/gotracker/opl2"
-
 	"github.com/gotracker/playback/frequency"
 	"github.com/gotracker/playback/index"
 	"github.com/gotracker/playback/instrument"
@@ -40,8 +38,13 @@ type Voice interface {
 	GetSampleRate() frequency.Frequency
 }
 
+// OPL2Chip is the register-write side of an OPL2 chip
+type OPL2Chip interface {
+	WriteReg(reg uint32, val uint8)
+}
+
 type VoiceOPL2er interface {
-	SetOPL2Chip(chip *opl2.Chip)
+	SetOPL2Chip(chip OPL2Chip)
 }
 
 type RenderVoice[TPeriod Period, TGlobalVolume, TMixingVolume, TVolume Volume, TPanning Panning] interface {
@@ -108,6 +111,12 @@ type PanModulator[TPanning Panning] interface {
 	GetFinalPan() panning.Position
 }
 
+type SurroundModulator interface {
+	// Surround Parameters
+	SetSurround(enabled bool) error
+	IsSurround() bool
+}
+
 type PitchPanModulator[TPanning Panning] interface {
 	SetPitchPanNote(st note.Semitone) error
 	IsPitchPanEnabled() bool
@@ -150,3 +159,9 @@ type FilterEnvelope interface {
 	SetFilterEnvelopePosition(pos int) error
 	GetCurrentFilterEnvelope() uint8
 }
+
+type FilterModulator interface {
+	// Filter Parameters
+	SetFilterCutoff(cutoff uint8) error
+	SetFilterResonance(resonance uint8) error
+}